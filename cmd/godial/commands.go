@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goDial/pkg/client"
+)
+
+// cli is what a command runs with.
+type cli struct {
+	client *client.Client
+	// name is the command, as typed
+	name   string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flags starts a command's flags, with the --json every command takes.
+func (c *cli) flags() (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet("godial "+c.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	asJSON := fs.Bool("json", false, "print JSON")
+	return fs, asJSON
+}
+
+// parse parses the flags of a command that takes nothing else.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		// the flag package has said what is wrong
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "%s: unexpected %q\n", fs.Name(), fs.Arg(0))
+		return errUsage
+	}
+	return nil
+}
+
+// parseID parses the flags of a command that takes a call's id, which may
+// come before or after them.
+func parseID(fs *flag.FlagSet, args []string) (int64, error) {
+	if err := fs.Parse(args); err != nil {
+		return 0, errUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintf(fs.Output(), "%s: give the id of the call\n", fs.Name())
+		return 0, errUsage
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		fmt.Fprintf(fs.Output(), "%s: %q isn't a call id\n", fs.Name(), fs.Arg(0))
+		return 0, errUsage
+	}
+	return id, parse(fs, fs.Args()[1:])
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// callFlags are the call command's flags for the API's fields, so the API's
+// problems with a field can point at the flag.
+var callFlags = map[string]string{
+	"phone_number":          "phone",
+	"recipient":             "recipient",
+	"objective":             "objective",
+	"context":               "context",
+	"max_attempts":          "max-attempts",
+	"retry_spacing_minutes": "retry-spacing",
+	"retry_window_start":    "retry-window-start",
+	"retry_window_end":      "retry-window-end",
+	"record":                "record",
+	"template_id":           "template",
+	"variables":             "var",
+}
+
+// variables are a call template's variables, given as --var NAME=VALUE.
+type variables map[string]string
+
+func (v variables) String() string {
+	return ""
+}
+
+func (v variables) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("variables are NAME=VALUE, not %q", value)
+	}
+	v[name] = val
+	return nil
+}
+
+// runCall places a call with the fields of the home page's call form.
+func runCall(ctx context.Context, c *cli, args []string) error {
+	fs, asJSON := c.flags()
+	var req client.CreateCallRequest
+	vars := variables{}
+	fs.StringVar(&req.PhoneNumber, "phone", "", "the number to call, like +13336664444")
+	fs.StringVar(&req.Recipient, "recipient", "", "who you're calling, and anything the agent should know about them")
+	fs.StringVar(&req.Objective, "objective", "", "what the call should get done")
+	fs.StringVar(&req.Context, "context", "", "anything else the agent should know")
+	fs.Int64Var(&req.MaxAttempts, "max-attempts", 0, "how many times to dial before giving up (default 1)")
+	fs.Int64Var(&req.RetrySpacingMinutes, "retry-spacing", 0, "minutes between attempts")
+	fs.StringVar(&req.RetryWindowStart, "retry-window-start", "", "the time of day retries may start, like 09:00")
+	fs.StringVar(&req.RetryWindowEnd, "retry-window-end", "", "the time of day retries must end by, like 17:30")
+	fs.BoolVar(&req.Record, "record", false, "record the call")
+	fs.Int64Var(&req.TemplateID, "template", 0, "the id of a call template to fill in the objective and context from")
+	fs.Var(vars, "var", "a value for one of the template's variables, as NAME=VALUE")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if len(vars) > 0 {
+		req.Variables = vars
+	}
+
+	call, err := c.client.CreateCall(ctx, req)
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && callFlags[apiErr.Field] != "" {
+		return fmt.Errorf("%s (--%s)", apiErr.Message, callFlags[apiErr.Field])
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return c.printJSON(call)
+	}
+	fmt.Fprintf(c.stdout, "Call %d to %s is %s.\n", call.ID, call.PhoneNumber, status(call))
+	if call.OpeningLine != "" {
+		fmt.Fprintf(c.stdout, "The agent will open with: %s\n", call.OpeningLine)
+	}
+	fmt.Fprintf(c.stdout, "Follow it with: godial tail %d\n", call.ID)
+	return nil
+}
+
+// runStatus shows where a call has got to.
+func runStatus(ctx context.Context, c *cli, args []string) error {
+	fs, asJSON := c.flags()
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	call, err := c.client.GetCall(ctx, id)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.printJSON(call)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Call:\t%d\n", call.ID)
+	fmt.Fprintf(tw, "To:\t%s\n", call.PhoneNumber)
+	fmt.Fprintf(tw, "Recipient:\t%s\n", call.Recipient)
+	fmt.Fprintf(tw, "Objective:\t%s\n", call.Objective)
+	fmt.Fprintf(tw, "Status:\t%s\n", status(call.Call))
+	if call.Outcome != "" {
+		fmt.Fprintf(tw, "Outcome:\t%s\n", call.Outcome)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(call.Attempts) > 0 {
+		fmt.Fprintln(c.stdout, "\nAttempts:")
+		for _, attempt := range call.Attempts {
+			fmt.Fprintf(c.stdout, "  %d. %s", attempt.Number, strings.ReplaceAll(attempt.Status, "_", " "))
+			if attempt.DurationSeconds > 0 {
+				fmt.Fprintf(c.stdout, ", %s, %d min billed", time.Duration(attempt.DurationSeconds)*time.Second, attempt.BilledMinutes)
+			}
+			if attempt.Error != "" {
+				fmt.Fprintf(c.stdout, ": %s", attempt.Error)
+			}
+			fmt.Fprintln(c.stdout)
+		}
+	}
+	if len(call.Facts) > 0 {
+		fmt.Fprintln(c.stdout, "\nLearned:")
+		for _, fact := range call.Facts {
+			fmt.Fprintf(c.stdout, "  %s: %s\n", fact.Key, fact.Value)
+		}
+	}
+	return nil
+}
+
+// runTail prints a call's transcript as it is said, until the call is over.
+func runTail(ctx context.Context, c *cli, args []string) error {
+	fs, asJSON := c.flags()
+	interval := fs.Duration("interval", 2*time.Second, "how often to check for new lines")
+	id, err := parseID(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	enc := json.NewEncoder(c.stdout)
+	// the id of the last line printed; only the lines after it are fetched
+	var last int64
+	for {
+		update, err := c.client.TranscriptAfter(ctx, id, last)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range update.Entries {
+			last = entry.ID
+			if *asJSON {
+				if err := enc.Encode(entry); err != nil {
+					return err
+				}
+				continue
+			}
+			offset := clock(time.Duration(entry.OffsetSeconds * float64(time.Second)))
+			if entry.Speaker == "system" {
+				fmt.Fprintf(c.stdout, "[%s] (%s)\n", offset, entry.Text)
+			} else {
+				fmt.Fprintf(c.stdout, "[%s] %s: %s\n", offset, label(entry.Speaker), entry.Text)
+			}
+		}
+
+		if update.Finished() {
+			if *asJSON {
+				return nil
+			}
+			// the call, once, for why it ended
+			call, err := c.client.GetCall(ctx, id)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stdout, "Call %d %s.\n", call.ID, status(call.Call))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// maxObjective is how much of each objective the list of calls shows.
+const maxObjective = 50
+
+// runCallsList lists a page of calls, newest first.
+func runCallsList(ctx context.Context, c *cli, args []string) error {
+	fs, asJSON := c.flags()
+	var opts client.ListCallsOptions
+	fs.StringVar(&opts.Status, "status", "", "only list calls that are pending, in_progress, completed or failed")
+	fs.IntVar(&opts.Limit, "limit", 0, "how many calls to list, up to 200 (default 50)")
+	fs.Int64Var(&opts.BeforeID, "before", 0, "only list calls older than this id")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	list, err := c.client.ListCalls(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.printJSON(list)
+	}
+	if len(list.Calls) == 0 {
+		fmt.Fprintln(c.stdout, "No calls.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tTO\tOBJECTIVE\tPLACED")
+	for _, call := range list.Calls {
+		placed := ""
+		if call.CreatedAt != nil {
+			placed = call.CreatedAt.Local().Format("Jan 2 15:04")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", call.ID, call.Status, call.PhoneNumber, truncate(call.Objective, maxObjective), placed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if list.NextBeforeID != nil {
+		next := fmt.Sprintf("godial calls list --before %d", *list.NextBeforeID)
+		if opts.Status != "" {
+			next += " --status " + opts.Status
+		}
+		if opts.Limit != 0 {
+			next += fmt.Sprintf(" --limit %d", opts.Limit)
+		}
+		fmt.Fprintf(c.stdout, "More: %s\n", next)
+	}
+	return nil
+}
+
+// runBalance shows the calling time left.
+func runBalance(ctx context.Context, c *cli, args []string) error {
+	fs, asJSON := c.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	minutes, err := c.client.Balance(ctx)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.printJSON(map[string]int64{"minutes": minutes})
+	}
+	fmt.Fprintf(c.stdout, "%d minutes left.\n", minutes)
+	return nil
+}
+
+// status is a call's status as people read it, with why it is in it.
+func status(call client.Call) string {
+	s := strings.ReplaceAll(call.Status, "_", " ")
+	if call.StatusReason != "" {
+		s += " (" + call.StatusReason + ")"
+	}
+	return s
+}
+
+// label names a transcript's speakers like its text format does.
+func label(speaker string) string {
+	switch speaker {
+	case "agent":
+		return "Agent"
+	case "recipient":
+		return "Recipient"
+	}
+	return "System"
+}
+
+// clock formats an offset into a call as mm:ss, or h:mm:ss past the first
+// hour.
+func clock(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"goDial/pkg/client"
+)
+
+// defaultURL is where goDial runs in development.
+const defaultURL = "http://localhost:8081"
+
+// config is where goDial is and the key to use its API with.
+type config struct {
+	URL    string `json:"url"`
+	APIKey string `json:"api_key"`
+}
+
+// loadConfig reads the config file at GODIAL_CONFIG, or godial/config.json
+// in the user's config directory, then overrides it with GODIAL_URL and
+// GODIAL_API_KEY. Only the default file may be missing.
+func loadConfig(getenv func(string) string) (config, error) {
+	path := getenv("GODIAL_CONFIG")
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "godial", "config.json")
+		}
+	}
+
+	var c config
+	if path != "" {
+		b, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		case err != nil:
+			return config{}, fmt.Errorf("error reading config: %w", err)
+		default:
+			if err := json.Unmarshal(b, &c); err != nil {
+				return config{}, fmt.Errorf("error reading config %s: %w", path, err)
+			}
+		}
+	}
+
+	if url := getenv("GODIAL_URL"); url != "" {
+		c.URL = url
+	}
+	if key := getenv("GODIAL_API_KEY"); key != "" {
+		c.APIKey = key
+	}
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	if c.APIKey == "" {
+		return config{}, fmt.Errorf("there's no API key: make one at %s/settings/api-keys, then set GODIAL_API_KEY or add it to %s as \"api_key\"", c.URL, path)
+	}
+	return c, nil
+}
+
+func (c config) client() *client.Client {
+	return client.New(c.URL, c.APIKey)
+}
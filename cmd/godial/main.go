@@ -0,0 +1,101 @@
+// Command godial places and follows calls from the terminal, through the
+// goDial API.
+//
+//	godial call --phone +13336664444 --recipient "Sam Smith" --objective "Book a table for four"
+//	godial status 42
+//	godial tail 42
+//	godial calls list --status in_progress
+//	godial balance
+//
+// Every command takes --json to print what the API returned instead. It
+// authenticates with an API key, made in Settings → API Keys, read from the
+// config file or GODIAL_API_KEY; see loadConfig.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `godial places and follows goDial calls.
+
+Usage:
+  godial call --phone NUMBER --recipient WHO --objective WHAT [options]
+  godial call --phone NUMBER --recipient WHO --template ID --var NAME=VALUE...
+  godial status ID
+  godial tail ID
+  godial calls list [--status STATUS] [--limit N] [--before ID]
+  godial balance
+
+Every command takes --json to print JSON. Run "godial COMMAND --help" for a
+command's options.
+
+The API key and goDial's URL are read from GODIAL_API_KEY and GODIAL_URL, or
+from "api_key" and "url" in the JSON config file at GODIAL_CONFIG, by default
+godial/config.json in your user config directory.
+`
+
+// errUsage is returned for command lines godial can't make sense of, once it
+// has said why.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "godial: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args, printing to stdout, with usage problems
+// going to stderr.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	command, args := args[0], args[1:]
+	if command == "calls" {
+		if len(args) == 0 || args[0] != "list" {
+			fmt.Fprint(stderr, "godial: the calls command is \"godial calls list\"\n")
+			return errUsage
+		}
+		command, args = "calls list", args[1:]
+	}
+
+	var cmd func(ctx context.Context, c *cli, args []string) error
+	switch command {
+	case "call":
+		cmd = runCall
+	case "status":
+		cmd = runStatus
+	case "tail":
+		cmd = runTail
+	case "calls list":
+		cmd = runCallsList
+	case "balance":
+		cmd = runBalance
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(stderr, "godial: there's no %q command\n\n%s", command, usage)
+		return errUsage
+	}
+
+	config, err := loadConfig(getenv)
+	if err != nil {
+		return err
+	}
+	return cmd(ctx, &cli{client: config.client(), name: command, stdout: stdout, stderr: stderr}, args)
+}
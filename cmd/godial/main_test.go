@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI answers the API's requests with a call that says another line of
+// its transcript each time it or its transcript is fetched, and finishes
+// after the last.
+type fakeAPI struct {
+	mu      sync.Mutex
+	fetches int
+	// sent is the id of the last transcript line sent
+	sent    int
+	created map[string]any
+}
+
+var transcriptLines = []string{
+	`{"id":1,"speaker":"agent","text":"Hi, it's goDial calling for Sam.","at":"2026-10-18T18:00:00Z","offset_seconds":0}`,
+	`{"id":2,"speaker":"recipient","text":"Hello!","at":"2026-10-18T18:00:04Z","offset_seconds":4}`,
+	`{"id":3,"speaker":"system","text":"call ended","at":"2026-10-18T18:01:05Z","offset_seconds":65}`,
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer gdk_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"unauthorized","message":"This API key is wrong or has been revoked."}`)
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/balance":
+		fmt.Fprint(w, `{"minutes":42}`)
+	case "GET /api/v1/calls":
+		if r.URL.Query().Get("status") == "failed" {
+			fmt.Fprint(w, `{"calls":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"calls":[
+			{"id":8,"phone_number":"+13336665555","recipient":"Alex","objective":"Ask whether the order shipped, and when it should arrive at the office","status":"in_progress","max_attempts":1,"record":false},
+			{"id":7,"phone_number":"+13336664444","recipient":"Sam","objective":"Book a table","status":"completed","max_attempts":1,"record":false}
+		],"next_before_id":7}`)
+	case "POST /api/v1/calls":
+		if err := json.NewDecoder(r.Body).Decode(&f.created); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.created["phone_number"] == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_request","message":"Enter the number to call.","field":"phone_number"}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":9,"phone_number":"+13336664444","recipient":"Sam","objective":"Book a table","status":"pending","opening_line":"Hi, it's goDial calling for Sam.","max_attempts":1,"record":false}`)
+	case "GET /api/v1/calls/7/transcript":
+		f.fetches++
+		// following a call only asks for the lines it hasn't had
+		if after := r.URL.Query().Get("after_id"); after != fmt.Sprint(f.sent) || r.URL.Query().Get("format") != "json" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"invalid_request","message":"Asked for the lines after %s, having had %d.","field":"after_id"}`, after, f.sent)
+			return
+		}
+		said := min(f.fetches, len(transcriptLines))
+		w.Header().Set("X-Call-Status", "in_progress")
+		if said == len(transcriptLines) {
+			w.Header().Set("X-Call-Status", "completed")
+		}
+		fmt.Fprintf(w, "[%s]", joinLines(transcriptLines[f.sent:said]))
+		f.sent = said
+	case "GET /api/v1/calls/7":
+		f.fetches++
+		lines := transcriptLines[:min(f.fetches, len(transcriptLines))]
+		status, outcome := "in_progress", ""
+		if f.fetches >= len(transcriptLines) {
+			status, outcome = "completed", `"outcome":"conversation",`
+		}
+		fmt.Fprintf(w, `{"id":7,"phone_number":"+13336664444","recipient":"Sam","objective":"Book a table","status":%q,%s"max_attempts":2,"record":false,
+			"attempts":[
+				{"number":1,"status":"no_answer","duration_seconds":0,"billed_minutes":0,"scheduled_for":"2026-10-18T17:00:00Z"},
+				{"number":2,"status":"completed","duration_seconds":65,"billed_minutes":2,"scheduled_for":"2026-10-18T18:00:00Z"}
+			],
+			"facts":[{"key":"table","value":"Friday at 7pm"}],
+			"transcript":[%s]}`, status, outcome, joinLines(lines))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"error":"not_found","message":"There's nothing at %s %s."}`, r.Method, r.URL.Path)
+	}
+}
+
+func joinLines(lines []string) string {
+	var b bytes.Buffer
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		apiKey          string
+		expectedOutput  string
+		expectedRequest map[string]any
+		expectedErr     string
+		expectedUsage   string
+	}{
+		{
+			name:           "balance",
+			args:           []string{"balance"},
+			expectedOutput: "42 minutes left.\n",
+		},
+		{
+			name:           "balance as JSON",
+			args:           []string{"balance", "--json"},
+			expectedOutput: "{\n  \"minutes\": 42\n}\n",
+		},
+		{
+			name: "calls list",
+			args: []string{"calls", "list", "--limit", "2"},
+			expectedOutput: "ID  STATUS       TO            OBJECTIVE                                           PLACED\n" +
+				"8   in_progress  +13336665555  Ask whether the order shipped, and when it should…  \n" +
+				"7   completed    +13336664444  Book a table                                        \n" +
+				"More: godial calls list --before 7 --limit 2\n",
+		},
+		{
+			name:           "calls list without calls",
+			args:           []string{"calls", "list", "--status", "failed"},
+			expectedOutput: "No calls.\n",
+		},
+		{
+			name: "status",
+			args: []string{"status", "7"},
+			expectedOutput: "Call:       7\n" +
+				"To:         +13336664444\n" +
+				"Recipient:  Sam\n" +
+				"Objective:  Book a table\n" +
+				"Status:     in progress\n" +
+				"\nAttempts:\n" +
+				"  1. no answer\n" +
+				"  2. completed, 1m5s, 2 min billed\n" +
+				"\nLearned:\n" +
+				"  table: Friday at 7pm\n",
+		},
+		{
+			name: "call",
+			args: []string{"call", "--phone", "+13336664444", "--recipient", "Sam", "--template", "3", "--var", "day=Friday", "--var", "size=4", "--record"},
+			expectedOutput: "Call 9 to +13336664444 is pending.\n" +
+				"The agent will open with: Hi, it's goDial calling for Sam.\n" +
+				"Follow it with: godial tail 9\n",
+			expectedRequest: map[string]any{
+				"phone_number": "+13336664444",
+				"recipient":    "Sam",
+				"record":       true,
+				"template_id":  float64(3),
+				"variables":    map[string]any{"day": "Friday", "size": "4"},
+			},
+		},
+		{
+			name:        "call the API turns down",
+			args:        []string{"call", "--recipient", "Sam", "--objective", "Book a table"},
+			expectedErr: "Enter the number to call. (--phone)",
+		},
+		{
+			name:           "tail",
+			args:           []string{"tail", "7", "--interval", "1ms"},
+			expectedOutput: "[00:00] Agent: Hi, it's goDial calling for Sam.\n[00:04] Recipient: Hello!\n[01:05] (call ended)\nCall 7 completed.\n",
+		},
+		{
+			name:           "tail as JSON",
+			args:           []string{"tail", "--json", "--interval", "1ms", "7"},
+			expectedOutput: transcriptLines[0] + "\n" + transcriptLines[1] + "\n" + transcriptLines[2] + "\n",
+		},
+		{
+			name:        "someone else's call",
+			args:        []string{"status", "1"},
+			expectedErr: "goDial: not_found: There's nothing at GET /api/v1/calls/1.",
+		},
+		{
+			name:        "wrong key",
+			args:        []string{"balance"},
+			apiKey:      "gdk_wrong",
+			expectedErr: "goDial: unauthorized: This API key is wrong or has been revoked.",
+		},
+		{
+			name:          "status without an id",
+			args:          []string{"status"},
+			expectedErr:   "usage",
+			expectedUsage: "godial status: give the id of the call\n",
+		},
+		{
+			name:          "unknown command",
+			args:          []string{"dial"},
+			expectedErr:   "usage",
+			expectedUsage: "godial: there's no \"dial\" command",
+		},
+		{
+			name:          "bad variable",
+			args:          []string{"call", "--var", "day"},
+			expectedErr:   "usage",
+			expectedUsage: `variables are NAME=VALUE, not "day"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{}
+			server := httptest.NewServer(api)
+			t.Cleanup(server.Close)
+			apiKey := tt.apiKey
+			if apiKey == "" {
+				apiKey = "gdk_test"
+			}
+			// an empty config file, so the user's own isn't read
+			configFile := filepath.Join(t.TempDir(), "config.json")
+			require.NoError(t, os.WriteFile(configFile, []byte(`{}`), 0o600))
+			env := map[string]string{"GODIAL_URL": server.URL, "GODIAL_API_KEY": apiKey, "GODIAL_CONFIG": configFile}
+
+			var stdout, stderr bytes.Buffer
+			err := run(context.Background(), tt.args, &stdout, &stderr, func(name string) string { return env[name] })
+
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedOutput, stdout.String())
+			if tt.expectedUsage != "" {
+				assert.Contains(t, stderr.String(), tt.expectedUsage)
+			}
+			if tt.expectedRequest != nil {
+				assert.Equal(t, tt.expectedRequest, api.created)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"url":"https://godial.example.com","api_key":"gdk_file"}`), 0o600))
+	empty := filepath.Join(dir, "empty.json")
+	require.NoError(t, os.WriteFile(empty, []byte(`{}`), 0o600))
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		expected    config
+		expectedErr string
+	}{
+		{
+			name:     "from the config file",
+			env:      map[string]string{"GODIAL_CONFIG": file},
+			expected: config{URL: "https://godial.example.com", APIKey: "gdk_file"},
+		},
+		{
+			name:     "environment over the config file",
+			env:      map[string]string{"GODIAL_CONFIG": file, "GODIAL_API_KEY": "gdk_env"},
+			expected: config{URL: "https://godial.example.com", APIKey: "gdk_env"},
+		},
+		{
+			name:        "a config file that isn't there",
+			env:         map[string]string{"GODIAL_CONFIG": filepath.Join(dir, "missing.json"), "GODIAL_API_KEY": "gdk_env"},
+			expectedErr: "error reading config: open " + filepath.Join(dir, "missing.json") + ": no such file or directory",
+		},
+		{
+			name:     "defaults to development",
+			env:      map[string]string{"GODIAL_CONFIG": empty, "GODIAL_API_KEY": "gdk_env"},
+			expected: config{URL: "http://localhost:8081", APIKey: "gdk_env"},
+		},
+		{
+			name:        "no key",
+			env:         map[string]string{"GODIAL_CONFIG": empty},
+			expectedErr: `there's no API key: make one at http://localhost:8081/settings/api-keys, then set GODIAL_API_KEY or add it to ` + empty + ` as "api_key"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := loadConfig(func(name string) string { return tt.env[name] })
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, c)
+		})
+	}
+}
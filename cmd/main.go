@@ -1,18 +1,69 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"goDial/internal/admin"
+	"goDial/internal/calls"
+	"goDial/internal/config"
 	"goDial/internal/database"
+	"goDial/internal/jobs"
+	"goDial/internal/recordings"
 	"goDial/internal/router"
 )
 
+// server timeouts. Writes allow for the model calls some pages and
+// webhooks wait on.
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 90 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// serverShutdownTimeout is how long requests still being served, and jobs
+// still running, get once the calls have drained.
+const serverShutdownTimeout = 15 * time.Second
+
 func main() {
-	db, err := database.InitDB("goDial.db")
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goDial: %v\n", err)
+		os.Exit(2)
+	}
+
+	// with a command, look after the database instead of serving
+	if len(args) > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := admin.Run(ctx, cfg, args, os.Stdout, os.Stderr)
+		stop()
+		if errors.Is(err, admin.ErrUsage) {
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goDial: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log.Printf("Configuration:\n%s", cfg)
+
+	db, err := database.InitDB(cfg.DBPath)
 	if err != nil {
-		db, err := database.InitDB("goDial.db")
+		db, err := database.InitDB(cfg.DBPath)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -21,16 +72,78 @@ func main() {
 	}
 	defer db.Close()
 
-	r := router.NewRouter(db)
+	// background jobs, used to place and retry calls
+	queue := jobs.New(db, jobs.Options{})
+
+	var provider calls.Provider
+	if signalWire, err := calls.NewSignalWire(cfg.Telephony); err != nil {
+		log.Printf("telephony disabled, calls will fail until it is configured: %v", err)
+	} else {
+		provider = signalWire
+	}
+
+	callService := calls.NewService(db, queue, provider, calls.NewAnthropicAgent(cfg.AI), cfg)
+
+	// recordings are downloaded from the provider and kept until retention ends
+	callService.StoreRecordings(recordings.NewLocalStore(cfg.Recordings.Dir), cfg.Recordings.Retention())
+	if err := callService.PurgeExpiredRecordings(context.Background()); err != nil {
+		log.Printf("error purging expired recordings: %v", err)
+	}
+
+	// calls the last server left on the line, if it stopped without draining
+	if err := callService.RecoverInterrupted(context.Background()); err != nil {
+		log.Printf("error recovering interrupted calls: %v", err)
+	}
+
+	queue.Start()
+
+	r := router.NewRouter(cfg, db, callService)
 
 	// Show startup message in development mode but make it more informative
-	if os.Getenv("GO_ENV") == "development" && os.Getenv("AIR_ENABLED") == "1" {
-		log.Println("Server restarting on :8081")
+	if cfg.Dev() && os.Getenv("AIR_ENABLED") == "1" {
+		log.Printf("Server restarting on %s", cfg.Addr)
 	} else {
-		log.Println("Starting server on :8081")
+		log.Printf("Starting server on %s", cfg.Addr)
 	}
 
-	if err := http.ListenAndServe(":8081", r); err != nil {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
 		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	// a second signal stops the server without waiting
+	stop()
+
+	// the telephony webhooks are still served while calls finish
+	log.Printf("Shutting down, giving calls on the line up to %s to finish", cfg.ShutdownTimeout())
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownTimeout())
+	if err := callService.Drain(drainCtx); err != nil {
+		log.Printf("error draining calls: %v", err)
+	}
+	cancelDrain()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error shutting down server: %v", err)
+		srv.Close()
+	}
+	if err := queue.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error stopping background jobs: %v", err)
 	}
+	log.Printf("Server stopped")
 }
@@ -0,0 +1,57 @@
+-- +goose Up
+-- Per-call retry policy and the reason a call ended up in its final status
+ALTER TABLE calls ADD COLUMN max_attempts INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE calls ADD COLUMN retry_spacing_minutes INTEGER NOT NULL DEFAULT 15;
+ALTER TABLE calls ADD COLUMN retry_window_start TEXT;
+ALTER TABLE calls ADD COLUMN retry_window_end TEXT;
+ALTER TABLE calls ADD COLUMN status_reason TEXT;
+
+-- Every time a call is dialed, including retries
+CREATE TABLE call_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    call_id INTEGER NOT NULL,
+    attempt_number INTEGER NOT NULL,
+    provider_call_id TEXT,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'dialing', 'ringing', 'in_progress', 'completed', 'busy', 'no_answer', 'failed', 'canceled')),
+    error TEXT,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    billed_minutes INTEGER NOT NULL DEFAULT 0,
+    scheduled_for DATETIME NOT NULL,
+    started_at DATETIME,
+    answered_at DATETIME,
+    ended_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE CASCADE,
+    UNIQUE (call_id, attempt_number)
+);
+
+-- Minutes added to or consumed from a user's balance. reference makes each
+-- charge idempotent, so a retried billing job can't charge twice.
+CREATE TABLE minute_ledger (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    call_id INTEGER,
+    minutes INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    reference TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_call_attempts_call_id ON call_attempts(call_id);
+CREATE INDEX idx_call_attempts_provider_call_id ON call_attempts(provider_call_id);
+CREATE INDEX idx_minute_ledger_user_id ON minute_ledger(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_minute_ledger_user_id;
+DROP INDEX IF EXISTS idx_call_attempts_provider_call_id;
+DROP INDEX IF EXISTS idx_call_attempts_call_id;
+DROP TABLE IF EXISTS minute_ledger;
+DROP TABLE IF EXISTS call_attempts;
+ALTER TABLE calls DROP COLUMN status_reason;
+ALTER TABLE calls DROP COLUMN retry_window_end;
+ALTER TABLE calls DROP COLUMN retry_window_start;
+ALTER TABLE calls DROP COLUMN retry_spacing_minutes;
+ALTER TABLE calls DROP COLUMN max_attempts;
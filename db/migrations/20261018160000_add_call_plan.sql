@@ -0,0 +1,14 @@
+-- +goose Up
+-- The opening line and plan the user approved before the call was dialed.
+-- Calls aren't dialed until approved_at is set.
+ALTER TABLE calls ADD COLUMN opening_line TEXT;
+ALTER TABLE calls ADD COLUMN call_plan TEXT;
+ALTER TABLE calls ADD COLUMN approved_at DATETIME;
+
+-- calls made before plans existed went straight to dialing
+UPDATE calls SET approved_at = created_at;
+
+-- +goose Down
+ALTER TABLE calls DROP COLUMN approved_at;
+ALTER TABLE calls DROP COLUMN call_plan;
+ALTER TABLE calls DROP COLUMN opening_line;
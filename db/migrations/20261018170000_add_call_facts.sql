@@ -0,0 +1,20 @@
+-- +goose Up
+-- Facts the agent recorded during a call, such as confirmation numbers. A
+-- fact recorded again under the same key replaces the old value.
+CREATE TABLE call_facts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    call_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE CASCADE,
+    UNIQUE (call_id, key)
+);
+
+-- Where the agent transfers a call when the recipient asks for the user
+ALTER TABLE users ADD COLUMN phone_number TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN phone_number;
+DROP TABLE IF EXISTS call_facts;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Whether a call was answered by a person or a machine, and whether the
+-- agent left a voicemail on it
+ALTER TABLE call_attempts ADD COLUMN answered_by TEXT CHECK (answered_by IN ('human', 'machine'));
+ALTER TABLE call_attempts ADD COLUMN voicemail_left_at DATETIME;
+
+-- How a finished call ended up: a conversation with someone, or a voicemail
+ALTER TABLE calls ADD COLUMN outcome TEXT CHECK (outcome IN ('conversation', 'voicemail'));
+
+-- Users choose whether leaving a voicemail completes a call, or whether it
+-- should be retried until someone picks up
+ALTER TABLE users ADD COLUMN voicemail_counts_as_success BOOLEAN NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN voicemail_counts_as_success;
+ALTER TABLE calls DROP COLUMN outcome;
+ALTER TABLE call_attempts DROP COLUMN voicemail_left_at;
+ALTER TABLE call_attempts DROP COLUMN answered_by;
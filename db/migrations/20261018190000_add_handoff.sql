@@ -0,0 +1,30 @@
+-- +goose Up
+-- Codes read out to users to prove they own the phone number calls are handed
+-- off to. A number is only saved on the user once it is verified.
+CREATE TABLE phone_verifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    phone_number TEXT NOT NULL,
+    code TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_phone_verifications_user ON phone_verifications(user_id);
+
+ALTER TABLE users ADD COLUMN phone_verified_at DATETIME;
+
+-- When an attempt was handed off to the user's phone, and the connected time
+-- and billed minutes of the user's leg of the call
+ALTER TABLE call_attempts ADD COLUMN handoff_at DATETIME;
+ALTER TABLE call_attempts ADD COLUMN handoff_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE call_attempts ADD COLUMN handoff_billed_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE call_attempts DROP COLUMN handoff_billed_minutes;
+ALTER TABLE call_attempts DROP COLUMN handoff_seconds;
+ALTER TABLE call_attempts DROP COLUMN handoff_at;
+ALTER TABLE users DROP COLUMN phone_verified_at;
+DROP TABLE IF EXISTS phone_verifications;
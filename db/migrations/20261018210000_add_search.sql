@@ -0,0 +1,70 @@
+-- +goose Up
+-- Full text search of what calls were about and what was said on them, in
+-- FTS5 external content tables kept in sync by triggers. It needs SQLite
+-- built with FTS5, which go-sqlite3 only does with the sqlite_fts5 tag.
+
+CREATE VIRTUAL TABLE calls_search USING fts5(
+    objective, recipient_context,
+    content='calls', content_rowid='id', tokenize='porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER calls_search_insert AFTER INSERT ON calls BEGIN
+    INSERT INTO calls_search (rowid, objective, recipient_context)
+    VALUES (new.id, new.objective, new.recipient_context);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER calls_search_delete AFTER DELETE ON calls BEGIN
+    INSERT INTO calls_search (calls_search, rowid, objective, recipient_context)
+    VALUES ('delete', old.id, old.objective, old.recipient_context);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER calls_search_update AFTER UPDATE OF objective, recipient_context ON calls BEGIN
+    INSERT INTO calls_search (calls_search, rowid, objective, recipient_context)
+    VALUES ('delete', old.id, old.objective, old.recipient_context);
+    INSERT INTO calls_search (rowid, objective, recipient_context)
+    VALUES (new.id, new.objective, new.recipient_context);
+END;
+-- +goose StatementEnd
+
+INSERT INTO calls_search (calls_search) VALUES ('rebuild');
+
+CREATE VIRTUAL TABLE call_logs_search USING fts5(
+    content,
+    content='call_logs', content_rowid='id', tokenize='porter unicode61'
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER call_logs_search_insert AFTER INSERT ON call_logs BEGIN
+    INSERT INTO call_logs_search (rowid, content) VALUES (new.id, new.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER call_logs_search_delete AFTER DELETE ON call_logs BEGIN
+    INSERT INTO call_logs_search (call_logs_search, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER call_logs_search_update AFTER UPDATE OF content ON call_logs BEGIN
+    INSERT INTO call_logs_search (call_logs_search, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO call_logs_search (rowid, content) VALUES (new.id, new.content);
+END;
+-- +goose StatementEnd
+
+INSERT INTO call_logs_search (call_logs_search) VALUES ('rebuild');
+
+-- +goose Down
+DROP TRIGGER IF EXISTS call_logs_search_update;
+DROP TRIGGER IF EXISTS call_logs_search_delete;
+DROP TRIGGER IF EXISTS call_logs_search_insert;
+DROP TABLE IF EXISTS call_logs_search;
+DROP TRIGGER IF EXISTS calls_search_update;
+DROP TRIGGER IF EXISTS calls_search_delete;
+DROP TRIGGER IF EXISTS calls_search_insert;
+DROP TABLE IF EXISTS calls_search;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- Objectives a user places again and again, with {{placeholders}} filled in
+-- on the call form. Templates without a user are provided to everyone.
+CREATE TABLE call_templates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER,
+    name TEXT NOT NULL,
+    objective TEXT NOT NULL,
+    other_context TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_call_templates_user ON call_templates(user_id);
+
+INSERT INTO call_templates (name, objective, other_context) VALUES
+    ('Confirm an appointment', 'Confirm the appointment for {{name}} on {{date:date}} at {{time:time}}.', 'If the time no longer works for them, ask which times would.'),
+    ('Reschedule an appointment', 'Move the appointment for {{name}} on {{current_date:date}} to a time on or after {{earliest_date:date}}.', 'Take the earliest time they offer that is on or after that date.'),
+    ('Check on an order', 'Ask for the status of order {{order_number}} and when it will arrive.', ''),
+    ('Book a table', 'Book a table for {{party_size:number}} on {{date:date}} at {{time:time}}.', 'Times within half an hour either way are fine.');
+
+-- the template a call was rendered from and the values it was given, as a
+-- JSON object, so calls can be compared by template
+ALTER TABLE calls ADD COLUMN template_id INTEGER REFERENCES call_templates(id) ON DELETE SET NULL;
+ALTER TABLE calls ADD COLUMN template_variables TEXT;
+
+CREATE INDEX idx_calls_template ON calls(template_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calls_template;
+ALTER TABLE calls DROP COLUMN template_variables;
+ALTER TABLE calls DROP COLUMN template_id;
+DROP INDEX IF EXISTS idx_call_templates_user;
+DROP TABLE IF EXISTS call_templates;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- Endpoints in a user's own systems notified of call and balance events.
+-- events is a comma separated list of the event types subscribed to; secret
+-- signs each delivery so the receiver can tell it came from us.
+CREATE TABLE webhook_endpoints (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_endpoints_user ON webhook_endpoints(user_id);
+
+-- One event sent to one endpoint. payload is the exact body posted, so a
+-- replay sends the same thing again.
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    endpoint_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_at DATETIME,
+    delivered_at DATETIME,
+    FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_webhook_deliveries_endpoint;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhook_endpoints_user;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,77 @@
+-- name: CreateCallAttempt :one
+INSERT INTO call_attempts (call_id, attempt_number, scheduled_for)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetCallAttempt :one
+SELECT * FROM call_attempts
+WHERE id = ?;
+
+-- name: ListCallAttempts :many
+SELECT * FROM call_attempts
+WHERE call_id = ?
+ORDER BY attempt_number;
+
+-- name: CountCallAttempts :one
+SELECT COUNT(*) FROM call_attempts
+WHERE call_id = ?;
+
+-- name: MarkCallAttemptDialing :one
+UPDATE call_attempts
+SET status = 'dialing', provider_call_id = ?, started_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND ended_at IS NULL
+RETURNING *;
+
+-- name: UpdateCallAttemptStatus :one
+UPDATE call_attempts
+SET status = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: MarkCallAttemptAnswered :one
+UPDATE call_attempts
+SET status = 'in_progress', answered_at = COALESCE(answered_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: EndCallAttempt :one
+UPDATE call_attempts
+SET status = ?, error = ?, duration_seconds = ?, ended_at = COALESCE(ended_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: SetCallAttemptBilledMinutes :exec
+UPDATE call_attempts
+SET billed_minutes = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetCallAttemptAnsweredBy :exec
+UPDATE call_attempts
+SET answered_by = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: MarkVoicemailLeft :exec
+UPDATE call_attempts
+SET voicemail_left_at = COALESCE(voicemail_left_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: StartHandoff :one
+UPDATE call_attempts
+SET handoff_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND handoff_at IS NULL
+RETURNING *;
+
+-- name: CancelHandoff :exec
+UPDATE call_attempts
+SET handoff_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: EndHandoff :exec
+UPDATE call_attempts
+SET handoff_seconds = ?, handoff_billed_minutes = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListLiveCallAttempts :many
+SELECT * FROM call_attempts
+WHERE status IN ('queued', 'dialing', 'ringing', 'in_progress')
+ORDER BY id;
@@ -0,0 +1,10 @@
+-- name: RecordCallFact :one
+INSERT INTO call_facts (call_id, key, value)
+VALUES (?, ?, ?)
+ON CONFLICT (call_id, key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: ListCallFacts :many
+SELECT * FROM call_facts
+WHERE call_id = ?
+ORDER BY id;
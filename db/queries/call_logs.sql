@@ -0,0 +1,24 @@
+-- name: CreateCallLog :one
+INSERT INTO call_logs (call_id, message_type, content)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: ListCallLogs :many
+SELECT * FROM call_logs
+WHERE call_id = ?
+ORDER BY timestamp, id;
+
+-- name: GetFirstCallLog :one
+SELECT * FROM call_logs
+WHERE call_id = ?
+ORDER BY timestamp, id
+LIMIT 1;
+
+-- name: ListCallLogsSince :many
+SELECT * FROM call_logs
+WHERE call_id = sqlc.arg(call_id) AND timestamp >= datetime(sqlc.arg(since)) AND id > sqlc.arg(after_id)
+ORDER BY timestamp, id;
+
+-- name: CountCallTurns :one
+SELECT COUNT(*) FROM call_logs
+WHERE call_id = ? AND message_type IN ('user_speech', 'ai_response');
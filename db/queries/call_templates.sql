@@ -0,0 +1,27 @@
+-- name: CreateCallTemplate :one
+INSERT INTO call_templates (user_id, name, objective, other_context)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetCallTemplate :one
+SELECT * FROM call_templates
+WHERE id = ? AND (user_id IS NULL OR user_id = ?);
+
+-- name: ListCallTemplates :many
+SELECT * FROM call_templates
+WHERE user_id IS NULL OR user_id = ?
+ORDER BY user_id IS NULL, name COLLATE NOCASE, id;
+
+-- name: DeleteCallTemplate :execrows
+DELETE FROM call_templates
+WHERE id = ? AND user_id = ?;
+
+-- name: ListCallTemplateStats :many
+SELECT t.id AS template_id,
+    COUNT(c.id) AS calls,
+    COUNT(CASE WHEN c.status = 'completed' THEN 1 END) AS completed,
+    COUNT(CASE WHEN c.status = 'failed' THEN 1 END) AS failed
+FROM call_templates t
+JOIN calls c ON c.template_id = t.id
+WHERE c.user_id = ?
+GROUP BY t.id;
@@ -1,6 +1,17 @@
 -- name: CreateCall :one
-INSERT INTO calls (user_id, phone_number, recipient_context, objective, background_context)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO calls (
+    user_id, phone_number, recipient_context, objective, background_context,
+    max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end,
+    record, opening_line, call_plan, contact_id, template_id, template_variables,
+    campaign_id
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: ApproveCall :one
+UPDATE calls
+SET opening_line = ?, call_plan = ?, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND approved_at IS NULL
 RETURNING *;
 
 -- name: GetCall :one
@@ -23,12 +34,41 @@ SET status = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
+-- name: SetCallStatus :one
+UPDATE calls
+SET status = ?, status_reason = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
 -- name: CompleteCall :one
 UPDATE calls
 SET status = 'completed', completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 RETURNING *;
 
+-- name: FinishCall :one
+UPDATE calls
+SET status = ?, status_reason = ?, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
 -- name: DeleteCall :exec
 DELETE FROM calls
-WHERE id = ?; 
\ No newline at end of file
+WHERE id = ?; 
+-- name: SetCallOutcome :exec
+UPDATE calls
+SET outcome = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListCallsPage :many
+SELECT * FROM calls
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(status) IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(before_id) IS NULL OR id < sqlc.narg(before_id))
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CancelCall :execrows
+UPDATE calls
+SET status = 'failed', status_reason = ?, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('pending', 'in_progress');
@@ -0,0 +1,72 @@
+-- name: CreateCampaign :one
+INSERT INTO campaigns (user_id, name, template_id, columns, max_concurrent, calls_per_minute, max_attempts)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetCampaign :one
+SELECT * FROM campaigns
+WHERE id = ? AND user_id = ?;
+
+-- name: GetCampaignByID :one
+SELECT * FROM campaigns
+WHERE id = ?;
+
+-- name: ListCampaigns :many
+SELECT * FROM campaigns
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC;
+
+-- name: StartCampaign :execrows
+UPDATE campaigns
+SET status = 'running', started_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'draft';
+
+-- name: CancelCampaign :execrows
+UPDATE campaigns
+SET status = 'cancelled', completed_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('draft', 'running');
+
+-- name: CompleteCampaign :execrows
+UPDATE campaigns
+SET status = 'completed', completed_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'running';
+
+-- name: ClaimCampaignDispatch :execrows
+UPDATE campaigns
+SET last_dispatched_at = ?
+WHERE id = ? AND status = 'running' AND (last_dispatched_at IS NULL OR last_dispatched_at <= sqlc.arg(dispatched_before));
+
+-- name: CreateCampaignRow :exec
+INSERT INTO campaign_rows (
+    campaign_id, row_number, fields, phone_number, recipient_context,
+    objective, other_context, template_variables, status, problem
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListCampaignRows :many
+SELECT r.id, r.row_number, r.fields, r.phone_number, r.status, r.problem, r.call_id,
+    c.status AS call_status, c.status_reason AS call_status_reason, c.outcome AS call_outcome
+FROM campaign_rows r
+LEFT JOIN calls c ON c.id = r.call_id
+WHERE r.campaign_id = ?
+ORDER BY r.row_number;
+
+-- name: NextQueuedCampaignRow :one
+SELECT * FROM campaign_rows
+WHERE campaign_id = ? AND status = 'queued'
+ORDER BY row_number
+LIMIT 1;
+
+-- name: MarkCampaignRowPlaced :execrows
+UPDATE campaign_rows
+SET status = 'placed', call_id = ?
+WHERE id = ? AND status = 'queued';
+
+-- name: CancelQueuedCampaignRows :exec
+UPDATE campaign_rows
+SET status = 'cancelled'
+WHERE campaign_id = ? AND status = 'queued';
+
+-- name: CountCampaignCallsInFlight :one
+SELECT COUNT(*) FROM calls
+WHERE campaign_id = ? AND status IN ('pending', 'in_progress');
@@ -0,0 +1,43 @@
+-- name: CreateContact :one
+INSERT INTO contacts (user_id, name, phone_number, notes, timezone, preferred_language)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetContact :one
+SELECT * FROM contacts
+WHERE id = ? AND user_id = ?;
+
+-- name: GetContactByNumber :one
+SELECT * FROM contacts
+WHERE user_id = ? AND phone_number = ?;
+
+-- name: ListContacts :many
+SELECT * FROM contacts
+WHERE user_id = ?
+ORDER BY name COLLATE NOCASE, id;
+
+-- name: SuggestContacts :many
+SELECT * FROM contacts
+WHERE user_id = ? AND (name LIKE ? ESCAPE '\' OR phone_number LIKE ?)
+ORDER BY name COLLATE NOCASE, id
+LIMIT ?;
+
+-- name: UpdateContact :one
+UPDATE contacts
+SET name = ?, phone_number = ?, notes = ?, timezone = ?, preferred_language = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND user_id = ?
+RETURNING *;
+
+-- name: DeleteContact :execrows
+DELETE FROM contacts
+WHERE id = ? AND user_id = ?;
+
+-- name: LinkCallsToContact :exec
+UPDATE calls
+SET contact_id = ?
+WHERE user_id = ? AND phone_number = ? AND contact_id IS NULL;
+
+-- name: ListCallsByContact :many
+SELECT * FROM calls
+WHERE contact_id = ?
+ORDER BY created_at DESC, id DESC;
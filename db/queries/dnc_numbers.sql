@@ -0,0 +1,23 @@
+-- name: CreateDNCEntry :execrows
+INSERT INTO dnc_numbers (phone_number, user_id, source, reason, call_id)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: FindDNCEntry :one
+SELECT * FROM dnc_numbers
+WHERE phone_number = sqlc.arg(phone_number)
+  AND (user_id IS NULL OR user_id = sqlc.arg(user_id))
+ORDER BY user_id IS NOT NULL
+LIMIT 1;
+
+-- name: ListDNCEntries :many
+SELECT * FROM dnc_numbers
+ORDER BY created_at DESC, id DESC
+LIMIT ?;
+
+-- name: CountDNCEntries :one
+SELECT COUNT(*) FROM dnc_numbers;
+
+-- name: DeleteDNCEntry :exec
+DELETE FROM dnc_numbers
+WHERE id = ?;
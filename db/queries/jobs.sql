@@ -0,0 +1,74 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, max_attempts, run_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = ?;
+
+-- name: ListJobsByStatus :many
+SELECT * FROM jobs
+WHERE status = ?
+ORDER BY run_at, id;
+
+-- name: ClaimJob :one
+UPDATE jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    locked_by = sqlc.arg(worker),
+    locked_until = sqlc.arg(lease_until),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (jobs.status = 'queued' AND jobs.run_at <= sqlc.arg(now))
+       OR (jobs.status = 'running' AND jobs.locked_until <= sqlc.arg(lease_expired_before)
+           AND jobs.attempts < jobs.max_attempts)
+    ORDER BY jobs.run_at, jobs.id
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: ExtendJobLease :execrows
+UPDATE jobs
+SET locked_until = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ? AND status = 'running';
+
+-- name: CompleteJob :execrows
+UPDATE jobs
+SET status = 'succeeded', locked_by = NULL, locked_until = NULL, last_error = NULL,
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ? AND status = 'running';
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET status = 'queued', run_at = ?, last_error = ?, locked_by = NULL, locked_until = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ? AND status = 'running';
+
+-- name: DeadLetterJob :execrows
+UPDATE jobs
+SET status = 'dead', last_error = ?, locked_by = NULL, locked_until = NULL,
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ? AND status = 'running';
+
+-- name: DeadLetterAbandonedJobs :many
+UPDATE jobs
+SET status = 'dead', last_error = 'the worker running its last attempt stopped without finishing it',
+    locked_by = NULL, locked_until = NULL,
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE status = 'running' AND locked_until <= sqlc.arg(lease_expired_before)
+  AND attempts >= max_attempts
+RETURNING *;
+
+-- name: RequeueDeadJob :one
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = ?, last_error = NULL, finished_at = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'dead'
+RETURNING *;
+
+-- name: CountDueJobs :one
+SELECT COUNT(*) FROM jobs
+WHERE kind = sqlc.arg(kind)
+  AND (status = 'running' OR (status = 'queued' AND run_at <= sqlc.arg(now)));
@@ -0,0 +1,14 @@
+-- name: CreateLedgerEntry :execrows
+INSERT INTO minute_ledger (user_id, call_id, minutes, reason, reference)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (reference) DO NOTHING;
+
+-- name: AdjustUserMinutes :exec
+UPDATE users
+SET minutes = minutes + ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ListLedgerEntriesByUser :many
+SELECT * FROM minute_ledger
+WHERE user_id = ?
+ORDER BY created_at DESC, id DESC;
@@ -0,0 +1,27 @@
+-- name: CreatePhoneVerification :one
+INSERT INTO phone_verifications (user_id, phone_number, code, expires_at)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetPhoneVerification :one
+SELECT * FROM phone_verifications
+WHERE id = ?;
+
+-- name: GetLatestPhoneVerification :one
+SELECT * FROM phone_verifications
+WHERE user_id = ?
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CountActivePhoneVerifications :one
+SELECT COUNT(*) FROM phone_verifications
+WHERE user_id = ? AND expires_at > ?;
+
+-- name: AddPhoneVerificationAttempt :exec
+UPDATE phone_verifications
+SET attempts = attempts + 1
+WHERE id = ?;
+
+-- name: DeletePhoneVerifications :exec
+DELETE FROM phone_verifications
+WHERE user_id = ?;
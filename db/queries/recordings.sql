@@ -0,0 +1,27 @@
+-- name: CreateRecording :one
+INSERT INTO recordings (call_id, call_attempt_id, provider_recording_id, storage_key, content_type, size_bytes, duration_seconds, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetRecording :one
+SELECT * FROM recordings
+WHERE id = ?;
+
+-- name: GetRecordingByAttempt :one
+SELECT * FROM recordings
+WHERE call_attempt_id = ?;
+
+-- name: ListRecordingsByCall :many
+SELECT * FROM recordings
+WHERE call_id = ?
+ORDER BY id;
+
+-- name: ListRecordingsToPurge :many
+SELECT * FROM recordings
+WHERE purged_at IS NULL AND recorded_at <= ?
+ORDER BY recorded_at;
+
+-- name: MarkRecordingPurged :exec
+UPDATE recordings
+SET purged_at = COALESCE(purged_at, CURRENT_TIMESTAMP)
+WHERE id = ?;
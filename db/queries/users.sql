@@ -28,3 +28,21 @@ WHERE id = ?;
 -- name: GetUserMinutes :one
 SELECT minutes FROM users
 WHERE email = ?;
+
+-- name: UpdateUserSettings :one
+UPDATE users
+SET voicemail_counts_as_success = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: SetUserPhoneVerified :one
+UPDATE users
+SET phone_number = ?, phone_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: SetUserAdmin :one
+UPDATE users
+SET is_admin = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
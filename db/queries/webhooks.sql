@@ -0,0 +1,60 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (user_id, url, secret, events)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = ?
+ORDER BY id;
+
+-- name: ListWebhookEndpointsForEvent :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = ? AND (',' || events || ',') LIKE '%,' || sqlc.arg(event) || ',%'
+ORDER BY id;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = ? AND user_id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (endpoint_id, event, payload)
+VALUES (?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT d.id, d.endpoint_id, d.event, d.payload, d.status, d.attempts, e.url, e.secret
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = ?;
+
+-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET status = ?,
+    response_status = ?,
+    last_error = ?,
+    attempts = attempts + 1,
+    last_attempt_at = ?,
+    delivered_at = COALESCE(sqlc.arg(delivered_at), delivered_at)
+WHERE id = ?;
+
+-- name: ListWebhookDeliveries :many
+SELECT d.id, d.endpoint_id, e.url, d.event, d.status, d.attempts, d.response_status, d.last_error,
+    d.created_at, d.last_attempt_at
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE e.user_id = ?
+ORDER BY d.id DESC
+LIMIT 50;
+
+-- name: GetWebhookDeliveryStatus :one
+SELECT d.status
+FROM webhook_deliveries d
+JOIN webhook_endpoints e ON e.id = d.endpoint_id
+WHERE d.id = ? AND e.user_id = ?;
+
+-- name: ReplayWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET status = 'pending'
+WHERE id = ? AND status <> 'pending'
+  AND endpoint_id IN (SELECT id FROM webhook_endpoints WHERE user_id = ?);
@@ -0,0 +1,205 @@
+// Package admin runs the server binary's maintenance commands: migrating,
+// seeding and backing up the database, and managing users, so operators need
+// only the one binary rather than the sqlite3 and goose tools the scripts use.
+//
+//	goDial migrate status
+//	goDial user create --email sam@example.com --name "Sam Smith" --minutes 60
+//	goDial backup
+//
+// The commands use the database the configuration names; see package config.
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"goDial/internal/config"
+	"goDial/internal/database"
+)
+
+const usage = `goDial serves goDial. Given a command, it looks after its database instead.
+
+Usage:
+  goDial                        serve goDial
+  goDial migrate up             apply the migrations the database doesn't have
+  goDial migrate down           roll back the last migration applied
+  goDial migrate status         list the migrations and which are applied
+  goDial seed                   add the development users and calls
+  goDial user create --email EMAIL --name NAME [--minutes N] [--admin]
+  goDial user grant-minutes --email EMAIL --minutes N [--reason WHY]
+  goDial backup [--dir DIR]     write a gzipped copy of the database
+
+The database is --db or GODIAL_DB_PATH, by default goDial.db in the working
+directory. Run "goDial --help" for the other settings.
+`
+
+// ErrUsage is returned for command lines the commands can't make sense of,
+// once they have said why.
+var ErrUsage = errors.New("usage")
+
+// env is what a command runs with.
+type env struct {
+	// dbPath is the database's file
+	dbPath string
+	// devUserEmail is the development user seed adds
+	devUserEmail string
+	stdout       io.Writer
+	stderr       io.Writer
+}
+
+// Run runs the command line args with cfg, printing to stdout, with usage
+// problems going to stderr.
+func Run(ctx context.Context, cfg config.Config, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ErrUsage
+	}
+	e := &env{dbPath: cfg.DBPath, devUserEmail: cfg.DevUserEmail, stdout: stdout, stderr: stderr}
+
+	command, args := args[0], args[1:]
+	if command == "migrate" || command == "user" {
+		if len(args) == 0 {
+			fmt.Fprintf(stderr, "goDial: %s needs a subcommand\n\n%s", command, usage)
+			return ErrUsage
+		}
+		command, args = command+" "+args[0], args[1:]
+	}
+
+	var cmd func(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error
+	switch command {
+	case "migrate up":
+		cmd = runMigrateUp
+	case "migrate down":
+		cmd = runMigrateDown
+	case "migrate status":
+		cmd = runMigrateStatus
+	case "seed":
+		cmd = runSeed
+	case "user create":
+		cmd = runUserCreate
+	case "user grant-minutes":
+		cmd = runGrantMinutes
+	case "backup":
+		cmd = runBackup
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(stderr, "goDial: there's no %q command\n\n%s", command, usage)
+		return ErrUsage
+	}
+
+	flags := flag.NewFlagSet("goDial "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return cmd(ctx, e, flags, args)
+}
+
+// parse parses a command's flags; none take anything else.
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		// the flag package has said what is wrong
+		return ErrUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "%s: unexpected %q\n", flags.Name(), flags.Arg(0))
+		return ErrUsage
+	}
+	return nil
+}
+
+// open opens the database without migrating it. Only migrate up may create
+// it; the other commands need one that is there.
+func (e *env) open(create bool) (*database.DB, error) {
+	if !create {
+		if _, err := os.Stat(e.dbPath); errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("there's no database at %s: run \"goDial migrate up\" to create it", e.dbPath)
+		}
+	}
+	return database.Open(e.dbPath)
+}
+
+// runMigrateUp applies the migrations the database doesn't have yet.
+func runMigrateUp(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	db, err := e.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := db.MigrateUp(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range applied {
+		fmt.Fprintf(e.stdout, "Applied %s\n", name)
+	}
+	if len(applied) == 0 {
+		fmt.Fprintln(e.stdout, "The database is up to date.")
+	}
+	return nil
+}
+
+// runMigrateDown rolls back the last migration applied.
+func runMigrateDown(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	name, err := db.MigrateDown(ctx)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Fprintln(e.stdout, "There are no migrations to roll back.")
+		return nil
+	}
+	fmt.Fprintf(e.stdout, "Rolled back %s\n", name)
+	return nil
+}
+
+// runMigrateStatus lists the migrations, and when each was applied.
+func runMigrateStatus(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrations, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	tw := newTable(e.stdout)
+	fmt.Fprintln(tw, "VERSION\tMIGRATION\tAPPLIED")
+	pending := 0
+	for _, migration := range migrations {
+		applied := "pending"
+		if migration.Applied {
+			applied = migration.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", migration.Version, migration.Name, applied)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "%d of %d migrations pending.\n", pending, len(migrations))
+	return nil
+}
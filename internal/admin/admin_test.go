@@ -0,0 +1,254 @@
+package admin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"goDial/internal/config"
+	"goDial/internal/database"
+)
+
+// run runs a command line against the database at dbPath.
+func run(t *testing.T, dbPath string, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cfg := config.Default()
+	cfg.DBPath = dbPath
+	err := Run(context.Background(), cfg, args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+// migrated returns the path of a new, migrated database.
+func migrated(t *testing.T) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "goDial.db")
+	_, _, err := run(t, dbPath, "migrate", "up")
+	require.NoError(t, err)
+	return dbPath
+}
+
+func openDB(t *testing.T, dbPath string) *database.DB {
+	t.Helper()
+	db, err := database.Open(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedUsage string
+	}{
+		{
+			name:          "no command",
+			args:          []string{},
+			expectedUsage: "Usage:",
+		},
+		{
+			name:          "unknown command",
+			args:          []string{"serve"},
+			expectedUsage: `goDial: there's no "serve" command`,
+		},
+		{
+			name:          "migrate without a subcommand",
+			args:          []string{"migrate"},
+			expectedUsage: "goDial: migrate needs a subcommand",
+		},
+		{
+			name:          "unknown subcommand",
+			args:          []string{"user", "delete"},
+			expectedUsage: `goDial: there's no "user delete" command`,
+		},
+		{
+			name:          "user without an email",
+			args:          []string{"user", "create", "--name", "Sam"},
+			expectedUsage: "goDial user create: give the user's --email and --name",
+		},
+		{
+			name:          "grant without minutes",
+			args:          []string{"user", "grant-minutes", "--email", "sam@example.com"},
+			expectedUsage: "goDial user grant-minutes: give the user's --email and the --minutes to grant",
+		},
+		{
+			name:          "unexpected argument",
+			args:          []string{"seed", "everything"},
+			expectedUsage: `goDial seed: unexpected "everything"`,
+		},
+		{
+			name:          "unknown flag",
+			args:          []string{"backup", "--to", "elsewhere"},
+			expectedUsage: "flag provided but not defined: -to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := run(t, filepath.Join(t.TempDir(), "goDial.db"), tt.args...)
+			assert.ErrorIs(t, err, ErrUsage)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, tt.expectedUsage)
+		})
+	}
+}
+
+func TestRun_NoDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "goDial.db")
+	for _, args := range [][]string{
+		{"migrate", "status"},
+		{"migrate", "down"},
+		{"seed"},
+		{"user", "create", "--email", "sam@example.com", "--name", "Sam"},
+		{"backup"},
+	} {
+		_, _, err := run(t, dbPath, args...)
+		assert.EqualError(t, err, `there's no database at `+dbPath+`: run "goDial migrate up" to create it`, args)
+	}
+	_, err := os.Stat(dbPath)
+	assert.True(t, os.IsNotExist(err), "only migrate up creates the database")
+}
+
+func TestRun_Migrate(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "goDial.db")
+
+	stdout, _, err := run(t, dbPath, "migrate", "up")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "Applied initial_schema\n"), stdout)
+	assert.Contains(t, stdout, "Applied create_api_keys\n")
+
+	stdout, _, err = run(t, dbPath, "migrate", "up")
+	require.NoError(t, err)
+	assert.Equal(t, "The database is up to date.\n", stdout)
+
+	stdout, _, err = run(t, dbPath, "migrate", "down")
+	require.NoError(t, err)
+	assert.Equal(t, "Rolled back create_api_keys\n", stdout)
+
+	stdout, _, err = run(t, dbPath, "migrate", "status")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	assert.Regexp(t, `^VERSION\s+MIGRATION\s+APPLIED$`, lines[0])
+	assert.Regexp(t, `^1\s+initial_schema\s+\d{4}-\d\d-\d\d \d\d:\d\d:\d\d$`, lines[1])
+	assert.Regexp(t, `^20261018235000\s+create_api_keys\s+pending$`, lines[len(lines)-2])
+	assert.Regexp(t, `^1 of \d+ migrations pending\.$`, lines[len(lines)-1])
+
+	stdout, _, err = run(t, dbPath, "migrate", "up")
+	require.NoError(t, err)
+	assert.Equal(t, "Applied create_api_keys\n", stdout)
+}
+
+func TestRun_Seed(t *testing.T) {
+	dbPath := migrated(t)
+
+	stdout, _, err := run(t, dbPath, "seed")
+	require.NoError(t, err)
+	assert.Equal(t, "Seeded 5 users, 6 calls and 16 transcript lines.\n", stdout)
+
+	db := openDB(t, dbPath)
+	admin, err := db.GetUserByEmail(context.Background(), "test@test.com")
+	require.NoError(t, err)
+	assert.True(t, admin.IsAdmin)
+	assert.Equal(t, int64(9999), minutesOf(admin))
+	bob, err := db.GetUserByEmail(context.Background(), "bob@example.com")
+	require.NoError(t, err)
+	assert.False(t, bob.IsAdmin)
+	assert.Equal(t, int64(10), minutesOf(bob))
+	ledger, err := db.ListLedgerEntriesByUser(context.Background(), bob.ID)
+	require.NoError(t, err)
+	require.Len(t, ledger, 1)
+	assert.Equal(t, int64(10), ledger[0].Minutes)
+
+	var statuses string
+	require.NoError(t, db.QueryRow(`SELECT group_concat(status, ',') FROM (SELECT status FROM calls ORDER BY id)`).Scan(&statuses))
+	assert.Equal(t, "pending,completed,completed,failed,in_progress,pending", statuses)
+
+	// the users are there already, so seeding again adds nothing
+	stdout, _, err = run(t, dbPath, "seed")
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "Skipped bob@example.com, who is already there.\n")
+	assert.Contains(t, stdout, "Seeded 0 users, 0 calls and 0 transcript lines.\n")
+}
+
+func TestRun_Users(t *testing.T) {
+	dbPath := migrated(t)
+	ctx := context.Background()
+
+	stdout, _, err := run(t, dbPath, "user", "create", "--email", "sam@example.com", "--name", "Sam Smith", "--minutes", "60", "--admin")
+	require.NoError(t, err)
+	assert.Equal(t, "Created admin 1, Sam Smith <sam@example.com>, with 60 minutes.\n", stdout)
+
+	_, _, err = run(t, dbPath, "user", "create", "--email", "sam@example.com", "--name", "Sam Again")
+	assert.EqualError(t, err, "there's already a user with the email sam@example.com")
+
+	stdout, _, err = run(t, dbPath, "user", "grant-minutes", "--email", "sam@example.com", "--minutes", "30", "--reason", "support_credit")
+	require.NoError(t, err)
+	assert.Equal(t, "sam@example.com has 90 minutes.\n", stdout)
+
+	stdout, _, err = run(t, dbPath, "user", "grant-minutes", "--email", "sam@example.com", "--minutes", "-15")
+	require.NoError(t, err)
+	assert.Equal(t, "sam@example.com has 75 minutes.\n", stdout)
+
+	_, _, err = run(t, dbPath, "user", "grant-minutes", "--email", "nobody@example.com", "--minutes", "5")
+	assert.EqualError(t, err, "there's no user with the email nobody@example.com")
+
+	db := openDB(t, dbPath)
+	user, err := db.GetUserByEmail(ctx, "sam@example.com")
+	require.NoError(t, err)
+	assert.True(t, user.IsAdmin)
+	ledger, err := db.ListLedgerEntriesByUser(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, ledger, 3)
+	var total int64
+	reasons := map[string]int64{}
+	for _, entry := range ledger {
+		total += entry.Minutes
+		reasons[entry.Reason] += entry.Minutes
+	}
+	assert.Equal(t, minutesOf(user), total, "the ledger adds up to the balance")
+	assert.Equal(t, map[string]int64{"admin_grant": 45, "support_credit": 30}, reasons)
+}
+
+func TestRun_Backup(t *testing.T) {
+	dbPath := migrated(t)
+	_, _, err := run(t, dbPath, "user", "create", "--email", "sam@example.com", "--name", "Sam Smith")
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	stdout, _, err := run(t, dbPath, "backup", "--dir", dir)
+	require.NoError(t, err)
+	match := regexp.MustCompile(`^Backed up .+ to (.+/goDial_backup_\d{8}_\d{6}\.db\.gz) \(\d+ bytes\)\.\n$`).FindStringSubmatch(stdout)
+	require.NotNil(t, match, stdout)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "only the compressed backup is left")
+
+	// the backup decompresses to a database with the user in it
+	f, err := os.Open(match[1])
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	restored := filepath.Join(t.TempDir(), "restored.db")
+	out, err := os.Create(restored)
+	require.NoError(t, err)
+	_, err = io.Copy(out, zr)
+	require.NoError(t, err)
+	require.NoError(t, out.Close())
+
+	db := openDB(t, restored)
+	user, err := db.GetUserByEmail(context.Background(), "sam@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Sam Smith", user.Name)
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runBackup writes a consistent, gzipped copy of the database to
+// DIR/goDial_backup_YYYYMMDD_HHMMSS.db.gz, like db-backup.sh, while the server
+// may still be using it.
+func runBackup(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	dir := flags.String("dir", "backups", "the directory to write the backup to")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("error creating backup directory: %w", err)
+	}
+	path := filepath.Join(*dir, "goDial_backup_"+time.Now().Format("20060102_150405")+".db")
+	if err := db.Backup(ctx, path); err != nil {
+		return err
+	}
+	size, err := gzipFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Backed up %s to %s.gz (%d bytes).\n", e.dbPath, path, size)
+	return nil
+}
+
+// gzipFile replaces the file at path with path.gz, and returns its size.
+func gzipFile(path string) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("error opening backup: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("error creating compressed backup: %w", err)
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	if _, err := io.Copy(zw, in); err != nil {
+		return 0, fmt.Errorf("error compressing backup: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("error compressing backup: %w", err)
+	}
+	info, err := out.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("error compressing backup: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, fmt.Errorf("error compressing backup: %w", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("error removing uncompressed backup: %w", err)
+	}
+	return info.Size(), nil
+}
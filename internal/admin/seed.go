@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+
+	"goDial/internal/database"
+)
+
+// seedUser is a development user, with the calls they have made.
+type seedUser struct {
+	email   string
+	name    string
+	minutes int64
+	admin   bool
+	calls   []seedCall
+}
+
+type seedCall struct {
+	phoneNumber string
+	recipient   string
+	objective   string
+	background  string
+	status      string
+	// transcript is the call's log, as message type and content pairs
+	transcript [][2]string
+}
+
+// seedUsers is the data db-seed.sh adds, besides the development user.
+var seedUsers = []seedUser{
+	{
+		email: "alice@example.com", name: "Alice Johnson",
+		calls: []seedCall{
+			{
+				phoneNumber: "+1-555-0101", recipient: "Pizza restaurant manager",
+				objective:  "Order a large pepperoni pizza for delivery",
+				background: "Hungry and want dinner delivered by 7 PM",
+				status:     "pending",
+			},
+			{
+				phoneNumber: "+1-555-0105", recipient: "Best friend Sarah",
+				objective:  "Share exciting news about job promotion",
+				background: "Just got promoted at work and want to share the good news",
+				status:     "completed",
+				transcript: [][2]string{
+					{"system", "Call initiated to +1-555-0105"},
+					{"user_speech", "Hey Sarah! I have some exciting news to share with you!"},
+					{"ai_response", "Oh my gosh, what is it? You sound so excited!"},
+					{"user_speech", "I just got promoted to Senior Developer at my company!"},
+					{"ai_response", "That is amazing! Congratulations! I am so happy for you. We need to celebrate!"},
+					{"user_speech", "Thank you! I am so thrilled. Let us plan something for this weekend."},
+					{"ai_response", "Absolutely! I will text you some ideas. So proud of you!"},
+					{"system", "Call completed successfully"},
+				},
+			},
+		},
+	},
+	{
+		email: "bob@example.com", name: "Bob Smith", minutes: 10,
+		calls: []seedCall{
+			{
+				phoneNumber: "+1-555-0102", recipient: "Gym membership cancellation department",
+				objective:  "Cancel my gym membership",
+				background: "Moving to another city and cannot use this gym anymore",
+				status:     "completed",
+				transcript: [][2]string{
+					{"system", "Call initiated to +1-555-0102"},
+					{"user_speech", "Hello, I would like to cancel my gym membership please."},
+					{"ai_response", "Hi! I can help you with that. Can I get your membership number?"},
+					{"user_speech", "Yes, it is GM-12345678"},
+					{"ai_response", "Thank you. I see your account. May I ask the reason for cancellation?"},
+					{"user_speech", "I am moving to another city and will not be able to use this location."},
+					{"ai_response", "I understand. I have processed your cancellation. You will receive a confirmation email."},
+					{"system", "Call completed successfully"},
+				},
+			},
+			{
+				phoneNumber: "+1-555-0106", recipient: "Bank customer service",
+				objective:  "Dispute fraudulent charge on credit card",
+				background: "Found unknown charge of $200 on statement from last week",
+				status:     "failed",
+			},
+		},
+	},
+	{
+		email: "charlie@example.com", name: "Charlie Brown", minutes: 1000,
+		calls: []seedCall{
+			{
+				phoneNumber: "+1-555-0103", recipient: "Doctor office receptionist",
+				objective:  "Schedule annual checkup appointment",
+				background: "Need to schedule routine physical exam, prefer morning appointments",
+				status:     "in_progress",
+			},
+		},
+	},
+	{
+		email: "diana@example.com", name: "Diana Prince", minutes: 1,
+		calls: []seedCall{
+			{
+				phoneNumber: "+1-555-0104", recipient: "Internet service provider support",
+				objective:  "Upgrade internet speed plan",
+				background: "Current plan is too slow for working from home",
+				status:     "pending",
+			},
+		},
+	},
+}
+
+// runSeed adds the development user, as an admin, and the other users with
+// their calls. Users already in the database are left as they are, calls and
+// all, so seeding twice adds nothing.
+func runSeed(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	devUser := seedUser{email: e.devUserEmail, name: "test", minutes: 9999, admin: true}
+	var users, calls, lines int
+	err = inTx(ctx, db, func(q *database.Queries) error {
+		for _, seed := range append([]seedUser{devUser}, seedUsers...) {
+			_, err := q.GetUserByEmail(ctx, seed.email)
+			if err == nil {
+				fmt.Fprintf(e.stdout, "Skipped %s, who is already there.\n", seed.email)
+				continue
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("error looking up user %s: %w", seed.email, err)
+			}
+
+			user, err := createUser(ctx, q, seed.email, seed.name, seed.minutes, seed.admin)
+			if err != nil {
+				return err
+			}
+			users++
+			for _, c := range seed.calls {
+				if err := seedCallFor(ctx, q, user, c); err != nil {
+					return err
+				}
+				calls++
+				lines += len(c.transcript)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "Seeded %d users, %d calls and %d transcript lines.\n", users, calls, lines)
+	return nil
+}
+
+func seedCallFor(ctx context.Context, q *database.Queries, user database.User, c seedCall) error {
+	call, err := q.CreateCall(ctx, database.CreateCallParams{
+		UserID:              user.ID,
+		PhoneNumber:         c.phoneNumber,
+		RecipientContext:    sql.NullString{String: c.recipient, Valid: true},
+		Objective:           c.objective,
+		BackgroundContext:   sql.NullString{String: c.background, Valid: true},
+		MaxAttempts:         1,
+		RetrySpacingMinutes: 15,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating call for %s: %w", user.Email, err)
+	}
+	if c.status != "pending" {
+		if _, err := q.UpdateCallStatus(ctx, database.UpdateCallStatusParams{
+			Status: sql.NullString{String: c.status, Valid: true},
+			ID:     call.ID,
+		}); err != nil {
+			return fmt.Errorf("error setting status of call %d: %w", call.ID, err)
+		}
+	}
+	for _, line := range c.transcript {
+		if _, err := q.CreateCallLog(ctx, database.CreateCallLogParams{
+			CallID:      call.ID,
+			MessageType: line[0],
+			Content:     line[1],
+		}); err != nil {
+			return fmt.Errorf("error logging call %d: %w", call.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// runUserCreate adds a user, with the minutes and admin rights given.
+func runUserCreate(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	email := flags.String("email", "", "the user's email address")
+	name := flags.String("name", "", "the user's name")
+	minutes := flags.Int64("minutes", 0, "calling minutes to start them with")
+	admin := flags.Bool("admin", false, "let them use the admin pages")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	*email = strings.TrimSpace(*email)
+	*name = strings.TrimSpace(*name)
+	if *email == "" || *name == "" {
+		fmt.Fprintf(flags.Output(), "%s: give the user's --email and --name\n", flags.Name())
+		return ErrUsage
+	}
+	if *minutes < 0 {
+		fmt.Fprintf(flags.Output(), "%s: --minutes can't be negative\n", flags.Name())
+		return ErrUsage
+	}
+
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.GetUserByEmail(ctx, *email); err == nil {
+		return fmt.Errorf("there's already a user with the email %s", *email)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error looking up user %s: %w", *email, err)
+	}
+
+	var user database.User
+	err = inTx(ctx, db, func(q *database.Queries) error {
+		user, err = createUser(ctx, q, *email, *name, *minutes, *admin)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	role := "user"
+	if user.IsAdmin {
+		role = "admin"
+	}
+	fmt.Fprintf(e.stdout, "Created %s %d, %s <%s>, with %d minutes.\n", role, user.ID, user.Name, user.Email, *minutes)
+	return nil
+}
+
+// runGrantMinutes adds minutes to a user's balance, or takes them away when
+// negative, with a ledger entry saying why.
+func runGrantMinutes(ctx context.Context, e *env, flags *flag.FlagSet, args []string) error {
+	email := flags.String("email", "", "the user's email address")
+	minutes := flags.Int64("minutes", 0, "minutes to add, or take away when negative")
+	reason := flags.String("reason", "admin_grant", "why, for the minute ledger")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if *email == "" || *minutes == 0 {
+		fmt.Fprintf(flags.Output(), "%s: give the user's --email and the --minutes to grant\n", flags.Name())
+		return ErrUsage
+	}
+
+	db, err := e.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user, err := db.GetUserByEmail(ctx, strings.TrimSpace(*email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("there's no user with the email %s", *email)
+	}
+	if err != nil {
+		return fmt.Errorf("error looking up user %s: %w", *email, err)
+	}
+
+	err = inTx(ctx, db, func(q *database.Queries) error {
+		return grantMinutes(ctx, q, user.ID, *minutes, *reason)
+	})
+	if err != nil {
+		return err
+	}
+	user, err = db.GetUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error getting user %d: %w", user.ID, err)
+	}
+	fmt.Fprintf(e.stdout, "%s has %d minutes.\n", user.Email, minutesOf(user))
+	return nil
+}
+
+// createUser adds a user with minutes, granted through the ledger.
+func createUser(ctx context.Context, q *database.Queries, email, name string, minutes int64, admin bool) (database.User, error) {
+	user, err := q.CreateUser(ctx, database.CreateUserParams{Email: email, Name: name})
+	if err != nil {
+		return database.User{}, fmt.Errorf("error creating user %s: %w", email, err)
+	}
+	if admin {
+		user, err = q.SetUserAdmin(ctx, database.SetUserAdminParams{IsAdmin: true, ID: user.ID})
+		if err != nil {
+			return database.User{}, fmt.Errorf("error making user %s an admin: %w", email, err)
+		}
+	}
+	if minutes != 0 {
+		if err := grantMinutes(ctx, q, user.ID, minutes, "admin_grant"); err != nil {
+			return database.User{}, err
+		}
+	}
+	return user, nil
+}
+
+// grantMinutes adjusts a user's balance and writes the ledger entry for it,
+// the way billing does for calls.
+func grantMinutes(ctx context.Context, q *database.Queries, userID, minutes int64, reason string) error {
+	// grants aren't retried, so each gets a reference of its own
+	reference := fmt.Sprintf("%s:%d:%d", reason, userID, time.Now().UnixNano())
+	if _, err := q.CreateLedgerEntry(ctx, database.CreateLedgerEntryParams{
+		UserID:    userID,
+		Minutes:   minutes,
+		Reason:    reason,
+		Reference: reference,
+	}); err != nil {
+		return fmt.Errorf("error writing ledger entry %s: %w", reference, err)
+	}
+	if err := q.AdjustUserMinutes(ctx, database.AdjustUserMinutesParams{Minutes: minutes, ID: userID}); err != nil {
+		return fmt.Errorf("error granting %d minutes to user %d: %w", minutes, userID, err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds.
+func inTx(ctx context.Context, db *database.DB, fn func(q *database.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(db.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// minutesOf reads the untyped users.minutes column.
+func minutesOf(user database.User) int64 {
+	switch m := user.Minutes.(type) {
+	case int64:
+		return m
+	case float64:
+		return int64(m)
+	}
+	return 0
+}
+
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
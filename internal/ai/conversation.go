@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Speaker identifies who said a line in a phone conversation.
+type Speaker string
+
+const (
+	SpeakerAgent     Speaker = "agent"
+	SpeakerRecipient Speaker = "recipient"
+)
+
+// Turn is one line of a phone conversation. Agent turns may use tools as well
+// as, or instead of, saying something.
+type Turn struct {
+	Speaker Speaker
+	Text    string
+	Tools   []ToolCall
+}
+
+// Response is what the agent does next: something to say, tools to use, or
+// both.
+type Response struct {
+	Text  string
+	Tools []ToolCall
+}
+
+// callStartedCue stands in for the recipient's side when the agent has to
+// speak first, since the api expects the conversation to open with a user turn.
+const callStartedCue = "(The call has connected. Say your opening line.)"
+
+// silenceCue is used when the agent spoke last and is waiting on the recipient.
+const silenceCue = "(The recipient has not said anything.)"
+
+// Reply generates the agent's next move in a phone conversation. system tells
+// the model who it is calling and what it needs to accomplish.
+func (c *Client) Reply(ctx context.Context, system string, turns []Turn) (Response, error) {
+	client, err := c.newClient()
+	if err != nil {
+		return Response{}, err
+	}
+
+	message, err := client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     c.model(),
+		System:    []anthropic.TextBlockParam{{Text: system}},
+		Messages:  conversationMessages(turns),
+		Tools:     callTools,
+		MaxTokens: c.maxTokens(300),
+	})
+	if err != nil {
+		return Response{}, fmt.Errorf("error generating conversation reply: %w", err)
+	}
+
+	var reply Response
+	var text strings.Builder
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			reply.Tools = append(reply.Tools, ToolCall{ID: block.ID, Name: block.Name, Input: block.Input})
+		}
+	}
+	reply.Text = strings.TrimSpace(text.String())
+	return reply, nil
+}
+
+// piece is part of a message before consecutive pieces from the same side are
+// merged.
+type piece struct {
+	role    anthropic.MessageParamRole
+	lines   []string
+	uses    []anthropic.ContentBlockParamUnion
+	results []anthropic.ContentBlockParamUnion
+}
+
+// conversationMessages maps a transcript onto alternating user/assistant
+// messages: the recipient is the user and the agent is the assistant. A turn
+// where the agent used tools is followed by a user message carrying their
+// results, as the api requires.
+func conversationMessages(turns []Turn) []anthropic.MessageParam {
+	var pieces []piece
+	add := func(p piece) {
+		if n := len(pieces); n > 0 && pieces[n-1].role == p.role {
+			last := &pieces[n-1]
+			last.lines = append(last.lines, p.lines...)
+			last.uses = append(last.uses, p.uses...)
+			last.results = append(last.results, p.results...)
+			return
+		}
+		pieces = append(pieces, p)
+	}
+
+	for _, turn := range turns {
+		text := strings.TrimSpace(turn.Text)
+		if text == "" && len(turn.Tools) == 0 {
+			continue
+		}
+
+		if turn.Speaker != SpeakerAgent {
+			add(piece{role: anthropic.MessageParamRoleUser, lines: []string{text}})
+			continue
+		}
+
+		agent := piece{role: anthropic.MessageParamRoleAssistant}
+		if text != "" {
+			agent.lines = []string{text}
+		}
+		results := piece{role: anthropic.MessageParamRoleUser}
+		for _, call := range turn.Tools {
+			agent.uses = append(agent.uses, anthropic.NewToolUseBlock(call.ID, call.Input, call.Name))
+			results.results = append(results.results, anthropic.NewToolResultBlock(call.ID, call.Result, call.IsError))
+		}
+		add(agent)
+		if len(results.results) > 0 {
+			add(results)
+		}
+	}
+
+	if len(pieces) == 0 || pieces[0].role != anthropic.MessageParamRoleUser {
+		pieces = append([]piece{{role: anthropic.MessageParamRoleUser, lines: []string{callStartedCue}}}, pieces...)
+	}
+	if pieces[len(pieces)-1].role != anthropic.MessageParamRoleUser {
+		pieces = append(pieces, piece{role: anthropic.MessageParamRoleUser, lines: []string{silenceCue}})
+	}
+
+	messages := make([]anthropic.MessageParam, 0, len(pieces))
+	for _, p := range pieces {
+		if p.role == anthropic.MessageParamRoleAssistant {
+			var blocks []anthropic.ContentBlockParamUnion
+			if len(p.lines) > 0 {
+				blocks = append(blocks, anthropic.NewTextBlock(strings.Join(p.lines, "\n")))
+			}
+			messages = append(messages, anthropic.NewAssistantMessage(append(blocks, p.uses...)...))
+			continue
+		}
+		// tool results have to come before anything else in a user message
+		blocks := p.results
+		if len(p.lines) > 0 {
+			blocks = append(blocks, anthropic.NewTextBlock(strings.Join(p.lines, "\n")))
+		}
+		messages = append(messages, anthropic.NewUserMessage(blocks...))
+	}
+	return messages
+}
@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationMessages_ToolResultsFollowToolUses(t *testing.T) {
+	fact := ToolCall{ID: "t1", Name: ToolRecordFact, Input: json.RawMessage(`{"key":"price","value":"$40"}`), Result: "saved price"}
+	turns := []Turn{
+		{Speaker: SpeakerAgent, Text: "Got it, forty dollars.", Tools: []ToolCall{fact}},
+		{Speaker: SpeakerRecipient, Text: "Anything else?"},
+	}
+
+	raw, err := json.Marshal(conversationMessages(turns))
+	require.NoError(t, err)
+
+	type block struct {
+		Type      string `json:"type"`
+		Text      string `json:"text"`
+		ID        string `json:"id"`
+		ToolUseID string `json:"tool_use_id"`
+	}
+	var messages []struct {
+		Role    string  `json:"role"`
+		Content []block `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &messages))
+
+	require.Len(t, messages, 3)
+	assert.Equal(t, "user", messages[0].Role)
+	assert.Equal(t, callStartedCue, messages[0].Content[0].Text)
+
+	assert.Equal(t, "assistant", messages[1].Role)
+	require.Len(t, messages[1].Content, 2)
+	assert.Equal(t, block{Type: "text", Text: "Got it, forty dollars."}, messages[1].Content[0])
+	assert.Equal(t, "tool_use", messages[1].Content[1].Type)
+	assert.Equal(t, "t1", messages[1].Content[1].ID)
+
+	assert.Equal(t, "user", messages[2].Role)
+	require.Len(t, messages[2].Content, 2)
+	assert.Equal(t, "tool_result", messages[2].Content[0].Type, "tool results come first")
+	assert.Equal(t, "t1", messages[2].Content[0].ToolUseID)
+	assert.Equal(t, block{Type: "text", Text: "Anything else?"}, messages[2].Content[1])
+}
@@ -3,25 +3,53 @@ package ai
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"goDial/internal/config"
 )
 
-// CallAnthropic function takes in any string, and responds with a generated Anthropic response in a string.
-func CallAnthropic(prompt string) (string, error) {
-	// Get API key from environment variable
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		return "", fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
+// Client talks to the Anthropic model its configuration names.
+type Client struct {
+	cfg config.AI
+}
+
+// New returns a client for the model in cfg.
+func New(cfg config.AI) *Client {
+	return &Client{cfg: cfg}
+}
+
+// newClient creates an anthropic client using the configured api key.
+func (c *Client) newClient() (anthropic.Client, error) {
+	if c.cfg.APIKey == "" {
+		return anthropic.Client{}, fmt.Errorf("ANTHROPIC_API_KEY environment variable not set")
 	}
 
 	// Create client with the api key we have
-	client := anthropic.NewClient(
-		option.WithAPIKey(apiKey),
-	)
+	return anthropic.NewClient(
+		option.WithAPIKey(c.cfg.APIKey),
+	), nil
+}
+
+// model is the configured model.
+func (c *Client) model() anthropic.Model {
+	return anthropic.Model(c.cfg.Model)
+}
+
+// maxTokens is the most a use that needs up to n tokens may ask for, within
+// the configured cap.
+func (c *Client) maxTokens(n int64) int64 {
+	return min(n, c.cfg.MaxTokens)
+}
+
+// CallAnthropic function takes in any string, and responds with a generated Anthropic response in a string.
+func (c *Client) CallAnthropic(prompt string) (string, error) {
+	client, err := c.newClient()
+	if err != nil {
+		return "", err
+	}
 
 	// creates a new user message in the message json struct anthropic uses
 	messages := []anthropic.MessageParam{
@@ -30,9 +58,9 @@ func CallAnthropic(prompt string) (string, error) {
 
 	// get response from anthropic, adding it to messages struct
 	message, err := client.Messages.New(context.TODO(), anthropic.MessageNewParams{
-		Model:     anthropic.ModelClaude3_7SonnetLatest,
+		Model:     c.model(),
 		Messages:  messages,
-		MaxTokens: 1024,
+		MaxTokens: c.cfg.MaxTokens,
 	})
 	if err != nil {
 		return "", fmt.Errorf("error creating new anthropic client messages: %w\n", err)
@@ -56,8 +84,8 @@ func CallAnthropic(prompt string) (string, error) {
 
 // checking if user input is an input we're comfortable excecuting based on the prompt.
 // returns an empty string if the request was good, else, has the reason the request was bad.
-func CheckPromptValidity(userPrompt string) (string, error) {
-	resp, err := CallAnthropic("below is a request the user has asked an employee to complete. we have a phone number, and then this set of instructions. Your job is to *only* respond with either, 'true', or explain why you dont think the request is valid. You will respond with true if you feel that the request is in no way harmful to complete, does not contain legal implications in any US state. If you feel we may have any ethical concerns, please respond with nothing more than you reason for thinking the request may not be valid..." + userPrompt)
+func (c *Client) CheckPromptValidity(userPrompt string) (string, error) {
+	resp, err := c.CallAnthropic("below is a request the user has asked an employee to complete. we have a phone number, and then this set of instructions. Your job is to *only* respond with either, 'true', or explain why you dont think the request is valid. You will respond with true if you feel that the request is in no way harmful to complete, does not contain legal implications in any US state. If you feel we may have any ethical concerns, please respond with nothing more than you reason for thinking the request may not be valid..." + userPrompt)
 	if err != nil {
 		return "error in call anthropic...", fmt.Errorf("error calling anthropic: %v\n", err)
 	}
@@ -6,8 +6,18 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"goDial/internal/config"
 )
 
+// testClient is a client for the default model, with the key from the
+// environment as it is when called.
+func testClient() *Client {
+	cfg := config.Default().AI
+	cfg.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	return New(cfg)
+}
+
 func TestCallAnthropic_NoAPIKey(t *testing.T) {
 	// Save original API key
 	originalKey := os.Getenv("ANTHROPIC_API_KEY")
@@ -22,7 +32,7 @@ func TestCallAnthropic_NoAPIKey(t *testing.T) {
 	// Unset API key
 	os.Unsetenv("ANTHROPIC_API_KEY")
 
-	response, err := CallAnthropic("test prompt")
+	response, err := testClient().CallAnthropic("test prompt")
 
 	assert.Error(t, err, "Should return error when API key is not set")
 	assert.Empty(t, response, "Response should be empty when API key is not set")
@@ -38,7 +48,7 @@ func TestCallAnthropic_WithAPIKey(t *testing.T) {
 
 	// Test with a simple prompt
 	prompt := "Say hello"
-	response, err := CallAnthropic(prompt)
+	response, err := testClient().CallAnthropic(prompt)
 
 	// Note: This test will make a real API call
 	// In a production environment, you'd want to mock this
@@ -59,7 +69,7 @@ func TestCallAnthropic_EmptyPrompt(t *testing.T) {
 		t.Skip("Skipping test: ANTHROPIC_API_KEY not set")
 	}
 
-	response, err := CallAnthropic("")
+	response, err := testClient().CallAnthropic("")
 
 	// Should handle empty prompts gracefully
 	// The exact behavior depends on the API, but it shouldn't panic
@@ -79,7 +89,7 @@ func TestCallAnthropic_LongPrompt(t *testing.T) {
 
 	// Create a long prompt
 	longPrompt := strings.Repeat("This is a test prompt. ", 100)
-	response, err := CallAnthropic(longPrompt)
+	response, err := testClient().CallAnthropic(longPrompt)
 
 	// Should handle long prompts
 	if err != nil {
@@ -103,7 +113,7 @@ func TestCheckPromptValidity_NoAPIKey(t *testing.T) {
 	// Unset API key
 	os.Unsetenv("ANTHROPIC_API_KEY")
 
-	reason, err := CheckPromptValidity("test prompt")
+	reason, err := testClient().CheckPromptValidity("test prompt")
 
 	assert.Error(t, err, "Should return error when API key is not set")
 	assert.NotEmpty(t, reason, "Reason should not be empty when there's an error")
@@ -119,7 +129,7 @@ func TestCheckPromptValidity_ValidPrompt(t *testing.T) {
 
 	// Test with a clearly valid prompt
 	validPrompt := "Please call the pizza restaurant and order a large pepperoni pizza for delivery to 123 Main St."
-	reason, err := CheckPromptValidity(validPrompt)
+	reason, err := testClient().CheckPromptValidity(validPrompt)
 
 	// Note: This test makes a real API call
 	// The exact response depends on the AI model's assessment
@@ -141,7 +151,7 @@ func TestCheckPromptValidity_PotentiallyHarmfulPrompt(t *testing.T) {
 
 	// Test with a potentially problematic prompt
 	harmfulPrompt := "Call the bank and pretend to be the account holder to get their personal information"
-	reason, err := CheckPromptValidity(harmfulPrompt)
+	reason, err := testClient().CheckPromptValidity(harmfulPrompt)
 
 	// This should likely be flagged as invalid
 	// Note: The exact response depends on the AI model's assessment
@@ -159,7 +169,7 @@ func TestCheckPromptValidity_EmptyPrompt(t *testing.T) {
 		t.Skip("Skipping test: ANTHROPIC_API_KEY not set")
 	}
 
-	reason, err := CheckPromptValidity("")
+	reason, err := testClient().CheckPromptValidity("")
 
 	// Should handle empty prompts gracefully
 	if err != nil {
@@ -206,7 +216,7 @@ func TestPromptValidation_EdgeCases(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			reason, err := CheckPromptValidity(tc.prompt)
+			reason, err := testClient().CheckPromptValidity(tc.prompt)
 
 			// Should not panic and should return proper types
 			if err != nil {
@@ -230,7 +240,7 @@ func BenchmarkCallAnthropic(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = CallAnthropic(prompt)
+		_, _ = testClient().CallAnthropic(prompt)
 	}
 }
 
@@ -244,7 +254,7 @@ func BenchmarkCheckPromptValidity(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = CheckPromptValidity(prompt)
+		_, _ = testClient().CheckPromptValidity(prompt)
 	}
 }
 
@@ -265,14 +275,14 @@ func TestAIPackage_MockScenarios(t *testing.T) {
 
 		// Test with empty key
 		os.Unsetenv("ANTHROPIC_API_KEY")
-		_, err := CallAnthropic("test")
+		_, err := testClient().CallAnthropic("test")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "ANTHROPIC_API_KEY environment variable not set")
 
 		// Test with key set
 		os.Setenv("ANTHROPIC_API_KEY", "test-key")
 		// This will fail at the API call level, but should pass the key check
-		_, err = CallAnthropic("test")
+		_, err = testClient().CallAnthropic("test")
 		// Error should be about API call, not missing key
 		if err != nil {
 			assert.NotContains(t, err.Error(), "ANTHROPIC_API_KEY environment variable not set")
@@ -281,8 +291,8 @@ func TestAIPackage_MockScenarios(t *testing.T) {
 
 	t.Run("Function signatures", func(t *testing.T) {
 		// Verify function signatures are correct
-		var f1 func(string) (string, error) = CallAnthropic
-		var f2 func(string) (string, error) = CheckPromptValidity
+		var f1 func(string) (string, error) = testClient().CallAnthropic
+		var f2 func(string) (string, error) = testClient().CheckPromptValidity
 
 		assert.NotNil(t, f1, "CallAnthropic should have correct signature")
 		assert.NotNil(t, f2, "CheckPromptValidity should have correct signature")
@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// CallPlan is what the agent will open with and how it means to get the
+// objective done, drafted for the user to review before the call is dialed.
+type CallPlan struct {
+	OpeningLine string
+	Plan        string
+}
+
+// headings the model is asked to answer under, so the reply can be split
+const (
+	openingHeading = "OPENING LINE:"
+	planHeading    = "PLAN:"
+)
+
+const planPrompt = `You are preparing an AI phone agent to make a call on behalf of a user. Write two things:
+
+1. The agent's opening line: one or two natural sentences it says as soon as the recipient picks up. An automated announcement will already have said the caller is an AI, so don't repeat that.
+2. A short plan for the call, written to the agent in the second person ("You are calling..."), covering who it is calling, what it needs to accomplish, the steps to get there, and what to do if the recipient is unavailable or says no. Plain sentences, no markdown, under 200 words.
+
+Answer in exactly this form and nothing else:
+` + openingHeading + ` <the opening line>
+` + planHeading + `
+<the plan>`
+
+// PlanCall drafts an opening line and plan for a call from what the user
+// asked for.
+func (c *Client) PlanCall(ctx context.Context, objective, recipient, background string) (CallPlan, error) {
+	client, err := c.newClient()
+	if err != nil {
+		return CallPlan{}, err
+	}
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "Who is being called: %s\n", recipient)
+	fmt.Fprintf(&request, "What the user wants accomplished: %s\n", objective)
+	if background != "" {
+		fmt.Fprintf(&request, "Other context from the user: %s\n", background)
+	}
+
+	message, err := client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     c.model(),
+		System:    []anthropic.TextBlockParam{{Text: planPrompt}},
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(request.String()))},
+		MaxTokens: c.maxTokens(600),
+	})
+	if err != nil {
+		return CallPlan{}, fmt.Errorf("error generating call plan: %w", err)
+	}
+
+	var reply strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			reply.WriteString(block.Text)
+		}
+	}
+	return parseCallPlan(reply.String())
+}
+
+// parseCallPlan splits the model's reply into its opening line and plan.
+func parseCallPlan(reply string) (CallPlan, error) {
+	_, rest, ok := strings.Cut(reply, openingHeading)
+	if !ok {
+		return CallPlan{}, errors.New("error reading call plan: no opening line in reply")
+	}
+	opening, plan, ok := strings.Cut(rest, planHeading)
+	if !ok {
+		return CallPlan{}, errors.New("error reading call plan: no plan in reply")
+	}
+
+	p := CallPlan{OpeningLine: strings.TrimSpace(opening), Plan: strings.TrimSpace(plan)}
+	if p.OpeningLine == "" || p.Plan == "" {
+		return CallPlan{}, errors.New("error reading call plan: opening line or plan is empty")
+	}
+	return p, nil
+}
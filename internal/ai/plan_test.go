@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCallPlan(t *testing.T) {
+	tests := []struct {
+		name            string
+		reply           string
+		expectedOpening string
+		expectedPlan    string
+		expectError     bool
+	}{
+		{
+			name:            "well formed",
+			reply:           "OPENING LINE: Hi Sam, I'm calling to wish you a happy birthday!\nPLAN:\nYou are calling Sam.\nWish them a happy birthday.",
+			expectedOpening: "Hi Sam, I'm calling to wish you a happy birthday!",
+			expectedPlan:    "You are calling Sam.\nWish them a happy birthday.",
+		},
+		{
+			name:            "preamble before the headings is ignored",
+			reply:           "Sure, here you go.\n\nOPENING LINE:\nHello!\n\nPLAN: Say hello.",
+			expectedOpening: "Hello!",
+			expectedPlan:    "Say hello.",
+		},
+		{name: "no opening line", reply: "PLAN: Say hello.", expectError: true},
+		{name: "no plan", reply: "OPENING LINE: Hello!", expectError: true},
+		{name: "empty plan", reply: "OPENING LINE: Hello!\nPLAN:\n", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := parseCallPlan(tt.reply)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOpening, plan.OpeningLine)
+			assert.Equal(t, tt.expectedPlan, plan.Plan)
+		})
+	}
+}
+
+func TestPlanCall_NoAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	_, err := testClient().PlanCall(context.Background(), "say happy birthday", "Sam", "")
+	assert.ErrorContains(t, err, "ANTHROPIC_API_KEY environment variable not set")
+}
@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// names of the tools the agent can use on a call
+const (
+	ToolEndCall          = "end_call"
+	ToolSendDTMF         = "send_dtmf"
+	ToolTransferToUser   = "transfer_to_user"
+	ToolRecordFact       = "record_fact"
+	ToolScheduleCallback = "schedule_callback"
+)
+
+// EndCallInput is the input of end_call.
+type EndCallInput struct {
+	Reason   string `json:"reason"`
+	OptedOut bool   `json:"opted_out,omitempty"`
+}
+
+// SendDTMFInput is the input of send_dtmf.
+type SendDTMFInput struct {
+	Digits string `json:"digits"`
+}
+
+// TransferToUserInput is the input of transfer_to_user.
+type TransferToUserInput struct {
+	Reason string `json:"reason"`
+}
+
+// RecordFactInput is the input of record_fact.
+type RecordFactInput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ScheduleCallbackInput is the input of schedule_callback.
+type ScheduleCallbackInput struct {
+	DelayMinutes int    `json:"delay_minutes"`
+	Reason       string `json:"reason"`
+}
+
+// ToolCall is one use of a tool by the agent. Result is filled in once the
+// call engine has carried it out, and is what the agent is told happened.
+type ToolCall struct {
+	ID      string          `json:"id"`
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Result  string          `json:"result,omitempty"`
+	IsError bool            `json:"is_error,omitempty"`
+}
+
+// Decode reads the tool's input into v, one of the *Input types above.
+func (c ToolCall) Decode(v any) error {
+	if err := json.Unmarshal(c.Input, v); err != nil {
+		return fmt.Errorf("error reading input of %s: %w", c.Name, err)
+	}
+	return nil
+}
+
+// callTools describes the tools to the model.
+var callTools = []anthropic.ToolUnionParam{
+	tool(ToolEndCall,
+		"Hang up the call. Use it once the objective is done, the recipient wants to end the call, or there is nothing more to do. Say goodbye in the same message, before hanging up.",
+		map[string]any{
+			"reason":    map[string]any{"type": "string", "description": "Why the call is ending, for the user's records."},
+			"opted_out": map[string]any{"type": "boolean", "description": "True when the recipient asked not to be called again, or to be taken off a list."},
+		}, "reason"),
+	tool(ToolSendDTMF,
+		"Press keys on the phone keypad, for example to pick an option in an automated phone menu. Only use it when a menu asks for a key press.",
+		map[string]any{
+			"digits": map[string]any{"type": "string", "pattern": "^[0-9*#w]{1,32}$", "description": "The keys to press, in order: 0-9, * and #. A w waits half a second between keys."},
+		}, "digits"),
+	tool(ToolTransferToUser,
+		"Connect the recipient to the user you are calling for, when they ask to speak to a person or the call needs the user's own decision.",
+		map[string]any{
+			"reason": map[string]any{"type": "string", "description": "Why the call is being handed to the user."},
+		}, "reason"),
+	tool(ToolRecordFact,
+		"Save something the user will want to know from the call, such as a confirmation number, a price, an appointment time or an answer to their question.",
+		map[string]any{
+			"key":   map[string]any{"type": "string", "description": "A short snake_case label, e.g. confirmation_number."},
+			"value": map[string]any{"type": "string", "description": "What was learned, as the recipient said it."},
+		}, "key", "value"),
+	tool(ToolScheduleCallback,
+		"Schedule another call to this number later, when the recipient asks to be called back or the person needed isn't available.",
+		map[string]any{
+			"delay_minutes": map[string]any{"type": "integer", "minimum": 5, "maximum": 10080, "description": "How many minutes from now to call back."},
+			"reason":        map[string]any{"type": "string", "description": "Why a callback is needed, for the user's records."},
+		}, "delay_minutes", "reason"),
+}
+
+func tool(name, description string, properties map[string]any, required ...string) anthropic.ToolUnionParam {
+	schema := anthropic.ToolInputSchemaParam{
+		Properties:  properties,
+		ExtraFields: map[string]any{"required": required},
+	}
+	t := anthropic.ToolUnionParamOfTool(schema, name)
+	t.OfTool.Description = anthropic.String(description)
+	return t
+}
@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const voicemailPrompt = `You are an AI phone agent that reached someone's voicemail while calling on behalf of a user. Write the message to leave after the beep.
+
+Keep it under 60 words and natural to hear read aloud: say who you are calling for, the reason for the call in a sentence, and what you would like the recipient to do, if anything. An automated announcement will already have said the caller is an AI, so don't repeat that. Never make up phone numbers, names, times or details the user didn't give. No markdown or stage directions; reply with the message and nothing else.`
+
+// DraftVoicemail writes the message the agent leaves when a call reaches
+// voicemail, from what the user asked the call to do.
+func (c *Client) DraftVoicemail(ctx context.Context, objective, recipient, caller string) (string, error) {
+	client, err := c.newClient()
+	if err != nil {
+		return "", err
+	}
+
+	var request strings.Builder
+	fmt.Fprintf(&request, "Calling on behalf of: %s\n", caller)
+	fmt.Fprintf(&request, "Who is being called: %s\n", recipient)
+	fmt.Fprintf(&request, "What the user wants accomplished: %s\n", objective)
+
+	message, err := client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     c.model(),
+		System:    []anthropic.TextBlockParam{{Text: voicemailPrompt}},
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(request.String()))},
+		MaxTokens: c.maxTokens(200),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error generating voicemail: %w", err)
+	}
+
+	var reply strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			reply.WriteString(block.Text)
+		}
+	}
+	text := strings.TrimSpace(reply.String())
+	if text == "" {
+		return "", errors.New("error generating voicemail: empty reply")
+	}
+	return text, nil
+}
@@ -0,0 +1,15 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDraftVoicemail_NoAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	_, err := testClient().DraftVoicemail(context.Background(), "say happy birthday", "Sam", "Alex")
+	assert.ErrorContains(t, err, "ANTHROPIC_API_KEY environment variable not set")
+}
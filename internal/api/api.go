@@ -0,0 +1,86 @@
+// Package api is the shape of the /api/v1 JSON endpoints: what they take and
+// return, and how they write responses and errors, so every endpoint answers
+// the same way whichever package serves it.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Prefix is where the API is served.
+const Prefix = "/api/v1"
+
+// Error codes, sent as the error of every error response.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeUnauthorized      = "unauthorized"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeInternal          = "internal_error"
+	// the server is shutting down, and isn't taking new calls
+	CodeUnavailable = "unavailable"
+	// the same codes the call form answers with
+	CodeForbidden = "forbidden"
+	CodeDoNotCall = "do_not_call"
+)
+
+// Error is the body of every error response.
+type Error struct {
+	Code    string `json:"error"`
+	Message string `json:"message"`
+	// Field is the request field that needs fixing, for invalid_request.
+	Field string `json:"field,omitempty"`
+}
+
+// maxBodyBytes bounds the request bodies Decode reads.
+const maxBodyBytes = 1 << 20
+
+// WriteJSON writes v as the response, with status.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("api: error writing response: %v", err)
+	}
+}
+
+// WriteError writes an error response.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	WriteJSON(w, status, Error{Code: code, Message: message})
+}
+
+// WriteFieldError writes an invalid_request response about one field of the
+// request.
+func WriteFieldError(w http.ResponseWriter, field, message string) {
+	WriteJSON(w, http.StatusBadRequest, Error{Code: CodeInvalidRequest, Message: message, Field: field})
+}
+
+// Decode reads the JSON request body into v. Unknown fields are an error, so
+// a misspelled option isn't silently ignored. When it returns false the error
+// response has been written.
+func Decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteError(w, http.StatusRequestEntityTooLarge, CodeInvalidRequest, fmt.Sprintf("The request body must be under %d bytes.", maxBodyBytes))
+		return false
+	}
+	WriteError(w, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("The request body isn't the JSON expected: %v.", err))
+	return false
+}
+
+// NotFound answers requests for paths the API doesn't have.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, http.StatusNotFound, CodeNotFound, fmt.Sprintf("There's nothing at %s %s.", r.Method, r.URL.Path))
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedCode  int
+		expectedError string
+	}{
+		{name: "request", body: `{"phone_number": "+13336664444", "recipient": "Sam"}`},
+		{name: "not JSON", body: `phone_number=+13336664444`, expectedCode: http.StatusBadRequest, expectedError: "The request body isn't the JSON expected: invalid character 'p' looking for beginning of value."},
+		{name: "unknown field", body: `{"phone": "+13336664444"}`, expectedCode: http.StatusBadRequest, expectedError: `The request body isn't the JSON expected: json: unknown field "phone".`},
+		{name: "too large", body: `{"objective": "` + strings.Repeat("a", maxBodyBytes) + `"}`, expectedCode: http.StatusRequestEntityTooLarge, expectedError: "The request body must be under 1048576 bytes."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			var req CreateCallRequest
+			ok := Decode(w, httptest.NewRequest("POST", "/api/v1/calls", strings.NewReader(tt.body)), &req)
+
+			if tt.expectedCode == 0 {
+				assert.True(t, ok)
+				assert.Equal(t, CreateCallRequest{PhoneNumber: "+13336664444", Recipient: "Sam"}, req)
+				return
+			}
+			assert.False(t, ok)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var got Error
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, Error{Code: CodeInvalidRequest, Message: tt.expectedError}, got)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	_ "embed"
+	"log"
+	"net/http"
+)
+
+// OpenAPI is the OpenAPI 3 document describing the API. Keep it in step with
+// the handlers: the router's tests send every operation it documents through
+// them and check the responses against it.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// HandleOpenAPI serves the OpenAPI document.
+func HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(OpenAPI); err != nil {
+		log.Printf("api: error writing OpenAPI document: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"goDial/internal/transcript"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schema is the part of an OpenAPI schema these tests read.
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	AllOf      []*schema          `json:"allOf"`
+	Enum       []string           `json:"enum"`
+}
+
+func openAPISchemas(t *testing.T) map[string]*schema {
+	t.Helper()
+	var doc struct {
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(OpenAPI, &doc))
+	return doc.Components.Schemas
+}
+
+// TestOpenAPI_SchemasMatchTypes checks each schema describes the JSON its Go
+// type encodes to: the same fields, with the ones always sent required.
+func TestOpenAPI_SchemasMatchTypes(t *testing.T) {
+	schemas := openAPISchemas(t)
+	types := map[string]reflect.Type{
+		"Error":             reflect.TypeOf(Error{}),
+		"CreateCallRequest": reflect.TypeOf(CreateCallRequest{}),
+		"Call":              reflect.TypeOf(Call{}),
+		"CallDetail":        reflect.TypeOf(CallDetail{}),
+		"Attempt":           reflect.TypeOf(Attempt{}),
+		"Fact":              reflect.TypeOf(Fact{}),
+		"TranscriptEntry":   reflect.TypeOf(transcript.Entry{}),
+		"CallList":          reflect.TypeOf(CallList{}),
+		"Contact":           reflect.TypeOf(Contact{}),
+		"ContactList":       reflect.TypeOf(ContactList{}),
+		"Balance":           reflect.TypeOf(Balance{}),
+	}
+
+	for name, typ := range types {
+		t.Run(name, func(t *testing.T) {
+			s, ok := schemas[name]
+			require.True(t, ok, "there's no %s schema", name)
+			properties, required := flatten(t, schemas, s)
+
+			fields, always := jsonFields(typ)
+			assert.ElementsMatch(t, fields, properties, "properties")
+			assert.ElementsMatch(t, always, required, "required")
+		})
+	}
+}
+
+func TestOpenAPI_ErrorCodes(t *testing.T) {
+	codes := []string{CodeInvalidRequest, CodeUnauthorized, CodeNotFound, CodeConflict, CodeInsufficientScope, CodeInternal, CodeForbidden, CodeDoNotCall, CodeUnavailable}
+	assert.ElementsMatch(t, codes, openAPISchemas(t)["Error"].Properties["error"].Enum)
+}
+
+func TestOpenAPI_RefsResolve(t *testing.T) {
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(OpenAPI, &doc))
+	components := doc["components"].(map[string]any)
+
+	for _, ref := range regexp.MustCompile(`"\$ref": *"([^"]+)"`).FindAllSubmatch(OpenAPI, -1) {
+		path := strings.Split(strings.TrimPrefix(string(ref[1]), "#/components/"), "/")
+		require.Len(t, path, 2, "%s isn't a component", ref[1])
+		section, ok := components[path[0]].(map[string]any)
+		require.True(t, ok, "%s", ref[1])
+		assert.Contains(t, section, path[1], "%s doesn't resolve", ref[1])
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	w := httptest.NewRecorder()
+	HandleOpenAPI(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(OpenAPI), w.Body.String())
+}
+
+// flatten merges a schema's allOf parts into its properties and required
+// fields.
+func flatten(t *testing.T, schemas map[string]*schema, s *schema) (properties, required []string) {
+	t.Helper()
+	if s.Ref != "" {
+		return flatten(t, schemas, schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")])
+	}
+	for _, part := range s.AllOf {
+		p, r := flatten(t, schemas, part)
+		properties = append(properties, p...)
+		required = append(required, r...)
+	}
+	for name := range s.Properties {
+		properties = append(properties, name)
+	}
+	return properties, append(required, s.Required...)
+}
+
+// jsonFields are the JSON names of a struct's fields, and those without
+// omitempty, which are always sent.
+func jsonFields(typ reflect.Type) (fields, always []string) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.Anonymous {
+			f, a := jsonFields(field.Type)
+			fields = append(fields, f...)
+			always = append(always, a...)
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, name)
+		if !slices.Contains(strings.Split(options, ","), "omitempty") {
+			always = append(always, name)
+		}
+	}
+	return fields, always
+}
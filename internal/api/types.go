@@ -0,0 +1,181 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/transcript"
+)
+
+// CreateCallRequest is the call form, as JSON.
+type CreateCallRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	// Recipient is who is being called, and anything the agent should
+	// know about them.
+	Recipient string `json:"recipient"`
+	// Objective may be left out when a template brings its own.
+	Objective string `json:"objective,omitempty"`
+	Context   string `json:"context,omitempty"`
+
+	// retries, left out to dial once. The numbers are pointers so a zero
+	// sent is told apart from one left out, and rejected like the form's.
+	MaxAttempts         *int64 `json:"max_attempts,omitempty"`
+	RetrySpacingMinutes *int64 `json:"retry_spacing_minutes,omitempty"`
+	RetryWindowStart    string `json:"retry_window_start,omitempty"`
+	RetryWindowEnd      string `json:"retry_window_end,omitempty"`
+
+	Record bool `json:"record,omitempty"`
+
+	// TemplateID fills in the objective and context from one of the user's
+	// call templates, with Variables as the values of its variables.
+	TemplateID int64             `json:"template_id,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"`
+}
+
+// Call is a call as the API returns it.
+type Call struct {
+	ID           int64      `json:"id"`
+	PhoneNumber  string     `json:"phone_number"`
+	Recipient    string     `json:"recipient"`
+	Objective    string     `json:"objective"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"status_reason,omitempty"`
+	Outcome      string     `json:"outcome,omitempty"`
+	OpeningLine  string     `json:"opening_line,omitempty"`
+	MaxAttempts  int64      `json:"max_attempts"`
+	Record       bool       `json:"record"`
+	ContactID    *int64     `json:"contact_id,omitempty"`
+	CampaignID   *int64     `json:"campaign_id,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	ApprovedAt   *time.Time `json:"approved_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// NewCall converts a call for the API.
+func NewCall(call database.Call) Call {
+	return Call{
+		ID:           call.ID,
+		PhoneNumber:  call.PhoneNumber,
+		Recipient:    call.RecipientContext.String,
+		Objective:    call.Objective,
+		Status:       call.Status.String,
+		StatusReason: call.StatusReason.String,
+		Outcome:      call.Outcome.String,
+		OpeningLine:  call.OpeningLine.String,
+		MaxAttempts:  call.MaxAttempts,
+		Record:       call.Record,
+		ContactID:    optionalID(call.ContactID),
+		CampaignID:   optionalID(call.CampaignID),
+		CreatedAt:    optionalTime(call.CreatedAt),
+		ApprovedAt:   optionalTime(call.ApprovedAt),
+		CompletedAt:  optionalTime(call.CompletedAt),
+	}
+}
+
+// CallDetail is a call with everything that has happened on it so far.
+type CallDetail struct {
+	Call
+	Attempts []Attempt `json:"attempts"`
+	// Facts are what the agent learned on the call, in the order it noted
+	// them: the call's summary.
+	Facts      []Fact             `json:"facts"`
+	Transcript []transcript.Entry `json:"transcript"`
+}
+
+// Attempt is one dial of a call.
+type Attempt struct {
+	Number          int64      `json:"number"`
+	Status          string     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	BilledMinutes   int64      `json:"billed_minutes"`
+	ScheduledFor    time.Time  `json:"scheduled_for"`
+	AnsweredAt      *time.Time `json:"answered_at,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+}
+
+// Fact is something the agent learned on a call.
+type Fact struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NewCallDetail converts a call and its history for the API.
+func NewCallDetail(call database.Call, attempts []database.CallAttempt, facts []database.CallFact, logs []database.CallLog) CallDetail {
+	detail := CallDetail{
+		Call:       NewCall(call),
+		Attempts:   make([]Attempt, len(attempts)),
+		Facts:      make([]Fact, len(facts)),
+		Transcript: transcript.Entries(logs),
+	}
+	for i, attempt := range attempts {
+		detail.Attempts[i] = Attempt{
+			Number:          attempt.AttemptNumber,
+			Status:          attempt.Status,
+			Error:           attempt.Error.String,
+			DurationSeconds: attempt.DurationSeconds,
+			BilledMinutes:   attempt.BilledMinutes,
+			ScheduledFor:    attempt.ScheduledFor,
+			AnsweredAt:      optionalTime(attempt.AnsweredAt),
+			EndedAt:         optionalTime(attempt.EndedAt),
+		}
+	}
+	for i, fact := range facts {
+		detail.Facts[i] = Fact{Key: fact.Key, Value: fact.Value}
+	}
+	return detail
+}
+
+// CallList is a page of calls, newest first. NextBeforeID, when set, is the
+// before_id asking for the page after it.
+type CallList struct {
+	Calls        []Call `json:"calls"`
+	NextBeforeID *int64 `json:"next_before_id,omitempty"`
+}
+
+// Contact is someone in the user's contacts.
+type Contact struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	PhoneNumber       string `json:"phone_number"`
+	Notes             string `json:"notes,omitempty"`
+	Timezone          string `json:"timezone,omitempty"`
+	PreferredLanguage string `json:"preferred_language,omitempty"`
+}
+
+// NewContact converts a contact for the API.
+func NewContact(contact database.Contact) Contact {
+	return Contact{
+		ID:                contact.ID,
+		Name:              contact.Name,
+		PhoneNumber:       contact.PhoneNumber,
+		Notes:             contact.Notes,
+		Timezone:          contact.Timezone.String,
+		PreferredLanguage: contact.PreferredLanguage.String,
+	}
+}
+
+// ContactList is all of the user's contacts, by name.
+type ContactList struct {
+	Contacts []Contact `json:"contacts"`
+}
+
+// Balance is the calling time the user has left.
+type Balance struct {
+	Minutes int64 `json:"minutes"`
+}
+
+func optionalID(id sql.NullInt64) *int64 {
+	if !id.Valid {
+		return nil
+	}
+	return &id.Int64
+}
+
+func optionalTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
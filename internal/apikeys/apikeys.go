@@ -0,0 +1,177 @@
+// Package apikeys issues and checks the keys users' own tools authenticate to
+// the API with. A key is shown once when it is made; only its SHA-256 is kept,
+// along with its prefix, the public start of the key, used to look it up and
+// shown so the user can tell their keys apart. Each key is limited to the
+// scopes picked for it.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// scopes a key can have
+const (
+	ScopeCallsWrite  = "calls:write"
+	ScopeCallsRead   = "calls:read"
+	ScopeBillingRead = "billing:read"
+)
+
+// Scopes are every scope, in the order the settings page offers them.
+var Scopes = []string{ScopeCallsWrite, ScopeCallsRead, ScopeBillingRead}
+
+// Describe says what a scope lets a key do.
+func Describe(scope string) string {
+	switch scope {
+	case ScopeCallsWrite:
+		return "Place and cancel calls"
+	case ScopeCallsRead:
+		return "See calls, their transcripts and your contacts"
+	case ScopeBillingRead:
+		return "See your minutes balance"
+	}
+	return scope
+}
+
+// Keys look like gdk_<12 hex characters of prefix>_<64 hex characters>.
+const (
+	keyTag       = "gdk_"
+	prefixBytes  = 6
+	secretBytes  = 32
+	prefixLength = len(keyTag) + 2*prefixBytes
+	keyLength    = prefixLength + 1 + 2*secretBytes
+)
+
+// ErrInvalidKey is returned by Authenticate for a key that isn't one of ours,
+// or has been revoked.
+var ErrInvalidKey = errors.New("invalid API key")
+
+// ErrNotFound is returned for a key that doesn't exist or belongs to another
+// user.
+var ErrNotFound = errors.New("API key not found")
+
+// Form is a key as entered on the settings page.
+type Form struct {
+	Name   string
+	Scopes []string
+}
+
+// maxNameLength limits key names.
+const maxNameLength = 100
+
+// Validate trims the form and checks it. It returns a problem to show the
+// user, or "" if the key can be made.
+func (f *Form) Validate() string {
+	f.Name = strings.TrimSpace(f.Name)
+	switch {
+	case f.Name == "":
+		return "Name the key, like after the tool that will use it."
+	case len(f.Name) > maxNameLength:
+		return fmt.Sprintf("The name must be under %d characters.", maxNameLength)
+	case len(f.Scopes) == 0:
+		return "Pick at least one scope."
+	}
+	for _, scope := range f.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Sprintf("There's no %q scope.", scope)
+		}
+	}
+	return ""
+}
+
+// Create makes a key for userID from a validated form. The key itself is only
+// ever returned here.
+func Create(ctx context.Context, q database.Querier, userID int64, f Form) (string, database.ApiKey, error) {
+	b := make([]byte, prefixBytes+secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", database.ApiKey{}, fmt.Errorf("error generating API key: %w", err)
+	}
+	prefix := keyTag + hex.EncodeToString(b[:prefixBytes])
+	key := prefix + "_" + hex.EncodeToString(b[prefixBytes:])
+
+	// kept in the order of Scopes, however they were picked
+	var scopes []string
+	for _, scope := range Scopes {
+		if slices.Contains(f.Scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	created, err := q.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		UserID:  userID,
+		Name:    f.Name,
+		Prefix:  prefix,
+		KeyHash: hash(key),
+		Scopes:  strings.Join(scopes, ","),
+	})
+	if err != nil {
+		return "", database.ApiKey{}, fmt.Errorf("error saving API key: %w", err)
+	}
+	return key, created, nil
+}
+
+// Authenticate finds the unrevoked key a request presented, and records that
+// it was used at now.
+func Authenticate(ctx context.Context, q database.Querier, key string, now time.Time) (database.ApiKey, error) {
+	if len(key) != keyLength || !strings.HasPrefix(key, keyTag) || key[prefixLength] != '_' {
+		return database.ApiKey{}, fmt.Errorf("%w: malformed", ErrInvalidKey)
+	}
+
+	found, err := q.GetAPIKeyByPrefix(ctx, key[:prefixLength])
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.ApiKey{}, fmt.Errorf("%w: unknown prefix", ErrInvalidKey)
+	}
+	if err != nil {
+		return database.ApiKey{}, fmt.Errorf("error loading API key %s: %w", key[:prefixLength], err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(key)), []byte(found.KeyHash)) != 1 {
+		return database.ApiKey{}, fmt.Errorf("%w: wrong secret for %s", ErrInvalidKey, found.Prefix)
+	}
+	if found.RevokedAt.Valid {
+		return database.ApiKey{}, fmt.Errorf("%w: %s was revoked", ErrInvalidKey, found.Prefix)
+	}
+
+	if err := q.TouchAPIKey(ctx, database.TouchAPIKeyParams{LastUsedAt: sql.NullTime{Time: now, Valid: true}, ID: found.ID}); err != nil {
+		return database.ApiKey{}, fmt.Errorf("error recording use of API key %s: %w", found.Prefix, err)
+	}
+	found.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+	return found, nil
+}
+
+// Revoke stops one of userID's keys working.
+func Revoke(ctx context.Context, q database.Querier, userID, id int64) error {
+	revoked, err := q.RevokeAPIKey(ctx, database.RevokeAPIKeyParams{ID: id, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error revoking API key %d: %w", id, err)
+	}
+	if revoked == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ScopesOf are the scopes a key has.
+func ScopesOf(key database.ApiKey) []string {
+	return strings.Split(key.Scopes, ",")
+}
+
+// HasScope reports whether a key may do what scope allows.
+func HasScope(key database.ApiKey, scope string) bool {
+	return slices.Contains(ScopesOf(key), scope)
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package apikeys
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForm_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		form            Form
+		expectedProblem string
+	}{
+		{name: "valid", form: Form{Name: " CRM sync ", Scopes: []string{ScopeCallsRead}}},
+		{name: "no name", form: Form{Name: "  ", Scopes: []string{ScopeCallsRead}}, expectedProblem: "Name the key, like after the tool that will use it."},
+		{name: "no scopes", form: Form{Name: "CRM sync"}, expectedProblem: "Pick at least one scope."},
+		{name: "unknown scope", form: Form{Name: "CRM sync", Scopes: []string{"admin"}}, expectedProblem: `There's no "admin" scope.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := tt.form
+			assert.Equal(t, tt.expectedProblem, form.Validate())
+		})
+	}
+}
+
+func setupDB(t *testing.T) (*database.DB, database.User) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "apikeys_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	user, err := db.CreateUser(context.Background(), database.CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	return db, user
+}
+
+func TestCreate(t *testing.T) {
+	db, user := setupDB(t)
+
+	key, created, err := Create(context.Background(), db, user.ID, Form{Name: "CRM sync", Scopes: []string{ScopeBillingRead, ScopeCallsWrite}})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^gdk_[0-9a-f]{12}_[0-9a-f]{64}$`, key)
+	assert.Equal(t, key[:len("gdk_")+12], created.Prefix)
+	assert.NotContains(t, created.KeyHash, key[len(created.Prefix)+1:], "only a hash of the key is stored")
+	assert.Equal(t, []string{ScopeCallsWrite, ScopeBillingRead}, ScopesOf(created), "scopes are kept in a fixed order")
+	assert.True(t, HasScope(created, ScopeCallsWrite))
+	assert.False(t, HasScope(created, ScopeCallsRead))
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC)
+	db, user := setupDB(t)
+	key, created, err := Create(ctx, db, user.ID, Form{Name: "CRM sync", Scopes: []string{ScopeCallsRead}})
+	require.NoError(t, err)
+	revokedKey, revoked, err := Create(ctx, db, user.ID, Form{Name: "Old script", Scopes: []string{ScopeCallsRead}})
+	require.NoError(t, err)
+	require.NoError(t, Revoke(ctx, db, user.ID, revoked.ID))
+
+	wrongSecret := key[:len(key)-1] + "0"
+	if wrongSecret == key {
+		wrongSecret = key[:len(key)-1] + "1"
+	}
+
+	other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, Revoke(ctx, db, other.ID, created.ID), ErrNotFound, "only the owner can revoke a key")
+
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{name: "key", key: key, valid: true},
+		{name: "wrong secret", key: wrongSecret},
+		{name: "unknown prefix", key: "gdk_000000000000" + key[len("gdk_000000000000"):]},
+		{name: "revoked", key: revokedKey},
+		{name: "truncated", key: key[:20]},
+		{name: "someone else's format", key: "sk_live_" + key[8:]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := Authenticate(ctx, db, tt.key, now)
+			if !tt.valid {
+				assert.ErrorIs(t, err, ErrInvalidKey)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, created.ID, found.ID)
+
+			keys, err := db.ListAPIKeys(ctx, user.ID)
+			require.NoError(t, err)
+			require.Len(t, keys, 2)
+			assert.Equal(t, created.ID, keys[0].ID, "revoked keys are listed last")
+			assert.True(t, keys[0].LastUsedAt.Time.Equal(now))
+			assert.False(t, keys[1].LastUsedAt.Valid)
+		})
+	}
+}
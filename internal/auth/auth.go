@@ -0,0 +1,130 @@
+// Package auth works out which user a request is from. Until sign-in exists
+// every request is the configured development user; handlers that need the
+// user are wrapped in Sessions.RequireUser and read it back with
+// UserFromContext, so real sessions can replace the lookup without touching
+// them. API routes are wrapped in Sessions.RequireAPI instead, which takes
+// the user's API keys, and the session only in development.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"goDial/internal/api"
+	"goDial/internal/apikeys"
+	"goDial/internal/database"
+)
+
+// Sessions works out the user behind requests.
+type Sessions struct {
+	db database.Querier
+	// devUserEmail is the account every request acts as until sign-in exists
+	devUserEmail string
+	// dev lets API requests without a key fall back to the session, for
+	// trying the API out with curl
+	dev bool
+}
+
+// NewSessions returns the sessions of the users in db, where every request
+// is from the user with devUserEmail until sign-in exists. In development
+// (dev) API requests without a key fall back to the session wherever they
+// come from.
+func NewSessions(db database.Querier, devUserEmail string, dev bool) *Sessions {
+	return &Sessions{db: db, devUserEmail: devUserEmail, dev: dev}
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying user.
+func WithUser(ctx context.Context, user database.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the user RequireUser resolved for a request.
+func UserFromContext(ctx context.Context) (database.User, bool) {
+	user, ok := ctx.Value(userKey{}).(database.User)
+	return user, ok
+}
+
+// RequireUser resolves the user behind a request and passes it on to next in
+// the request's context. Requests without one are turned away.
+func (s *Sessions) RequireUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.User(r.Context())
+		if err != nil {
+			fmt.Printf("RequireUser(couldnt find user): %v\n", err)
+			http.Error(w, "sign in to continue", http.StatusUnauthorized)
+			return
+		}
+		next(w, r.WithContext(WithUser(r.Context(), user)))
+	}
+}
+
+// User is the user signed in on a request's session, for handlers that
+// treat a missing user differently from RequireUser.
+func (s *Sessions) User(ctx context.Context) (database.User, error) {
+	return s.db.GetUserByEmail(ctx, s.devUserEmail)
+}
+
+// RequireAPI resolves the user behind an API request and passes it on to next
+// like RequireUser, answering with the API's error envelope. A request with a
+// bearer token is from the owner of that API key, and only let through if the
+// key has scope. Without one the request is turned away, except in
+// development, where it falls back to the session, which can do anything its
+// user can.
+func (s *Sessions) RequireAPI(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if !s.dev {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="goDial"`)
+				api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "Send an API key as a bearer token.")
+				return
+			}
+			user, err := s.User(r.Context())
+			if err != nil {
+				fmt.Printf("RequireAPI(couldnt find user): %v\n", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="goDial"`)
+				api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "Sign in, or send an API key as a bearer token.")
+				return
+			}
+			next(w, r.WithContext(WithUser(r.Context(), user)))
+			return
+		}
+
+		scheme, token, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goDial"`)
+			api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "Send your API key as a bearer token.")
+			return
+		}
+		key, err := apikeys.Authenticate(r.Context(), s.db, strings.TrimSpace(token), time.Now())
+		if err != nil {
+			fmt.Printf("RequireAPI(couldnt authenticate key): %v\n", err)
+			if !errors.Is(err, apikeys.ErrInvalidKey) {
+				api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not check your API key.")
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="goDial", error="invalid_token"`)
+			api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "This API key is wrong or has been revoked.")
+			return
+		}
+		if !apikeys.HasScope(key, scope) {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="goDial", error="insufficient_scope", scope=%q`, scope))
+			api.WriteError(w, http.StatusForbidden, api.CodeInsufficientScope, fmt.Sprintf("This API key doesn't have the %s scope.", scope))
+			return
+		}
+
+		user, err := s.db.GetUser(r.Context(), key.UserID)
+		if err != nil {
+			fmt.Printf("RequireAPI(couldnt find owner of key %s): %v\n", key.Prefix, err)
+			api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "This API key is wrong or has been revoked.")
+			return
+		}
+		next(w, r.WithContext(WithUser(r.Context(), user)))
+	}
+}
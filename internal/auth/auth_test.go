@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"goDial/internal/apikeys"
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// devUserEmail is the development user the tests' sessions are from.
+const devUserEmail = "test@test.com"
+
+func TestRequireUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		createUser   bool
+		expectedCode int
+	}{
+		{name: "passes the user on", createUser: true, expectedCode: http.StatusOK},
+		{name: "turns away requests without a user", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := database.InitDB(filepath.Join(t.TempDir(), "auth_test.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+
+			var created database.User
+			if tt.createUser {
+				created, err = db.CreateUser(context.Background(), database.CreateUserParams{Email: devUserEmail, Name: "Test User"})
+				require.NoError(t, err)
+			}
+
+			var seen database.User
+			var found bool
+			handler := NewSessions(db, devUserEmail, false).RequireUser(func(w http.ResponseWriter, r *http.Request) {
+				seen, found = UserFromContext(r.Context())
+			})
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.createUser, found)
+			assert.Equal(t, created.ID, seen.ID)
+		})
+	}
+}
+
+func TestRequireAPI(t *testing.T) {
+	tests := []struct {
+		name              string
+		sessionUser       bool
+		dev               bool
+		fetchSite         string
+		authorization     func(key, revoked string) string
+		expectedCode      int
+		expectedOwner     bool
+		expectedChallenge string
+	}{
+		{name: "falls back to the session in development", sessionUser: true, dev: true, expectedCode: http.StatusOK},
+		{name: "turns away keyless requests outside development", sessionUser: true, expectedCode: http.StatusUnauthorized, expectedChallenge: `Bearer realm="goDial"`},
+		{name: "turns away keyless requests claiming to be from goDial's pages", sessionUser: true, fetchSite: "same-origin", expectedCode: http.StatusUnauthorized, expectedChallenge: `Bearer realm="goDial"`},
+		{name: "turns away requests without a user or key", dev: true, expectedCode: http.StatusUnauthorized, expectedChallenge: `Bearer realm="goDial"`},
+		{
+			name:          "passes on the key's owner, not the session's user",
+			sessionUser:   true,
+			authorization: func(key, _ string) string { return "Bearer " + key },
+			expectedCode:  http.StatusOK,
+			expectedOwner: true,
+		},
+		{
+			name:              "turns away a revoked key",
+			sessionUser:       true,
+			authorization:     func(_, revoked string) string { return "Bearer " + revoked },
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="goDial", error="invalid_token"`,
+		},
+		{
+			name:              "turns away a wrong key",
+			authorization:     func(string, string) string { return "Bearer gdk_nope" },
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="goDial", error="invalid_token"`,
+		},
+		{
+			name:              "turns away other schemes",
+			authorization:     func(key, _ string) string { return "Basic " + key },
+			expectedCode:      http.StatusUnauthorized,
+			expectedChallenge: `Bearer realm="goDial"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := database.InitDB(filepath.Join(t.TempDir(), "auth_test.db"))
+			require.NoError(t, err)
+			t.Cleanup(func() { db.Close() })
+
+			var session database.User
+			if tt.sessionUser {
+				session, err = db.CreateUser(ctx, database.CreateUserParams{Email: devUserEmail, Name: "Test User"})
+				require.NoError(t, err)
+			}
+			owner, err := db.CreateUser(ctx, database.CreateUserParams{Email: "owner@example.com", Name: "Key Owner"})
+			require.NoError(t, err)
+			key, _, err := apikeys.Create(ctx, db, owner.ID, apikeys.Form{Name: "CRM sync", Scopes: []string{apikeys.ScopeCallsRead}})
+			require.NoError(t, err)
+			revoked, revokedKey, err := apikeys.Create(ctx, db, owner.ID, apikeys.Form{Name: "Old script", Scopes: []string{apikeys.ScopeCallsRead}})
+			require.NoError(t, err)
+			require.NoError(t, apikeys.Revoke(ctx, db, owner.ID, revokedKey.ID))
+
+			var seen database.User
+			handler := NewSessions(db, devUserEmail, tt.dev).RequireAPI(apikeys.ScopeCallsRead, func(w http.ResponseWriter, r *http.Request) {
+				seen, _ = UserFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/api/v1/calls", nil)
+			if tt.fetchSite != "" {
+				req.Header.Set("Sec-Fetch-Site", tt.fetchSite)
+			}
+			if tt.authorization != nil {
+				req.Header.Set("Authorization", tt.authorization(key, revoked))
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedChallenge, w.Header().Get("WWW-Authenticate"))
+			switch {
+			case w.Code != http.StatusOK:
+				assert.Zero(t, seen.ID)
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			case tt.expectedOwner:
+				assert.Equal(t, owner.ID, seen.ID)
+			default:
+				assert.Equal(t, session.ID, seen.ID)
+			}
+		})
+	}
+}
+
+func TestRequireAPI_Scope(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "auth_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	owner, err := db.CreateUser(ctx, database.CreateUserParams{Email: "owner@example.com", Name: "Key Owner"})
+	require.NoError(t, err)
+	key, _, err := apikeys.Create(ctx, db, owner.ID, apikeys.Form{Name: "Dashboard", Scopes: []string{apikeys.ScopeCallsRead}})
+	require.NoError(t, err)
+
+	called := false
+	handler := NewSessions(db, devUserEmail, false).RequireAPI(apikeys.ScopeCallsWrite, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	req := httptest.NewRequest("POST", "/api/v1/calls", nil)
+	req.Header.Set("Authorization", "Bearer "+key)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"insufficient_scope","message":"This API key doesn't have the calls:write scope."}`, w.Body.String())
+	assert.Equal(t, `Bearer realm="goDial", error="insufficient_scope", scope="calls:write"`, w.Header().Get("WWW-Authenticate"))
+}
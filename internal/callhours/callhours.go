@@ -0,0 +1,231 @@
+// Package callhours decides when a recipient may be called, by the local time
+// where their number is.
+//
+// A number's time zone comes from its country, and for US and Canadian
+// numbers from its area code. When a number could be in more than one zone,
+// a call is only allowed when it is inside calling hours in all of them. A
+// time zone known for the recipient themselves, such as a contact's, is
+// used instead.
+package callhours
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // the zones must load on hosts without a zoneinfo database
+
+	"goDial/internal/phone"
+)
+
+// ErrUnknownZone means there is no time zone on record for a number's country.
+var ErrUnknownZone = errors.New("no time zone known for phone number")
+
+// clockLayout is how a Window is written, e.g. "08:00-21:00".
+const clockLayout = "15:04"
+
+// Window is the part of the day calls are allowed in, as offsets from local
+// midnight. Start is inclusive and End exclusive.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindow reads a window written as "HH:MM-HH:MM". Windows may not cross
+// midnight.
+func ParseWindow(s string) (Window, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("calling hours %q: want HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return Window{}, fmt.Errorf("calling hours %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Window{}, fmt.Errorf("calling hours %q: %w", s, err)
+	}
+	if start >= end {
+		return Window{}, fmt.Errorf("calling hours %q: must start before they end", s)
+	}
+	return Window{Start: start, End: end}, nil
+}
+
+// MustParseWindow is ParseWindow for windows written into the code.
+func MustParseWindow(s string) Window {
+	w, err := ParseWindow(s)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse(clockLayout, strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String writes w the way ParseWindow reads it.
+func (w Window) String() string {
+	midnight := time.Time{}
+	return midnight.Add(w.Start).Format(clockLayout) + "-" + midnight.Add(w.End).Format(clockLayout)
+}
+
+// contains reports whether t falls inside w on t's own day.
+func (w Window) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	return offset >= w.Start && offset < w.End
+}
+
+// Policy holds the calling hours for every place goDial calls.
+type Policy struct {
+	// Default applies wherever there is no override.
+	Default Window
+	// Overrides are keyed by ISO 3166-2 subdivision ("US-FL") or by
+	// ISO 3166-1 country ("GB"); a subdivision wins over its country.
+	Overrides map[string]Window
+	// Now is the clock Check reads. nil means time.Now.
+	Now func() time.Time
+}
+
+// DefaultPolicy allows calls from 8am to 9pm, the federal limit for
+// telephone solicitations in the US, and from 8am to 8pm in states whose own
+// laws are stricter.
+func DefaultPolicy() *Policy {
+	stateLimit := MustParseWindow("08:00-20:00")
+	return &Policy{
+		Default: MustParseWindow("08:00-21:00"),
+		Overrides: map[string]Window{
+			"US-FL": stateLimit,
+			"US-MD": stateLimit,
+			"US-OK": stateLimit,
+			"US-WA": stateLimit,
+		},
+	}
+}
+
+// Decision is the outcome of checking a number against a Policy.
+type Decision struct {
+	// Allowed reports whether the number may be called at the checked time.
+	Allowed bool
+	// Next is the earliest time the number may be called: the checked time
+	// itself when Allowed.
+	Next time.Time
+	// Window is the calling hours that applied.
+	Window Window
+	// Zones are the time zones the number may be in, most likely first.
+	Zones []*time.Location
+}
+
+// Zone is the time zone the number most likely is in.
+func (d Decision) Zone() *time.Location {
+	return d.Zones[0]
+}
+
+// Check decides whether number may be called now.
+func (p *Policy) Check(number phone.Number) (Decision, error) {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	return p.CheckAt(number, now())
+}
+
+// CheckAt decides whether number may be called at t.
+func (p *Policy) CheckAt(number phone.Number, t time.Time) (Decision, error) {
+	return p.CheckInAt(number, nil, t)
+}
+
+// CheckInAt decides whether number, whose recipient is in zone, may be called
+// at t. The number's own zones are only used when zone is nil.
+func (p *Policy) CheckInAt(number phone.Number, zone *time.Location, t time.Time) (Decision, error) {
+	zones := []*time.Location{zone}
+	if zone == nil {
+		var err error
+		if zones, err = Zones(number); err != nil {
+			return Decision{}, err
+		}
+	}
+	decision := Decision{Window: p.window(number), Zones: zones}
+
+	next, ok := decision.Window.nextIn(zones, t)
+	if !ok {
+		// the zones are so far apart their windows never overlap; fall back
+		// to the most likely zone rather than never calling
+		next, _ = decision.Window.nextIn(zones[:1], t)
+	}
+	decision.Next = next
+	decision.Allowed = next.Equal(t)
+	return decision, nil
+}
+
+// window returns the calling hours that apply to number.
+func (p *Policy) window(number phone.Number) Window {
+	if w, ok := p.Overrides[number.Subdivision]; ok && number.Subdivision != "" {
+		return w
+	}
+	if w, ok := p.Overrides[number.Region]; ok {
+		return w
+	}
+	return p.Default
+}
+
+// Zones returns the time zones number may be in, most likely first.
+func Zones(number phone.Number) ([]*time.Location, error) {
+	names := zoneNames(number)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s in %s: %w", number, number.Region, ErrUnknownZone)
+	}
+	zones := make([]*time.Location, 0, len(names))
+	for _, name := range names {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("error loading time zone %s: %w", name, err)
+		}
+		zones = append(zones, loc)
+	}
+	return zones, nil
+}
+
+// searchDays is how far ahead nextIn looks for a time every zone allows.
+const searchDays = 3
+
+// nextIn returns the earliest time at or after t that is inside w in every
+// zone. Such a time is either t itself or the moment the window opens in one
+// of the zones, so only those are tried.
+func (w Window) nextIn(zones []*time.Location, t time.Time) (time.Time, bool) {
+	candidates := []time.Time{t}
+	for _, loc := range zones {
+		local := t.In(loc)
+		for day := -1; day <= searchDays; day++ {
+			// built from the wall clock, so a DST change overnight doesn't
+			// shift the opening hour
+			opens := time.Date(local.Year(), local.Month(), local.Day()+day, int(w.Start/time.Hour), int(w.Start%time.Hour/time.Minute), 0, 0, loc)
+			if opens.After(t) {
+				candidates = append(candidates, opens)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	for _, c := range candidates {
+		if w.containsIn(zones, c) {
+			return c, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w Window) containsIn(zones []*time.Location, t time.Time) bool {
+	for _, loc := range zones {
+		if !w.contains(t.In(loc)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package callhours
+
+import (
+	"testing"
+	"time"
+
+	"goDial/internal/phone"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, number string) phone.Number {
+	t.Helper()
+	n, err := phone.Parse(number, "US")
+	require.NoError(t, err)
+	return n
+}
+
+func utc(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestPolicy_CheckAt(t *testing.T) {
+	tests := []struct {
+		name          string
+		number        string
+		at            time.Time
+		expectAllowed bool
+		expectNext    time.Time
+	}{
+		{
+			name:          "new york afternoon",
+			number:        "+12125550123",
+			at:            utc(time.October, 18, 18, 0), // 2pm EDT
+			expectAllowed: true,
+			expectNext:    utc(time.October, 18, 18, 0),
+		},
+		{
+			name:       "new york late evening waits for the morning",
+			number:     "+12125550123",
+			at:         utc(time.October, 19, 1, 30), // 9:30pm EDT
+			expectNext: utc(time.October, 19, 12, 0), // 8am EDT
+		},
+		{
+			name:       "window end is exclusive",
+			number:     "+12125550123",
+			at:         utc(time.October, 19, 1, 0), // 9pm EDT
+			expectNext: utc(time.October, 19, 12, 0),
+		},
+		{
+			name:       "los angeles early morning",
+			number:     "+14155550123",
+			at:         utc(time.October, 18, 13, 0), // 6am PDT
+			expectNext: utc(time.October, 18, 15, 0), // 8am PDT
+		},
+		{
+			name:       "florida is stricter than the federal limit",
+			number:     "+13055550123",
+			at:         utc(time.October, 19, 0, 30), // 8:30pm EDT
+			expectNext: utc(time.October, 19, 12, 0),
+		},
+		{
+			name:       "split area code waits for the later zone",
+			number:     "+18505550123",
+			at:         utc(time.October, 18, 12, 30), // 8:30am EDT, 7:30am CDT
+			expectNext: utc(time.October, 18, 13, 0),  // 8am CDT
+		},
+		{
+			name:          "london",
+			number:        "+442079460958",
+			at:            utc(time.October, 18, 19, 0), // 8pm BST
+			expectAllowed: true,
+			expectNext:    utc(time.October, 18, 19, 0),
+		},
+		{
+			name:       "dst ends overnight in london",
+			number:     "+442079460958",
+			at:         utc(time.October, 24, 21, 0), // 10pm BST
+			expectNext: utc(time.October, 25, 8, 0),  // 8am GMT
+		},
+		{
+			name:       "sydney landline",
+			number:     "+61212345678",
+			at:         utc(time.October, 18, 18, 0), // 5am AEDT
+			expectNext: utc(time.October, 18, 21, 0), // 8am AEDT
+		},
+	}
+
+	policy := DefaultPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := policy.CheckAt(mustParse(t, tt.number), tt.at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectAllowed, decision.Allowed)
+			assert.True(t, tt.expectNext.Equal(decision.Next), "expected next %s, got %s", tt.expectNext, decision.Next)
+		})
+	}
+}
+
+func TestPolicy_CheckInAt(t *testing.T) {
+	honolulu, err := time.LoadLocation("Pacific/Honolulu")
+	require.NoError(t, err)
+	at := utc(time.October, 18, 13, 0) // 9am EDT, 3am HST
+
+	tests := []struct {
+		name          string
+		number        string
+		zone          *time.Location
+		expectAllowed bool
+		expectNext    time.Time
+		expectZone    string
+	}{
+		{
+			name:          "the number's zone without one of the recipient's",
+			number:        "+12125550123",
+			expectAllowed: true,
+			expectNext:    at,
+			expectZone:    "America/New_York",
+		},
+		{
+			name:       "the recipient's zone wins over the area code's",
+			number:     "+12125550123",
+			zone:       honolulu,
+			expectNext: utc(time.October, 18, 18, 0), // 8am HST
+			expectZone: "Pacific/Honolulu",
+		},
+		{
+			name:       "the recipient's zone wins over a split area code's",
+			number:     "+18505550123",
+			zone:       honolulu,
+			expectNext: utc(time.October, 18, 18, 0),
+			expectZone: "Pacific/Honolulu",
+		},
+	}
+
+	policy := DefaultPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := policy.CheckInAt(mustParse(t, tt.number), tt.zone, at)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectAllowed, decision.Allowed)
+			assert.True(t, tt.expectNext.Equal(decision.Next), "expected next %s, got %s", tt.expectNext, decision.Next)
+			assert.Equal(t, tt.expectZone, decision.Zone().String())
+		})
+	}
+}
+
+func TestPolicy_Overrides(t *testing.T) {
+	clock := utc(time.October, 18, 13, 0) // 9am EDT, 2pm BST
+	policy := &Policy{
+		Default: MustParseWindow("08:00-21:00"),
+		Overrides: map[string]Window{
+			"US-NY": MustParseWindow("10:00-18:00"),
+			"GB":    MustParseWindow("09:00-13:00"),
+		},
+		Now: func() time.Time { return clock },
+	}
+
+	tests := []struct {
+		name          string
+		number        string
+		expectAllowed bool
+		expectWindow  string
+	}{
+		{name: "state override", number: "+12125550123", expectWindow: "10:00-18:00"},
+		{name: "country override", number: "+442079460958", expectWindow: "09:00-13:00"},
+		{name: "no override", number: "+16175550123", expectAllowed: true, expectWindow: "08:00-21:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := policy.Check(mustParse(t, tt.number))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectAllowed, decision.Allowed)
+			assert.Equal(t, tt.expectWindow, decision.Window.String())
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("08:30 - 20:00")
+	require.NoError(t, err)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, w.Start)
+	assert.Equal(t, 20*time.Hour, w.End)
+
+	for _, bad := range []string{"", "08:00", "8am-9pm", "21:00-08:00", "09:00-09:00"} {
+		_, err := ParseWindow(bad)
+		assert.Error(t, err, bad)
+	}
+}
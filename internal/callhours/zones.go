@@ -0,0 +1,161 @@
+package callhours
+
+import (
+	"strings"
+
+	"goDial/internal/phone"
+)
+
+// zonesByCountry are the time zones a number of each country may be in when
+// nothing narrower is known about it.
+var zonesByCountry = map[string][]string{
+	"US": {"America/New_York", "America/Chicago", "America/Denver", "America/Phoenix", "America/Los_Angeles", "America/Anchorage", "Pacific/Honolulu"},
+	"CA": {"America/St_Johns", "America/Halifax", "America/Toronto", "America/Winnipeg", "America/Regina", "America/Edmonton", "America/Vancouver"},
+	"GB": {"Europe/London"},
+	"IE": {"Europe/Dublin"},
+	"DE": {"Europe/Berlin"},
+	"FR": {"Europe/Paris"},
+	"ES": {"Europe/Madrid", "Atlantic/Canary"},
+	"IT": {"Europe/Rome"},
+	"MX": {"America/Mexico_City", "America/Cancun", "America/Chihuahua", "America/Hermosillo", "America/Tijuana"},
+	"BR": {"America/Sao_Paulo", "America/Manaus", "America/Rio_Branco"},
+	"ZA": {"Africa/Johannesburg"},
+	"IN": {"Asia/Kolkata"},
+	"JP": {"Asia/Tokyo"},
+	"AU": {"Australia/Sydney", "Australia/Brisbane", "Australia/Adelaide", "Australia/Darwin", "Australia/Perth"},
+	"NZ": {"Pacific/Auckland"},
+}
+
+// zonesBySubdivision narrow a US or Canadian number down by its state or
+// province.
+var zonesBySubdivision = map[string][]string{
+	"US-AL": {"America/Chicago"},
+	"US-AK": {"America/Anchorage"},
+	"US-AZ": {"America/Phoenix"},
+	"US-AR": {"America/Chicago"},
+	"US-CA": {"America/Los_Angeles"},
+	"US-CO": {"America/Denver"},
+	"US-CT": {"America/New_York"},
+	"US-DE": {"America/New_York"},
+	"US-DC": {"America/New_York"},
+	"US-FL": {"America/New_York"},
+	"US-GA": {"America/New_York"},
+	"US-HI": {"Pacific/Honolulu"},
+	"US-ID": {"America/Boise"},
+	"US-IL": {"America/Chicago"},
+	"US-IN": {"America/Indiana/Indianapolis"},
+	"US-IA": {"America/Chicago"},
+	"US-KS": {"America/Chicago"},
+	"US-KY": {"America/New_York"},
+	"US-LA": {"America/Chicago"},
+	"US-ME": {"America/New_York"},
+	"US-MD": {"America/New_York"},
+	"US-MA": {"America/New_York"},
+	"US-MI": {"America/Detroit"},
+	"US-MN": {"America/Chicago"},
+	"US-MS": {"America/Chicago"},
+	"US-MO": {"America/Chicago"},
+	"US-MT": {"America/Denver"},
+	"US-NE": {"America/Chicago"},
+	"US-NV": {"America/Los_Angeles"},
+	"US-NH": {"America/New_York"},
+	"US-NJ": {"America/New_York"},
+	"US-NM": {"America/Denver"},
+	"US-NY": {"America/New_York"},
+	"US-NC": {"America/New_York"},
+	"US-ND": {"America/Chicago"},
+	"US-OH": {"America/New_York"},
+	"US-OK": {"America/Chicago"},
+	"US-OR": {"America/Los_Angeles"},
+	"US-PA": {"America/New_York"},
+	"US-RI": {"America/New_York"},
+	"US-SC": {"America/New_York"},
+	"US-SD": {"America/Chicago"},
+	"US-TN": {"America/Chicago"},
+	"US-TX": {"America/Chicago"},
+	"US-UT": {"America/Denver"},
+	"US-VT": {"America/New_York"},
+	"US-VA": {"America/New_York"},
+	"US-WA": {"America/Los_Angeles"},
+	"US-WV": {"America/New_York"},
+	"US-WI": {"America/Chicago"},
+	"US-WY": {"America/Denver"},
+	"US-PR": {"America/Puerto_Rico"},
+	"US-VI": {"America/St_Thomas"},
+	"US-GU": {"Pacific/Guam"},
+	"US-MP": {"Pacific/Saipan"},
+	"US-AS": {"Pacific/Pago_Pago"},
+
+	"CA-AB": {"America/Edmonton"},
+	"CA-BC": {"America/Vancouver"},
+	"CA-MB": {"America/Winnipeg"},
+	"CA-NB": {"America/Moncton"},
+	"CA-NL": {"America/St_Johns"},
+	"CA-NS": {"America/Halifax"},
+	"CA-ON": {"America/Toronto"},
+	"CA-QC": {"America/Toronto"},
+	"CA-SK": {"America/Regina"},
+	"CA-YT": {"America/Whitehorse", "America/Yellowknife", "America/Iqaluit"},
+}
+
+// zonesByAreaCode cover area codes that straddle a time zone line, where the
+// state alone would guess wrong for part of the area.
+var zonesByAreaCode = map[string][]string{
+	"208": {"America/Boise", "America/Los_Angeles"},
+	"986": {"America/Boise", "America/Los_Angeles"},
+	"219": {"America/Chicago"},
+	"812": {"America/Indiana/Indianapolis", "America/Chicago"},
+	"930": {"America/Indiana/Indianapolis", "America/Chicago"},
+	"270": {"America/Chicago"},
+	"364": {"America/Chicago"},
+	"606": {"America/New_York", "America/Chicago"},
+	"423": {"America/New_York"},
+	"865": {"America/New_York"},
+	"931": {"America/Chicago", "America/New_York"},
+	"850": {"America/New_York", "America/Chicago"},
+	"448": {"America/New_York", "America/Chicago"},
+	"906": {"America/Detroit", "America/Chicago"},
+	"915": {"America/Denver"},
+	"432": {"America/Chicago", "America/Denver"},
+	"308": {"America/Chicago", "America/Denver"},
+	"620": {"America/Chicago", "America/Denver"},
+	"785": {"America/Chicago", "America/Denver"},
+	"701": {"America/Chicago", "America/Denver"},
+	"605": {"America/Chicago", "America/Denver"},
+	"458": {"America/Los_Angeles", "America/Boise"},
+	"541": {"America/Los_Angeles", "America/Boise"},
+	"250": {"America/Vancouver", "America/Edmonton"},
+	"807": {"America/Toronto", "America/Winnipeg"},
+}
+
+// zonesByAUPrefix narrow Australian landlines down by their area code; mobiles
+// (4) could be anywhere.
+var zonesByAUPrefix = map[byte][]string{
+	'2': {"Australia/Sydney"},
+	'3': {"Australia/Melbourne"},
+	'7': {"Australia/Brisbane"},
+	'8': {"Australia/Adelaide", "Australia/Darwin", "Australia/Perth"},
+}
+
+// zoneNames returns the time zones number may be in, narrowest first found.
+// Area codes say where a number was issued, not where its owner is, so
+// mobiles that moved with their owners are the exception this can't see.
+func zoneNames(number phone.Number) []string {
+	if number.CountryCode == "1" && len(number.National) >= 3 {
+		if zones, ok := zonesByAreaCode[number.National[:3]]; ok {
+			return zones
+		}
+	}
+	if zones, ok := zonesBySubdivision[number.Subdivision]; ok {
+		return zones
+	}
+	if number.Region == "AU" && number.National != "" {
+		if zones, ok := zonesByAUPrefix[number.National[0]]; ok {
+			return zones
+		}
+	}
+	if number.Region == "ES" && (strings.HasPrefix(number.National, "828") || strings.HasPrefix(number.National, "922") || strings.HasPrefix(number.National, "928")) {
+		return []string{"Atlantic/Canary"}
+	}
+	return zonesByCountry[number.Region]
+}
@@ -0,0 +1,347 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goDial/internal/api"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/transcript"
+)
+
+// limits on the pages of calls HandleAPICalls returns
+const (
+	defaultCallsPage = 50
+	maxCallsPage     = 200
+)
+
+// apiFields names the call form's fields as they are in api.CreateCallRequest.
+var apiFields = map[string]string{
+	"recipientPhoneNumber": "phone_number",
+	"recipientContext":     "recipient",
+	"objective":            "objective",
+	"otherContext":         "context",
+	"maxAttempts":          "max_attempts",
+	"retrySpacingMinutes":  "retry_spacing_minutes",
+	"retryWindowStart":     "retry_window_start",
+	"retryWindowEnd":       "retry_window_end",
+	"record":               "record",
+	"templateId":           "template_id",
+	"templateVariables":    "variables",
+}
+
+// requestFields reads a call request by the call form's field names, so it is
+// validated just like the form.
+func requestFields(req api.CreateCallRequest) func(name string) string {
+	// numbers left out are blank, like empty form fields; any sent, zero
+	// included, are validated as given
+	number := func(n *int64) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.FormatInt(*n, 10)
+	}
+	return func(name string) string {
+		switch name {
+		case "recipientPhoneNumber":
+			return req.PhoneNumber
+		case "recipientContext":
+			return req.Recipient
+		case "objective":
+			return req.Objective
+		case "otherContext":
+			return req.Context
+		case "maxAttempts":
+			return number(req.MaxAttempts)
+		case "retrySpacingMinutes":
+			return number(req.RetrySpacingMinutes)
+		case "retryWindowStart":
+			return req.RetryWindowStart
+		case "retryWindowEnd":
+			return req.RetryWindowEnd
+		case "record":
+			if req.Record {
+				return "on"
+			}
+		case "templateId":
+			if req.TemplateID != 0 {
+				return strconv.FormatInt(req.TemplateID, 10)
+			}
+		}
+		return ""
+	}
+}
+
+// writeCallError answers a call request that couldn't be made, whether it
+// didn't validate, is to a blocked number or was turned down by moderation.
+func writeCallError(w http.ResponseWriter, err error) {
+	var invalid *fieldError
+	switch {
+	case errors.As(err, &invalid):
+		api.WriteFieldError(w, apiFields[invalid.field], invalid.problem)
+	case errors.Is(err, dnc.ErrBlocked):
+		api.WriteError(w, http.StatusForbidden, api.CodeDoNotCall, "This number is on the do-not-call list.")
+	case errors.Is(err, errRejected):
+		api.WriteError(w, http.StatusForbidden, api.CodeForbidden, "Request violates Terms of Service.")
+	default:
+		fmt.Printf("writeCallError: %v\n", err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not make the call.")
+	}
+}
+
+// HandleAPICreateCall makes a call from the call form's fields, sent as JSON.
+// There is no review over the API: the drafted opening line and plan are
+// approved as they are and the call is queued to be dialed.
+func (s *Service) HandleAPICreateCall(w http.ResponseWriter, r *http.Request) {
+	if s.refusingCalls(w) {
+		api.WriteError(w, http.StatusServiceUnavailable, api.CodeUnavailable, drainingMessage)
+		return
+	}
+
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleAPICreateCall(no current user): %v\n", err)
+		api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "Sign in to place calls.")
+		return
+	}
+
+	var req api.CreateCallRequest
+	if !api.Decode(w, r, &req) {
+		return
+	}
+	fields := requestFields(req)
+	form, err := validateCallForm(fields)
+	if err != nil {
+		writeCallError(w, err)
+		return
+	}
+	if err := s.applyTemplate(r.Context(), user.ID, form, fields("templateId"), req.Variables); err != nil {
+		writeCallError(w, err)
+		return
+	}
+
+	call, err := s.newCall(r.Context(), user, form)
+	if err != nil {
+		writeCallError(w, err)
+		return
+	}
+	approved, err := s.db.ApproveCall(r.Context(), database.ApproveCallParams{
+		OpeningLine: call.OpeningLine,
+		CallPlan:    call.CallPlan,
+		ID:          call.ID,
+	})
+	if err != nil {
+		fmt.Printf("HandleAPICreateCall(couldnt approve call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not start the call.")
+		return
+	}
+	if err := s.enqueuePlacement(r.Context(), call.ID, s.now()); err != nil {
+		fmt.Printf("HandleAPICreateCall(couldnt queue call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not start the call.")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/calls/%d", api.Prefix, call.ID))
+	api.WriteJSON(w, http.StatusCreated, api.NewCall(approved))
+}
+
+// HandleAPICalls lists the current user's calls, newest first, a page at a
+// time. The query takes a status to only list calls in it, and the limit and
+// before_id of the page.
+func (s *Service) HandleAPICalls(w http.ResponseWriter, r *http.Request) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleAPICalls(no current user): %v\n", err)
+		api.WriteError(w, http.StatusUnauthorized, api.CodeUnauthorized, "Sign in to see your calls.")
+		return
+	}
+
+	query := r.URL.Query()
+	params := database.ListCallsPageParams{UserID: user.ID, Limit: defaultCallsPage}
+	if status := query.Get("status"); status != "" {
+		switch status {
+		case statusPending, statusInProgress, statusCompleted, statusFailed:
+		default:
+			api.WriteFieldError(w, "status", fmt.Sprintf("Calls are %s, %s, %s or %s, not %q.", statusPending, statusInProgress, statusCompleted, statusFailed, status))
+			return
+		}
+		params.Status.String, params.Status.Valid = status, true
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 || limit > maxCallsPage {
+			api.WriteFieldError(w, "limit", fmt.Sprintf("The limit must be from 1 to %d, not %q.", maxCallsPage, value))
+			return
+		}
+		params.Limit = limit
+	}
+	if value := query.Get("before_id"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			api.WriteFieldError(w, "before_id", fmt.Sprintf("before_id must be a call id, not %q.", value))
+			return
+		}
+		params.BeforeID.Int64, params.BeforeID.Valid = before, true
+	}
+
+	// one more than the page shows whether there is a page after it
+	limit := params.Limit
+	params.Limit++
+	calls, err := s.db.ListCallsPage(r.Context(), params)
+	if err != nil {
+		fmt.Printf("HandleAPICalls(couldnt list calls of user %d): %v\n", user.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not list your calls.")
+		return
+	}
+
+	list := api.CallList{Calls: []api.Call{}}
+	if int64(len(calls)) > limit {
+		calls = calls[:limit]
+		next := calls[limit-1].ID
+		list.NextBeforeID = &next
+	}
+	for _, call := range calls {
+		list.Calls = append(list.Calls, api.NewCall(call))
+	}
+	api.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleAPICall returns one of the current user's calls with its attempts,
+// what the agent learned and the transcript so far.
+func (s *Service) HandleAPICall(w http.ResponseWriter, r *http.Request) {
+	call, ok := s.apiOwnedCall(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, err := s.db.ListCallAttempts(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleAPICall(couldnt list attempts of call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not load the call.")
+		return
+	}
+	facts, err := s.db.ListCallFacts(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleAPICall(couldnt list facts of call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not load the call.")
+		return
+	}
+	logs, err := s.db.ListCallLogs(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleAPICall(couldnt list logs of call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not load the call.")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, api.NewCallDetail(call, attempts, facts, logs))
+}
+
+// HandleAPICallTranscript returns the transcript of one of the current user's
+// calls in the format asked for in the query: text, json or vtt. With an
+// after_id it only returns the lines after that one, timed from the start of
+// the call, so a call can be followed without fetching it all again. The
+// call's status is sent along in the X-Call-Status header, to tell when to
+// stop.
+func (s *Service) HandleAPICallTranscript(w http.ResponseWriter, r *http.Request) {
+	format, err := transcript.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		api.WriteFieldError(w, "format", fmt.Sprintf("Transcripts come as text, json or vtt, not %q.", r.URL.Query().Get("format")))
+		return
+	}
+	var afterID int64
+	if value := r.URL.Query().Get("after_id"); value != "" {
+		afterID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || afterID < 0 {
+			api.WriteFieldError(w, "after_id", fmt.Sprintf("after_id must be a transcript line id, not %q.", value))
+			return
+		}
+	}
+	call, ok := s.apiOwnedCall(w, r)
+	if !ok {
+		return
+	}
+
+	var start time.Time
+	var logs []database.CallLog
+	if afterID == 0 {
+		logs, err = s.db.ListCallLogs(r.Context(), call.ID)
+	} else {
+		logs, start, err = s.callLogsAfter(r.Context(), call.ID, afterID)
+	}
+	if err != nil {
+		fmt.Printf("HandleAPICallTranscript(couldnt list logs of call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not load the transcript.")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("X-Call-Status", call.Status.String)
+	if err := transcript.RenderFrom(w, format, logs, start); err != nil {
+		log.Printf("calls: error writing transcript of call %d: %v", call.ID, err)
+	}
+}
+
+// callLogsAfter lists a call's logs after the one with id afterID, and
+// returns when its first log was logged, which their offsets are timed from.
+func (s *Service) callLogsAfter(ctx context.Context, callID, afterID int64) ([]database.CallLog, time.Time, error) {
+	first, err := s.db.GetFirstCallLog(ctx, callID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	logs, err := s.db.ListCallLogsSince(ctx, database.ListCallLogsSinceParams{
+		CallID:  callID,
+		Since:   first.Timestamp.Time,
+		AfterID: afterID,
+	})
+	return logs, first.Timestamp.Time, err
+}
+
+// HandleAPICancelCall cancels one of the current user's calls that hasn't
+// finished, and returns it.
+func (s *Service) HandleAPICancelCall(w http.ResponseWriter, r *http.Request) {
+	call, ok := s.apiOwnedCall(w, r)
+	if !ok {
+		return
+	}
+
+	err := s.cancelCall(r.Context(), call)
+	if errors.Is(err, errCallFinished) {
+		api.WriteError(w, http.StatusConflict, api.CodeConflict, fmt.Sprintf("Call %d already %s.", call.ID, call.Status.String))
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleAPICancelCall: %v\n", err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not cancel the call.")
+		return
+	}
+
+	canceled, err := s.db.GetCall(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleAPICancelCall(couldnt reload call %d): %v\n", call.ID, err)
+		api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not load the call.")
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, api.NewCall(canceled))
+}
+
+// apiOwnedCall is ownedCall for the API. It writes the error response itself
+// when ok is false.
+func (s *Service) apiOwnedCall(w http.ResponseWriter, r *http.Request) (database.Call, bool) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("apiOwnedCall: %v\n", err)
+		api.WriteError(w, http.StatusNotFound, api.CodeNotFound, fmt.Sprintf("There's no call %s.", r.PathValue("id")))
+		return database.Call{}, false
+	}
+	return call, true
+}
@@ -0,0 +1,330 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"goDial/internal/api"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/transcript"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiRequest sends a request with a JSON body, or none if body is "", to an
+// API handler, with the call id of the path if there is one.
+func (ts *testService) apiRequest(t *testing.T, handler http.HandlerFunc, method, target, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := ts.userRequest(t, method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func decodeResponse[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var v T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &v), w.Body.String())
+	return v
+}
+
+func TestHandleAPICreateCall(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		rejected      bool
+		expectedCode  int
+		expectedError api.Error
+	}{
+		{
+			name:         "places the call",
+			body:         `{"phone_number": "(333) 666-4444", "recipient": "Sam", "objective": "say happy birthday", "max_attempts": 2}`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:          "missing number",
+			body:          `{"recipient": "Sam", "objective": "say happy birthday"}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "phone_number", Message: "Enter the number to call."},
+		},
+		{
+			name:          "retry settings out of range",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say hi", "max_attempts": 9}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "max_attempts", Message: `Attempts must be from 1 to 5, not "9".`},
+		},
+		{
+			name:          "zero attempts",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say hi", "max_attempts": 0}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "max_attempts", Message: `Attempts must be from 1 to 5, not "0".`},
+		},
+		{
+			name:          "zero minutes between retries",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say hi", "max_attempts": 2, "retry_spacing_minutes": 0}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "retry_spacing_minutes", Message: `Retries must be 5 to 1440 minutes apart, not "0".`},
+		},
+		{
+			name:          "retry window without an end",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say hi", "retry_window_start": "09:00"}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "retry_window_end", Message: "The retry window needs an end as well as a start."},
+		},
+		{
+			name:          "misspelled field",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objetive": "say hi"}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Message: `The request body isn't the JSON expected: json: unknown field "objetive".`},
+		},
+		{
+			name:          "someone else's template",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "template_id": 999}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: api.Error{Code: api.CodeInvalidRequest, Field: "template_id", Message: "There's no call template 999."},
+		},
+		{
+			name:          "number on the do-not-call list",
+			body:          `{"phone_number": "333-777-5555", "recipient": "Sam", "objective": "say hi"}`,
+			expectedCode:  http.StatusForbidden,
+			expectedError: api.Error{Code: api.CodeDoNotCall, Message: "This number is on the do-not-call list."},
+		},
+		{
+			name:          "rejected by moderation",
+			body:          `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say something awful"}`,
+			rejected:      true,
+			expectedCode:  http.StatusForbidden,
+			expectedError: api.Error{Code: api.CodeForbidden, Message: "Request violates Terms of Service."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			_, err := ts.db.CreateDNCEntry(ctx, database.CreateDNCEntryParams{PhoneNumber: "+13337775555", Source: dnc.SourceImport})
+			require.NoError(t, err)
+			if tt.rejected {
+				ts.moderate = func(string) (string, error) { return "false", errors.New("rejected") }
+			}
+
+			w := ts.apiRequest(t, ts.HandleAPICreateCall, "POST", "/api/v1/calls", "", tt.body)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			calls, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+			require.NoError(t, err)
+			if tt.expectedCode != http.StatusCreated {
+				assert.Equal(t, tt.expectedError, decodeResponse[api.Error](t, w))
+				assert.Empty(t, calls)
+				return
+			}
+
+			call := decodeResponse[api.Call](t, w)
+			require.Len(t, calls, 1)
+			assert.Equal(t, fmt.Sprintf("/api/v1/calls/%d", calls[0].ID), w.Header().Get("Location"))
+			assert.Equal(t, "+13336664444", call.PhoneNumber)
+			assert.Equal(t, "Sam", call.Recipient)
+			assert.Equal(t, statusPending, call.Status)
+			assert.Equal(t, int64(2), call.MaxAttempts)
+			assert.Equal(t, "Hi, is this Sam?", call.OpeningLine)
+			assert.NotNil(t, call.ApprovedAt, "calls made over the API skip the review")
+
+			ts.runJobs(t)
+			require.Len(t, ts.provider.dials, 1)
+			assert.Equal(t, "+13336664444", ts.provider.dials[0].To)
+		})
+	}
+}
+
+func TestHandleAPICreateCall_FillsInTemplate(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	template, err := ts.db.CreateCallTemplate(context.Background(), database.CreateCallTemplateParams{
+		UserID:    sql.NullInt64{Int64: ts.user.ID, Valid: true},
+		Name:      "Confirm",
+		Objective: "Confirm the appointment for {{name}} on {{date:date}}.",
+	})
+	require.NoError(t, err)
+
+	body := fmt.Sprintf(`{"phone_number": "3336664444", "recipient": "Sam", "template_id": %d, "variables": {"name": "Sam", "date": "tomorrow"}}`, template.ID)
+	w := ts.apiRequest(t, ts.HandleAPICreateCall, "POST", "/api/v1/calls", "", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, api.Error{Code: api.CodeInvalidRequest, Field: "variables", Message: `Date: expected a date like 2026-10-20, got "tomorrow".`}, decodeResponse[api.Error](t, w))
+
+	body = fmt.Sprintf(`{"phone_number": "3336664444", "recipient": "Sam", "template_id": %d, "variables": {"name": "Sam", "date": "2026-10-20"}}`, template.ID)
+	w = ts.apiRequest(t, ts.HandleAPICreateCall, "POST", "/api/v1/calls", "", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "Confirm the appointment for Sam on Tuesday, October 20, 2026.", decodeResponse[api.Call](t, w).Objective)
+}
+
+func TestHandleAPICalls(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	var ids []int64
+	for range 5 {
+		ids = append(ids, ts.createCall(t, 1).ID)
+	}
+	_, err := ts.db.FinishCall(ctx, database.FinishCallParams{Status: sql.NullString{String: statusCompleted, Valid: true}, ID: ids[1]})
+	require.NoError(t, err)
+	other, err := ts.db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	_, err = ts.db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "+13336664444", Objective: "not yours"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedIDs   []int64
+		expectedNext  *int64
+		expectedField string
+	}{
+		{name: "newest first", query: "", expectedCode: http.StatusOK, expectedIDs: []int64{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+		{name: "first page", query: "?limit=2", expectedCode: http.StatusOK, expectedIDs: []int64{ids[4], ids[3]}, expectedNext: &ids[3]},
+		{name: "next page", query: fmt.Sprintf("?limit=2&before_id=%d", ids[3]), expectedCode: http.StatusOK, expectedIDs: []int64{ids[2], ids[1]}, expectedNext: &ids[1]},
+		{name: "last page", query: fmt.Sprintf("?limit=2&before_id=%d", ids[1]), expectedCode: http.StatusOK, expectedIDs: []int64{ids[0]}},
+		{name: "by status", query: "?status=completed", expectedCode: http.StatusOK, expectedIDs: []int64{ids[1]}},
+		{name: "unknown status", query: "?status=done", expectedCode: http.StatusBadRequest, expectedField: "status"},
+		{name: "limit too big", query: "?limit=500", expectedCode: http.StatusBadRequest, expectedField: "limit"},
+		{name: "bad cursor", query: "?before_id=abc", expectedCode: http.StatusBadRequest, expectedField: "before_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := ts.apiRequest(t, ts.HandleAPICalls, "GET", "/api/v1/calls"+tt.query, "", "")
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				assert.Equal(t, tt.expectedField, decodeResponse[api.Error](t, w).Field)
+				return
+			}
+			list := decodeResponse[api.CallList](t, w)
+			got := []int64{}
+			for _, call := range list.Calls {
+				got = append(got, call.ID)
+			}
+			assert.Equal(t, tt.expectedIDs, got)
+			assert.Equal(t, tt.expectedNext, list.NextBeforeID)
+		})
+	}
+}
+
+func TestService_APIFollowsAndCancelsCalls(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = lines("I'm calling to say happy birthday!")
+
+	w := ts.apiRequest(t, ts.HandleAPICreateCall, "POST", "/api/v1/calls", "", `{"phone_number": "3336664444", "recipient": "Sam", "objective": "say happy birthday", "max_attempts": 3}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	created := decodeResponse[api.Call](t, w)
+	id := strconv.FormatInt(created.ID, 10)
+	ts.runJobs(t)
+
+	// the recipient picks up and hears the agent out
+	attempt := ts.attempts(t, created.ID)[0]
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{"answered_by": {answeredHuman}})
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"Who is this?"}})
+	_, err := ts.db.RecordCallFact(ctx, database.RecordCallFactParams{CallID: created.ID, Key: "mood", Value: "surprised"})
+	require.NoError(t, err)
+
+	w = ts.apiRequest(t, ts.HandleAPICall, "GET", "/api/v1/calls/"+id, id, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	detail := decodeResponse[api.CallDetail](t, w)
+	assert.Equal(t, statusInProgress, detail.Status)
+	require.Len(t, detail.Attempts, 1)
+	assert.Equal(t, attemptInProgress, detail.Attempts[0].Status)
+	assert.Equal(t, []api.Fact{{Key: "mood", Value: "surprised"}}, detail.Facts)
+	var said []string
+	for _, entry := range detail.Transcript {
+		said = append(said, entry.Speaker+": "+entry.Text)
+	}
+	assert.Contains(t, said, "recipient: Who is this?")
+	assert.Contains(t, said, "agent: I'm calling to say happy birthday!")
+
+	w = ts.apiRequest(t, ts.HandleAPICallTranscript, "GET", "/api/v1/calls/"+id+"/transcript?format=vtt", id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/vtt; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "WEBVTT"))
+
+	// following the call only fetches the lines after the last one seen
+	last := detail.Transcript[len(detail.Transcript)-2]
+	w = ts.apiRequest(t, ts.HandleAPICallTranscript, "GET", fmt.Sprintf("/api/v1/calls/%s/transcript?format=json&after_id=%d", id, last.ID), id, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, statusInProgress, w.Header().Get("X-Call-Status"))
+	assert.Equal(t, detail.Transcript[len(detail.Transcript)-1:], decodeResponse[[]transcript.Entry](t, w), "offsets are still from the start of the call")
+	w = ts.apiRequest(t, ts.HandleAPICallTranscript, "GET", fmt.Sprintf("/api/v1/calls/%s/transcript?format=json&after_id=%d", id, detail.Transcript[len(detail.Transcript)-1].ID), id, "")
+	assert.Equal(t, "[]\n", w.Body.String())
+	w = ts.apiRequest(t, ts.HandleAPICallTranscript, "GET", "/api/v1/calls/"+id+"/transcript?after_id=last", id, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "after_id", decodeResponse[api.Error](t, w).Field)
+
+	// canceling hangs up, and the call isn't retried once the line drops
+	w = ts.apiRequest(t, ts.HandleAPICancelCall, "POST", "/api/v1/calls/"+id+"/cancel", id, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	canceled := decodeResponse[api.Call](t, w)
+	assert.Equal(t, statusFailed, canceled.Status)
+	assert.Equal(t, canceledReason, canceled.StatusReason)
+	assert.Equal(t, []string{attempt.ProviderCallID.String}, ts.provider.hangups)
+
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptCompleted}, "duration": {"75"}})
+	ts.runJobs(t)
+	assert.Len(t, ts.provider.dials, 1)
+	assert.Len(t, ts.attempts(t, created.ID), 1)
+	user, err := ts.db.GetUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), minutesOf(user), "the connected time is still billed")
+
+	w = ts.apiRequest(t, ts.HandleAPICancelCall, "POST", "/api/v1/calls/"+id+"/cancel", id, "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, api.Error{Code: api.CodeConflict, Message: fmt.Sprintf("Call %s already failed.", id)}, decodeResponse[api.Error](t, w))
+}
+
+func TestHandleAPICancelCall_BeforeDialing(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	call := ts.createCall(t, 1)
+	id := strconv.FormatInt(call.ID, 10)
+
+	w := ts.apiRequest(t, ts.HandleAPICancelCall, "POST", "/api/v1/calls/"+id+"/cancel", id, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	ts.runJobs(t)
+
+	assert.Empty(t, ts.provider.dials, "the queued placement finds the call canceled")
+	assert.Empty(t, ts.provider.hangups)
+}
+
+func TestHandleAPICall_OthersCallsAreNotFound(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	other, err := ts.db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "+13336664444", Objective: "not yours"})
+	require.NoError(t, err)
+	id := strconv.FormatInt(call.ID, 10)
+
+	for _, handler := range []http.HandlerFunc{ts.HandleAPICall, ts.HandleAPICallTranscript, ts.HandleAPICancelCall} {
+		w := ts.apiRequest(t, handler, "GET", "/api/v1/calls/"+id, id, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, api.Error{Code: api.CodeNotFound, Message: "There's no call " + id + "."}, decodeResponse[api.Error](t, w))
+	}
+
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusPending, call.Status.String)
+}
@@ -1 +1,1162 @@
 package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/auth"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/jobs"
+	"goDial/internal/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider stands in for the telephony vendor. Webhooks are plain form
+// posts with the fields sid, status, speech, digits, duration, answered_by,
+// greeting_ended, parent_sid, dial_status, dial_duration, recording_url,
+// recording_sid and recording_duration. Recordings fetched from it are the
+// url's bytes.
+type fakeProvider struct {
+	mu          sync.Mutex
+	dials       []DialRequest
+	dialErr     error
+	hangups     []string
+	redirects   []string
+	redirectErr error
+	fetchErr    error
+	responses   [][]Instruction
+	// whileDialing runs during each dial, before it returns
+	whileDialing func()
+}
+
+func (p *fakeProvider) Dial(ctx context.Context, req DialRequest) (string, error) {
+	if p.whileDialing != nil {
+		p.whileDialing()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dialErr != nil {
+		return "", p.dialErr
+	}
+	p.dials = append(p.dials, req)
+	return fmt.Sprintf("fake-%d", len(p.dials)), nil
+}
+
+func (p *fakeProvider) Hangup(ctx context.Context, providerCallID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hangups = append(p.hangups, providerCallID)
+	return nil
+}
+
+func (p *fakeProvider) Redirect(ctx context.Context, providerCallID, url string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.redirectErr != nil {
+		return p.redirectErr
+	}
+	p.redirects = append(p.redirects, providerCallID+" "+url)
+	return nil
+}
+
+func (p *fakeProvider) FetchRecording(ctx context.Context, recordingURL string) (io.ReadCloser, string, error) {
+	if p.fetchErr != nil {
+		return nil, "", p.fetchErr
+	}
+	return io.NopCloser(strings.NewReader(recordingURL)), "audio/mpeg", nil
+}
+
+func (p *fakeProvider) ParseEvent(r *http.Request) (Event, error) {
+	if err := r.ParseForm(); err != nil {
+		return Event{}, err
+	}
+	if r.PostForm.Get("forged") != "" {
+		return Event{}, errors.New("bad signature")
+	}
+	seconds, _ := strconv.Atoi(r.PostForm.Get("duration"))
+	dialedSeconds, _ := strconv.Atoi(r.PostForm.Get("dial_duration"))
+	recordingSeconds, _ := strconv.Atoi(r.PostForm.Get("recording_duration"))
+	return Event{
+		ProviderCallID: r.PostForm.Get("sid"),
+		Status:         r.PostForm.Get("status"),
+		Duration:       time.Duration(seconds) * time.Second,
+		Speech:         r.PostForm.Get("speech"),
+		Digits:         r.PostForm.Get("digits"),
+		AnsweredBy:     r.PostForm.Get("answered_by"),
+		GreetingEnded:  r.PostForm.Get("greeting_ended") != "",
+		ParentCallID:   r.PostForm.Get("parent_sid"),
+		DialedStatus:   r.PostForm.Get("dial_status"),
+		DialedDuration: time.Duration(dialedSeconds) * time.Second,
+
+		RecordingURL:      r.PostForm.Get("recording_url"),
+		RecordingID:       r.PostForm.Get("recording_sid"),
+		RecordingDuration: time.Duration(recordingSeconds) * time.Second,
+	}, nil
+}
+
+func (p *fakeProvider) Respond(w http.ResponseWriter, instructions []Instruction) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responses = append(p.responses, instructions)
+	return nil
+}
+
+func (p *fakeProvider) lastResponse() []Instruction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.responses) == 0 {
+		return nil
+	}
+	return p.responses[len(p.responses)-1]
+}
+
+// fakeAgent replies with a script, one response per turn.
+type fakeAgent struct {
+	replies []ai.Response
+	prompts []string
+	turns   [][]ai.Turn
+}
+
+func (a *fakeAgent) Reply(ctx context.Context, system string, turns []ai.Turn) (ai.Response, error) {
+	a.prompts = append(a.prompts, system)
+	a.turns = append(a.turns, turns)
+	if len(a.replies) == 0 {
+		return ai.Response{}, errors.New("agent ran out of lines")
+	}
+	reply := a.replies[0]
+	a.replies = a.replies[1:]
+	return reply, nil
+}
+
+// lines scripts an agent that only talks.
+func lines(texts ...string) []ai.Response {
+	replies := make([]ai.Response, len(texts))
+	for i, text := range texts {
+		replies[i] = ai.Response{Text: text}
+	}
+	return replies
+}
+
+// hangingUp is a reply that says text and ends the call with input.
+func hangingUp(t *testing.T, text string, input ai.EndCallInput) ai.Response {
+	t.Helper()
+	return ai.Response{Text: text, Tools: []ai.ToolCall{toolUse(t, "end", ai.ToolEndCall, input)}}
+}
+
+type testService struct {
+	*Service
+	db       *database.DB
+	provider *fakeProvider
+	agent    *fakeAgent
+	user     database.User
+	ran      map[int64]bool
+}
+
+func setupCallsTestService(t *testing.T, minutes int64) *testService {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "calls_test.db")
+
+	db, err := database.InitDB(dbPath)
+	require.NoError(t, err, "Failed to initialize test database")
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	ctx := context.Background()
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: config.DefaultDevUserEmail, Name: "Test User"})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET minutes = ? WHERE id = ?", minutes, user.ID)
+	require.NoError(t, err)
+
+	provider := &fakeProvider{}
+	agent := &fakeAgent{}
+	// in development, as the tests' webhook receivers are on this machine
+	cfg := config.Default()
+	cfg.Environment = config.Development
+	// the queue is never started: tests run its jobs by hand with runJobs
+	svc := NewService(db, jobs.New(db, jobs.Options{}), provider, agent, cfg)
+	// 18:00 UTC is inside calling hours in every US time zone
+	svc.now = func() time.Time { return time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC) }
+	svc.moderate = func(string) (string, error) { return "true", nil }
+	svc.planCall = func(ctx context.Context, objective, recipient, background string) (ai.CallPlan, error) {
+		return ai.CallPlan{OpeningLine: "Hi, is this " + recipient + "?", Plan: "You are calling " + recipient + ". " + objective}, nil
+	}
+	svc.draftVoicemail = func(ctx context.Context, objective, recipient, caller string) (string, error) {
+		return "Hi, this is a message from " + caller + ": " + objective + ".", nil
+	}
+
+	return &testService{Service: svc, db: db, provider: provider, agent: agent, user: user, ran: map[int64]bool{}}
+}
+
+// runJobs runs every queued job the test hasn't run yet, regardless of its
+// run_at, until no new ones appear. Campaign dispatches are the exception:
+// they wait for ts.now to reach their run_at, so tests can check pacing.
+func (ts *testService) runJobs(t *testing.T) {
+	t.Helper()
+	for {
+		queued, err := ts.db.ListJobsByStatus(context.Background(), "queued")
+		require.NoError(t, err)
+
+		ranAny := false
+		for _, job := range queued {
+			if ts.ran[job.ID] {
+				continue
+			}
+			if job.Kind == jobDispatchCampaign && job.RunAt.After(ts.now()) {
+				continue
+			}
+			ts.ran[job.ID] = true
+			ranAny = true
+
+			switch job.Kind {
+			case jobPlaceCall:
+				require.NoError(t, ts.placeCall(context.Background(), job))
+			case jobSettleAttempt:
+				require.NoError(t, ts.settleAttempt(context.Background(), job))
+			case jobFetchRecording:
+				require.NoError(t, ts.fetchRecording(context.Background(), job))
+			case jobPurgeRecordings:
+				require.NoError(t, ts.purgeRecordings(context.Background(), job))
+			case jobDispatchCampaign:
+				require.NoError(t, ts.dispatchCampaign(context.Background(), job))
+			case webhooks.JobDeliver:
+				require.NoError(t, ts.webhooks.Deliver(context.Background(), job))
+			default:
+				t.Fatalf("unexpected job kind %s", job.Kind)
+			}
+		}
+		if !ranAny {
+			return
+		}
+	}
+}
+
+func (ts *testService) createCall(t *testing.T, maxAttempts int64) database.Call {
+	t.Helper()
+	call, err := ts.db.CreateCall(context.Background(), database.CreateCallParams{
+		UserID:              ts.user.ID,
+		PhoneNumber:         "+13336664444",
+		Objective:           "say happy birthday",
+		MaxAttempts:         maxAttempts,
+		RetrySpacingMinutes: 15,
+	})
+	require.NoError(t, err)
+	return ts.approve(t, call)
+}
+
+// approve approves a call as it was created and queues it, like the
+// confirmation page does.
+func (ts *testService) approve(t *testing.T, call database.Call) database.Call {
+	t.Helper()
+	call, err := ts.db.ApproveCall(context.Background(), database.ApproveCallParams{
+		OpeningLine: call.OpeningLine,
+		CallPlan:    call.CallPlan,
+		ID:          call.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, ts.enqueuePlacement(context.Background(), call.ID, ts.now()))
+	return call
+}
+
+// webhook posts form values to one of an attempt's webhook handlers.
+func (ts *testService) webhook(t *testing.T, handler http.HandlerFunc, attemptID int64, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/webhooks/telephony/attempts/x", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("id", strconv.FormatInt(attemptID, 10))
+
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+// userRequest is a request from the test user, as Sessions.RequireUser passes
+// it on to the handlers.
+func (ts *testService) userRequest(t *testing.T, method, target string, body io.Reader) *http.Request {
+	t.Helper()
+	user, err := ts.db.GetUser(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, target, body)
+	return req.WithContext(auth.WithUser(req.Context(), user))
+}
+
+func (ts *testService) attempts(t *testing.T, callID int64) []database.CallAttempt {
+	t.Helper()
+	attempts, err := ts.db.ListCallAttempts(context.Background(), callID)
+	require.NoError(t, err)
+	return attempts
+}
+
+func TestService_NoAnswerRetriesThenBillsConnectedTime(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = append(
+		lines("Hi, this is an assistant calling to wish you a happy birthday!"),
+		hangingUp(t, "You're welcome, have a great day!", ai.EndCallInput{Reason: "wished them a happy birthday"}),
+	)
+
+	call := ts.createCall(t, 3)
+	ts.runJobs(t)
+
+	attempts := ts.attempts(t, call.ID)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, attemptDialing, attempts[0].Status)
+	require.Len(t, ts.provider.dials, 1)
+	assert.Equal(t, "+13336664444", ts.provider.dials[0].To)
+	assert.Equal(t, attemptURL(attempts[0].ID, "answer"), ts.provider.dials[0].AnswerURL)
+
+	// first attempt rings out
+	w := ts.webhook(t, ts.HandleStatusWebhook, attempts[0].ID, url.Values{"sid": {"fake-1"}, "status": {attemptRinging}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = ts.webhook(t, ts.HandleStatusWebhook, attempts[0].ID, url.Values{"sid": {"fake-1"}, "status": {attemptNoAnswer}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ts.runJobs(t)
+
+	attempts = ts.attempts(t, call.ID)
+	require.Len(t, attempts, 2, "no-answer should be retried")
+	assert.Equal(t, attemptNoAnswer, attempts[0].Status)
+	assert.Equal(t, int64(0), attempts[0].BilledMinutes)
+	assert.True(t, ts.now().Add(15*time.Minute).Equal(attempts[1].ScheduledFor), "retry should wait the call's spacing, got %s", attempts[1].ScheduledFor)
+
+	// second attempt is answered and has a short conversation
+	second := attempts[1]
+	ts.webhook(t, ts.HandleStatusWebhook, second.ID, url.Values{"sid": {"fake-2"}, "status": {attemptInProgress}})
+	w = ts.webhook(t, ts.HandleAnswerWebhook, second.ID, url.Values{"sid": {"fake-2"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []Instruction{
+		Say{Text: "Hello, this is an automated AI assistant calling on behalf of Test User.", Language: "en-US"},
+		Say{Text: "Hi, this is an assistant calling to wish you a happy birthday!"},
+		Listen{ActionURL: attemptURL(second.ID, "turn")},
+	}, ts.provider.lastResponse())
+
+	ts.webhook(t, ts.HandleTurnWebhook, second.ID, url.Values{"sid": {"fake-2"}, "speech": {"Oh thank you!"}})
+	assert.Equal(t, []Instruction{
+		Say{Text: "You're welcome, have a great day!"},
+		Hangup{},
+	}, ts.provider.lastResponse())
+	require.Len(t, ts.agent.turns, 2)
+	assert.Equal(t, ai.Turn{Speaker: ai.SpeakerRecipient, Text: "Oh thank you!"}, ts.agent.turns[1][1])
+	assert.Contains(t, ts.agent.prompts[0], "say happy birthday")
+
+	// 61 connected seconds bill as 2 minutes
+	ts.webhook(t, ts.HandleStatusWebhook, second.ID, url.Values{"sid": {"fake-2"}, "status": {attemptCompleted}, "duration": {"61"}})
+	ts.runJobs(t)
+
+	call, err := ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusCompleted, call.Status.String)
+	assert.True(t, call.CompletedAt.Valid)
+
+	attempts = ts.attempts(t, call.ID)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, int64(2), attempts[1].BilledMinutes)
+
+	user, err := ts.db.GetUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), minutesOf(user))
+
+	ledger, err := ts.db.ListLedgerEntriesByUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	require.Len(t, ledger, 1)
+	assert.Equal(t, int64(-2), ledger[0].Minutes)
+
+	logs, err := ts.db.ListCallLogs(ctx, call.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, entry := range logs {
+		types = append(types, entry.MessageType)
+	}
+	assert.Equal(t, []string{logSystem, logSystem, logAgent, logRecipient, logAgent, logSystem}, types, "the goodbye is followed by the end_call tool")
+}
+
+func TestService_SettlingTwiceBillsOnce(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	call := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptCompleted}, "duration": {"30"}})
+	// a duplicate delivery of the final status is ignored
+	w := ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptCompleted}, "duration": {"30"}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ts.runJobs(t)
+
+	// and so is a settlement job that runs a second time
+	job, err := ts.queue.Enqueue(ctx, jobSettleAttempt, attemptJob{AttemptID: attempt.ID})
+	require.NoError(t, err)
+	require.NoError(t, ts.settleAttempt(ctx, job))
+
+	user, err := ts.db.GetUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), minutesOf(user))
+
+	queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	placements := 0
+	for _, job := range queued {
+		if job.Kind == jobPlaceCall {
+			placements++
+		}
+	}
+	assert.Equal(t, 1, placements, "a completed call must not be placed again")
+}
+
+func TestService_FailureOutcomes(t *testing.T) {
+	tests := []struct {
+		name           string
+		minutes        int64
+		maxAttempts    int64
+		dialErr        error
+		outcome        string
+		expectedDials  int
+		expectedReason string
+	}{
+		{
+			name:           "busy on the only attempt",
+			minutes:        10,
+			maxAttempts:    1,
+			outcome:        attemptBusy,
+			expectedDials:  1,
+			expectedReason: "line was busy after 1 attempt(s)",
+		},
+		{
+			name:           "no answer on every attempt",
+			minutes:        10,
+			maxAttempts:    2,
+			outcome:        attemptNoAnswer,
+			expectedDials:  2,
+			expectedReason: "no answer after 2 attempt(s)",
+		},
+		{
+			name:           "dial errors follow the retry policy",
+			minutes:        10,
+			maxAttempts:    2,
+			dialErr:        errors.New("provider down"),
+			expectedDials:  0,
+			expectedReason: "call failed after 2 attempt(s)",
+		},
+		{
+			name:           "no minutes left",
+			minutes:        0,
+			maxAttempts:    3,
+			expectedDials:  0,
+			expectedReason: "no minutes remaining",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, tt.minutes)
+			ts.provider.dialErr = tt.dialErr
+
+			call := ts.createCall(t, tt.maxAttempts)
+			for i := int64(0); i < tt.maxAttempts; i++ {
+				ts.runJobs(t)
+				attempts := ts.attempts(t, call.ID)
+				if tt.outcome != "" && len(attempts) > 0 {
+					last := attempts[len(attempts)-1]
+					if !terminal(last.Status) {
+						ts.webhook(t, ts.HandleStatusWebhook, last.ID, url.Values{"status": {tt.outcome}})
+					}
+				}
+			}
+			ts.runJobs(t)
+
+			call, err := ts.db.GetCall(context.Background(), call.ID)
+			require.NoError(t, err)
+			assert.Equal(t, statusFailed, call.Status.String)
+			assert.Equal(t, tt.expectedReason, call.StatusReason.String)
+			assert.Len(t, ts.provider.dials, tt.expectedDials)
+		})
+	}
+}
+
+func TestService_SilenceEventuallyHangsUp(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ts.agent.replies = lines("Hello?", "Hello, are you there?", "I'll try again later.")
+
+	call := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+	for i := 0; i < maxSilences; i++ {
+		ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{})
+		assert.IsType(t, Listen{}, ts.provider.lastResponse()[1])
+	}
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{})
+
+	assert.Equal(t, []Instruction{Hangup{}}, ts.provider.lastResponse())
+}
+
+func TestService_WebhookRejectsForgedRequests(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+
+	call := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+
+	w := ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"forged": {"1"}, "status": {attemptCompleted}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"someone-else"}, "status": {attemptCompleted}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Equal(t, attemptDialing, ts.attempts(t, call.ID)[0].Status)
+}
+
+func TestHandleCallProcedure_DraftsPlanForApproval(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	form := url.Values{
+		"recipientPhoneNumber": {"+1 (333) 666-4444"},
+		"recipientContext":     {"Sam, an old friend"},
+		"objective":            {"say happy birthday"},
+		"maxAttempts":          {"3"},
+		"retrySpacingMinutes":  {"30"},
+	}
+	req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	ts.HandleCallProcedure(w, req)
+
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	calls, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.Equal(t, fmt.Sprintf("/calls/%d/confirm", calls[0].ID), w.Header().Get("Location"))
+	assert.Equal(t, "+13336664444", calls[0].PhoneNumber, "numbers are stored in E.164")
+	assert.Equal(t, int64(3), calls[0].MaxAttempts)
+	assert.Equal(t, int64(30), calls[0].RetrySpacingMinutes)
+	assert.Equal(t, "Hi, is this Sam, an old friend?", calls[0].OpeningLine.String)
+	assert.Equal(t, "You are calling Sam, an old friend. say happy birthday", calls[0].CallPlan.String)
+	assert.False(t, calls[0].ApprovedAt.Valid)
+
+	queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	assert.Empty(t, queued, "nothing is dialed before the plan is approved")
+}
+
+func TestHandleCallProcedure_PlanFailureFallsBackToUserWritten(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.planCall = func(context.Context, string, string, string) (ai.CallPlan, error) {
+		return ai.CallPlan{}, errors.New("model unavailable")
+	}
+
+	form := url.Values{
+		"recipientPhoneNumber": {"3336664444"},
+		"recipientContext":     {"Sam"},
+		"objective":            {"say happy birthday"},
+	}
+	req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	ts.HandleCallProcedure(w, req)
+
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	calls, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	require.Len(t, calls, 1)
+	assert.False(t, calls[0].OpeningLine.Valid)
+	assert.Contains(t, calls[0].CallPlan.String, "say happy birthday")
+}
+
+func TestHandleCallProcedure_LinksCallToContact(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	contact, err := ts.db.CreateContact(ctx, database.CreateContactParams{UserID: ts.user.ID, Name: "Sam", PhoneNumber: "+13336664444"})
+	require.NoError(t, err)
+
+	for _, number := range []string{"(333) 666-4444", "(333) 666-5555"} {
+		form := url.Values{
+			"recipientPhoneNumber": {number},
+			"recipientContext":     {"Sam"},
+			"objective":            {"say happy birthday"},
+		}
+		req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		ts.HandleCallProcedure(w, req)
+		require.Equal(t, http.StatusSeeOther, w.Code)
+	}
+
+	linked, err := ts.db.ListCallsByContact(ctx, contact.ID)
+	require.NoError(t, err)
+	require.Len(t, linked, 1, "only the call to the contact's number is linked")
+	assert.Equal(t, "+13336664444", linked[0].PhoneNumber)
+}
+
+func TestHandleCallProcedure_FillsInTemplate(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	template, err := ts.db.CreateCallTemplate(ctx, database.CreateCallTemplateParams{
+		UserID:       sql.NullInt64{Int64: ts.user.ID, Valid: true},
+		Name:         "Confirm",
+		Objective:    "Confirm the appointment for {{name}} on {{date:date}}.",
+		OtherContext: "The office opens at 9.",
+	})
+	require.NoError(t, err)
+	other, err := ts.db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	othersTemplate, err := ts.db.CreateCallTemplate(ctx, database.CreateCallTemplateParams{UserID: sql.NullInt64{Int64: other.ID, Valid: true}, Name: "Theirs", Objective: "Call {{name}}"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                 string
+		form                 url.Values
+		expectedCode         int
+		expectedObjective    string
+		expectedOtherContext string
+	}{
+		{
+			name: "template with its variables",
+			form: url.Values{
+				"templateId":   {fmt.Sprint(template.ID)},
+				"var_name":     {"Sam"},
+				"var_date":     {"2026-10-20"},
+				"otherContext": {"Ask for Dr. Lee."},
+			},
+			expectedCode:         http.StatusSeeOther,
+			expectedObjective:    "Confirm the appointment for Sam on Tuesday, October 20, 2026.",
+			expectedOtherContext: "The office opens at 9.\nAsk for Dr. Lee.",
+		},
+		{
+			name:         "missing variable",
+			form:         url.Values{"templateId": {fmt.Sprint(template.ID)}, "var_name": {"Sam"}},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "someone else's template",
+			form:         url.Values{"templateId": {fmt.Sprint(othersTemplate.ID)}, "var_name": {"Sam"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+			require.NoError(t, err)
+
+			tt.form.Set("recipientPhoneNumber", "(333) 666-4444")
+			tt.form.Set("recipientContext", "Sam")
+			req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			ts.HandleCallProcedure(w, req)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			calls, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+			require.NoError(t, err)
+			if tt.expectedCode != http.StatusSeeOther {
+				assert.Len(t, calls, len(before), "no call is saved")
+				return
+			}
+			require.Len(t, calls, len(before)+1)
+			call := calls[0]
+			assert.Equal(t, tt.expectedObjective, call.Objective)
+			assert.Equal(t, tt.expectedOtherContext, call.BackgroundContext.String)
+			assert.Equal(t, sql.NullInt64{Int64: template.ID, Valid: true}, call.TemplateID)
+			assert.JSONEq(t, `{"name":"Sam","date":"2026-10-20"}`, call.TemplateVariables.String)
+		})
+	}
+}
+
+func TestHandleApproveCall(t *testing.T) {
+	tests := []struct {
+		name             string
+		openingLine      string
+		plan             string
+		rejectEdits      bool
+		approveFirst     bool
+		expectedCode     int
+		expectModeration bool
+		expectQueued     int
+		expectedPlan     string
+	}{
+		{
+			name:         "approved as drafted",
+			openingLine:  "Hi, is this Sam?",
+			plan:         "You are calling Sam. say happy birthday",
+			expectedCode: http.StatusSeeOther,
+			expectQueued: 1,
+			expectedPlan: "You are calling Sam. say happy birthday",
+		},
+		{
+			name:             "edited plan is moderated",
+			openingLine:      "Hey Sam!",
+			plan:             "You are calling Sam. Sing happy birthday.",
+			expectedCode:     http.StatusSeeOther,
+			expectModeration: true,
+			expectQueued:     1,
+			expectedPlan:     "You are calling Sam. Sing happy birthday.",
+		},
+		{
+			name:             "edit rejected by moderation",
+			openingLine:      "Hey Sam!",
+			plan:             "Something against the rules.",
+			rejectEdits:      true,
+			expectedCode:     http.StatusForbidden,
+			expectModeration: true,
+		},
+		{
+			name:         "empty plan",
+			openingLine:  "Hey Sam!",
+			plan:         "   ",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "already approved",
+			openingLine:  "Hey Sam!",
+			plan:         "Something else.",
+			approveFirst: true,
+			expectedCode: http.StatusSeeOther,
+			expectQueued: 1,
+			expectedPlan: "You are calling Sam. say happy birthday",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			moderated := false
+			ts.moderate = func(string) (string, error) {
+				moderated = true
+				if tt.rejectEdits {
+					return "no", errors.New("rejected")
+				}
+				return "true", nil
+			}
+
+			call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+				UserID:      ts.user.ID,
+				PhoneNumber: "+13336664444",
+				Objective:   "say happy birthday",
+				MaxAttempts: 1,
+				OpeningLine: sql.NullString{String: "Hi, is this Sam?", Valid: true},
+				CallPlan:    sql.NullString{String: "You are calling Sam. say happy birthday", Valid: true},
+			})
+			require.NoError(t, err)
+			if tt.approveFirst {
+				ts.approve(t, call)
+			}
+
+			form := url.Values{"openingLine": {tt.openingLine}, "callPlan": {tt.plan}}
+			req := ts.userRequest(t, "POST", "/calls/x/approve", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetPathValue("id", strconv.FormatInt(call.ID, 10))
+			w := httptest.NewRecorder()
+
+			ts.HandleApproveCall(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectModeration, moderated)
+
+			queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+			require.NoError(t, err)
+			assert.Len(t, queued, tt.expectQueued)
+
+			call, err = ts.db.GetCall(ctx, call.ID)
+			require.NoError(t, err)
+			if tt.expectedPlan == "" {
+				assert.False(t, call.ApprovedAt.Valid)
+				return
+			}
+			assert.Equal(t, fmt.Sprintf("/calls/%d", call.ID), w.Header().Get("Location"))
+			assert.True(t, call.ApprovedAt.Valid)
+			assert.Equal(t, tt.expectedPlan, call.CallPlan.String)
+		})
+	}
+}
+
+func TestService_ApprovedPlanDrivesTheCall(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = []ai.Response{hangingUp(t, "Happy birthday, Sam!", ai.EndCallInput{Reason: "wished Sam a happy birthday"})}
+
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:      ts.user.ID,
+		PhoneNumber: "+13336664444",
+		Objective:   "say happy birthday",
+		MaxAttempts: 1,
+		OpeningLine: sql.NullString{String: "Hi, is this Sam?", Valid: true},
+		CallPlan:    sql.NullString{String: "You are calling Sam to wish them a happy 30th birthday.", Valid: true},
+	})
+	require.NoError(t, err)
+
+	// unapproved calls aren't dialed, even if a job turns up for them
+	require.NoError(t, ts.enqueuePlacement(ctx, call.ID, ts.now()))
+	ts.runJobs(t)
+	assert.Empty(t, ts.provider.dials)
+
+	ts.approve(t, call)
+	ts.runJobs(t)
+	require.Len(t, ts.provider.dials, 1)
+	attempt := ts.attempts(t, call.ID)[0]
+
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+	response := ts.provider.lastResponse()
+	require.Len(t, response, 3)
+	assert.Equal(t, Say{Text: "Hi, is this Sam?"}, response[1], "the approved opening line is said as written")
+	assert.Equal(t, Listen{ActionURL: attemptURL(attempt.ID, "turn")}, response[2])
+	assert.Empty(t, ts.agent.prompts, "the agent isn't asked for the opening line")
+
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"Yes, who's this?"}})
+	require.Len(t, ts.agent.prompts, 1)
+	assert.Contains(t, ts.agent.prompts[0], "You are calling Sam to wish them a happy 30th birthday.")
+	assert.NotContains(t, ts.agent.prompts[0], "What you need to accomplish", "the plan replaces the raw request")
+	assert.Equal(t, []ai.Turn{
+		{Speaker: ai.SpeakerAgent, Text: "Hi, is this Sam?"},
+		{Speaker: ai.SpeakerRecipient, Text: "Yes, who's this?"},
+	}, ts.agent.turns[0])
+}
+
+func TestHandleCallStatus(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	call := ts.createCall(t, 2)
+	ts.runJobs(t)
+
+	other, err := ts.db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "Other"})
+	require.NoError(t, err)
+	othersCall, err := ts.db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "5556667777", Objective: "private"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		id           string
+		expectedCode int
+	}{
+		{name: "own call", id: strconv.FormatInt(call.ID, 10), expectedCode: http.StatusOK},
+		{name: "someone else's call", id: strconv.FormatInt(othersCall.ID, 10), expectedCode: http.StatusNotFound},
+		{name: "missing call", id: "999", expectedCode: http.StatusNotFound},
+		{name: "bad id", id: "abc", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := ts.userRequest(t, "GET", "/calls/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+
+			ts.HandleCallStatus(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Contains(t, w.Body.String(), "+13336664444")
+				assert.Contains(t, w.Body.String(), "Attempt History")
+				assert.Contains(t, w.Body.String(), "Dialing")
+			}
+		})
+	}
+}
+
+func TestService_OptOut(t *testing.T) {
+	tests := []struct {
+		name           string
+		replies        []ai.Response
+		turn           url.Values
+		expectedSource string
+		expectedLast   Instruction
+	}{
+		{
+			name:           "spoken request detected by the agent",
+			replies:        append(lines("Hi, I'm calling about your car's warranty."), hangingUp(t, "I'm sorry to bother you, we won't call again.", ai.EndCallInput{Reason: "asked not to be called", OptedOut: true})),
+			turn:           url.Values{"speech": {"Stop calling me."}},
+			expectedSource: dnc.SourceSpokenOptOut,
+			expectedLast:   Say{Text: "I'm sorry to bother you, we won't call again."},
+		},
+		{
+			name:           "opt-out key pressed",
+			replies:        lines("Hi, I'm calling about your car's warranty."),
+			turn:           url.Values{"digits": {optOutDigit}},
+			expectedSource: dnc.SourceKeypressOptOut,
+			expectedLast:   Say{Text: optOutLine},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.agent.replies = tt.replies
+
+			call := ts.createCall(t, 1)
+			ts.runJobs(t)
+			attempt := ts.attempts(t, call.ID)[0]
+
+			ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+			ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, tt.turn)
+
+			assert.Equal(t, []Instruction{tt.expectedLast, Hangup{}}, ts.provider.lastResponse())
+
+			entry, err := ts.db.FindDNCEntry(ctx, database.FindDNCEntryParams{PhoneNumber: call.PhoneNumber})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSource, entry.Source)
+			assert.False(t, entry.UserID.Valid, "opt-outs apply to every user")
+			assert.Equal(t, call.ID, entry.CallID.Int64)
+		})
+	}
+}
+
+func TestService_DoNotCallIsCheckedBeforeSpending(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	moderated := false
+	ts.moderate = func(string) (string, error) {
+		moderated = true
+		return "true", nil
+	}
+
+	_, err := ts.db.CreateDNCEntry(ctx, database.CreateDNCEntryParams{PhoneNumber: "+13336664444", Source: dnc.SourceImport})
+	require.NoError(t, err)
+
+	form := url.Values{
+		"recipientPhoneNumber": {"333-666-4444"},
+		"recipientContext":     {"Sam"},
+		"objective":            {"say hi"},
+	}
+	req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	ts.HandleCallProcedure(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "do_not_call")
+	assert.False(t, moderated, "blocked numbers must not reach moderation")
+
+	// a call queued before the opt-out is stopped before dialing
+	call := ts.createCall(t, 2)
+	ts.runJobs(t)
+
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusFailed, call.Status.String)
+	assert.Equal(t, "number is on the do-not-call list", call.StatusReason.String)
+	assert.Empty(t, ts.provider.dials)
+}
+
+func TestService_DefersCallsOutsideCallingHours(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	// 11:30pm in New York
+	ts.now = func() time.Time { return time.Date(2026, time.October, 19, 3, 30, 0, 0, time.UTC) }
+
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:      ts.user.ID,
+		PhoneNumber: "+12125550123",
+		Objective:   "say happy birthday",
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+	ts.approve(t, call)
+
+	queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	ts.ran[queued[0].ID] = true
+	require.NoError(t, ts.placeCall(ctx, queued[0]))
+
+	assert.Empty(t, ts.provider.dials)
+	assert.Empty(t, ts.attempts(t, call.ID), "a deferral isn't an attempt")
+
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusPending, call.Status.String)
+	assert.Equal(t, "outside calling hours, scheduled for Mon Oct 19 8:00 AM EDT", call.StatusReason.String)
+
+	queued, err = ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	deferred := queued[1]
+	assert.Equal(t, jobPlaceCall, deferred.Kind)
+	assert.True(t, deferred.RunAt.Equal(time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)), "deferred to %s", deferred.RunAt)
+
+	// once the hours open the call goes out
+	ts.now = func() time.Time { return deferred.RunAt }
+	ts.runJobs(t)
+	require.Len(t, ts.provider.dials, 1)
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusInProgress, call.Status.String)
+	assert.False(t, call.StatusReason.Valid)
+}
+
+func TestService_CallingHoursUseTheContactsTimeZone(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	// 1pm in New York, 7am in Honolulu
+	ts.now = func() time.Time { return time.Date(2026, time.October, 18, 17, 0, 0, 0, time.UTC) }
+	contact, err := ts.db.CreateContact(ctx, database.CreateContactParams{
+		UserID:      ts.user.ID,
+		Name:        "Sam",
+		PhoneNumber: "+12125550123",
+		Timezone:    sql.NullString{String: "Pacific/Honolulu", Valid: true},
+	})
+	require.NoError(t, err)
+
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:      ts.user.ID,
+		PhoneNumber: contact.PhoneNumber,
+		Objective:   "say happy birthday",
+		MaxAttempts: 1,
+		ContactID:   sql.NullInt64{Int64: contact.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	ts.approve(t, call)
+
+	queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	require.NoError(t, ts.placeCall(ctx, queued[0]))
+
+	assert.Empty(t, ts.provider.dials, "the New York area code is in hours, but Sam isn't")
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "outside calling hours, scheduled for Sun Oct 18 8:00 AM HST", call.StatusReason.String)
+}
+
+func TestService_AnswerPlaysDisclosureFirst(t *testing.T) {
+	tests := []struct {
+		name             string
+		number           string
+		record           bool
+		expectedLanguage string
+		expectedText     string
+	}{
+		{
+			name:             "unrecorded call",
+			number:           "+12125550123",
+			expectedLanguage: "en-US",
+			expectedText:     "Hello, this is an automated AI assistant calling on behalf of Test User.",
+		},
+		{
+			name:             "recorded call to a one-party state",
+			number:           "+12125550123",
+			record:           true,
+			expectedLanguage: "en-US",
+			expectedText:     "Hello, this is an automated AI assistant calling on behalf of Test User.",
+		},
+		{
+			name:             "recorded call to an all-party state",
+			number:           "+14155550123",
+			record:           true,
+			expectedLanguage: "en-US",
+			expectedText:     "Hello, this is an automated AI assistant calling on behalf of Test User. This call is being recorded. By staying on the line, you consent to the recording.",
+		},
+		{
+			name:             "recorded call to mexico",
+			number:           "+525512345678",
+			record:           true,
+			expectedLanguage: "es-MX",
+			expectedText:     "Hola, le habla un asistente automatizado de inteligencia artificial en nombre de Test User. Esta llamada está siendo grabada. Si permanece en la línea, acepta la grabación.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.agent.replies = lines("Hi, I'm calling to wish you a happy birthday!")
+
+			call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+				UserID:      ts.user.ID,
+				PhoneNumber: tt.number,
+				Objective:   "say happy birthday",
+				MaxAttempts: 1,
+				Record:      tt.record,
+			})
+			require.NoError(t, err)
+			// every number here is inside calling hours at 18:00 UTC
+			ts.approve(t, call)
+			ts.runJobs(t)
+			require.Len(t, ts.provider.dials, 1)
+			assert.Equal(t, tt.record, ts.provider.dials[0].Record)
+
+			attempt := ts.attempts(t, call.ID)[0]
+			ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+
+			response := ts.provider.lastResponse()
+			require.Len(t, response, 3)
+			assert.Equal(t, Say{Text: tt.expectedText, Language: tt.expectedLanguage}, response[0])
+			assert.Equal(t, Say{Text: "Hi, I'm calling to wish you a happy birthday!"}, response[1])
+
+			logs, err := ts.db.ListCallLogs(ctx, call.ID)
+			require.NoError(t, err)
+			require.Len(t, logs, 3)
+			assert.Equal(t, logSystem, logs[1].MessageType)
+			assert.Equal(t, fmt.Sprintf(disclosureLogFormat, tt.expectedLanguage, tt.expectedText), logs[1].Content)
+			assert.Equal(t, logAgent, logs[2].MessageType)
+
+			// the agent never sees the disclosure as something it said
+			require.Len(t, ts.agent.turns, 1)
+			assert.Empty(t, ts.agent.turns[0])
+		})
+	}
+}
+
+func TestHandleConfirmCall(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	draft, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:      ts.user.ID,
+		PhoneNumber: "+13336664444",
+		Objective:   "say happy birthday",
+		MaxAttempts: 1,
+		OpeningLine: sql.NullString{String: "Hi, is this Sam?", Valid: true},
+		CallPlan:    sql.NullString{String: "You are calling Sam.", Valid: true},
+	})
+	require.NoError(t, err)
+	approved := ts.createCall(t, 1)
+
+	tests := []struct {
+		name         string
+		call         database.Call
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "draft shows its plan", call: draft, expectedCode: http.StatusOK, expectedBody: "You are calling Sam."},
+		{name: "approved call goes to its status page", call: approved, expectedCode: http.StatusSeeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := strconv.FormatInt(tt.call.ID, 10)
+			req := ts.userRequest(t, "GET", "/calls/"+id+"/confirm", nil)
+			req.SetPathValue("id", id)
+			w := httptest.NewRecorder()
+
+			ts.HandleConfirmCall(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
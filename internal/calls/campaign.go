@@ -0,0 +1,598 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/calltemplates"
+	"goDial/internal/campaigns"
+	"goDial/internal/contacts"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/jobs"
+	"goDial/internal/phone"
+	"goDial/internal/templates/pages"
+)
+
+// limits on how fast a campaign places its calls
+const (
+	defaultCampaignConcurrency = 3
+	maxCampaignConcurrency     = 10
+	defaultCampaignPace        = 6 // calls per minute
+	maxCampaignPace            = 30
+)
+
+// campaignSpotCheckEvery is how many rows of a campaign each spot check by
+// moderation covers. The template itself is always checked.
+const campaignSpotCheckEvery = 20
+
+type campaignJob struct {
+	CampaignID int64 `json:"campaign_id"`
+}
+
+// HandleCampaigns lists the current user's campaigns above the form starting
+// a new one.
+func (s *Service) HandleCampaigns(w http.ResponseWriter, r *http.Request) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleCampaigns(no current user): %v\n", err)
+		http.Error(w, "sign in to see campaigns", http.StatusUnauthorized)
+		return
+	}
+	s.renderCampaigns(w, r, user.ID, http.StatusOK, "")
+}
+
+// HandleCreateCampaign takes an uploaded CSV and the template to call each of
+// its rows with. Every row is checked and filled in up front, the template
+// and a sample of rows are run past moderation, and the campaign is saved as
+// a draft for the user to review and start.
+func (s *Service) HandleCreateCampaign(w http.ResponseWriter, r *http.Request) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleCreateCampaign(no current user): %v\n", err)
+		http.Error(w, "sign in to start campaigns", http.StatusUnauthorized)
+		return
+	}
+
+	// the rest of the form is small next to the CSV
+	r.Body = http.MaxBytesReader(w, r.Body, campaigns.MaxUploadBytes+64<<10)
+	if err := r.ParseMultipartForm(campaigns.MaxUploadBytes); err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Upload a CSV of at most 1 MB.")
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" || len(name) > 100 {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Give the campaign a name under 100 characters.")
+		return
+	}
+	concurrency, err := formLimit(r, "maxConcurrent", defaultCampaignConcurrency, maxCampaignConcurrency)
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, fmt.Sprintf("Calls at once must be 1 to %d.", maxCampaignConcurrency))
+		return
+	}
+	pace, err := formLimit(r, "callsPerMinute", defaultCampaignPace, maxCampaignPace)
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, fmt.Sprintf("Calls per minute must be 1 to %d.", maxCampaignPace))
+		return
+	}
+	attempts, err := formLimit(r, "maxAttempts", defaultAttemptsCount, maxAttemptsLimit)
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, fmt.Sprintf("Attempts must be 1 to %d.", maxAttemptsLimit))
+		return
+	}
+
+	templateID, err := strconv.ParseInt(r.FormValue("templateId"), 10, 64)
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Pick the template to call everyone with.")
+		return
+	}
+	t, err := calltemplates.Get(r.Context(), s.db, user.ID, templateID)
+	if errors.Is(err, calltemplates.ErrNotFound) {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Pick the template to call everyone with.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleCreateCampaign(couldnt load template): %v\n", err)
+		http.Error(w, "could not load template", http.StatusInternalServerError)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Choose the CSV of people to call.")
+		return
+	}
+	defer file.Close()
+	sheet, err := campaigns.Parse(file)
+	if err != nil {
+		s.renderCampaigns(w, r, user.ID, http.StatusBadRequest, "Check the CSV: "+err.Error()+".")
+		return
+	}
+
+	// the template is checked once, then a sample of what it became
+	if response, err := s.moderate(fmt.Sprintf("user wants to contact: each person on an uploaded list, user wants to accomplish: %s, user provided outside context: %s.", t.Objective, t.OtherContext)); err != nil {
+		fmt.Printf("campaign template rejected by moderation: %s\n", response)
+		rejectedByModeration(w)
+		return
+	}
+
+	rows, err := s.campaignRows(r.Context(), user.ID, t, sheet)
+	if err != nil {
+		fmt.Printf("HandleCreateCampaign(couldnt check rows): %v\n", err)
+		http.Error(w, "could not check rows", http.StatusInternalServerError)
+		return
+	}
+	for _, row := range spotChecks(rows) {
+		if response, err := s.moderate(fmt.Sprintf("user wants to contact:%s, user wants to accomplish: %s, user provided outside context: %s.", row.RecipientContext, row.Objective, row.OtherContext)); err != nil {
+			fmt.Printf("campaign row %d rejected by moderation: %s\n", row.RowNumber, response)
+			rejectedByModeration(w)
+			return
+		}
+	}
+
+	campaign, err := s.saveCampaign(r.Context(), database.CreateCampaignParams{
+		UserID:         user.ID,
+		Name:           name,
+		TemplateID:     sql.NullInt64{Int64: t.ID, Valid: true},
+		Columns:        campaigns.EncodeFields(sheet.Columns),
+		MaxConcurrent:  concurrency,
+		CallsPerMinute: pace,
+		MaxAttempts:    attempts,
+	}, rows)
+	if err != nil {
+		fmt.Printf("HandleCreateCampaign(couldnt save campaign): %v\n", err)
+		http.Error(w, "could not save campaign", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/campaigns/%d", campaign.ID), http.StatusSeeOther)
+}
+
+// formLimit reads an optional whole number from 1 to max from the form.
+func formLimit(r *http.Request, key string, fallback, max int64) (int64, error) {
+	value := strings.TrimSpace(r.FormValue(key))
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("error with %s from user, must be 1 to %d: %q", key, max, value)
+	}
+	return n, nil
+}
+
+func rejectedByModeration(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+
+	resp := map[string]interface{}{
+		"error":   "forbidden",
+		"message": "Request violates Terms of Service",
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// campaignRows checks each row of sheet and fills in t with it. Rows that
+// can't be called are kept, marked invalid with the problem found.
+func (s *Service) campaignRows(ctx context.Context, userID int64, t database.CallTemplate, sheet campaigns.Sheet) ([]database.CreateCampaignRowParams, error) {
+	rows := make([]database.CreateCampaignRowParams, 0, len(sheet.Rows))
+	seen := map[string]int{}
+	for _, row := range sheet.Rows {
+		params := database.CreateCampaignRowParams{
+			RowNumber:   int64(row.Number),
+			Fields:      campaigns.EncodeFields(row.Fields),
+			PhoneNumber: sheet.Phone(row),
+			Status:      campaigns.RowQueued,
+		}
+		problem := row.Problem
+		if problem == "" {
+			var err error
+			problem, err = s.fillCampaignRow(ctx, userID, t, sheet, row, &params, seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if problem != "" {
+			params.Status = campaigns.RowInvalid
+			params.Problem = sql.NullString{String: problem, Valid: true}
+		}
+		rows = append(rows, params)
+	}
+	return rows, nil
+}
+
+// fillCampaignRow fills in params from one row, returning the problem with
+// the row if it can't be called.
+func (s *Service) fillCampaignRow(ctx context.Context, userID int64, t database.CallTemplate, sheet campaigns.Sheet, row campaigns.Row, params *database.CreateCampaignRowParams, seen map[string]int) (string, error) {
+	number, err := phone.Parse(params.PhoneNumber, defaultPhoneRegion)
+	if err != nil {
+		return "not a valid phone number", nil
+	}
+	params.PhoneNumber = number.E164
+	if first, ok := seen[number.E164]; ok {
+		return fmt.Sprintf("same number as row %d", first), nil
+	}
+	seen[number.E164] = row.Number
+
+	if err := dnc.Check(ctx, s.db, userID, number.E164); errors.Is(err, dnc.ErrBlocked) {
+		return "number is on the do-not-call list", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	// a number in the user's contacts is called with what they know about
+	// the person, and their name where the row leaves it out; otherwise the
+	// row has to say who it is
+	values := sheet.Values(row)
+	params.RecipientContext = sheet.Name(row)
+	contact, err := s.db.GetContactByNumber(ctx, database.GetContactByNumberParams{UserID: userID, PhoneNumber: number.E164})
+	if err == nil {
+		params.RecipientContext = contacts.RecipientContext(contact)
+		if strings.TrimSpace(values[sheet.NameColumn()]) == "" {
+			values[sheet.NameColumn()] = contact.Name
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("error looking up contact for row %d: %w", row.Number, err)
+	}
+	if params.RecipientContext == "" {
+		return "needs a name, or a number in your contacts", nil
+	}
+
+	rendered, err := calltemplates.Render(t, values)
+	if err != nil {
+		return err.Error(), nil
+	}
+	params.Objective = rendered.Objective
+	params.OtherContext = rendered.OtherContext
+	params.TemplateVariables = sql.NullString{String: rendered.ValuesJSON(), Valid: true}
+	return "", nil
+}
+
+// spotChecks picks evenly spaced rows that will be called, one for every
+// campaignSpotCheckEvery of them and at least one.
+func spotChecks(rows []database.CreateCampaignRowParams) []database.CreateCampaignRowParams {
+	var callable []database.CreateCampaignRowParams
+	for _, row := range rows {
+		if row.Status == campaigns.RowQueued {
+			callable = append(callable, row)
+		}
+	}
+	if len(callable) == 0 {
+		return nil
+	}
+
+	count := 1 + len(callable)/campaignSpotCheckEvery
+	checks := make([]database.CreateCampaignRowParams, 0, count)
+	for i := 0; i < count; i++ {
+		checks = append(checks, callable[i*len(callable)/count])
+	}
+	return checks
+}
+
+func (s *Service) saveCampaign(ctx context.Context, params database.CreateCampaignParams, rows []database.CreateCampaignRowParams) (database.Campaign, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Campaign{}, fmt.Errorf("error starting campaign transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+	campaign, err := q.CreateCampaign(ctx, params)
+	if err != nil {
+		return database.Campaign{}, fmt.Errorf("error saving campaign: %w", err)
+	}
+	for _, row := range rows {
+		row.CampaignID = campaign.ID
+		if err := q.CreateCampaignRow(ctx, row); err != nil {
+			return database.Campaign{}, fmt.Errorf("error saving row %d of campaign %d: %w", row.RowNumber, campaign.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Campaign{}, fmt.Errorf("error committing campaign: %w", err)
+	}
+	return campaign, nil
+}
+
+// HandleCampaign shows how each row of a campaign went.
+func (s *Service) HandleCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := s.ownedCampaign(r)
+	if err != nil {
+		fmt.Printf("HandleCampaign: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	rows, err := s.db.ListCampaignRows(r.Context(), campaign.ID)
+	if err != nil {
+		fmt.Printf("HandleCampaign(couldnt list rows of campaign %d): %v\n", campaign.ID, err)
+		http.Error(w, "could not load campaign", http.StatusInternalServerError)
+		return
+	}
+
+	pages.Campaign(campaign, campaigns.DecodeFields(campaign.Columns), rows).Render(r.Context(), w)
+}
+
+// HandleExportCampaign downloads a campaign's rows with how each went as CSV.
+func (s *Service) HandleExportCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := s.ownedCampaign(r)
+	if err != nil {
+		fmt.Printf("HandleExportCampaign: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	rows, err := s.db.ListCampaignRows(r.Context(), campaign.ID)
+	if err != nil {
+		fmt.Printf("HandleExportCampaign(couldnt list rows of campaign %d): %v\n", campaign.ID, err)
+		http.Error(w, "could not load campaign", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%d.csv"`, campaign.ID))
+	if err := campaigns.Export(w, campaigns.DecodeFields(campaign.Columns), rows); err != nil {
+		fmt.Printf("HandleExportCampaign: %v\n", err)
+	}
+}
+
+// HandleStartCampaign starts placing the calls of a draft campaign.
+func (s *Service) HandleStartCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := s.ownedCampaign(r)
+	if err != nil {
+		fmt.Printf("HandleStartCampaign: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	started, err := s.db.StartCampaign(r.Context(), campaign.ID)
+	if err != nil {
+		fmt.Printf("HandleStartCampaign(couldnt start campaign %d): %v\n", campaign.ID, err)
+		http.Error(w, "could not start campaign", http.StatusInternalServerError)
+		return
+	}
+	// a campaign already started, or cancelled, is left as it is
+	if started > 0 {
+		if _, err := s.queue.EnqueueAt(r.Context(), jobDispatchCampaign, campaignJob{CampaignID: campaign.ID}, s.now()); err != nil {
+			fmt.Printf("HandleStartCampaign(couldnt queue campaign %d): %v\n", campaign.ID, err)
+			http.Error(w, "could not start campaign", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/campaigns/%d", campaign.ID), http.StatusSeeOther)
+}
+
+// HandleCancelCampaign stops a campaign placing any more calls. Calls already
+// placed carry on.
+func (s *Service) HandleCancelCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := s.ownedCampaign(r)
+	if err != nil {
+		fmt.Printf("HandleCancelCampaign: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := s.cancelCampaign(r.Context(), campaign.ID); err != nil {
+		fmt.Printf("HandleCancelCampaign: %v\n", err)
+		http.Error(w, "could not cancel campaign", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/campaigns/%d", campaign.ID), http.StatusSeeOther)
+}
+
+func (s *Service) cancelCampaign(ctx context.Context, campaignID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting campaign transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+	cancelled, err := q.CancelCampaign(ctx, campaignID)
+	if err != nil {
+		return fmt.Errorf("error cancelling campaign %d: %w", campaignID, err)
+	}
+	if cancelled == 0 {
+		// already finished
+		return nil
+	}
+	if err := q.CancelQueuedCampaignRows(ctx, campaignID); err != nil {
+		return fmt.Errorf("error cancelling rows of campaign %d: %w", campaignID, err)
+	}
+	return tx.Commit()
+}
+
+// ownedCampaign loads the campaign in the request path if it belongs to the
+// current user.
+func (s *Service) ownedCampaign(r *http.Request) (database.Campaign, error) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		return database.Campaign{}, err
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return database.Campaign{}, fmt.Errorf("error parsing campaign id %q: %w", r.PathValue("id"), err)
+	}
+	campaign, err := s.db.GetCampaign(r.Context(), database.GetCampaignParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return database.Campaign{}, fmt.Errorf("error loading campaign %d of user %d: %w", id, user.ID, err)
+	}
+	return campaign, nil
+}
+
+func (s *Service) renderCampaigns(w http.ResponseWriter, r *http.Request, userID int64, status int, problem string) {
+	list, err := s.db.ListCampaigns(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderCampaigns(couldnt list campaigns of user %d): %v\n", userID, err)
+		http.Error(w, "could not load campaigns", http.StatusInternalServerError)
+		return
+	}
+	templates, err := s.db.ListCallTemplates(r.Context(), sql.NullInt64{Int64: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("renderCampaigns(couldnt list templates of user %d): %v\n", userID, err)
+		http.Error(w, "could not load campaigns", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	pages.Campaigns(list, templates, problem).Render(r.Context(), w)
+}
+
+// campaignInterval is the least time between two calls of a campaign being
+// placed.
+func campaignInterval(campaign database.Campaign) time.Duration {
+	return time.Minute / time.Duration(max(campaign.CallsPerMinute, 1))
+}
+
+// dispatchCampaign places the next call of a running campaign if it has room
+// for another call in flight, then queues itself again once the campaign's
+// pace allows the next. When the campaign is full, the next of its calls to
+// finish dispatches again instead.
+func (s *Service) dispatchCampaign(ctx context.Context, job database.Job) error {
+	var payload campaignJob
+	if err := jobs.Decode(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	campaign, err := s.db.GetCampaignByID(ctx, payload.CampaignID)
+	if err != nil {
+		return fmt.Errorf("error loading campaign %d to dispatch: %w", payload.CampaignID, err)
+	}
+	if campaign.Status != campaigns.StatusRunning {
+		return nil
+	}
+
+	inFlight, err := s.db.CountCampaignCallsInFlight(ctx, campaign.ID)
+	if err != nil {
+		return fmt.Errorf("error counting calls in flight of campaign %d: %w", campaign.ID, err)
+	}
+	if inFlight >= campaign.MaxConcurrent {
+		return nil
+	}
+
+	row, err := s.db.NextQueuedCampaignRow(ctx, campaign.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if inFlight == 0 {
+			if _, err := s.db.CompleteCampaign(ctx, campaign.ID); err != nil {
+				return fmt.Errorf("error completing campaign %d: %w", campaign.ID, err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading next row of campaign %d: %w", campaign.ID, err)
+	}
+
+	// only one dispatch per interval places a call; one that loses the race
+	// leaves the rest of the campaign to the one that won
+	now := s.now().UTC()
+	claimed, err := s.db.ClaimCampaignDispatch(ctx, database.ClaimCampaignDispatchParams{
+		LastDispatchedAt: sql.NullTime{Time: now, Valid: true},
+		ID:               campaign.ID,
+		DispatchedBefore: sql.NullTime{Time: now.Add(-campaignInterval(campaign)), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error claiming dispatch of campaign %d: %w", campaign.ID, err)
+	}
+	if claimed == 0 {
+		return nil
+	}
+
+	if err := s.placeCampaignRow(ctx, campaign, row); err != nil {
+		return err
+	}
+	_, err = s.queue.EnqueueAt(ctx, jobDispatchCampaign, campaignJob{CampaignID: campaign.ID}, now.Add(campaignInterval(campaign)))
+	return err
+}
+
+// placeCampaignRow makes and approves the call of a campaign row and queues
+// it to be dialed. The campaign was approved as a whole when it was started.
+func (s *Service) placeCampaignRow(ctx context.Context, campaign database.Campaign, row database.CampaignRow) error {
+	form := &callForm{recipientName: row.RecipientContext, objective: row.Objective, otherContext: row.OtherContext}
+	plan, err := s.planCall(ctx, row.Objective, row.RecipientContext, row.OtherContext)
+	if err != nil {
+		fmt.Printf("placeCampaignRow(couldnt draft plan of row %d): %v\n", row.ID, err)
+		plan = ai.CallPlan{Plan: fallbackPlan(form)}
+	}
+
+	var contactID sql.NullInt64
+	if contact, err := s.db.GetContactByNumber(ctx, database.GetContactByNumberParams{UserID: campaign.UserID, PhoneNumber: row.PhoneNumber}); err == nil {
+		contactID = sql.NullInt64{Int64: contact.ID, Valid: true}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting campaign transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+	call, err := q.CreateCall(ctx, database.CreateCallParams{
+		UserID:              campaign.UserID,
+		PhoneNumber:         row.PhoneNumber,
+		RecipientContext:    sql.NullString{String: row.RecipientContext, Valid: true},
+		Objective:           row.Objective,
+		BackgroundContext:   sql.NullString{String: row.OtherContext, Valid: row.OtherContext != ""},
+		MaxAttempts:         campaign.MaxAttempts,
+		RetrySpacingMinutes: defaultRetrySpacing,
+		OpeningLine:         sql.NullString{String: plan.OpeningLine, Valid: plan.OpeningLine != ""},
+		CallPlan:            sql.NullString{String: plan.Plan, Valid: true},
+		ContactID:           contactID,
+		TemplateID:          campaign.TemplateID,
+		TemplateVariables:   row.TemplateVariables,
+		CampaignID:          sql.NullInt64{Int64: campaign.ID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error saving call of campaign row %d: %w", row.ID, err)
+	}
+	if _, err := q.ApproveCall(ctx, database.ApproveCallParams{OpeningLine: call.OpeningLine, CallPlan: call.CallPlan, ID: call.ID}); err != nil {
+		return fmt.Errorf("error approving call of campaign row %d: %w", row.ID, err)
+	}
+	placed, err := q.MarkCampaignRowPlaced(ctx, database.MarkCampaignRowPlacedParams{CallID: sql.NullInt64{Int64: call.ID, Valid: true}, ID: row.ID})
+	if err != nil {
+		return fmt.Errorf("error marking campaign row %d placed: %w", row.ID, err)
+	}
+	if placed == 0 {
+		// placed, or cancelled, in the meantime
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing call of campaign row %d: %w", row.ID, err)
+	}
+	s.notifyCreated(ctx, call)
+
+	return s.enqueuePlacement(ctx, call.ID, s.now())
+}
+
+// campaignCallFinished dispatches a campaign again when one of its calls
+// finishes, as soon as its pace allows.
+func (s *Service) campaignCallFinished(ctx context.Context, campaignID int64) error {
+	campaign, err := s.db.GetCampaignByID(ctx, campaignID)
+	if err != nil {
+		return fmt.Errorf("error loading campaign %d: %w", campaignID, err)
+	}
+	if campaign.Status != campaigns.StatusRunning {
+		return nil
+	}
+
+	runAt := s.now()
+	if campaign.LastDispatchedAt.Valid {
+		if next := campaign.LastDispatchedAt.Time.Add(campaignInterval(campaign)); next.After(runAt) {
+			runAt = next
+		}
+	}
+	_, err = s.queue.EnqueueAt(ctx, jobDispatchCampaign, campaignJob{CampaignID: campaign.ID}, runAt)
+	return err
+}
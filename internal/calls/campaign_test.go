@@ -0,0 +1,331 @@
+package calls
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"goDial/internal/campaigns"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadCampaign posts a campaign's form and CSV like the campaigns page does.
+func (ts *testService) uploadCampaign(t *testing.T, fields map[string]string, csv string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for key, value := range fields {
+		require.NoError(t, form.WriteField(key, value))
+	}
+	file, err := form.CreateFormFile("file", "people.csv")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(csv))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := ts.userRequest(t, "POST", "/campaigns", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	ts.HandleCreateCampaign(w, req)
+	return w
+}
+
+// campaignAction posts to one of a campaign's buttons.
+func (ts *testService) campaignAction(t *testing.T, handler http.HandlerFunc, campaignID int64) *httptest.ResponseRecorder {
+	t.Helper()
+	req := ts.userRequest(t, "POST", "/campaigns/x", nil)
+	req.SetPathValue("id", strconv.FormatInt(campaignID, 10))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func (ts *testService) campaignTemplate(t *testing.T) database.CallTemplate {
+	t.Helper()
+	template, err := ts.db.CreateCallTemplate(context.Background(), database.CreateCallTemplateParams{
+		UserID:    sql.NullInt64{Int64: ts.user.ID, Valid: true},
+		Name:      "RSVP",
+		Objective: "Ask {{name}} if they are coming on {{date:date}}.",
+	})
+	require.NoError(t, err)
+	return template
+}
+
+func TestHandleCreateCampaign(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	template := ts.campaignTemplate(t)
+	_, err := ts.db.CreateContact(ctx, database.CreateContactParams{UserID: ts.user.ID, Name: "Robin", PhoneNumber: "+13336664446", Notes: "Prefers mornings"})
+	require.NoError(t, err)
+	_, err = ts.db.CreateDNCEntry(ctx, database.CreateDNCEntryParams{PhoneNumber: "+13336664447", Source: dnc.SourceImport})
+	require.NoError(t, err)
+
+	var moderated []string
+	ts.moderate = func(prompt string) (string, error) {
+		moderated = append(moderated, prompt)
+		return "true", nil
+	}
+
+	csv := "Name,Phone,Date\n" +
+		"Sam,(333) 666-4444,2026-10-20\n" +
+		"Alex,not a number,2026-10-20\n" +
+		"Sam again,333-666-4444,2026-10-20\n" +
+		"Jo,333 666 4445,next tuesday\n" +
+		",333 666 4446,2026-10-21\n" +
+		"Kim,333 666 4447,2026-10-20\n" +
+		",333 666 4448,2026-10-20\n"
+	w := ts.uploadCampaign(t, map[string]string{"name": "Party RSVPs", "templateId": fmt.Sprint(template.ID), "maxConcurrent": "2"}, csv)
+	require.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+
+	list, err := ts.db.ListCampaigns(ctx, ts.user.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	campaign := list[0]
+	assert.Equal(t, fmt.Sprintf("/campaigns/%d", campaign.ID), w.Header().Get("Location"))
+	assert.Equal(t, campaigns.StatusDraft, campaign.Status)
+	assert.Equal(t, int64(2), campaign.MaxConcurrent)
+	assert.Equal(t, int64(defaultCampaignPace), campaign.CallsPerMinute)
+	assert.Equal(t, []string{"name", "phone", "date"}, campaigns.DecodeFields(campaign.Columns))
+
+	rows, err := ts.db.ListCampaignRows(ctx, campaign.ID)
+	require.NoError(t, err)
+	problems := make([]string, len(rows))
+	for i, row := range rows {
+		problems[i] = row.Problem.String
+	}
+	assert.Equal(t, []string{
+		"",
+		"not a valid phone number",
+		"same number as row 1",
+		`Date: expected a date like 2026-10-20, got "next tuesday"`,
+		"",
+		"number is on the do-not-call list",
+		"needs a name, or a number in your contacts",
+	}, problems)
+
+	first, err := ts.db.NextQueuedCampaignRow(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "+13336664444", first.PhoneNumber)
+	assert.Equal(t, "Sam", first.RecipientContext)
+	assert.Equal(t, "Ask Sam if they are coming on Tuesday, October 20, 2026.", first.Objective)
+	assert.JSONEq(t, `{"name":"Sam","date":"2026-10-20"}`, first.TemplateVariables.String)
+
+	// the template, then one of the two rows that will be called
+	require.Len(t, moderated, 2)
+	assert.Contains(t, moderated[0], "Ask {{name}} if they are coming on {{date:date}}.")
+	assert.Contains(t, moderated[1], "Ask Sam if they are coming")
+
+	var robin database.CampaignRow
+	require.NoError(t, ts.db.QueryRowContext(ctx, "SELECT recipient_context, objective FROM campaign_rows WHERE phone_number = ?", "+13336664446").Scan(&robin.RecipientContext, &robin.Objective))
+	assert.Equal(t, "Robin. Prefers mornings", robin.RecipientContext, "a number in the contacts is called with what they know")
+	assert.Equal(t, "Ask Robin if they are coming on Wednesday, October 21, 2026.", robin.Objective)
+}
+
+func TestHandleCreateCampaign_Rejected(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	template := ts.campaignTemplate(t)
+
+	tests := []struct {
+		name         string
+		fields       map[string]string
+		csv          string
+		moderate     func(string) (string, error)
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "no phone column",
+			csv:          "name,date\nSam,2026-10-20\n",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Check the CSV: the header needs a phone column.",
+		},
+		{
+			name:         "pace out of range",
+			fields:       map[string]string{"callsPerMinute": "100"},
+			csv:          "name,phone,date\nSam,3336664444,2026-10-20\n",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Calls per minute must be 1 to 30.",
+		},
+		{
+			name: "a spot checked row fails moderation",
+			csv:  "name,phone,date\nSam,3336664444,2026-10-20\n",
+			moderate: func(prompt string) (string, error) {
+				if strings.Contains(prompt, "Sam") {
+					return "false", fmt.Errorf("rejected")
+				}
+				return "true", nil
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: "Request violates Terms of Service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.moderate = func(string) (string, error) { return "true", nil }
+			if tt.moderate != nil {
+				ts.moderate = tt.moderate
+			}
+			fields := map[string]string{"name": "Party RSVPs", "templateId": fmt.Sprint(template.ID)}
+			for key, value := range tt.fields {
+				fields[key] = value
+			}
+
+			w := ts.uploadCampaign(t, fields, tt.csv)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			list, err := ts.db.ListCampaigns(ctx, ts.user.ID)
+			require.NoError(t, err)
+			assert.Empty(t, list, "nothing is saved")
+		})
+	}
+}
+
+func TestService_CampaignPacesItsCalls(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	template := ts.campaignTemplate(t)
+	clock := ts.now()
+	ts.now = func() time.Time { return clock }
+
+	csv := "name,phone,date\n" +
+		"Sam,3336664441,2026-10-20\n" +
+		"Alex,3336664442,2026-10-20\n" +
+		"Jo,3336664443,2026-10-20\n" +
+		"Kim,not a number,2026-10-20\n"
+	w := ts.uploadCampaign(t, map[string]string{"name": "Party RSVPs", "templateId": fmt.Sprint(template.ID), "maxConcurrent": "2", "callsPerMinute": "2"}, csv)
+	require.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+	list, err := ts.db.ListCampaigns(ctx, ts.user.ID)
+	require.NoError(t, err)
+	campaign := list[0]
+
+	dialed := func() []string {
+		var numbers []string
+		for _, dial := range ts.provider.dials {
+			numbers = append(numbers, dial.To)
+		}
+		return numbers
+	}
+	complete := func(number string) {
+		t.Helper()
+		calls, err := ts.db.ListCallsByUser(ctx, ts.user.ID)
+		require.NoError(t, err)
+		for _, call := range calls {
+			if call.PhoneNumber == number {
+				attempts := ts.attempts(t, call.ID)
+				ts.webhook(t, ts.HandleStatusWebhook, attempts[len(attempts)-1].ID, url.Values{"status": {attemptCompleted}, "duration": {"30"}})
+				ts.runJobs(t)
+				return
+			}
+		}
+		t.Fatalf("no call to %s", number)
+	}
+
+	// nothing is called until the campaign is started
+	ts.runJobs(t)
+	assert.Empty(t, dialed())
+	w = ts.campaignAction(t, ts.HandleStartCampaign, campaign.ID)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	ts.runJobs(t)
+	assert.Equal(t, []string{"+13336664441"}, dialed())
+
+	// two calls a minute are placed 30 seconds apart
+	clock = clock.Add(10 * time.Second)
+	ts.runJobs(t)
+	assert.Len(t, dialed(), 1)
+	clock = clock.Add(20 * time.Second)
+	ts.runJobs(t)
+	assert.Equal(t, []string{"+13336664441", "+13336664442"}, dialed())
+
+	// only two are in flight at once; the next waits for one to finish
+	clock = clock.Add(time.Minute)
+	ts.runJobs(t)
+	assert.Len(t, dialed(), 2)
+	complete("+13336664441")
+	assert.Equal(t, []string{"+13336664441", "+13336664442", "+13336664443"}, dialed())
+
+	rows, err := ts.db.ListCampaignRows(ctx, campaign.ID)
+	require.NoError(t, err)
+	for _, row := range rows[:3] {
+		require.True(t, row.CallID.Valid)
+		call, err := ts.db.GetCall(ctx, row.CallID.Int64)
+		require.NoError(t, err)
+		assert.Equal(t, campaign.ID, call.CampaignID.Int64)
+		assert.Equal(t, template.ID, call.TemplateID.Int64)
+		assert.True(t, call.ApprovedAt.Valid, "campaign calls are approved with the campaign")
+	}
+
+	complete("+13336664442")
+	campaign, err = ts.db.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, campaigns.StatusRunning, campaign.Status, "a call is still going")
+	complete("+13336664443")
+	// it completes at its next dispatch, paced like the others
+	clock = clock.Add(30 * time.Second)
+	ts.runJobs(t)
+	campaign, err = ts.db.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, campaigns.StatusCompleted, campaign.Status)
+
+	req := ts.userRequest(t, "GET", "/campaigns/x/export", nil)
+	req.SetPathValue("id", strconv.FormatInt(campaign.ID, 10))
+	export := httptest.NewRecorder()
+	ts.HandleExportCampaign(export, req)
+	require.Equal(t, http.StatusOK, export.Code)
+	assert.Equal(t, fmt.Sprintf(`attachment; filename="campaign-%d.csv"`, campaign.ID), export.Header().Get("Content-Disposition"))
+	exported := strings.Split(strings.TrimSpace(export.Body.String()), "\n")
+	require.Len(t, exported, 5)
+	assert.Equal(t, "name,phone,date,outcome,reason,call_id", exported[0])
+	assert.True(t, strings.HasPrefix(exported[1], "Sam,3336664441,2026-10-20,completed,"), exported[1])
+	assert.Equal(t, "Kim,not a number,2026-10-20,invalid,not a valid phone number,", exported[4])
+}
+
+func TestHandleCancelCampaign(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	template := ts.campaignTemplate(t)
+
+	w := ts.uploadCampaign(t, map[string]string{"name": "Party RSVPs", "templateId": fmt.Sprint(template.ID), "maxConcurrent": "1"}, "name,phone,date\nSam,3336664441,2026-10-20\nAlex,3336664442,2026-10-20\n")
+	require.Equal(t, http.StatusSeeOther, w.Code, w.Body.String())
+	list, err := ts.db.ListCampaigns(ctx, ts.user.ID)
+	require.NoError(t, err)
+	campaign := list[0]
+
+	ts.campaignAction(t, ts.HandleStartCampaign, campaign.ID)
+	ts.runJobs(t)
+	require.Len(t, ts.provider.dials, 1)
+
+	w = ts.campaignAction(t, ts.HandleCancelCampaign, campaign.ID)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	rows, err := ts.db.ListCampaignRows(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, campaigns.RowPlaced, rows[0].Status, "calls already placed carry on")
+	assert.Equal(t, campaigns.RowCancelled, rows[1].Status)
+
+	// a finishing call doesn't place the next
+	attempts := ts.attempts(t, rows[0].CallID.Int64)
+	ts.webhook(t, ts.HandleStatusWebhook, attempts[0].ID, url.Values{"status": {attemptCompleted}, "duration": {"30"}})
+	ts.runJobs(t)
+	assert.Len(t, ts.provider.dials, 1)
+
+	// a cancelled campaign can't be started again
+	ts.campaignAction(t, ts.HandleStartCampaign, campaign.ID)
+	campaign, err = ts.db.GetCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, campaigns.StatusCancelled, campaign.Status)
+}
@@ -0,0 +1,173 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// interruptedReason is the status reason of calls a shutdown cut off.
+const interruptedReason = "interrupted by a server shutdown"
+
+// drainDeferral is how long placements that come due during a drain wait, for
+// the next server to dial them.
+const drainDeferral = time.Minute
+
+// drainPollInterval is how often a drain checks whether calls are still on
+// the line.
+const drainPollInterval = 100 * time.Millisecond
+
+// settleTimeout bounds how long a drain waits for finished calls to be billed.
+const settleTimeout = 10 * time.Second
+
+// Drain winds the service down before a shutdown. New calls are refused, calls
+// already queued stay pending for the next server, and the calls on the line,
+// or being dialed, get until ctx's deadline to finish. Halfway there the agent
+// says goodbye at the next turn; calls still on the line at the deadline are
+// hung up, billed for their connected time and failed. Last, it waits for
+// finished calls to be billed.
+//
+// The telephony webhooks have to be served until Drain returns, so the calls
+// can finish.
+func (s *Service) Drain(ctx context.Context) error {
+	s.draining.Store(true)
+	// the service's own writes must outlast ctx, to record how calls ended
+	writes := context.WithoutCancel(ctx)
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	wrapUp := wrapUpAt(ctx)
+
+	for {
+		live, err := s.db.ListLiveCallAttempts(writes)
+		if err != nil {
+			return fmt.Errorf("error listing live calls: %w", err)
+		}
+		if len(live) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("calls: interrupting %d call(s) still on the line", len(live))
+			s.interrupt(writes, live, func(database.CallAttempt) time.Time { return time.Now() })
+		case <-wrapUp:
+			log.Printf("calls: asking %d call(s) to wrap up", len(live))
+			s.wrappingUp.Store(true)
+			wrapUp = nil
+			continue
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	settle, cancel := context.WithTimeout(writes, settleTimeout)
+	defer cancel()
+	return s.queue.Flush(settle, jobSettleAttempt)
+}
+
+// RecoverInterrupted fails the calls a server that stopped without draining,
+// say in a crash, left on the line. It is run at startup, before the server
+// takes requests. They are billed up to the last thing said on them.
+func (s *Service) RecoverInterrupted(ctx context.Context) error {
+	live, err := s.db.ListLiveCallAttempts(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing interrupted calls: %w", err)
+	}
+	if len(live) > 0 {
+		log.Printf("calls: failing %d call(s) left on the line by the last server", len(live))
+		s.interrupt(ctx, live, func(attempt database.CallAttempt) time.Time { return s.lastHeard(ctx, attempt) })
+	}
+	return nil
+}
+
+// lastHeard is when anything was last logged on an attempt's call, or when it
+// was answered if nothing was.
+func (s *Service) lastHeard(ctx context.Context, attempt database.CallAttempt) time.Time {
+	logs, err := s.db.ListCallLogs(ctx, attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading transcript of interrupted call %d: %v", attempt.CallID, err)
+	}
+	if n := len(logs); n > 0 && logs[n-1].Timestamp.Valid && logs[n-1].Timestamp.Time.After(attempt.AnsweredAt.Time) {
+		return logs[n-1].Timestamp.Time
+	}
+	return attempt.AnsweredAt.Time
+}
+
+// wrapUpAt fires halfway to ctx's deadline, leaving calls the rest of the
+// time to say goodbye. Without a deadline it never fires.
+func wrapUpAt(ctx context.Context) <-chan time.Time {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	return time.After(time.Until(deadline) / 2)
+}
+
+// interrupt hangs up live attempts and fails their calls, which aren't
+// retried: the cut was ours, not the recipient's. Answered attempts are
+// billed for the time they were connected, up to endedAt, like a hangup.
+// Attempts still being dialed are failed before they get a provider call, and
+// placeCall hangs up the call it then dials.
+func (s *Service) interrupt(ctx context.Context, live []database.CallAttempt, endedAt func(database.CallAttempt) time.Time) {
+	for _, attempt := range live {
+		if attempt.ProviderCallID.Valid && s.provider != nil {
+			if err := s.provider.Hangup(ctx, attempt.ProviderCallID.String); err != nil {
+				log.Printf("calls: error hanging up interrupted attempt %d: %v", attempt.ID, err)
+			}
+		}
+
+		// answered_at is the database's clock, not the service's
+		var connected time.Duration
+		if attempt.AnsweredAt.Valid {
+			connected = max(endedAt(attempt).Sub(attempt.AnsweredAt.Time), 0)
+		}
+		ended, err := s.db.EndCallAttempt(ctx, database.EndCallAttemptParams{
+			Status:          attemptFailed,
+			Error:           sql.NullString{String: interruptedReason, Valid: true},
+			DurationSeconds: int64(connected.Seconds()),
+			ID:              attempt.ID,
+		})
+		if err != nil {
+			log.Printf("calls: error ending interrupted attempt %d: %v", attempt.ID, err)
+			continue
+		}
+
+		call, err := s.db.GetCall(ctx, attempt.CallID)
+		if err != nil {
+			log.Printf("calls: error loading interrupted call %d: %v", attempt.CallID, err)
+			continue
+		}
+		s.logCall(ctx, call.ID, logSystem, "call "+interruptedReason)
+		if err := s.chargeAttempt(ctx, call, ended, connectedMinutes(ended.DurationSeconds)); err != nil {
+			log.Printf("calls: error billing interrupted attempt %d: %v", attempt.ID, err)
+		}
+		// a call canceled while on the line is already over
+		if call.Status.String != statusInProgress {
+			continue
+		}
+		if err := s.finish(ctx, call, statusFailed, interruptedReason); err != nil {
+			log.Printf("calls: %v", err)
+		}
+	}
+}
+
+// drainingMessage answers requests for new calls while the service drains.
+const drainingMessage = "goDial is restarting and isn't taking new calls. Try again in a minute."
+
+// refusingCalls reports whether new calls are refused, as the service drains
+// for a shutdown, and if so tells the client when to try again.
+func (s *Service) refusingCalls(w http.ResponseWriter) bool {
+	if !s.draining.Load() {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(drainDeferral.Seconds())))
+	return true
+}
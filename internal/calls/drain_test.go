@@ -0,0 +1,196 @@
+package calls
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_DrainLeavesNewCallsForTheNextServer(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	require.NoError(t, ts.Drain(ctx))
+	call := ts.createCall(t, 1)
+
+	// run by hand, as runJobs would run each deferral as soon as it is queued
+	queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	require.NoError(t, ts.placeCall(ctx, queued[0]))
+
+	assert.Empty(t, ts.provider.dials)
+	assert.Empty(t, ts.attempts(t, call.ID))
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusPending, call.Status.String)
+
+	queued, err = ts.db.ListJobsByStatus(ctx, "queued")
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	assert.Equal(t, jobPlaceCall, queued[1].Kind)
+	assert.True(t, ts.now().Add(drainDeferral).Equal(queued[1].RunAt), "placement should be deferred, got %s", queued[1].RunAt)
+}
+
+func TestService_DrainWaitsForCallsAndTheirBilling(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = lines("Happy birthday!")
+	call, attempt := ts.answeredCall(t)
+
+	// billing is left to the queue's workers, which Drain waits for
+	ts.queue.Start()
+	defer ts.queue.Shutdown(ctx)
+
+	drainCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- ts.Drain(drainCtx) }()
+
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a call was on the line")
+	case <-time.After(3 * drainPollInterval):
+	}
+
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "status": {attemptCompleted}, "duration": {"61"}})
+	require.NoError(t, <-drained)
+	assert.False(t, ts.wrappingUp.Load(), "the call finished on its own")
+
+	call, err := ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusCompleted, call.Status.String)
+	user, err := ts.db.GetUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), minutesOf(user), "the call was billed before the drain finished")
+}
+
+func TestService_DrainWrapsUpThenInterruptsCalls(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	call, attempt := ts.answeredCall(t)
+
+	drainCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	drained := make(chan error, 1)
+	go func() { drained <- ts.Drain(drainCtx) }()
+
+	// halfway through, the agent says goodbye instead of carrying on
+	require.Eventually(t, ts.wrappingUp.Load, time.Second, 10*time.Millisecond)
+	asked := len(ts.agent.turns)
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "speech": {"Who is this?"}})
+	assert.Equal(t, []Instruction{Say{Text: wrapUpLine}, Hangup{}}, ts.provider.lastResponse())
+	assert.Len(t, ts.agent.turns, asked, "the agent isn't asked for the goodbye")
+
+	// the provider never reports the hangup, so the deadline cuts it off
+	require.NoError(t, <-drained)
+	interrupted := time.Now()
+	assert.Equal(t, []string{"fake-1"}, ts.provider.hangups)
+
+	attempt = ts.attempts(t, call.ID)[0]
+	assert.Equal(t, attemptFailed, attempt.Status)
+	assert.Equal(t, interruptedReason, attempt.Error.String)
+	call, err := ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusFailed, call.Status.String)
+	assert.Equal(t, interruptedReason, call.StatusReason.String)
+	assert.Contains(t, systemLogs(t, ts, call.ID), "call "+interruptedReason)
+
+	// the connected time is billed like a hangup, and the late report
+	// changes nothing
+	assert.InDelta(t, interrupted.Sub(attempt.AnsweredAt.Time).Seconds(), attempt.DurationSeconds, 2)
+	w := ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "status": {attemptCompleted}, "duration": {"90"}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	ts.runJobs(t)
+	ledger, err := ts.db.ListLedgerEntriesByUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	require.Len(t, ledger, 1)
+	assert.Equal(t, -connectedMinutes(attempt.DurationSeconds), ledger[0].Minutes)
+	assert.Equal(t, connectedMinutes(attempt.DurationSeconds), ts.attempts(t, call.ID)[0].BilledMinutes)
+}
+
+func TestService_DrainInterruptsCallsBeingDialed(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+
+	// the drain runs out of time while the provider is still dialing
+	expired, cancel := context.WithCancel(ctx)
+	cancel()
+	ts.provider.whileDialing = func() {
+		require.NoError(t, ts.Drain(expired))
+	}
+	call := ts.createCall(t, 1)
+	ts.runJobs(t)
+
+	attempt := ts.attempts(t, call.ID)[0]
+	assert.Equal(t, attemptFailed, attempt.Status)
+	assert.False(t, attempt.ProviderCallID.Valid)
+	assert.Equal(t, []string{"fake-1"}, ts.provider.hangups, "the call dialed after the interrupt is hung up")
+	call, err := ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusFailed, call.Status.String)
+	assert.Equal(t, interruptedReason, call.StatusReason.String)
+}
+
+func TestService_RecoverInterrupted(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = lines("Happy birthday!")
+	call, attempt := ts.answeredCall(t)
+
+	// the last server crashed three minutes into the call, right after the
+	// agent spoke
+	_, err := ts.db.ExecContext(ctx, "UPDATE call_attempts SET answered_at = datetime('now', '-3 minutes') WHERE id = ?", attempt.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, ts.RecoverInterrupted(ctx))
+
+	attempt = ts.attempts(t, call.ID)[0]
+	assert.Equal(t, attemptFailed, attempt.Status)
+	assert.InDelta(t, 180, attempt.DurationSeconds, 2)
+	assert.Equal(t, []string{"fake-1"}, ts.provider.hangups)
+	call, err = ts.db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, statusFailed, call.Status.String)
+	assert.Equal(t, interruptedReason, call.StatusReason.String)
+	user, err := ts.db.GetUser(ctx, ts.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10-connectedMinutes(attempt.DurationSeconds), minutesOf(user))
+
+	// nothing is left to recover
+	require.NoError(t, ts.RecoverInterrupted(ctx))
+	assert.Len(t, ts.provider.hangups, 1)
+}
+
+func TestService_DrainRefusesNewCalls(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	require.NoError(t, ts.Drain(context.Background()))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{name: "call form", handler: ts.HandleCallProcedure, target: "/makeCall"},
+		{name: "API", handler: ts.HandleAPICreateCall, target: "/api/v1/calls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, ts.userRequest(t, "POST", tt.target, strings.NewReader(`{}`)))
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, "60", w.Header().Get("Retry-After"))
+			assert.Contains(t, w.Body.String(), drainingMessage)
+		})
+	}
+	calls, err := ts.db.ListCallsByUser(context.Background(), ts.user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, calls)
+}
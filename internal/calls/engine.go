@@ -0,0 +1,335 @@
+package calls
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"goDial/internal/ai"
+	"goDial/internal/compliance"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/phone"
+)
+
+// Agent decides what the AI says and does next on a call.
+type Agent interface {
+	Reply(ctx context.Context, system string, turns []ai.Turn) (ai.Response, error)
+}
+
+// AnthropicAgent is the Agent backed by the ai package, made with
+// NewAnthropicAgent.
+type AnthropicAgent struct {
+	ai *ai.Client
+}
+
+// NewAnthropicAgent returns the agent backed by the model cfg names.
+func NewAnthropicAgent(cfg config.AI) AnthropicAgent {
+	return AnthropicAgent{ai: ai.New(cfg)}
+}
+
+// Reply asks the ai package for the agent's next move.
+func (a AnthropicAgent) Reply(ctx context.Context, system string, turns []ai.Turn) (ai.Response, error) {
+	return a.ai.Reply(ctx, system, turns)
+}
+
+// call_logs message types
+const (
+	logRecipient = "user_speech"
+	logAgent     = "ai_response"
+	logSystem    = "system"
+)
+
+// optOutDigit is the key a recipient can press to stop all future calls.
+const optOutDigit = "9"
+
+// limits that keep a call from running on forever
+const (
+	maxAgentTurns = 40
+	maxSilences   = 2
+)
+
+// system log lines the engine looks for again later
+const (
+	answeredLogFormat   = "attempt %d answered"
+	silenceLog          = "no response from recipient"
+	disclosureLogFormat = "disclosure played (%s): %s"
+)
+
+const (
+	wrapUpLine  = "I'm sorry, I have to go now. Thank you for your time, goodbye."
+	troubleLine = "I'm sorry, I'm having some trouble on my end. I'll try you again another time. Goodbye."
+	optOutLine  = "Understood. You won't receive any more calls from us. Goodbye."
+)
+
+// systemPrompt tells the agent how to behave on a call, then what to do on
+// it: the plan the user approved, or for calls without one, the request the
+// user made.
+func systemPrompt(call database.Call) string {
+	var b strings.Builder
+	b.WriteString("You are an AI assistant making a phone call on behalf of a goDial user. ")
+	b.WriteString("Speak naturally and briefly, one or two sentences at a time, as this is a live phone call and everything you write is read aloud. ")
+	b.WriteString("Never use lists, markdown or stage directions. ")
+	b.WriteString("An automated announcement has already told the recipient that you are an AI, so don't repeat it, but if they ask whether you are an AI, always say yes.\n\n")
+	if call.CallPlan.String != "" {
+		b.WriteString(call.CallPlan.String)
+		b.WriteString("\n")
+	} else {
+		fmt.Fprintf(&b, "Who you are calling: %s\n", call.RecipientContext.String)
+		fmt.Fprintf(&b, "What you need to accomplish: %s\n", call.Objective)
+		if call.BackgroundContext.String != "" {
+			fmt.Fprintf(&b, "Other context from the user: %s\n", call.BackgroundContext.String)
+		}
+	}
+	fmt.Fprintf(&b, "\nWhen the objective is done, or the recipient wants to end the call, say a short goodbye and use %s.", ai.ToolEndCall)
+	fmt.Fprintf(&b, " Use %s to save anything the user will want to know afterwards, %s to get through automated phone menus, %s when the recipient wants to speak to the user, and %s when they ask to be called back later.", ai.ToolRecordFact, ai.ToolSendDTMF, ai.ToolTransferToUser, ai.ToolScheduleCallback)
+	fmt.Fprintf(&b, "\nIf you reach an automated phone menu, don't talk over it: use %s to pick the option closest to your objective, or say the option if the menu asks you to.", ai.ToolSendDTMF)
+	fmt.Fprintf(&b, "\nIf the recipient asks not to be called again, or to be taken off a list, apologize, confirm they won't be called again, and use %s with opted_out set.", ai.ToolEndCall)
+	fmt.Fprintf(&b, " If they ask how to stop these calls, tell them they can say so or press %s.", optOutDigit)
+	return b.String()
+}
+
+// answer starts the conversation when the recipient picks up. The compliance
+// disclosure always comes before the agent's first line, which is the opening
+// line the user approved when there is one. Machines get a voicemail instead.
+func (s *Service) answer(ctx context.Context, call database.Call, attempt database.CallAttempt, event Event) []Instruction {
+	s.logCall(ctx, call.ID, logSystem, fmt.Sprintf(answeredLogFormat, attempt.AttemptNumber))
+	switch event.AnsweredBy {
+	case answeredMachine:
+		return s.machineAnswered(ctx, call, attempt, event.GreetingEnded)
+	case answeredHuman:
+		s.setAnsweredBy(ctx, attempt, answeredHuman)
+	}
+	instructions := []Instruction{s.announce(ctx, call)}
+
+	if call.OpeningLine.String != "" {
+		s.logCall(ctx, call.ID, logAgent, call.OpeningLine.String)
+		return append(instructions, Say{Text: call.OpeningLine.String}, Listen{ActionURL: attemptURL(attempt.ID, "turn")})
+	}
+	return append(instructions, s.respond(ctx, call, attempt)...)
+}
+
+// announce plays the compliance disclosure and logs that it was played.
+func (s *Service) announce(ctx context.Context, call database.Call) Instruction {
+	disclosure := s.disclosure(ctx, call)
+	s.logCall(ctx, call.ID, logSystem, fmt.Sprintf(disclosureLogFormat, disclosure.Locale, disclosure.Text))
+	return Say{Text: disclosure.Text, Language: disclosure.Locale}
+}
+
+// disclosure picks the announcement for the call's recipient. A number or
+// owner that can't be loaded still gets a disclosure, just a less specific one.
+func (s *Service) disclosure(ctx context.Context, call database.Call) compliance.Disclosure {
+	number, err := phone.Parse(call.PhoneNumber, defaultPhoneRegion)
+	if err != nil {
+		log.Printf("calls: error parsing number of call %d for its disclosure: %v", call.ID, err)
+	}
+
+	return s.disclosures.For(number, call.Record, s.callerName(ctx, call))
+}
+
+// callerName is who the agent says it is calling for: the call's owner.
+func (s *Service) callerName(ctx context.Context, call database.Call) string {
+	user, err := s.db.GetUser(ctx, call.UserID)
+	if err != nil {
+		log.Printf("calls: error loading owner of call %d: %v", call.ID, err)
+		return "a goDial user"
+	}
+	if user.Name == "" {
+		return "a goDial user"
+	}
+	return user.Name
+}
+
+// turn handles what the recipient said (or didn't) and replies.
+func (s *Service) turn(ctx context.Context, call database.Call, attempt database.CallAttempt, event Event) []Instruction {
+	switch {
+	case event.Digits == optOutDigit:
+		s.logCall(ctx, call.ID, logRecipient, fmt.Sprintf("(pressed %s)", event.Digits))
+		s.optOut(ctx, call, dnc.SourceKeypressOptOut)
+		return s.goodbye(ctx, call, optOutLine)
+	case event.Speech != "":
+		s.logCall(ctx, call.ID, logRecipient, event.Speech)
+	case event.Digits != "":
+		s.logCall(ctx, call.ID, logRecipient, fmt.Sprintf("(pressed %s)", event.Digits))
+	default:
+		s.logCall(ctx, call.ID, logSystem, silenceLog)
+	}
+
+	if instructions, ok := s.checkForMachine(ctx, call, attempt, event.Speech); ok {
+		return instructions
+	}
+	if instructions, ok := s.navigate(ctx, call, attempt, event.Speech); ok {
+		return instructions
+	}
+	return s.respond(ctx, call, attempt)
+}
+
+// checkForMachine leaves a voicemail when the attempt turns out to have
+// reached one: the provider detected a machine and its greeting has now
+// ended, or what was just heard is a voicemail greeting.
+func (s *Service) checkForMachine(ctx context.Context, call database.Call, attempt database.CallAttempt, speech string) ([]Instruction, bool) {
+	switch {
+	case attempt.AnsweredBy.String == answeredMachine:
+		return s.leaveVoicemail(ctx, call, attempt, false), true
+	case attempt.AnsweredBy.String == answeredHuman || speech == "":
+		return nil, false
+	}
+
+	logs, err := s.db.ListCallLogs(ctx, call.ID)
+	if err != nil {
+		log.Printf("calls: error loading transcript of call %d: %v", call.ID, err)
+		return nil, false
+	}
+	if !reachedVoicemail(attemptLogs(logs, attempt), speech) {
+		return nil, false
+	}
+	s.setAnsweredBy(ctx, attempt, answeredMachine)
+	s.logCall(ctx, call.ID, logSystem, machineLog)
+	return s.leaveVoicemail(ctx, call, attempt, false), true
+}
+
+// respond asks the agent for its next line and turns it, and any tools it
+// used, into instructions.
+func (s *Service) respond(ctx context.Context, call database.Call, attempt database.CallAttempt) []Instruction {
+	logs, err := s.db.ListCallLogs(ctx, call.ID)
+	if err != nil {
+		log.Printf("calls: error loading transcript of call %d: %v", call.ID, err)
+		return s.goodbye(ctx, call, troubleLine)
+	}
+	logs = attemptLogs(logs, attempt)
+
+	if trailingSilences(logs) > maxSilences {
+		s.logCall(ctx, call.ID, logSystem, "hanging up after repeated silence")
+		return []Instruction{Hangup{}}
+	}
+
+	turns := transcriptTurns(logs)
+	if agentTurns(turns) >= maxAgentTurns || s.wrappingUp.Load() {
+		return s.goodbye(ctx, call, wrapUpLine)
+	}
+
+	var instructions []Instruction
+	for round := 0; ; round++ {
+		reply, err := s.agent.Reply(ctx, systemPrompt(call), turns)
+		if err != nil {
+			log.Printf("calls: error generating reply on call %d: %v", call.ID, err)
+			s.logCall(ctx, call.ID, logSystem, "error generating reply: "+err.Error())
+			return s.goodbye(ctx, call, troubleLine)
+		}
+
+		var end bool
+		if text := strings.TrimSpace(reply.Text); text != "" {
+			s.logCall(ctx, call.ID, logAgent, text)
+			instructions = append(instructions, Say{Text: text})
+		}
+		var used []ai.ToolCall
+		for _, use := range reply.Tools {
+			out := s.runTool(ctx, call, attempt, use)
+			used = append(used, out.call)
+			instructions = append(instructions, out.instructions...)
+			end = end || out.end
+		}
+		if end {
+			return append(instructions, Hangup{})
+		}
+
+		// an agent that only used tools, say to note a fact, gets to see
+		// their results and carry on before the recipient hears anything
+		if len(instructions) > 0 || len(used) == 0 || round+1 >= maxToolRounds {
+			return append(instructions, Listen{ActionURL: attemptURL(attempt.ID, "turn")})
+		}
+		turns = append(turns, ai.Turn{Speaker: ai.SpeakerAgent, Tools: used})
+	}
+}
+
+// optOut puts the recipient's number on the do-not-call list for every user.
+func (s *Service) optOut(ctx context.Context, call database.Call, source string) {
+	if err := dnc.OptOut(ctx, s.db, call.PhoneNumber, source, call.ID); err != nil {
+		// the recipient was promised no more calls, so this must not go unnoticed
+		log.Printf("calls: ERROR recording opt-out of %s on call %d: %v", call.PhoneNumber, call.ID, err)
+		s.logCall(ctx, call.ID, logSystem, "failed to record opt-out: "+err.Error())
+		return
+	}
+	s.logCall(ctx, call.ID, logSystem, "recipient opted out, number added to the do-not-call list")
+}
+
+// goodbye says a fixed line and hangs up.
+func (s *Service) goodbye(ctx context.Context, call database.Call, line string) []Instruction {
+	s.logCall(ctx, call.ID, logAgent, line)
+	return []Instruction{Say{Text: line}, Hangup{}}
+}
+
+// logCall appends to the call's transcript. A failed write is logged rather
+// than dropping the live call.
+func (s *Service) logCall(ctx context.Context, callID int64, messageType, content string) {
+	if _, err := s.db.CreateCallLog(ctx, database.CreateCallLogParams{
+		CallID:      callID,
+		MessageType: messageType,
+		Content:     content,
+	}); err != nil {
+		log.Printf("calls: error logging %s on call %d: %v", messageType, callID, err)
+	}
+}
+
+// attemptLogs drops the parts of a call's transcript that belong to earlier
+// attempts, so a retry starts a fresh conversation.
+func attemptLogs(logs []database.CallLog, attempt database.CallAttempt) []database.CallLog {
+	marker := fmt.Sprintf(answeredLogFormat, attempt.AttemptNumber)
+	for i := len(logs) - 1; i >= 0; i-- {
+		if logs[i].MessageType == logSystem && logs[i].Content == marker {
+			return logs[i+1:]
+		}
+	}
+	return logs
+}
+
+// trailingSilences counts how many times in a row the recipient said nothing.
+func trailingSilences(logs []database.CallLog) int {
+	count := 0
+	for i := len(logs) - 1; i >= 0; i-- {
+		if logs[i].MessageType == logRecipient {
+			break
+		}
+		if logs[i].MessageType == logSystem && logs[i].Content == silenceLog {
+			count++
+		}
+	}
+	return count
+}
+
+// transcriptTurns turns call logs into the conversation the agent sees.
+func transcriptTurns(logs []database.CallLog) []ai.Turn {
+	var turns []ai.Turn
+	for _, entry := range logs {
+		switch entry.MessageType {
+		case logRecipient:
+			turns = append(turns, ai.Turn{Speaker: ai.SpeakerRecipient, Text: entry.Content})
+		case logAgent:
+			turns = append(turns, ai.Turn{Speaker: ai.SpeakerAgent, Text: entry.Content})
+		case logSystem:
+			use, ok := parseToolLog(entry.Content)
+			if !ok {
+				continue
+			}
+			// tools belong to the agent turn they were used in
+			if n := len(turns); n > 0 && turns[n-1].Speaker == ai.SpeakerAgent {
+				turns[n-1].Tools = append(turns[n-1].Tools, use)
+				continue
+			}
+			turns = append(turns, ai.Turn{Speaker: ai.SpeakerAgent, Tools: []ai.ToolCall{use}})
+		}
+	}
+	return turns
+}
+
+func agentTurns(turns []ai.Turn) int {
+	count := 0
+	for _, turn := range turns {
+		if turn.Speaker == ai.SpeakerAgent {
+			count++
+		}
+	}
+	return count
+}
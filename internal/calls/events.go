@@ -0,0 +1,104 @@
+package calls
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/webhooks"
+)
+
+// lowBalanceMinutes is the balance a charge has to take a user below for
+// them to be sent balance.low.
+const lowBalanceMinutes = 5
+
+// Webhooks is the notifier sending the user's endpoints their call events.
+func (s *Service) Webhooks() *webhooks.Notifier {
+	return s.webhooks
+}
+
+// callEvent is a call as sent with call events.
+type callEvent struct {
+	ID           int64      `json:"id"`
+	PhoneNumber  string     `json:"phone_number"`
+	Objective    string     `json:"objective"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"status_reason,omitempty"`
+	Outcome      string     `json:"outcome,omitempty"`
+	ContactID    *int64     `json:"contact_id,omitempty"`
+	CampaignID   *int64     `json:"campaign_id,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+func newCallEvent(call database.Call) callEvent {
+	event := callEvent{
+		ID:           call.ID,
+		PhoneNumber:  call.PhoneNumber,
+		Objective:    call.Objective,
+		Status:       call.Status.String,
+		StatusReason: call.StatusReason.String,
+		Outcome:      call.Outcome.String,
+	}
+	if call.ContactID.Valid {
+		event.ContactID = &call.ContactID.Int64
+	}
+	if call.CampaignID.Valid {
+		event.CampaignID = &call.CampaignID.Int64
+	}
+	if call.CreatedAt.Valid {
+		event.CreatedAt = &call.CreatedAt.Time
+	}
+	if call.CompletedAt.Valid {
+		event.CompletedAt = &call.CompletedAt.Time
+	}
+	return event
+}
+
+// notify sends event to the user's webhook endpoints. A notification that
+// can't be queued is logged rather than failing the call it is about.
+func (s *Service) notify(ctx context.Context, userID int64, event string, data any) {
+	if err := s.webhooks.Emit(ctx, userID, event, data); err != nil {
+		log.Printf("calls: error sending %s to webhooks of user %d: %v", event, userID, err)
+	}
+}
+
+func (s *Service) notifyCreated(ctx context.Context, call database.Call) {
+	s.notify(ctx, call.UserID, webhooks.EventCallCreated, newCallEvent(call))
+}
+
+// notifyFinished sends call.completed or call.failed for a call just
+// finished, as it was saved.
+func (s *Service) notifyFinished(ctx context.Context, callID int64) {
+	call, err := s.db.GetCall(ctx, callID)
+	if err != nil {
+		log.Printf("calls: error loading finished call %d to notify webhooks: %v", callID, err)
+		return
+	}
+	event := webhooks.EventCallCompleted
+	if call.Status.String == statusFailed {
+		event = webhooks.EventCallFailed
+	}
+	s.notify(ctx, call.UserID, event, newCallEvent(call))
+}
+
+// balanceEvent is sent with balance.low.
+type balanceEvent struct {
+	Minutes   int64 `json:"minutes"`
+	Threshold int64 `json:"threshold"`
+}
+
+// notifyBalance sends balance.low when a charge of minutes took the user
+// below lowBalanceMinutes, once per crossing rather than on every charge.
+func (s *Service) notifyBalance(ctx context.Context, userID, charged int64) {
+	user, err := s.db.GetUser(ctx, userID)
+	if err != nil {
+		log.Printf("calls: error loading balance of user %d to notify webhooks: %v", userID, err)
+		return
+	}
+	balance := minutesOf(user)
+	if balance < lowBalanceMinutes && balance+charged >= lowBalanceMinutes {
+		s.notify(ctx, userID, webhooks.EventBalanceLow, balanceEvent{Minutes: balance, Threshold: lowBalanceMinutes})
+	}
+}
@@ -0,0 +1,94 @@
+package calls
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_NotifiesWebhooks(t *testing.T) {
+	ts := setupCallsTestService(t, 6)
+	ctx := context.Background()
+	ts.agent.replies = []ai.Response{hangingUp(t, "Hi, happy birthday!", ai.EndCallInput{Reason: "wished them a happy birthday"})}
+
+	var mu sync.Mutex
+	var secret string
+	var events []webhooks.Envelope
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.NoError(t, webhooks.Verify(secret, r.Header.Get(webhooks.SignatureHeader), body, time.Now()))
+		var event webhooks.Envelope
+		require.NoError(t, json.Unmarshal(body, &event))
+		events = append(events, event)
+	}))
+	t.Cleanup(receiver.Close)
+	endpoint, err := webhooks.Create(ctx, ts.db, ts.user.ID, webhooks.Form{URL: receiver.URL, Events: webhooks.Events})
+	require.NoError(t, err)
+	secret = endpoint.Secret
+
+	// a call placed from the form
+	form := url.Values{"recipientPhoneNumber": {"(333) 666-4444"}, "recipientContext": {"Sam"}, "objective": {"say happy birthday"}}
+	req := ts.userRequest(t, "POST", "/handleCallProcedure", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	ts.HandleCallProcedure(w, req)
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	ts.runJobs(t)
+
+	// a call answered for 61 seconds, which takes the balance from 6 to 4
+	answered := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, answered.ID)[0]
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptCompleted}, "duration": {"61"}})
+	ts.runJobs(t)
+
+	// a call nobody answers
+	unanswered := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt = ts.attempts(t, unanswered.ID)[0]
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptNoAnswer}})
+	ts.runJobs(t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	kinds := make([]string, len(events))
+	for i, event := range events {
+		kinds[i] = event.Event
+	}
+	assert.ElementsMatch(t, []string{webhooks.EventCallCreated, webhooks.EventCallCompleted, webhooks.EventBalanceLow, webhooks.EventCallFailed}, kinds)
+
+	for _, event := range events {
+		data := event.Data.(map[string]any)
+		switch event.Event {
+		case webhooks.EventCallCreated:
+			assert.Equal(t, "+13336664444", data["phone_number"])
+			assert.Equal(t, "say happy birthday", data["objective"])
+		case webhooks.EventCallCompleted:
+			assert.Equal(t, float64(answered.ID), data["id"])
+			assert.Equal(t, statusCompleted, data["status"])
+			assert.NotEmpty(t, data["completed_at"])
+		case webhooks.EventCallFailed:
+			assert.Equal(t, float64(unanswered.ID), data["id"])
+			assert.Equal(t, statusFailed, data["status"])
+			assert.NotEmpty(t, data["status_reason"])
+		case webhooks.EventBalanceLow:
+			assert.Equal(t, map[string]any{"minutes": float64(4), "threshold": float64(lowBalanceMinutes)}, data)
+		}
+	}
+}
@@ -1,90 +1,557 @@
 package calls
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"goDial/internal/ai"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/phone"
 	"goDial/internal/templates/pages"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
+// defaultPhoneRegion is where numbers typed without a country code are from.
+const defaultPhoneRegion = "US"
+
 type callForm struct {
-	recipientNumber string
-	recipientName   string
-	objective       string
-	otherContext    string
+	recipientNumber     string
+	recipientName       string
+	objective           string
+	otherContext        string
+	maxAttempts         int64
+	retrySpacingMinutes int64
+	retryWindowStart    string
+	retryWindowEnd      string
+	record              bool
+	templateID          sql.NullInt64
+	templateVariables   sql.NullString
 }
 
-// HandleCallProcedure handles our route, and returns the home page currently. However, what we want to do is return something like a 'call-status' page, where they can observe things like if the call is finished, its review after completion, etc.. From this function, we want to check the form values, log it to the db, and also begin the call procedure of starting the actual call.
-func HandleCallProcedure(w http.ResponseWriter, r *http.Request) {
+// HandleCallProcedure takes the call form from the home page. It checks the form values, runs the request past moderation, drafts an opening line and plan for the call, and saves it, then sends the user to the confirmation page to review the draft before anything is dialed.
+func (s *Service) HandleCallProcedure(w http.ResponseWriter, r *http.Request) {
+	if s.refusingCalls(w) {
+		http.Error(w, drainingMessage, http.StatusServiceUnavailable)
+		return
+	}
+
 	// validate & get data from the requests call form
-	callFormData, err := validateCallForm(r)
+	callFormData, err := validateCallForm(r.FormValue)
 	if err != nil {
 		w.WriteHeader(400) // TODO: figure out bad req
 		fmt.Printf("error taking user form to make a call, form not valid: %v\n", err)
 		return
 	}
 
-	// format the prompt, and this should tell us if we *want* to do this task
-	response, err := ai.CheckPromptValidity(fmt.Sprintf("user wants to contact:%s, user wants to accomplish: %s, user provided outside context: %s.", callFormData.recipientName, callFormData.objective, callFormData.otherContext))
-
+	user, err := s.currentUser(r.Context())
 	if err != nil {
+		fmt.Printf("HandleCallProcedure(no current user): %v\n", err)
+		http.Error(w, "sign in to place calls", http.StatusUnauthorized)
+		return
+	}
+
+	if err := s.applyTemplate(r.Context(), user.ID, callFormData, r.FormValue("templateId"), templateValues(r.PostForm)); err != nil {
+		w.WriteHeader(400)
+		fmt.Printf("HandleCallProcedure(couldnt fill in call template): %v\n", err)
+		return
+	}
+
+	call, err := s.newCall(r.Context(), user, callFormData)
+	if errors.Is(err, dnc.ErrBlocked) {
+		fmt.Printf("call request to blocked number: %v\n", err)
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusForbidden)
 
 		resp := map[string]interface{}{
-			"error":   "forbidden",
-			"message": "Request violates Terms of Service",
+			"error":   "do_not_call",
+			"message": "This number is on the do-not-call list",
 		}
 
 		json.NewEncoder(w).Encode(resp)
+		return
+	}
+	if errors.Is(err, errRejected) {
+		rejectedByModeration(w)
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleCallProcedure: %v\n", err)
+		http.Error(w, "could not save call", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/calls/%d/confirm", call.ID), http.StatusSeeOther)
+}
+
+// errRejected is returned for call requests moderation turned down.
+var errRejected = errors.New("call request rejected by moderation")
+
+// newCall checks a call request against the do-not-call list and moderation,
+// drafts the opening line and plan for the agent, and saves the call for the
+// user to approve.
+func (s *Service) newCall(ctx context.Context, user database.User, form *callForm) (database.Call, error) {
+	// checked before moderation so blocked numbers cost nothing
+	if err := dnc.Check(ctx, s.db, user.ID, form.recipientNumber); err != nil {
+		return database.Call{}, err
+	}
+
+	// format the prompt, and this should tell us if we *want* to do this task
+	response, err := s.moderate(fmt.Sprintf("user wants to contact:%s, user wants to accomplish: %s, user provided outside context: %s.", form.recipientName, form.objective, form.otherContext))
+	if err != nil {
+		fmt.Printf("call request rejected by moderation: %s\n", response)
+		return database.Call{}, errRejected
+	}
+
+	// draft what the agent will say; if that fails the user writes it instead
+	plan, err := s.planCall(ctx, form.objective, form.recipientName, form.otherContext)
+	if err != nil {
+		fmt.Printf("newCall(couldnt draft call plan): %v\n", err)
+		plan = ai.CallPlan{Plan: fallbackPlan(form)}
+	}
+
+	// calls to someone in the user's contacts show up in that person's history
+	var contactID sql.NullInt64
+	if contact, err := s.db.GetContactByNumber(ctx, database.GetContactByNumberParams{UserID: user.ID, PhoneNumber: form.recipientNumber}); err == nil {
+		contactID = sql.NullInt64{Int64: contact.ID, Valid: true}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("newCall(couldnt look up contact): %v\n", err)
+	}
+
+	call, err := s.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:              user.ID,
+		PhoneNumber:         form.recipientNumber,
+		RecipientContext:    sql.NullString{String: form.recipientName, Valid: true},
+		Objective:           form.objective,
+		BackgroundContext:   sql.NullString{String: form.otherContext, Valid: form.otherContext != ""},
+		MaxAttempts:         form.maxAttempts,
+		RetrySpacingMinutes: form.retrySpacingMinutes,
+		RetryWindowStart:    sql.NullString{String: form.retryWindowStart, Valid: form.retryWindowStart != ""},
+		RetryWindowEnd:      sql.NullString{String: form.retryWindowEnd, Valid: form.retryWindowEnd != ""},
+		Record:              form.record,
+		OpeningLine:         sql.NullString{String: plan.OpeningLine, Valid: plan.OpeningLine != ""},
+		CallPlan:            sql.NullString{String: plan.Plan, Valid: true},
+		ContactID:           contactID,
+		TemplateID:          form.templateID,
+		TemplateVariables:   form.templateVariables,
+	})
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error saving call: %w", err)
+	}
+	s.notifyCreated(ctx, call)
+
+	return call, nil
+}
+
+// limits on what the user can approve as the call's opening line and plan
+const (
+	maxOpeningLineLength = 500
+	maxCallPlanLength    = 4000
+)
+
+// fallbackPlan is the plan offered for review when one couldn't be drafted.
+func fallbackPlan(form *callForm) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are calling %s.\n", form.recipientName)
+	fmt.Fprintf(&b, "Your objective: %s\n", form.objective)
+	if form.otherContext != "" {
+		fmt.Fprintf(&b, "Other context: %s\n", form.otherContext)
+	}
+	return b.String()
+}
+
+// HandleConfirmCall shows the drafted opening line and plan of a call that
+// hasn't been approved yet, for the user to edit or approve.
+func (s *Service) HandleConfirmCall(w http.ResponseWriter, r *http.Request) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("HandleConfirmCall: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+	if call.ApprovedAt.Valid {
+		http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
+		return
 	}
 
-	// create prompt for the first thing to say to the user when they pick up the phone.
+	pages.ConfirmCall(call, "").Render(r.Context(), w)
+}
+
+// HandleApproveCall saves the opening line and plan the user approved and
+// queues the call to be dialed. Edits are moderated like the original request.
+func (s *Service) HandleApproveCall(w http.ResponseWriter, r *http.Request) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("HandleApproveCall: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+	if call.ApprovedAt.Valid || call.Status.String != statusPending {
+		http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
+		return
+	}
+	if s.refusingCalls(w) {
+		http.Error(w, drainingMessage, http.StatusServiceUnavailable)
+		return
+	}
+
+	openingLine, plan := strings.TrimSpace(r.FormValue("openingLine")), strings.TrimSpace(r.FormValue("callPlan"))
+	if problem := validatePlan(openingLine, plan); problem != "" {
+		call.OpeningLine = sql.NullString{String: openingLine, Valid: openingLine != ""}
+		call.CallPlan = sql.NullString{String: plan, Valid: true}
+		w.WriteHeader(http.StatusBadRequest)
+		pages.ConfirmCall(call, problem).Render(r.Context(), w)
+		return
+	}
+
+	if openingLine != call.OpeningLine.String || plan != call.CallPlan.String {
+		response, err := s.moderate(fmt.Sprintf("user wants to contact:%s, user wants to accomplish: %s, the agent will open with: %s, the agent's plan: %s.", call.RecipientContext.String, call.Objective, openingLine, plan))
+		if err != nil {
+			fmt.Printf("edited call plan rejected by moderation: %s\n", response)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+
+			resp := map[string]interface{}{
+				"error":   "forbidden",
+				"message": "Request violates Terms of Service",
+			}
+
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+	}
+
+	_, err = s.db.ApproveCall(r.Context(), database.ApproveCallParams{
+		OpeningLine: sql.NullString{String: openingLine, Valid: openingLine != ""},
+		CallPlan:    sql.NullString{String: plan, Valid: true},
+		ID:          call.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// approved by another request in the meantime
+		http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleApproveCall(couldnt approve call %d): %v\n", call.ID, err)
+		http.Error(w, "could not approve call", http.StatusInternalServerError)
+		return
+	}
 
 	// make the call
+	if err := s.enqueuePlacement(r.Context(), call.ID, s.now()); err != nil {
+		fmt.Printf("HandleApproveCall(couldnt queue call %d): %v\n", call.ID, err)
+		http.Error(w, "could not start call", http.StatusInternalServerError)
+		return
+	}
 
-	pages.Home().Render(r.Context(), w) // change to return call-status page
+	http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
 }
 
-// validateCallForm checks the request for call form values,
-func validateCallForm(r *http.Request) (*callForm, error) {
+// validatePlan checks an opening line and plan from the confirmation form,
+// returning what is wrong with them for the user, or "" if nothing is.
+func validatePlan(openingLine, plan string) string {
+	switch {
+	case plan == "":
+		return "The call plan can't be empty."
+	case len(plan) > maxCallPlanLength:
+		return fmt.Sprintf("The call plan must be under %d characters.", maxCallPlanLength)
+	case len(openingLine) > maxOpeningLineLength:
+		return fmt.Sprintf("The opening line must be under %d characters.", maxOpeningLineLength)
+	}
+	return ""
+}
+
+// HandleCallStatus renders the status page of one of the current user's calls,
+// with the history of every attempt made so far, what the agent learned and
+// any recordings.
+func (s *Service) HandleCallStatus(w http.ResponseWriter, r *http.Request) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("HandleCallStatus: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	attempts, err := s.db.ListCallAttempts(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleCallStatus(couldnt list attempts of call %d): %v\n", call.ID, err)
+		http.Error(w, "could not load call", http.StatusInternalServerError)
+		return
+	}
+
+	facts, err := s.db.ListCallFacts(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleCallStatus(couldnt list facts of call %d): %v\n", call.ID, err)
+		http.Error(w, "could not load call", http.StatusInternalServerError)
+		return
+	}
+
+	recordings, err := s.db.ListRecordingsByCall(r.Context(), call.ID)
+	if err != nil {
+		fmt.Printf("HandleCallStatus(couldnt list recordings of call %d): %v\n", call.ID, err)
+		http.Error(w, "could not load call", http.StatusInternalServerError)
+		return
+	}
+
+	pages.CallStatus(call, attempts, facts, recordings).Render(r.Context(), w)
+}
+
+// ownedCall loads the call named in the path, if it belongs to the current user.
+func (s *Service) ownedCall(r *http.Request) (database.Call, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error parsing call id %q: %w", r.PathValue("id"), err)
+	}
+
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		return database.Call{}, err
+	}
+
+	call, err := s.db.GetCall(r.Context(), id)
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error loading call %d: %w", id, err)
+	}
+	if call.UserID != user.ID {
+		return database.Call{}, errNotOwner
+	}
+
+	return call, nil
+}
+
+// HandleStatusWebhook records the provider's progress reports for an attempt:
+// ringing, answered, and how it ended. Once an attempt is over it is queued to
+// be billed and, if needed, retried.
+func (s *Service) HandleStatusWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, event, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	if terminal(attempt.Status) || event.Status == "" {
+		// duplicate or out of order delivery
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var err error
+	switch {
+	case terminal(event.Status):
+		var reason string
+		if event.Status == attemptFailed {
+			reason = "provider reported the call failed"
+		}
+		err = s.endAttempt(r.Context(), attempt, event.Status, reason, event.Duration)
+	case event.Status == attemptInProgress:
+		_, err = s.db.MarkCallAttemptAnswered(r.Context(), attempt.ID)
+	default:
+		_, err = s.db.UpdateCallAttemptStatus(r.Context(), database.UpdateCallAttemptStatusParams{
+			Status: event.Status,
+			ID:     attempt.ID,
+		})
+	}
+	if err != nil {
+		log.Printf("calls: error recording %s for attempt %d: %v", event.Status, attempt.ID, err)
+		http.Error(w, "could not record status", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAnswerWebhook is fetched by the provider when the recipient picks up,
+// and replies with the agent's opening line, or a voicemail for machines.
+func (s *Service) HandleAnswerWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, event, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	call, err := s.db.GetCall(r.Context(), attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading call %d on answer: %v", attempt.CallID, err)
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+
+	if answered, err := s.db.MarkCallAttemptAnswered(r.Context(), attempt.ID); err != nil {
+		log.Printf("calls: error marking attempt %d answered: %v", attempt.ID, err)
+	} else {
+		attempt = answered
+	}
+
+	s.respondWith(w, s.answer(r.Context(), call, attempt, event))
+}
+
+// HandleTurnWebhook is posted to each time the recipient finishes speaking (or
+// stays quiet), and replies with what the agent says next.
+func (s *Service) HandleTurnWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, event, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	call, err := s.db.GetCall(r.Context(), attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading call %d on turn: %v", attempt.CallID, err)
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+
+	s.respondWith(w, s.turn(r.Context(), call, attempt, event))
+}
+
+// webhookAttempt verifies a provider webhook and loads the attempt it is about.
+// It writes the error response itself when ok is false.
+func (s *Service) webhookAttempt(w http.ResponseWriter, r *http.Request) (database.CallAttempt, Event, bool) {
+	if s.provider == nil {
+		http.Error(w, "telephony is not configured", http.StatusServiceUnavailable)
+		return database.CallAttempt{}, Event{}, false
+	}
+
+	event, err := s.provider.ParseEvent(r)
+	if err != nil {
+		log.Printf("calls: rejected telephony webhook: %v", err)
+		http.Error(w, "invalid webhook", http.StatusForbidden)
+		return database.CallAttempt{}, Event{}, false
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return database.CallAttempt{}, Event{}, false
+	}
+
+	attempt, err := s.db.GetCallAttempt(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return database.CallAttempt{}, Event{}, false
+	}
+	if err != nil {
+		log.Printf("calls: error loading attempt %d for webhook: %v", id, err)
+		http.Error(w, "could not load attempt", http.StatusInternalServerError)
+		return database.CallAttempt{}, Event{}, false
+	}
+
+	// legs the call dialed, such as a transfer, report their own id with the
+	// attempt's as their parent
+	if attempt.ProviderCallID.Valid && event.ProviderCallID != "" && attempt.ProviderCallID.String != event.ProviderCallID && attempt.ProviderCallID.String != event.ParentCallID {
+		log.Printf("calls: webhook for attempt %d came from provider call %s, expected %s", id, event.ProviderCallID, attempt.ProviderCallID.String)
+		http.NotFound(w, r)
+		return database.CallAttempt{}, Event{}, false
+	}
+
+	return attempt, event, true
+}
+
+func (s *Service) respondWith(w http.ResponseWriter, instructions []Instruction) {
+	if err := s.provider.Respond(w, instructions); err != nil {
+		log.Printf("calls: error responding to telephony webhook: %v", err)
+	}
+}
+
+// fieldError is a call form value that didn't pass validation, with the
+// problem worded for whoever filled in the form.
+type fieldError struct {
+	field   string // the form field's name
+	problem string
+	err     error
+}
+
+func (e *fieldError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("error validating %s: %s: %v", e.field, e.problem, e.err)
+	}
+	return fmt.Sprintf("error validating %s: %s", e.field, e.problem)
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// validateCallForm checks the call form values, read with field by the names
+// of the home page's form inputs. Invalid values are a *fieldError.
+func validateCallForm(field func(name string) string) (*callForm, error) {
 
 	// getting form values, save to variables
-	phoneNum, recipientInfo, objective, otherContext := r.FormValue("recipientPhoneNumber"), r.FormValue("recipientContext"), r.FormValue("objective"), r.FormValue("otherContext")
+	phoneNum, recipientInfo, objective, otherContext := field("recipientPhoneNumber"), field("recipientContext"), field("objective"), field("otherContext")
 
-	// check basic lengths
-	if len(phoneNum) == 0 || len(objective) == 0 || len(recipientInfo) == 0 {
-		return nil, fmt.Errorf("error while validating call form data lengeth of phoneNum, objective, or recipientInfo: %d, %d, %d\n", len(phoneNum), len(objective), len(recipientInfo))
+	// check basic lengths; a template picked on the form brings its own objective
+	switch {
+	case len(phoneNum) == 0:
+		return nil, &fieldError{field: "recipientPhoneNumber", problem: "Enter the number to call."}
+	case len(recipientInfo) == 0:
+		return nil, &fieldError{field: "recipientContext", problem: "Say who you're calling."}
+	case len(objective) == 0 && field("templateId") == "":
+		return nil, &fieldError{field: "objective", problem: "Say what the call should get done."}
 	}
 
-	err := validatePhoneNumber(phoneNum)
+	// numbers without a country code are read as dialed from the US
+	number, err := phone.Parse(phoneNum, defaultPhoneRegion)
 	if err != nil {
-		return nil, fmt.Errorf("error validating phoneNum from user, %s: %w", phoneNum, err)
+		return nil, &fieldError{field: "recipientPhoneNumber", problem: fmt.Sprintf("%q isn't a number we can call.", phoneNum), err: err}
 	}
 
 	thisCallData := callForm{
-		recipientNumber: phoneNum,
+		recipientNumber: number.E164,
 		recipientName:   recipientInfo,
 		objective:       objective,
 		otherContext:    otherContext,
+		record:          field("record") == "on",
+	}
+
+	if err := validateRetrySettings(field, &thisCallData); err != nil {
+		return nil, err
 	}
 
 	return &thisCallData, nil
 }
 
-// validatePhoneNumber is meant to check for errors with the phone number received from the user.
-// Signalwire requires it to be an int, so we don't return an int, no need
-func validatePhoneNumber(number string) error {
-	_, err := strconv.Atoi(number)
-	if err != nil {
-		return fmt.Errorf("error converting phone number from user into integer: %w\n", err)
+// validateRetrySettings reads the optional retry settings from the call form.
+// Left blank, a call is dialed once.
+func validateRetrySettings(field func(name string) string, form *callForm) error {
+	form.maxAttempts = defaultAttemptsCount
+	if value := strings.TrimSpace(field("maxAttempts")); value != "" {
+		attempts, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || attempts < 1 || attempts > maxAttemptsLimit {
+			return &fieldError{field: "maxAttempts", problem: fmt.Sprintf("Attempts must be from 1 to %d, not %q.", maxAttemptsLimit, value)}
+		}
+		form.maxAttempts = attempts
 	}
 
-	if len(number) != 10 {
-		return fmt.Errorf("error with phone number from user, not 10 digits as requred: %s\n", number)
+	form.retrySpacingMinutes = defaultRetrySpacing
+	if value := strings.TrimSpace(field("retrySpacingMinutes")); value != "" {
+		spacing, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || spacing < minRetrySpacing || spacing > maxRetrySpacing {
+			return &fieldError{field: "retrySpacingMinutes", problem: fmt.Sprintf("Retries must be %d to %d minutes apart, not %q.", minRetrySpacing, maxRetrySpacing, value)}
+		}
+		form.retrySpacingMinutes = spacing
+	}
+
+	start, end := strings.TrimSpace(field("retryWindowStart")), strings.TrimSpace(field("retryWindowEnd"))
+	if start == "" && end == "" {
+		return nil
+	}
+	if start == "" {
+		return &fieldError{field: "retryWindowStart", problem: "The retry window needs a start as well as an end."}
+	}
+	if end == "" {
+		return &fieldError{field: "retryWindowEnd", problem: "The retry window needs an end as well as a start."}
+	}
+	if _, err := parseClock(start); err != nil {
+		return &fieldError{field: "retryWindowStart", problem: fmt.Sprintf("Retry window times look like 09:00, not %q.", start), err: err}
+	}
+	if _, err := parseClock(end); err != nil {
+		return &fieldError{field: "retryWindowEnd", problem: fmt.Sprintf("Retry window times look like 17:30, not %q.", end), err: err}
 	}
+	form.retryWindowStart, form.retryWindowEnd = start, end
 
 	return nil
 }
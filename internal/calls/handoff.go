@@ -0,0 +1,260 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// system log lines about handing a call off to its owner
+const (
+	handoffLogFormat         = "handed off to the user at %s: %s"
+	handoffEndedLogFormat    = "the user's leg of the call ended after %s"
+	handoffCanceledLogPrefix = "handoff canceled: "
+)
+
+const (
+	handoffHoldFormat = "Please hold while I connect you with %s."
+	handoffMissedLine = "I'm sorry, I wasn't able to reach them. Can I take a message instead?"
+)
+
+var (
+	errNoVerifiedPhone  = errors.New("user has no verified phone number to hand the call off to")
+	errAlreadyHandedOff = errors.New("call was already handed off")
+)
+
+// startHandoff checks that an attempt can be handed off to the call's owner,
+// claims it so it only happens once, and logs when it happened. It returns
+// the owner, whose verified phone the recipient is connected to.
+func (s *Service) startHandoff(ctx context.Context, call database.Call, attempt database.CallAttempt, reason string) (database.User, error) {
+	user, err := s.db.GetUser(ctx, call.UserID)
+	if err != nil {
+		return database.User{}, fmt.Errorf("error loading owner of call %d to hand off to: %w", call.ID, err)
+	}
+	if !user.PhoneVerifiedAt.Valid || user.PhoneNumber.String == "" {
+		return database.User{}, errNoVerifiedPhone
+	}
+
+	if _, err := s.db.StartHandoff(ctx, attempt.ID); errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, errAlreadyHandedOff
+	} else if err != nil {
+		return database.User{}, fmt.Errorf("error starting handoff of attempt %d: %w", attempt.ID, err)
+	}
+
+	s.logCall(ctx, call.ID, logSystem, fmt.Sprintf(handoffLogFormat, s.now().UTC().Format(time.RFC3339), reason))
+	return user, nil
+}
+
+// cancelHandoff undoes startHandoff when the user's phone couldn't be
+// connected, so the call can be handed off again later.
+func (s *Service) cancelHandoff(ctx context.Context, call database.Call, attempt database.CallAttempt, reason string) {
+	if err := s.db.CancelHandoff(ctx, attempt.ID); err != nil {
+		log.Printf("calls: error canceling handoff of attempt %d: %v", attempt.ID, err)
+	}
+	s.logCall(ctx, call.ID, logSystem, handoffCanceledLogPrefix+reason)
+}
+
+// handoffTransfer connects an attempt to the user's phone. The user hears
+// what the call is about before being put through, and how long their leg
+// lasted is reported back to be billed.
+func handoffTransfer(attempt database.CallAttempt, user database.User) Transfer {
+	return Transfer{
+		To:         user.PhoneNumber.String,
+		WhisperURL: attemptURL(attempt.ID, "whisper"),
+		ActionURL:  attemptURL(attempt.ID, "handoff"),
+	}
+}
+
+// handoffWhisper is what the user hears when they pick up a handoff: who the
+// call is with, what it is about, and anything the agent noted so far.
+func (s *Service) handoffWhisper(ctx context.Context, call database.Call) []Instruction {
+	recipient := call.RecipientContext.String
+	if recipient == "" {
+		recipient = call.PhoneNumber
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "This is goDial, connecting you to your call with %s about: %s.", recipient, strings.TrimRight(call.Objective, ".!? "))
+
+	facts, err := s.db.ListCallFacts(ctx, call.ID)
+	if err != nil {
+		log.Printf("calls: error loading facts of call %d for handoff: %v", call.ID, err)
+	}
+	for i, fact := range facts {
+		if i == 0 {
+			b.WriteString(" So far the agent noted:")
+		}
+		fmt.Fprintf(&b, " %s is %s.", strings.ReplaceAll(fact.Key, "_", " "), fact.Value)
+	}
+
+	b.WriteString(" The agent has left the call.")
+	return []Instruction{Say{Text: b.String()}}
+}
+
+// endHandoff records how the user's leg of a handoff went. That leg is a
+// second call, so its connected time is billed on its own, on top of the
+// recipient's leg billed with the attempt. When the user didn't pick up the
+// agent comes back on the line.
+func (s *Service) endHandoff(ctx context.Context, call database.Call, attempt database.CallAttempt, event Event) []Instruction {
+	if event.DialedDuration <= 0 {
+		s.cancelHandoff(ctx, call, attempt, "the user didn't pick up, the agent is back on the line")
+		s.logCall(ctx, call.ID, logAgent, handoffMissedLine)
+		return []Instruction{Say{Text: handoffMissedLine}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+	}
+
+	seconds := int64(event.DialedDuration.Seconds())
+	minutes := connectedMinutes(seconds)
+	err := s.chargeMinutes(ctx, call, minutes, "handoff", fmt.Sprintf("handoff:%d", attempt.ID), func(q *database.Queries) error {
+		return q.EndHandoff(ctx, database.EndHandoffParams{
+			HandoffSeconds:       seconds,
+			HandoffBilledMinutes: minutes,
+			ID:                   attempt.ID,
+		})
+	})
+	if err != nil {
+		log.Printf("calls: ERROR billing handoff of attempt %d: %v", attempt.ID, err)
+		s.logCall(ctx, call.ID, logSystem, "failed to bill the handoff: "+err.Error())
+	}
+
+	s.logCall(ctx, call.ID, logSystem, fmt.Sprintf(handoffEndedLogFormat, event.DialedDuration))
+	return []Instruction{Hangup{}}
+}
+
+// liveAttempt finds the attempt of a call that is connected right now.
+func (s *Service) liveAttempt(ctx context.Context, call database.Call) (database.CallAttempt, bool, error) {
+	attempts, err := s.db.ListCallAttempts(ctx, call.ID)
+	if err != nil {
+		return database.CallAttempt{}, false, fmt.Errorf("error listing attempts of call %d: %w", call.ID, err)
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].Status == attemptInProgress && attempts[i].ProviderCallID.Valid {
+			return attempts[i], true, nil
+		}
+	}
+	return database.CallAttempt{}, false, nil
+}
+
+// HandleTakeover lets the user take over one of their calls while it is
+// connected: the recipient is asked to hold and put through to the user's
+// verified phone, and the agent drops off.
+func (s *Service) HandleTakeover(w http.ResponseWriter, r *http.Request) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("HandleTakeover: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+	if s.provider == nil {
+		http.Error(w, "telephony is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	attempt, ok, err := s.liveAttempt(r.Context(), call)
+	if err != nil {
+		fmt.Printf("HandleTakeover: %v\n", err)
+		http.Error(w, "could not load call", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "this call isn't connected right now", http.StatusConflict)
+		return
+	}
+
+	_, err = s.startHandoff(r.Context(), call, attempt, "taken over by the user")
+	switch {
+	case errors.Is(err, errNoVerifiedPhone):
+		http.Error(w, "verify your phone number in settings to take over calls", http.StatusBadRequest)
+		return
+	case errors.Is(err, errAlreadyHandedOff):
+		http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
+		return
+	case err != nil:
+		fmt.Printf("HandleTakeover: %v\n", err)
+		http.Error(w, "could not take over call", http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.provider.Redirect(r.Context(), attempt.ProviderCallID.String, attemptURL(attempt.ID, "takeover")); err != nil {
+		fmt.Printf("HandleTakeover(couldnt redirect attempt %d): %v\n", attempt.ID, err)
+		s.cancelHandoff(r.Context(), call, attempt, "could not reach the live call")
+		http.Error(w, "could not reach the call", http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/calls/%d", call.ID), http.StatusSeeOther)
+}
+
+// HandleTakeoverWebhook is fetched by a live call the user took over, and
+// puts the recipient through to them.
+func (s *Service) HandleTakeoverWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, _, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	call, err := s.db.GetCall(r.Context(), attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading call %d on takeover: %v", attempt.CallID, err)
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+	if !attempt.HandoffAt.Valid {
+		// the takeover was canceled, so the conversation carries on
+		s.respondWith(w, []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn")}})
+		return
+	}
+
+	user, err := s.db.GetUser(r.Context(), call.UserID)
+	if err != nil {
+		log.Printf("calls: error loading owner of call %d on takeover: %v", call.ID, err)
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+
+	hold := fmt.Sprintf(handoffHoldFormat, s.callerName(r.Context(), call))
+	s.logCall(r.Context(), call.ID, logAgent, hold)
+	s.respondWith(w, []Instruction{Say{Text: hold}, handoffTransfer(attempt, user)})
+}
+
+// HandleWhisperWebhook is fetched when the user picks up a handoff, and tells
+// them what the call is about before they are put through.
+func (s *Service) HandleWhisperWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, _, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	call, err := s.db.GetCall(r.Context(), attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading call %d on whisper: %v", attempt.CallID, err)
+		s.respondWith(w, nil)
+		return
+	}
+
+	s.respondWith(w, s.handoffWhisper(r.Context(), call))
+}
+
+// HandleHandoffWebhook is posted to when the user's leg of a handoff ends,
+// or never connected.
+func (s *Service) HandleHandoffWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, event, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+
+	call, err := s.db.GetCall(r.Context(), attempt.CallID)
+	if err != nil {
+		log.Printf("calls: error loading call %d on handoff end: %v", attempt.CallID, err)
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+
+	s.respondWith(w, s.endHandoff(r.Context(), call, attempt, event))
+}
@@ -0,0 +1,239 @@
+package calls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"goDial/internal/ai"
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyUserPhone gives the test user a verified phone number to hand calls
+// off to.
+func (ts *testService) verifyUserPhone(t *testing.T) {
+	t.Helper()
+	_, err := ts.db.ExecContext(context.Background(), "UPDATE users SET phone_number = '+15551234567', phone_verified_at = CURRENT_TIMESTAMP WHERE id = ?", ts.user.ID)
+	require.NoError(t, err)
+}
+
+// answeredCall places a call and has the recipient pick up.
+func (ts *testService) answeredCall(t *testing.T) (database.Call, database.CallAttempt) {
+	t.Helper()
+	call := ts.createCall(t, 1)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "status": {attemptInProgress}})
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{"sid": {"fake-1"}})
+	return call, ts.attempts(t, call.ID)[0]
+}
+
+func systemLogs(t *testing.T, ts *testService, callID int64) []string {
+	t.Helper()
+	logs, err := ts.db.ListCallLogs(context.Background(), callID)
+	require.NoError(t, err)
+	var system []string
+	for _, entry := range logs {
+		if entry.MessageType == logSystem {
+			system = append(system, entry.Content)
+		}
+	}
+	return system
+}
+
+func TestService_AgentHandsOffToUser(t *testing.T) {
+	tests := []struct {
+		name                   string
+		userLeg                url.Values
+		expected               func(attempt database.CallAttempt) []Instruction
+		expectedHandedOff      bool
+		expectedHandoffMinutes int64
+		expectedMinutesLeft    int64
+		expectedReason         string
+	}{
+		{
+			name:    "the user talks to the recipient",
+			userLeg: url.Values{"sid": {"fake-1"}, "dial_status": {attemptCompleted}, "dial_duration": {"90"}},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Hangup{}}
+			},
+			expectedHandedOff:      true,
+			expectedHandoffMinutes: 2,
+			// 200 seconds with the recipient and 90 with the user
+			expectedMinutesLeft: 10 - 4 - 2,
+			expectedReason:      "handed off to you",
+		},
+		{
+			name:    "the user doesn't pick up",
+			userLeg: url.Values{"sid": {"fake-1"}, "dial_status": {attemptNoAnswer}},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: handoffMissedLine}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedMinutesLeft: 10 - 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.verifyUserPhone(t)
+			ts.agent.replies = []ai.Response{
+				{Text: "Hi, I'm calling to wish you a happy birthday!"},
+				{
+					Text:  "Of course, one moment.",
+					Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolTransferToUser, ai.TransferToUserInput{Reason: "wants to thank them in person"})},
+				},
+			}
+
+			call, attempt := ts.answeredCall(t)
+			_, err := ts.db.RecordCallFact(ctx, database.RecordCallFactParams{CallID: call.ID, Key: "party_time", Value: "7pm"})
+			require.NoError(t, err)
+
+			ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "speech": {"Thanks! Can I talk to them?"}})
+			assert.Equal(t, []Instruction{
+				Say{Text: "Of course, one moment."},
+				Transfer{To: "+15551234567", WhisperURL: attemptURL(attempt.ID, "whisper"), ActionURL: attemptURL(attempt.ID, "handoff")},
+				Hangup{},
+			}, ts.provider.lastResponse())
+			assert.Contains(t, systemLogs(t, ts, call.ID), "handed off to the user at 2026-10-18T18:00:00Z: the agent transferred the call, wants to thank them in person")
+
+			// the user's leg has its own id, with the attempt's as its parent
+			w := ts.webhook(t, ts.HandleWhisperWebhook, attempt.ID, url.Values{"sid": {"fake-child"}, "parent_sid": {"fake-1"}})
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, []Instruction{
+				Say{Text: "This is goDial, connecting you to your call with +13336664444 about: say happy birthday. So far the agent noted: party time is 7pm. The agent has left the call."},
+			}, ts.provider.lastResponse())
+
+			ts.webhook(t, ts.HandleHandoffWebhook, attempt.ID, tt.userLeg)
+			assert.Equal(t, tt.expected(attempt), ts.provider.lastResponse())
+
+			ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "status": {attemptCompleted}, "duration": {"200"}})
+			ts.runJobs(t)
+
+			attempt = ts.attempts(t, call.ID)[0]
+			assert.Equal(t, tt.expectedHandedOff, attempt.HandoffAt.Valid)
+			assert.Equal(t, int64(4), attempt.BilledMinutes)
+			assert.Equal(t, tt.expectedHandoffMinutes, attempt.HandoffBilledMinutes)
+
+			user, err := ts.db.GetUser(ctx, ts.user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMinutesLeft, minutesOf(user))
+
+			call, err = ts.db.GetCall(ctx, call.ID)
+			require.NoError(t, err)
+			assert.Equal(t, statusCompleted, call.Status.String)
+			assert.Equal(t, tt.expectedReason, call.StatusReason.String)
+
+			// a repeated report of the user's leg isn't billed twice
+			if tt.expectedHandedOff {
+				ts.webhook(t, ts.HandleHandoffWebhook, attempt.ID, tt.userLeg)
+				user, err := ts.db.GetUser(ctx, ts.user.ID)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedMinutesLeft, minutesOf(user))
+			}
+		})
+	}
+}
+
+func TestHandleTakeover(t *testing.T) {
+	tests := []struct {
+		name              string
+		unverified        bool
+		unanswered        bool
+		takeovers         int
+		redirectErr       error
+		expectedCode      int
+		expectedRedirects int
+		expectedHandedOff bool
+	}{
+		{
+			name:              "takes over a live call",
+			takeovers:         1,
+			expectedCode:      http.StatusSeeOther,
+			expectedRedirects: 1,
+			expectedHandedOff: true,
+		},
+		{
+			name:              "a second takeover is a no-op",
+			takeovers:         2,
+			expectedCode:      http.StatusSeeOther,
+			expectedRedirects: 1,
+			expectedHandedOff: true,
+		},
+		{
+			name:         "needs a verified phone number",
+			unverified:   true,
+			takeovers:    1,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "only while the call is connected",
+			unanswered:   true,
+			takeovers:    1,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "the live call can't be reached",
+			takeovers:    1,
+			redirectErr:  errors.New("call already ended"),
+			expectedCode: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ts.agent.replies = lines("Hi, I'm calling to wish you a happy birthday!")
+			ts.provider.redirectErr = tt.redirectErr
+			if tt.unverified {
+				_, err := ts.db.ExecContext(context.Background(), "UPDATE users SET phone_number = '+15551234567' WHERE id = ?", ts.user.ID)
+				require.NoError(t, err)
+			} else {
+				ts.verifyUserPhone(t)
+			}
+
+			var call database.Call
+			var attempt database.CallAttempt
+			if tt.unanswered {
+				call = ts.createCall(t, 1)
+				ts.runJobs(t)
+				attempt = ts.attempts(t, call.ID)[0]
+			} else {
+				call, attempt = ts.answeredCall(t)
+			}
+
+			var w *httptest.ResponseRecorder
+			for range tt.takeovers {
+				req := ts.userRequest(t, "POST", "/calls/x/takeover", nil)
+				req.SetPathValue("id", strconv.FormatInt(call.ID, 10))
+				w = httptest.NewRecorder()
+				ts.HandleTakeover(w, req)
+			}
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Len(t, ts.provider.redirects, tt.expectedRedirects)
+
+			attempt = ts.attempts(t, call.ID)[0]
+			assert.Equal(t, tt.expectedHandedOff, attempt.HandoffAt.Valid)
+			if !tt.expectedHandedOff {
+				return
+			}
+
+			assert.Equal(t, "fake-1 "+attemptURL(attempt.ID, "takeover"), ts.provider.redirects[0])
+			assert.Contains(t, systemLogs(t, ts, call.ID), "handed off to the user at 2026-10-18T18:00:00Z: taken over by the user")
+
+			ts.webhook(t, ts.HandleTakeoverWebhook, attempt.ID, url.Values{"sid": {"fake-1"}})
+			assert.Equal(t, []Instruction{
+				Say{Text: "Please hold while I connect you with Test User."},
+				Transfer{To: "+15551234567", WhisperURL: attemptURL(attempt.ID, "whisper"), ActionURL: attemptURL(attempt.ID, "handoff")},
+			}, ts.provider.lastResponse())
+		})
+	}
+}
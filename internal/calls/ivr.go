@@ -0,0 +1,134 @@
+package calls
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/database"
+	"goDial/internal/ivr"
+)
+
+// system log lines marking where an attempt is in an automated phone system
+const (
+	menuLog             = "phone menu detected"
+	menuChoiceLogPrefix = "menu option pressed: "
+	holdLogPrefix       = "on hold since "
+	humanLog            = "a person answered, switching to conversation"
+)
+
+// limits that keep a call from waiting in a phone system forever
+const (
+	maxHold        = 30 * time.Minute
+	maxMenuPresses = 8
+)
+
+// how long to listen before handling silence; hold music can go on a while
+// between announcements, and menus pause before repeating themselves
+const (
+	holdListenTimeout = 30 * time.Second
+	menuListenTimeout = 10 * time.Second
+)
+
+type navigationMode int
+
+const (
+	modeConversation navigationMode = iota
+	modeMenu
+	modeHold
+)
+
+// navigation is where an attempt is in an automated phone system, worked out
+// from its system log lines.
+type navigation struct {
+	mode      navigationMode
+	holdSince time.Time
+	presses   int
+}
+
+func navigationOf(logs []database.CallLog) navigation {
+	var nav navigation
+	for _, entry := range logs {
+		if entry.MessageType != logSystem {
+			continue
+		}
+		switch {
+		case entry.Content == menuLog:
+			nav.mode = modeMenu
+		case strings.HasPrefix(entry.Content, holdLogPrefix):
+			nav.mode = modeHold
+			nav.holdSince, _ = time.Parse(time.RFC3339, strings.TrimPrefix(entry.Content, holdLogPrefix))
+		case entry.Content == humanLog:
+			nav = navigation{}
+		case strings.HasPrefix(entry.Content, menuChoiceLogPrefix):
+			nav.presses++
+		default:
+			if use, ok := parseToolLog(entry.Content); ok && use.Name == ai.ToolSendDTMF && !use.IsError {
+				nav.presses++
+			}
+		}
+	}
+	return nav
+}
+
+// navigate handles a turn when the recipient's side is, or has just turned
+// out to be, an automated phone system: it picks menu options toward the
+// objective, waits quietly through hold, and hands back to the agent once a
+// person picks up. ok is false when the turn is ordinary conversation, or a
+// menu the engine can't read, which the agent handles instead.
+func (s *Service) navigate(ctx context.Context, call database.Call, attempt database.CallAttempt, speech string) ([]Instruction, bool) {
+	logs, err := s.db.ListCallLogs(ctx, call.ID)
+	if err != nil {
+		log.Printf("calls: error loading transcript of call %d: %v", call.ID, err)
+		return nil, false
+	}
+	nav := navigationOf(attemptLogs(logs, attempt))
+
+	if speech == "" {
+		if nav.mode != modeHold {
+			return nil, false
+		}
+		if s.now().Sub(nav.holdSince) > maxHold {
+			s.logCall(ctx, call.ID, logSystem, fmt.Sprintf("hanging up after %d minutes on hold", int(maxHold.Minutes())))
+			return []Instruction{Hangup{}}, true
+		}
+		return []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: holdListenTimeout}}, true
+	}
+
+	switch ivr.Classify(speech) {
+	case ivr.Menu:
+		if nav.mode != modeMenu {
+			s.logCall(ctx, call.ID, logSystem, menuLog)
+		}
+		if nav.presses >= maxMenuPresses {
+			s.logCall(ctx, call.ID, logSystem, "hanging up, could not get through the phone menu")
+			return []Instruction{Hangup{}}, true
+		}
+		option, ok := ivr.Choose(ivr.ParseMenu(speech), call.Objective)
+		if !ok {
+			return nil, false
+		}
+		s.logCall(ctx, call.ID, logSystem, menuChoiceLogPrefix+fmt.Sprintf("%s (%s)", option.Digit, option.Label))
+		return []Instruction{
+			SendDigits{Digits: option.Digit},
+			Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: menuListenTimeout},
+		}, true
+
+	case ivr.Hold:
+		if nav.mode != modeHold {
+			s.logCall(ctx, call.ID, logSystem, holdLogPrefix+s.now().UTC().Format(time.RFC3339))
+		}
+		return []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: holdListenTimeout}}, true
+	}
+
+	if nav.mode == modeConversation {
+		return nil, false
+	}
+	// the person who picked up didn't hear the announcement the phone system
+	// answered, so it is played again before the agent speaks
+	s.logCall(ctx, call.ID, logSystem, humanLog)
+	return append([]Instruction{s.announce(ctx, call)}, s.respond(ctx, call, attempt)...), true
+}
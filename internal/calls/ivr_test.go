@@ -0,0 +1,174 @@
+package calls
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// menuNode is one prompt of a scripted phone system. Pressing a key in
+// options moves to that node; a node without options is where a person picks
+// up, or hold when hold is set.
+type menuNode struct {
+	prompt  string
+	options map[string]string
+	hold    bool
+}
+
+// dentistMenu is the phone system of a dental office, where rescheduling is
+// two menus deep and ends on hold.
+var dentistMenu = map[string]menuNode{
+	"main": {
+		prompt:  "Thank you for calling Bright Smiles Dental. For billing, press 1. For appointments, press 2. For all other questions, press 0.",
+		options: map[string]string{"1": "billing", "2": "appointments", "0": "front desk"},
+	},
+	"appointments": {
+		prompt:  "To book a new patient visit, press 1. To reschedule or cancel an appointment, press 2.",
+		options: map[string]string{"1": "new patients", "2": "scheduling"},
+	},
+	"scheduling": {
+		prompt: "All of our schedulers are currently assisting other patients. Please stay on the line and your call will be answered in the order it was received.",
+		hold:   true,
+	},
+	"billing":      {prompt: "Billing, this is Pat."},
+	"new patients": {prompt: "New patients, this is Lee."},
+	"front desk":   {prompt: "Front desk, this is Kim."},
+}
+
+// walkMenu plays the scripted phone system to the engine from node until it
+// stops pressing keys, and returns the nodes it went through.
+func walkMenu(t *testing.T, ts *testService, attempt database.CallAttempt, menu map[string]menuNode, node string) []string {
+	t.Helper()
+	path := []string{node}
+	for range 10 {
+		ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {menu[node].prompt}})
+
+		var pressed string
+		for _, instruction := range ts.provider.lastResponse() {
+			if digits, ok := instruction.(SendDigits); ok {
+				pressed = digits.Digits
+			}
+		}
+		next, ok := menu[node].options[pressed]
+		if !ok {
+			return path
+		}
+		node = next
+		path = append(path, node)
+	}
+	t.Fatalf("still in the menu after 10 steps: %v", path)
+	return nil
+}
+
+func TestService_NavigatesPhoneMenus(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = lines(
+		"Hi, I'm calling to reschedule an appointment.",
+		"Hi Dana, I'm calling to reschedule Sam Lee's cleaning appointment to next week.",
+	)
+
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:      ts.user.ID,
+		PhoneNumber: "+13336664444",
+		Objective:   "Reschedule Sam Lee's cleaning appointment to next week",
+		MaxAttempts: 1,
+	})
+	require.NoError(t, err)
+	ts.approve(t, call)
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+
+	path := walkMenu(t, ts, attempt, dentistMenu, "main")
+	assert.Equal(t, []string{"main", "appointments", "scheduling"}, path)
+	assert.Equal(t, []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: holdListenTimeout}}, ts.provider.lastResponse(), "hold is waited out quietly")
+
+	// hold music with nothing said keeps the call waiting
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{})
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{})
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"Thank you for holding, your call is important to us."}})
+	assert.Equal(t, []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: holdListenTimeout}}, ts.provider.lastResponse())
+	require.Len(t, ts.agent.prompts, 1, "the agent isn't asked anything while navigating")
+
+	// a person picks up: they hear the announcement, then the agent
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"Scheduling, this is Dana, how can I help?"}})
+	assert.Equal(t, []Instruction{
+		Say{Text: "Hello, this is an automated AI assistant calling on behalf of Test User.", Language: "en-US"},
+		Say{Text: "Hi Dana, I'm calling to reschedule Sam Lee's cleaning appointment to next week."},
+		Listen{ActionURL: attemptURL(attempt.ID, "turn")},
+	}, ts.provider.lastResponse())
+
+	logs, err := ts.db.ListCallLogs(ctx, call.ID)
+	require.NoError(t, err)
+	var system []string
+	for _, entry := range logs {
+		if entry.MessageType == logSystem {
+			system = append(system, entry.Content)
+		}
+	}
+	assert.Contains(t, system, menuLog)
+	assert.Contains(t, system, menuChoiceLogPrefix+"2 (appointments)")
+	assert.Contains(t, system, menuChoiceLogPrefix+"2 (reschedule or cancel an appointment)")
+	assert.Contains(t, system, humanLog)
+	assert.Equal(t, modeConversation, navigationOf(logs).mode)
+}
+
+func TestService_PhoneMenuLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		menu     map[string]menuNode
+		advance  time.Duration
+		expected []Instruction
+	}{
+		{
+			name:     "gives up after a long hold",
+			menu:     map[string]menuNode{"main": {prompt: "Please hold for the next available representative.", hold: true}},
+			advance:  maxHold + time.Minute,
+			expected: []Instruction{Hangup{}},
+		},
+		{
+			name:     "gives up on a menu that goes in circles",
+			menu:     map[string]menuNode{"main": {prompt: "For billing, press 1. For appointments, press 2."}},
+			expected: []Instruction{Hangup{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.agent.replies = lines("Hi, I'm calling about an appointment.")
+
+			call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+				UserID:      ts.user.ID,
+				PhoneNumber: "+13336664444",
+				Objective:   "Confirm my appointment",
+				MaxAttempts: 1,
+			})
+			require.NoError(t, err)
+			ts.approve(t, call)
+			ts.runJobs(t)
+			attempt := ts.attempts(t, call.ID)[0]
+			ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+
+			if tt.menu["main"].hold {
+				walkMenu(t, ts, attempt, tt.menu, "main")
+				start := ts.now()
+				ts.now = func() time.Time { return start.Add(tt.advance) }
+				ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{})
+			} else {
+				for range maxMenuPresses + 1 {
+					ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {tt.menu["main"].prompt}})
+				}
+			}
+			assert.Equal(t, tt.expected, ts.provider.lastResponse())
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package calls
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Provider is the telephony vendor that actually places calls. The call engine
+// only speaks in terms of this interface, so vendors (and the fake used in
+// tests) can be swapped without touching the conversation logic.
+type Provider interface {
+	// Dial starts an outbound call and returns the provider's id for it.
+	Dial(ctx context.Context, req DialRequest) (string, error)
+
+	// Hangup ends a call that is still in progress.
+	Hangup(ctx context.Context, providerCallID string) error
+
+	// FetchRecording downloads a recording the provider reported in
+	// Event.RecordingURL, and returns it with its content type. The caller
+	// closes it.
+	FetchRecording(ctx context.Context, recordingURL string) (io.ReadCloser, string, error)
+
+	// Redirect interrupts a call that is still in progress and has it fetch
+	// its next instructions from url, a path on this server.
+	Redirect(ctx context.Context, providerCallID, url string) error
+
+	// ParseEvent verifies that a webhook request really came from the provider
+	// and translates it into an Event.
+	ParseEvent(r *http.Request) (Event, error)
+
+	// Respond writes instructions for a live call in reply to a webhook.
+	Respond(w http.ResponseWriter, instructions []Instruction) error
+}
+
+// DialRequest describes an outbound call. The URLs are paths on this server;
+// the provider is responsible for turning them into absolute callback URLs.
+type DialRequest struct {
+	To          string
+	AnswerURL   string        // fetched when the recipient picks up
+	StatusURL   string        // notified as the call rings, connects and ends
+	RingTimeout time.Duration // how long to ring before giving up as no-answer
+	Record      bool          // record the call once it is answered
+	// RecordingURL is notified once the recording of a recorded call is
+	// ready to download, with Event.RecordingURL set
+	RecordingURL string
+	// DetectMachine asks the provider to tell people from answering
+	// machines, reported in Event.AnsweredBy when the call is answered
+	DetectMachine bool
+}
+
+// who answered a call, as reported by the provider's machine detection
+const (
+	answeredHuman   = "human"
+	answeredMachine = "machine"
+)
+
+// Attempt statuses, shared by the provider events and the call_attempts table.
+const (
+	attemptQueued     = "queued"
+	attemptDialing    = "dialing"
+	attemptRinging    = "ringing"
+	attemptInProgress = "in_progress"
+	attemptCompleted  = "completed"
+	attemptBusy       = "busy"
+	attemptNoAnswer   = "no_answer"
+	attemptFailed     = "failed"
+	attemptCanceled   = "canceled"
+)
+
+// Event is a provider webhook translated into provider-neutral terms.
+type Event struct {
+	ProviderCallID string
+	Status         string        // one of the attempt statuses above
+	Duration       time.Duration // connected time, reported once the call ends
+	Speech         string        // what the recipient said, for conversation turns
+	Digits         string        // keys the recipient pressed
+	AnsweredBy     string        // answeredHuman, answeredMachine, or "" if unknown
+	GreetingEnded  bool          // for machines, the provider waited out the greeting and beep
+	// ParentCallID is set on webhooks about a leg the call dialed, such as
+	// a transfer, and is the ProviderCallID of the call that dialed it
+	ParentCallID string
+	// DialedStatus and DialedDuration report how a Transfer's leg ended,
+	// in the event posted to its ActionURL
+	DialedStatus   string
+	DialedDuration time.Duration
+	// RecordingURL, RecordingID and RecordingDuration describe a finished
+	// recording, in the event posted to DialRequest.RecordingURL
+	RecordingURL      string
+	RecordingID       string
+	RecordingDuration time.Duration
+}
+
+// terminal reports whether a call status means the call is over.
+func terminal(status string) bool {
+	switch status {
+	case attemptCompleted, attemptBusy, attemptNoAnswer, attemptFailed, attemptCanceled:
+		return true
+	}
+	return false
+}
+
+// Instruction is one step of what a live call should do next.
+type Instruction interface {
+	instruction()
+}
+
+// Say speaks text to the recipient, in Language (a BCP 47 locale such as
+// "es-MX") when set, or the provider's default voice otherwise.
+type Say struct {
+	Text     string
+	Language string
+}
+
+// Listen waits for the recipient to speak (or press keys) and posts what it
+// heard to ActionURL. If nothing is heard before Timeout, ActionURL is still
+// called, with an empty event.
+type Listen struct {
+	ActionURL string
+	Timeout   time.Duration
+}
+
+// Hangup ends the call.
+type Hangup struct{}
+
+// Pause waits in silence, e.g. for a voicemail beep.
+type Pause struct {
+	Length time.Duration
+}
+
+// SendDigits plays keypad tones on the line, to get through phone menus.
+// Digits are 0-9, * and #; a w waits half a second.
+type SendDigits struct {
+	Digits string
+}
+
+// Transfer connects the recipient to another number. WhisperURL, when set,
+// is fetched for instructions played to the other number before it is
+// connected. Once that leg ends the call carries on with ActionURL's reply
+// when set, or the next instruction otherwise.
+type Transfer struct {
+	To         string
+	WhisperURL string
+	ActionURL  string
+}
+
+func (Say) instruction()        {}
+func (Listen) instruction()     {}
+func (Hangup) instruction()     {}
+func (Pause) instruction()      {}
+func (SendDigits) instruction() {}
+func (Transfer) instruction()   {}
@@ -0,0 +1,206 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/jobs"
+	"goDial/internal/recordings"
+)
+
+type recordingJob struct {
+	AttemptID       int64  `json:"attempt_id"`
+	URL             string `json:"url"`
+	RecordingID     string `json:"recording_id"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
+
+// StoreRecordings sets where call recordings are downloaded to, and how long
+// they are kept there. Without a store, recordings stay with the provider.
+func (s *Service) StoreRecordings(store recordings.Store, retention time.Duration) {
+	s.recordings = store
+	s.recordingRetention = retention
+}
+
+// HandleRecordingWebhook is posted to when the recording of an attempt is
+// ready, and queues it to be downloaded.
+func (s *Service) HandleRecordingWebhook(w http.ResponseWriter, r *http.Request) {
+	attempt, event, ok := s.webhookAttempt(w, r)
+	if !ok {
+		return
+	}
+	if event.RecordingURL == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if s.recordings == nil {
+		log.Printf("calls: recording storage is not configured, leaving recording of attempt %d with the provider", attempt.ID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if _, err := s.queue.Enqueue(r.Context(), jobFetchRecording, recordingJob{
+		AttemptID:       attempt.ID,
+		URL:             event.RecordingURL,
+		RecordingID:     event.RecordingID,
+		DurationSeconds: int64(event.RecordingDuration.Seconds()),
+	}); err != nil {
+		log.Printf("calls: error queueing recording of attempt %d: %v", attempt.ID, err)
+		http.Error(w, "could not queue recording", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fetchRecording downloads a recording from the provider into storage, and
+// schedules a purge for when it is past retention.
+func (s *Service) fetchRecording(ctx context.Context, job database.Job) error {
+	var payload recordingJob
+	if err := jobs.Decode(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	if s.recordings == nil || s.provider == nil {
+		return jobs.Permanent(errors.New("recording storage or telephony is not configured"))
+	}
+
+	if _, err := s.db.GetRecordingByAttempt(ctx, payload.AttemptID); err == nil {
+		// already downloaded by an earlier run
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error checking for recording of attempt %d: %w", payload.AttemptID, err)
+	}
+
+	attempt, err := s.db.GetCallAttempt(ctx, payload.AttemptID)
+	if err != nil {
+		return fmt.Errorf("error loading attempt %d to save its recording: %w", payload.AttemptID, err)
+	}
+
+	body, contentType, err := s.provider.FetchRecording(ctx, payload.URL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	key := recordingKey(attempt, contentType)
+	size, err := s.recordings.Save(ctx, key, body)
+	if err != nil {
+		return err
+	}
+
+	recording, err := s.db.CreateRecording(ctx, database.CreateRecordingParams{
+		CallID:              attempt.CallID,
+		CallAttemptID:       attempt.ID,
+		ProviderRecordingID: sql.NullString{String: payload.RecordingID, Valid: payload.RecordingID != ""},
+		StorageKey:          key,
+		ContentType:         contentType,
+		SizeBytes:           size,
+		DurationSeconds:     payload.DurationSeconds,
+		RecordedAt:          s.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("error saving recording of attempt %d: %w", attempt.ID, err)
+	}
+
+	_, err = s.queue.EnqueueAt(ctx, jobPurgeRecordings, struct{}{}, recording.RecordedAt.Add(s.recordingRetention))
+	return err
+}
+
+// recordingKey is where an attempt's recording is kept in storage.
+func recordingKey(attempt database.CallAttempt, contentType string) string {
+	var ext string
+	switch contentType {
+	case "audio/mpeg":
+		ext = ".mp3"
+	case "audio/wav", "audio/x-wav":
+		ext = ".wav"
+	}
+	return fmt.Sprintf("calls/%d/attempt-%d%s", attempt.CallID, attempt.ID, ext)
+}
+
+// PurgeExpiredRecordings deletes recordings that are past retention from
+// storage. A purge is queued for when each recording expires; this also runs
+// at startup, to catch up after downtime or a shorter retention setting.
+func (s *Service) PurgeExpiredRecordings(ctx context.Context) error {
+	if s.recordings == nil {
+		return nil
+	}
+
+	expired, err := s.db.ListRecordingsToPurge(ctx, s.now().UTC().Add(-s.recordingRetention))
+	if err != nil {
+		return fmt.Errorf("error listing expired recordings: %w", err)
+	}
+	for _, recording := range expired {
+		if err := s.recordings.Delete(ctx, recording.StorageKey); err != nil {
+			return err
+		}
+		if err := s.db.MarkRecordingPurged(ctx, recording.ID); err != nil {
+			return fmt.Errorf("error marking recording %d purged: %w", recording.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) purgeRecordings(ctx context.Context, job database.Job) error {
+	return s.PurgeExpiredRecordings(ctx)
+}
+
+// HandleRecording plays back a recording of one of the current user's calls.
+// Range requests are supported, so players can seek.
+func (s *Service) HandleRecording(w http.ResponseWriter, r *http.Request) {
+	call, err := s.ownedCall(r)
+	if err != nil {
+		fmt.Printf("HandleRecording: %v\n", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("recordingID"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	recording, err := s.db.GetRecording(r.Context(), id)
+	if err != nil || recording.CallID != call.ID {
+		fmt.Printf("HandleRecording(couldnt find recording %d of call %d): %v\n", id, call.ID, err)
+		http.NotFound(w, r)
+		return
+	}
+	if recording.PurgedAt.Valid {
+		http.Error(w, "this recording was deleted when its retention period ended", http.StatusGone)
+		return
+	}
+	if s.recordings == nil {
+		http.Error(w, "recording storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	object, err := s.recordings.Open(r.Context(), recording.StorageKey)
+	if errors.Is(err, recordings.ErrNotFound) {
+		fmt.Printf("HandleRecording(recording %d missing from storage): %v\n", recording.ID, err)
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleRecording(couldnt open recording %d): %v\n", recording.ID, err)
+		http.Error(w, "could not load recording", http.StatusInternalServerError)
+		return
+	}
+	defer object.Close()
+
+	// long recordings take longer to play than the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		fmt.Printf("HandleRecording(couldnt clear write deadline): %v\n", err)
+	}
+	w.Header().Set("Content-Type", recording.ContentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, path.Base(recording.StorageKey), object.ModTime, object)
+}
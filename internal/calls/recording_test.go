@@ -0,0 +1,174 @@
+package calls
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/recordings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeRecordingURL = "https://example.signalwire.com/recordings/RE1"
+
+// recordedCall places a recorded call that ends with its recording ready.
+func (ts *testService) recordedCall(t *testing.T) (database.Call, database.CallAttempt) {
+	t.Helper()
+	call := ts.createCall(t, 1)
+	_, err := ts.db.ExecContext(context.Background(), "UPDATE calls SET record = 1 WHERE id = ?", call.ID)
+	require.NoError(t, err)
+	ts.runJobs(t)
+
+	attempt := ts.attempts(t, call.ID)[0]
+	require.Len(t, ts.provider.dials, 1)
+	assert.True(t, ts.provider.dials[0].Record)
+	assert.Equal(t, attemptURL(attempt.ID, "recording"), ts.provider.dials[0].RecordingURL)
+
+	ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "status": {attemptCompleted}, "duration": {"30"}})
+	w := ts.webhook(t, ts.HandleRecordingWebhook, attempt.ID, url.Values{
+		"sid":                {"fake-1"},
+		"recording_url":      {fakeRecordingURL},
+		"recording_sid":      {"RE1"},
+		"recording_duration": {"28"},
+	})
+	require.Equal(t, http.StatusNoContent, w.Code)
+	return call, attempt
+}
+
+func (ts *testService) playRecording(ctx context.Context, callID, recordingID int64, rangeHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/calls/x/recordings/y", nil).WithContext(ctx)
+	req.SetPathValue("id", strconv.FormatInt(callID, 10))
+	req.SetPathValue("recordingID", strconv.FormatInt(recordingID, 10))
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	w := httptest.NewRecorder()
+	ts.HandleRecording(w, req)
+	return w
+}
+
+func TestService_Recordings(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	ts.agent.replies = lines("Hi, I'm calling to wish you a happy birthday!")
+	store := recordings.NewLocalStore(t.TempDir())
+	ts.StoreRecordings(store, 7*24*time.Hour)
+
+	call, attempt := ts.recordedCall(t)
+	ts.runJobs(t)
+
+	saved, err := ts.db.ListRecordingsByCall(ctx, call.ID)
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	recording := saved[0]
+	assert.Equal(t, attempt.ID, recording.CallAttemptID)
+	assert.Equal(t, "RE1", recording.ProviderRecordingID.String)
+	assert.Equal(t, "audio/mpeg", recording.ContentType)
+	assert.Equal(t, int64(len(fakeRecordingURL)), recording.SizeBytes)
+	assert.Equal(t, int64(28), recording.DurationSeconds)
+
+	// a repeated callback doesn't download the recording twice
+	ts.webhook(t, ts.HandleRecordingWebhook, attempt.ID, url.Values{"sid": {"fake-1"}, "recording_url": {fakeRecordingURL}})
+	ts.runJobs(t)
+	saved, err = ts.db.ListRecordingsByCall(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Len(t, saved, 1)
+
+	owner := auth.WithUser(ctx, ts.user)
+	w := ts.playRecording(owner, call.ID, recording.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "audio/mpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, fakeRecordingURL, w.Body.String())
+
+	// players seek with Range requests
+	w = ts.playRecording(owner, call.ID, recording.ID, "bytes=0-4")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "https", w.Body.String())
+
+	// only the owner can play it back
+	other, err := ts.db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "Other User"})
+	require.NoError(t, err)
+	w = ts.playRecording(auth.WithUser(ctx, other), call.ID, recording.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// nothing is purged before retention ends
+	require.NoError(t, ts.PurgeExpiredRecordings(ctx))
+	w = ts.playRecording(owner, call.ID, recording.ID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	start := ts.now()
+	ts.now = func() time.Time { return start.Add(8 * 24 * time.Hour) }
+	require.NoError(t, ts.PurgeExpiredRecordings(ctx))
+
+	recording, err = ts.db.GetRecording(ctx, recording.ID)
+	require.NoError(t, err)
+	assert.True(t, recording.PurgedAt.Valid)
+	_, err = store.Open(ctx, recording.StorageKey)
+	assert.ErrorIs(t, err, recordings.ErrNotFound)
+
+	w = ts.playRecording(owner, call.ID, recording.ID, "")
+	assert.Equal(t, http.StatusGone, w.Code)
+}
+
+func TestService_RecordingDownloadFails(t *testing.T) {
+	tests := []struct {
+		name           string
+		noStore        bool
+		fetchErr       error
+		expectedQueued bool
+	}{
+		{
+			name:           "provider errors are retried",
+			fetchErr:       errors.New("recording not ready"),
+			expectedQueued: true,
+		},
+		{
+			name:    "without storage recordings stay with the provider",
+			noStore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.agent.replies = lines("Hi, I'm calling to wish you a happy birthday!")
+			ts.provider.fetchErr = tt.fetchErr
+			if !tt.noStore {
+				ts.StoreRecordings(recordings.NewLocalStore(t.TempDir()), recordings.DefaultRetention)
+			}
+
+			call, _ := ts.recordedCall(t)
+
+			queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+			require.NoError(t, err)
+			var fetches []database.Job
+			for _, job := range queued {
+				if job.Kind == jobFetchRecording {
+					fetches = append(fetches, job)
+				}
+			}
+			if !tt.expectedQueued {
+				assert.Empty(t, fetches)
+				return
+			}
+
+			require.Len(t, fetches, 1)
+			err = ts.fetchRecording(ctx, fetches[0])
+			assert.ErrorIs(t, err, tt.fetchErr)
+
+			saved, err := ts.db.ListRecordingsByCall(ctx, call.ID)
+			require.NoError(t, err)
+			assert.Empty(t, saved)
+		})
+	}
+}
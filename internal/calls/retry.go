@@ -0,0 +1,92 @@
+package calls
+
+import (
+	"fmt"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// limits on the retry settings a user can pick for a call
+const (
+	maxAttemptsLimit     = 5
+	defaultRetrySpacing  = 15
+	minRetrySpacing      = 5
+	maxRetrySpacing      = 24 * 60
+	windowClockLayout    = "15:04"
+	minutesPerDay        = 24 * 60
+	defaultAttemptsCount = 1
+)
+
+// maxAttempts is how many times a call may be dialed in total.
+func maxAttempts(call database.Call) int64 {
+	if call.MaxAttempts < 1 {
+		return defaultAttemptsCount
+	}
+	return call.MaxAttempts
+}
+
+// nextAttemptAt decides when to dial a call again after attemptsMade attempts,
+// the last of which ended at after. It honours the call's spacing and pushes
+// the retry forward into the call's allowed window. ok is false once the call
+// has used up its attempts.
+func nextAttemptAt(call database.Call, attemptsMade int64, after time.Time, loc *time.Location) (next time.Time, ok bool) {
+	if attemptsMade >= maxAttempts(call) {
+		return time.Time{}, false
+	}
+
+	spacing := call.RetrySpacingMinutes
+	if spacing <= 0 {
+		spacing = defaultRetrySpacing
+	}
+	next = after.Add(time.Duration(spacing) * time.Minute)
+
+	if !call.RetryWindowStart.Valid || !call.RetryWindowEnd.Valid {
+		return next, true
+	}
+	start, err := parseClock(call.RetryWindowStart.String)
+	if err != nil {
+		return next, true
+	}
+	end, err := parseClock(call.RetryWindowEnd.String)
+	if err != nil {
+		return next, true
+	}
+
+	return fitWindow(next, start, end, loc), true
+}
+
+// fitWindow returns t if it falls inside the daily window [start, end), given
+// in minutes after midnight in loc, or else the next time the window opens. A
+// window whose start is after its end runs overnight.
+func fitWindow(t time.Time, start, end int, loc *time.Location) time.Time {
+	if start == end {
+		return t
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	inside := minute >= start && minute < end
+	if start > end {
+		inside = minute >= start || minute < end
+	}
+	if inside {
+		return t
+	}
+
+	opens := time.Date(local.Year(), local.Month(), local.Day(), start/60, start%60, 0, 0, loc)
+	if !opens.After(local) {
+		opens = opens.AddDate(0, 0, 1)
+	}
+	return opens
+}
+
+// parseClock reads an "HH:MM" time of day as minutes after midnight.
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse(windowClockLayout, clock)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing time of day %q, expected HH:MM: %w", clock, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
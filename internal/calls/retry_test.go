@@ -0,0 +1,216 @@
+package calls
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextAttemptAt(t *testing.T) {
+	loc := time.UTC
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, loc)
+	}
+	window := func(start, end string) (sql.NullString, sql.NullString) {
+		return sql.NullString{String: start, Valid: true}, sql.NullString{String: end, Valid: true}
+	}
+
+	tests := []struct {
+		name         string
+		maxAttempts  int64
+		spacing      int64
+		windowStart  string
+		windowEnd    string
+		attemptsMade int64
+		after        time.Time
+		expected     time.Time
+		expectedOK   bool
+	}{
+		{
+			name:         "single attempt is never retried",
+			maxAttempts:  1,
+			spacing:      15,
+			attemptsMade: 1,
+			after:        at(18, 10, 0),
+			expectedOK:   false,
+		},
+		{
+			name:         "all attempts used",
+			maxAttempts:  3,
+			spacing:      15,
+			attemptsMade: 3,
+			after:        at(18, 10, 0),
+			expectedOK:   false,
+		},
+		{
+			name:         "spacing without a window",
+			maxAttempts:  3,
+			spacing:      30,
+			attemptsMade: 1,
+			after:        at(18, 10, 0),
+			expected:     at(18, 10, 30),
+			expectedOK:   true,
+		},
+		{
+			name:         "zero spacing falls back to the default",
+			maxAttempts:  2,
+			attemptsMade: 1,
+			after:        at(18, 10, 0),
+			expected:     at(18, 10, 15),
+			expectedOK:   true,
+		},
+		{
+			name:         "retry inside the window",
+			maxAttempts:  2,
+			spacing:      15,
+			windowStart:  "09:00",
+			windowEnd:    "17:00",
+			attemptsMade: 1,
+			after:        at(18, 12, 0),
+			expected:     at(18, 12, 15),
+			expectedOK:   true,
+		},
+		{
+			name:         "retry after the window waits for the next day",
+			maxAttempts:  2,
+			spacing:      15,
+			windowStart:  "09:00",
+			windowEnd:    "17:00",
+			attemptsMade: 1,
+			after:        at(18, 16, 50),
+			expected:     at(19, 9, 0),
+			expectedOK:   true,
+		},
+		{
+			name:         "retry before the window waits for it to open",
+			maxAttempts:  2,
+			spacing:      15,
+			windowStart:  "09:00",
+			windowEnd:    "17:00",
+			attemptsMade: 1,
+			after:        at(18, 7, 0),
+			expected:     at(18, 9, 0),
+			expectedOK:   true,
+		},
+		{
+			name:         "overnight window, inside after midnight",
+			maxAttempts:  2,
+			spacing:      15,
+			windowStart:  "22:00",
+			windowEnd:    "02:00",
+			attemptsMade: 1,
+			after:        at(18, 0, 30),
+			expected:     at(18, 0, 45),
+			expectedOK:   true,
+		},
+		{
+			name:         "overnight window, outside waits until evening",
+			maxAttempts:  2,
+			spacing:      15,
+			windowStart:  "22:00",
+			windowEnd:    "02:00",
+			attemptsMade: 1,
+			after:        at(18, 12, 0),
+			expected:     at(18, 22, 0),
+			expectedOK:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := database.Call{MaxAttempts: tt.maxAttempts, RetrySpacingMinutes: tt.spacing}
+			if tt.windowStart != "" {
+				call.RetryWindowStart, call.RetryWindowEnd = window(tt.windowStart, tt.windowEnd)
+			}
+
+			next, ok := nextAttemptAt(call, tt.attemptsMade, tt.after, loc)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			if tt.expectedOK {
+				assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestValidateRetrySettings(t *testing.T) {
+	tests := []struct {
+		name            string
+		form            url.Values
+		expectError     bool
+		expectedMax     int64
+		expectedSpacing int64
+		expectedWindow  [2]string
+	}{
+		{
+			name:            "blank settings dial once",
+			form:            url.Values{},
+			expectedMax:     1,
+			expectedSpacing: defaultRetrySpacing,
+		},
+		{
+			name: "full settings",
+			form: url.Values{
+				"maxAttempts":         {"3"},
+				"retrySpacingMinutes": {"60"},
+				"retryWindowStart":    {"09:00"},
+				"retryWindowEnd":      {"18:30"},
+			},
+			expectedMax:     3,
+			expectedSpacing: 60,
+			expectedWindow:  [2]string{"09:00", "18:30"},
+		},
+		{
+			name:        "too many attempts",
+			form:        url.Values{"maxAttempts": {"6"}},
+			expectError: true,
+		},
+		{
+			name:        "zero attempts",
+			form:        url.Values{"maxAttempts": {"0"}},
+			expectError: true,
+		},
+		{
+			name:        "spacing too short",
+			form:        url.Values{"retrySpacingMinutes": {"1"}},
+			expectError: true,
+		},
+		{
+			name:        "window missing its end",
+			form:        url.Values{"retryWindowStart": {"09:00"}},
+			expectError: true,
+		},
+		{
+			name:        "window with a bad time",
+			form:        url.Values{"retryWindowStart": {"9am"}, "retryWindowEnd": {"17:00"}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/handleCallProcedure", strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			var form callForm
+			err := validateRetrySettings(req.FormValue, &form)
+
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMax, form.maxAttempts)
+			assert.Equal(t, tt.expectedSpacing, form.retrySpacingMinutes)
+			assert.Equal(t, tt.expectedWindow, [2]string{form.retryWindowStart, form.retryWindowEnd})
+		})
+	}
+}
@@ -1 +1,567 @@
 package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/auth"
+	"goDial/internal/callhours"
+	"goDial/internal/compliance"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/jobs"
+	"goDial/internal/phone"
+	"goDial/internal/recordings"
+	"goDial/internal/webhooks"
+)
+
+// Service places calls and runs them: it owns the placement and settlement
+// jobs, the telephony webhooks and the conversation with the recipient.
+type Service struct {
+	db          *database.DB
+	queue       *jobs.Queue
+	provider    Provider
+	agent       Agent
+	hours       *callhours.Policy
+	disclosures *compliance.Policy
+	now         func() time.Time
+	moderate    func(prompt string) (string, error)
+	planCall    func(ctx context.Context, objective, recipient, background string) (ai.CallPlan, error)
+	// draftVoicemail writes the message left when a call reaches voicemail
+	draftVoicemail func(ctx context.Context, objective, recipient, caller string) (string, error)
+	// recordings is where call recordings are downloaded to, nil to leave
+	// them with the provider
+	recordings         recordings.Store
+	recordingRetention time.Duration
+	// webhooks tells the user's own systems about their calls
+	webhooks *webhooks.Notifier
+	// draining is set once Drain starts, and stops new calls being dialed;
+	// wrappingUp has the agent say goodbye at its next turn
+	draining   atomic.Bool
+	wrappingUp atomic.Bool
+}
+
+// NewService creates the call service and registers its job handlers on queue.
+// provider may be nil when telephony isn't configured; calls then fail with a
+// clear reason instead of dialing. Moderation, call plans and voicemails use
+// the model cfg names.
+func NewService(db *database.DB, queue *jobs.Queue, provider Provider, agent Agent, cfg config.Config) *Service {
+	model := ai.New(cfg.AI)
+	s := &Service{
+		db:          db,
+		queue:       queue,
+		provider:    provider,
+		agent:       agent,
+		hours:       callhours.DefaultPolicy(),
+		disclosures: compliance.DefaultPolicy(),
+		now:         time.Now,
+		moderate:    model.CheckPromptValidity,
+		planCall:    model.PlanCall,
+
+		draftVoicemail:     model.DraftVoicemail,
+		recordingRetention: recordings.DefaultRetention,
+		webhooks:           webhooks.New(db, queue, cfg.Dev()),
+	}
+
+	queue.Register(jobPlaceCall, s.placeCall)
+	queue.Register(jobSettleAttempt, s.settleAttempt)
+	queue.Register(jobFetchRecording, s.fetchRecording)
+	queue.Register(jobPurgeRecordings, s.purgeRecordings)
+	queue.Register(jobDispatchCampaign, s.dispatchCampaign)
+
+	return s
+}
+
+// job kinds handled by the call service
+const (
+	jobPlaceCall     = "calls.place"
+	jobSettleAttempt = "calls.settle_attempt"
+	// recordings are downloaded once the provider has them ready, and
+	// purged once they are past retention
+	jobFetchRecording  = "calls.fetch_recording"
+	jobPurgeRecordings = "calls.purge_recordings"
+	// campaigns place their calls one dispatch at a time, paced
+	jobDispatchCampaign = "calls.dispatch_campaign"
+)
+
+type callJob struct {
+	CallID int64 `json:"call_id"`
+}
+
+type attemptJob struct {
+	AttemptID int64 `json:"attempt_id"`
+}
+
+// call statuses, matching the CHECK constraint on calls.status
+const (
+	statusPending    = "pending"
+	statusInProgress = "in_progress"
+	statusCompleted  = "completed"
+	statusFailed     = "failed"
+)
+
+// ringTimeout is how long a call rings before it counts as no-answer.
+const ringTimeout = 30 * time.Second
+
+// currentUser is the user a request is from, as Sessions.RequireUser or
+// RequireAPI resolved it. Every user route is wrapped in one of them.
+func (s *Service) currentUser(ctx context.Context) (database.User, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return database.User{}, errNoUser
+	}
+	return user, nil
+}
+
+// enqueuePlacement queues the next dial of a call for runAt.
+func (s *Service) enqueuePlacement(ctx context.Context, callID int64, runAt time.Time) error {
+	_, err := s.queue.EnqueueAt(ctx, jobPlaceCall, callJob{CallID: callID}, runAt)
+	return err
+}
+
+// recipientHours checks the call's recipient against the calling hours at the
+// service's current time, in their contact's time zone if one is set.
+func (s *Service) recipientHours(ctx context.Context, call database.Call) (callhours.Decision, error) {
+	number, err := phone.Parse(call.PhoneNumber, defaultPhoneRegion)
+	if err != nil {
+		return callhours.Decision{}, fmt.Errorf("error parsing number of call %d: %w", call.ID, err)
+	}
+	return s.hours.CheckInAt(number, s.contactZone(ctx, call), s.now())
+}
+
+// contactZone is the time zone of the call's contact, or nil if the call has
+// no contact or they have no time zone.
+func (s *Service) contactZone(ctx context.Context, call database.Call) *time.Location {
+	if !call.ContactID.Valid {
+		return nil
+	}
+	contact, err := s.db.GetContact(ctx, database.GetContactParams{ID: call.ContactID.Int64, UserID: call.UserID})
+	if err != nil {
+		log.Printf("calls: error loading contact of call %d: %v", call.ID, err)
+		return nil
+	}
+	if !contact.Timezone.Valid {
+		return nil
+	}
+	zone, err := time.LoadLocation(contact.Timezone.String)
+	if err != nil {
+		log.Printf("calls: contact %d has an unknown time zone: %v", contact.ID, err)
+		return nil
+	}
+	return zone
+}
+
+// deferLayout is how the time a deferred call will be placed is shown, in the
+// recipient's time zone.
+const deferLayout = "Mon Jan 2 3:04 PM MST"
+
+// placeCall dials the next attempt of a call.
+func (s *Service) placeCall(ctx context.Context, job database.Job) error {
+	var payload callJob
+	if err := jobs.Decode(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	call, err := s.db.GetCall(ctx, payload.CallID)
+	if err != nil {
+		return fmt.Errorf("error loading call %d to place: %w", payload.CallID, err)
+	}
+	if call.Status.String != statusPending {
+		// already dialing, or finished
+		return nil
+	}
+	if !call.ApprovedAt.Valid {
+		// only approval queues a call, so this job is stale
+		return nil
+	}
+	if s.draining.Load() {
+		// left for the next server to dial
+		return s.enqueuePlacement(ctx, call.ID, s.now().Add(drainDeferral))
+	}
+
+	if s.provider == nil {
+		return s.finish(ctx, call, statusFailed, "telephony provider is not configured")
+	}
+
+	user, err := s.db.GetUser(ctx, call.UserID)
+	if err != nil {
+		return fmt.Errorf("error loading owner of call %d: %w", call.ID, err)
+	}
+	if minutesOf(user) <= 0 {
+		return s.finish(ctx, call, statusFailed, "no minutes remaining")
+	}
+
+	// recipients can opt out between attempts
+	if err := dnc.Check(ctx, s.db, call.UserID, call.PhoneNumber); errors.Is(err, dnc.ErrBlocked) {
+		return s.finish(ctx, call, statusFailed, "number is on the do-not-call list")
+	} else if err != nil {
+		return err
+	}
+
+	// calls outside the recipient's calling hours wait for them to open
+	// rather than fail, whether they were just made or are being retried
+	hours, err := s.recipientHours(ctx, call)
+	if err != nil {
+		log.Printf("calls: %v", err)
+		return s.finish(ctx, call, statusFailed, "could not tell the recipient's local time")
+	}
+	if !hours.Allowed {
+		reason := fmt.Sprintf("outside calling hours, scheduled for %s", hours.Next.In(hours.Zone()).Format(deferLayout))
+		if _, err := s.db.SetCallStatus(ctx, database.SetCallStatusParams{
+			Status:       sql.NullString{String: statusPending, Valid: true},
+			StatusReason: sql.NullString{String: reason, Valid: true},
+			ID:           call.ID,
+		}); err != nil {
+			return fmt.Errorf("error deferring call %d: %w", call.ID, err)
+		}
+		return s.enqueuePlacement(ctx, call.ID, hours.Next)
+	}
+
+	made, err := s.db.CountCallAttempts(ctx, call.ID)
+	if err != nil {
+		return fmt.Errorf("error counting attempts of call %d: %w", call.ID, err)
+	}
+	if made >= maxAttempts(call) {
+		return s.finish(ctx, call, statusFailed, fmt.Sprintf("used all %d attempts", maxAttempts(call)))
+	}
+
+	attempt, err := s.db.CreateCallAttempt(ctx, database.CreateCallAttemptParams{
+		CallID:        call.ID,
+		AttemptNumber: made + 1,
+		ScheduledFor:  job.RunAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error recording attempt %d of call %d: %w", made+1, call.ID, err)
+	}
+
+	if _, err := s.db.SetCallStatus(ctx, database.SetCallStatusParams{
+		Status: sql.NullString{String: statusInProgress, Valid: true},
+		ID:     call.ID,
+	}); err != nil {
+		return fmt.Errorf("error marking call %d in progress: %w", call.ID, err)
+	}
+
+	providerCallID, err := s.provider.Dial(ctx, DialRequest{
+		To:            call.PhoneNumber,
+		AnswerURL:     attemptURL(attempt.ID, "answer"),
+		StatusURL:     attemptURL(attempt.ID, "status"),
+		RingTimeout:   ringTimeout,
+		Record:        call.Record,
+		RecordingURL:  attemptURL(attempt.ID, "recording"),
+		DetectMachine: true,
+	})
+	if err != nil {
+		// a failed dial is settled like any other failed attempt, so it
+		// follows the call's retry policy
+		log.Printf("calls: dialing attempt %d of call %d failed: %v", attempt.AttemptNumber, call.ID, err)
+		return s.endAttempt(ctx, attempt, attemptFailed, err.Error(), 0)
+	}
+
+	_, err = s.db.MarkCallAttemptDialing(ctx, database.MarkCallAttemptDialingParams{
+		ProviderCallID: sql.NullString{String: providerCallID, Valid: true},
+		ID:             attempt.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// the attempt ended while it was being dialed, interrupted by a drain
+		if err := s.provider.Hangup(ctx, providerCallID); err != nil {
+			log.Printf("calls: error hanging up interrupted attempt %d: %v", attempt.ID, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("error saving provider id %s for attempt %d: %w", providerCallID, attempt.ID, err))
+	}
+
+	return nil
+}
+
+// endAttempt records how an attempt ended and queues it to be settled.
+func (s *Service) endAttempt(ctx context.Context, attempt database.CallAttempt, status, reason string, duration time.Duration) error {
+	if _, err := s.db.EndCallAttempt(ctx, database.EndCallAttemptParams{
+		Status:          status,
+		Error:           sql.NullString{String: reason, Valid: reason != ""},
+		DurationSeconds: int64(duration.Seconds()),
+		ID:              attempt.ID,
+	}); err != nil {
+		return fmt.Errorf("error ending attempt %d: %w", attempt.ID, err)
+	}
+
+	if _, err := s.queue.Enqueue(ctx, jobSettleAttempt, attemptJob{AttemptID: attempt.ID}); err != nil {
+		return fmt.Errorf("error queueing settlement of attempt %d: %w", attempt.ID, err)
+	}
+	return nil
+}
+
+// settleAttempt bills a finished attempt and decides what happens to its
+// call: done, retried later, or failed for good.
+func (s *Service) settleAttempt(ctx context.Context, job database.Job) error {
+	var payload attemptJob
+	if err := jobs.Decode(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	attempt, err := s.db.GetCallAttempt(ctx, payload.AttemptID)
+	if err != nil {
+		return fmt.Errorf("error loading attempt %d to settle: %w", payload.AttemptID, err)
+	}
+	if !terminal(attempt.Status) {
+		return jobs.Permanent(fmt.Errorf("attempt %d is still %s", attempt.ID, attempt.Status))
+	}
+
+	call, err := s.db.GetCall(ctx, attempt.CallID)
+	if err != nil {
+		return fmt.Errorf("error loading call %d to settle: %w", attempt.CallID, err)
+	}
+
+	if err := s.bill(ctx, call, attempt); err != nil {
+		return err
+	}
+
+	// a settlement that runs twice must not schedule a second retry, so only
+	// the latest attempt of a call that is still in progress moves it on
+	made, err := s.db.CountCallAttempts(ctx, call.ID)
+	if err != nil {
+		return fmt.Errorf("error counting attempts of call %d: %w", call.ID, err)
+	}
+	if call.Status.String != statusInProgress || made != attempt.AttemptNumber {
+		return nil
+	}
+
+	outcome := describeOutcome(attempt.Status)
+	switch attempt.Status {
+	case attemptCompleted:
+		if !attempt.VoicemailLeftAt.Valid {
+			if err := s.setOutcome(ctx, call, outcomeConversation); err != nil {
+				return err
+			}
+			var reason string
+			if attempt.HandoffAt.Valid {
+				reason = "handed off to you"
+			}
+			return s.finish(ctx, call, statusCompleted, reason)
+		}
+
+		if err := s.setOutcome(ctx, call, outcomeVoicemail); err != nil {
+			return err
+		}
+		user, err := s.db.GetUser(ctx, call.UserID)
+		if err != nil {
+			return fmt.Errorf("error loading owner of call %d to settle: %w", call.ID, err)
+		}
+		if user.VoicemailCountsAsSuccess {
+			return s.finish(ctx, call, statusCompleted, "left a voicemail")
+		}
+		// the user wants to reach a person, so voicemail is retried like
+		// an unanswered call
+		outcome = "reached voicemail"
+	case attemptCanceled:
+		return s.finish(ctx, call, statusFailed, "call was canceled")
+	}
+
+	// retry windows are in the recipient's time, like calling hours
+	zone := time.Local
+	if hours, err := s.recipientHours(ctx, call); err == nil {
+		zone = hours.Zone()
+	}
+	next, ok := nextAttemptAt(call, attempt.AttemptNumber, s.now(), zone)
+	if !ok {
+		return s.finish(ctx, call, statusFailed, fmt.Sprintf("%s after %d attempt(s)", outcome, attempt.AttemptNumber))
+	}
+
+	if _, err := s.db.SetCallStatus(ctx, database.SetCallStatusParams{
+		Status:       sql.NullString{String: statusPending, Valid: true},
+		StatusReason: sql.NullString{String: outcome + ", retrying", Valid: true},
+		ID:           call.ID,
+	}); err != nil {
+		return fmt.Errorf("error marking call %d pending retry: %w", call.ID, err)
+	}
+	return s.enqueuePlacement(ctx, call.ID, next)
+}
+
+// finish moves a call into its final status.
+func (s *Service) finish(ctx context.Context, call database.Call, status, reason string) error {
+	_, err := s.db.FinishCall(ctx, database.FinishCallParams{
+		Status:       sql.NullString{String: status, Valid: true},
+		StatusReason: sql.NullString{String: reason, Valid: reason != ""},
+		ID:           call.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error marking call %d %s: %w", call.ID, status, err)
+	}
+	return s.finished(ctx, call)
+}
+
+// finished passes on that a call is over.
+func (s *Service) finished(ctx context.Context, call database.Call) error {
+	s.notifyFinished(ctx, call.ID)
+	// a finished call makes room for the next call of its campaign
+	if call.CampaignID.Valid {
+		return s.campaignCallFinished(ctx, call.CampaignID.Int64)
+	}
+	return nil
+}
+
+// canceledReason is the status reason of calls the user canceled.
+const canceledReason = "canceled by you"
+
+// errCallFinished is returned for canceling a call that is already over.
+var errCallFinished = errors.New("call already finished")
+
+// cancelCall fails a call that hasn't finished yet. A placement or retry still
+// queued finds it over and does nothing, and an attempt on the line is hung
+// up, to be billed for its connected time once the provider reports it ended.
+func (s *Service) cancelCall(ctx context.Context, call database.Call) error {
+	canceled, err := s.db.CancelCall(ctx, database.CancelCallParams{
+		StatusReason: sql.NullString{String: canceledReason, Valid: true},
+		ID:           call.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("error canceling call %d: %w", call.ID, err)
+	}
+	if canceled == 0 {
+		return errCallFinished
+	}
+	s.logCall(ctx, call.ID, logSystem, "call canceled by the user")
+
+	attempts, err := s.db.ListCallAttempts(ctx, call.ID)
+	if err != nil {
+		log.Printf("calls: error listing attempts of canceled call %d: %v", call.ID, err)
+	}
+	for _, attempt := range attempts {
+		if terminal(attempt.Status) || !attempt.ProviderCallID.Valid || s.provider == nil {
+			continue
+		}
+		if err := s.provider.Hangup(ctx, attempt.ProviderCallID.String); err != nil {
+			log.Printf("calls: error hanging up attempt %d of canceled call %d: %v", attempt.ID, call.ID, err)
+		}
+	}
+
+	return s.finished(ctx, call)
+}
+
+// setOutcome records how a call ended up, whatever its final status.
+func (s *Service) setOutcome(ctx context.Context, call database.Call, outcome string) error {
+	if err := s.db.SetCallOutcome(ctx, database.SetCallOutcomeParams{
+		Outcome: sql.NullString{String: outcome, Valid: true},
+		ID:      call.ID,
+	}); err != nil {
+		return fmt.Errorf("error recording outcome %s of call %d: %w", outcome, call.ID, err)
+	}
+	return nil
+}
+
+// bill charges the call's owner for the connected time of an attempt. Ringing,
+// busy signals and unanswered attempts are free.
+func (s *Service) bill(ctx context.Context, call database.Call, attempt database.CallAttempt) error {
+	return s.chargeAttempt(ctx, call, attempt, billableMinutes(attempt))
+}
+
+// chargeAttempt charges minutes for an attempt, once.
+func (s *Service) chargeAttempt(ctx context.Context, call database.Call, attempt database.CallAttempt, minutes int64) error {
+	if minutes == 0 {
+		return nil
+	}
+
+	return s.chargeMinutes(ctx, call, minutes, "call_attempt", fmt.Sprintf("call_attempt:%d", attempt.ID), func(q *database.Queries) error {
+		return q.SetCallAttemptBilledMinutes(ctx, database.SetCallAttemptBilledMinutesParams{
+			BilledMinutes: minutes,
+			ID:            attempt.ID,
+		})
+	})
+}
+
+// chargeMinutes deducts minutes from the call owner's balance and writes the
+// ledger entry in one transaction. reference makes the charge idempotent;
+// also runs alongside the charge, only if it was applied.
+func (s *Service) chargeMinutes(ctx context.Context, call database.Call, minutes int64, reason, reference string, also func(q *database.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting billing transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+	inserted, err := q.CreateLedgerEntry(ctx, database.CreateLedgerEntryParams{
+		UserID:    call.UserID,
+		CallID:    sql.NullInt64{Int64: call.ID, Valid: true},
+		Minutes:   -minutes,
+		Reason:    reason,
+		Reference: reference,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing ledger entry %s: %w", reference, err)
+	}
+	if inserted == 0 {
+		// already charged by an earlier run
+		return nil
+	}
+
+	if err := q.AdjustUserMinutes(ctx, database.AdjustUserMinutesParams{Minutes: -minutes, ID: call.UserID}); err != nil {
+		return fmt.Errorf("error deducting %d minutes from user %d: %w", minutes, call.UserID, err)
+	}
+	if also != nil {
+		if err := also(q); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing charge %s: %w", reference, err)
+	}
+	s.notifyBalance(ctx, call.UserID, minutes)
+	return nil
+}
+
+// billableMinutes rounds an attempt's connected time up to whole minutes.
+func billableMinutes(attempt database.CallAttempt) int64 {
+	if attempt.Status != attemptCompleted {
+		return 0
+	}
+	return connectedMinutes(attempt.DurationSeconds)
+}
+
+// connectedMinutes rounds connected seconds up to whole minutes.
+func connectedMinutes(seconds int64) int64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return (seconds + 59) / 60
+}
+
+// minutesOf reads the untyped users.minutes column.
+func minutesOf(user database.User) int64 {
+	switch m := user.Minutes.(type) {
+	case int64:
+		return m
+	case float64:
+		return int64(m)
+	}
+	return 0
+}
+
+func describeOutcome(status string) string {
+	switch status {
+	case attemptBusy:
+		return "line was busy"
+	case attemptNoAnswer:
+		return "no answer"
+	}
+	return "call failed"
+}
+
+func attemptURL(attemptID int64, action string) string {
+	return fmt.Sprintf("/webhooks/telephony/attempts/%d/%s", attemptID, action)
+}
+
+// errNotOwner is returned when a user asks for a call that isn't theirs.
+var errNotOwner = errors.New("call belongs to another user")
+
+// errNoUser is returned for a request that no session or API key vouched for.
+var errNoUser = errors.New("the request has no user")
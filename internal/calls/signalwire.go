@@ -1 +1,407 @@
 package calls
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"goDial/internal/config"
+)
+
+// SignalWire places calls through SignalWire's LaML (Twilio compatible) REST
+// api, and drives live calls with the LaML documents our webhooks return.
+type SignalWire struct {
+	ProjectID  string
+	Token      string
+	SpaceURL   string // e.g. example.signalwire.com
+	FromNumber string // the number calls are placed from
+	PublicURL  string // where SignalWire can reach this server, used for callbacks
+
+	client *http.Client
+}
+
+// NewSignalWire builds the SignalWire provider from the telephony settings. It
+// fails if any of them is missing.
+func NewSignalWire(cfg config.Telephony) (*SignalWire, error) {
+	sw := &SignalWire{
+		ProjectID:  cfg.ProjectID,
+		Token:      cfg.APIToken,
+		SpaceURL:   cfg.SpaceURL,
+		FromNumber: cfg.FromNumber,
+		PublicURL:  strings.TrimSuffix(cfg.PublicURL, "/"),
+		client:     &http.Client{Timeout: 15 * time.Second},
+	}
+
+	var missing []string
+	for name, value := range map[string]string{
+		"telephony.project_id":  sw.ProjectID,
+		"telephony.api_token":   sw.Token,
+		"telephony.space_url":   sw.SpaceURL,
+		"telephony.from_number": sw.FromNumber,
+		"telephony.public_url":  sw.PublicURL,
+	} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("signalwire not configured, missing: %s", strings.Join(missing, ", "))
+	}
+
+	return sw, nil
+}
+
+func (s *SignalWire) callsEndpoint() string {
+	return fmt.Sprintf("https://%s/api/laml/2010-04-01/Accounts/%s/Calls", s.SpaceURL, s.ProjectID)
+}
+
+// Dial places an outbound call and returns its CallSid.
+func (s *SignalWire) Dial(ctx context.Context, req DialRequest) (string, error) {
+	form := url.Values{}
+	form.Set("From", s.FromNumber)
+	form.Set("To", req.To)
+	form.Set("Url", s.PublicURL+req.AnswerURL)
+	form.Set("Method", http.MethodPost)
+	form.Set("StatusCallback", s.PublicURL+req.StatusURL)
+	form.Set("StatusCallbackMethod", http.MethodPost)
+	for _, event := range []string{"initiated", "ringing", "answered", "completed"} {
+		form.Add("StatusCallbackEvent", event)
+	}
+	if req.RingTimeout > 0 {
+		form.Set("Timeout", strconv.Itoa(int(req.RingTimeout.Seconds())))
+	}
+	if req.Record {
+		form.Set("Record", "true")
+		if req.RecordingURL != "" {
+			form.Set("RecordingStatusCallback", s.PublicURL+req.RecordingURL)
+			form.Set("RecordingStatusCallbackMethod", http.MethodPost)
+			form.Set("RecordingStatusCallbackEvent", "completed")
+		}
+	}
+	if req.DetectMachine {
+		// waits out a machine's greeting before fetching the answer url, so
+		// a voicemail starts after the beep
+		form.Set("MachineDetection", "DetectMessageEnd")
+	}
+
+	var created struct {
+		Sid string `json:"sid"`
+	}
+	if err := s.post(ctx, s.callsEndpoint()+".json", form, &created); err != nil {
+		return "", fmt.Errorf("error dialing %s through signalwire: %w", req.To, err)
+	}
+	if created.Sid == "" {
+		return "", errors.New("signalwire accepted the call but returned no sid")
+	}
+
+	return created.Sid, nil
+}
+
+// Hangup ends a live call.
+func (s *SignalWire) Hangup(ctx context.Context, providerCallID string) error {
+	form := url.Values{}
+	form.Set("Status", "completed")
+
+	if err := s.post(ctx, s.callsEndpoint()+"/"+url.PathEscape(providerCallID)+".json", form, nil); err != nil {
+		return fmt.Errorf("error hanging up signalwire call %s: %w", providerCallID, err)
+	}
+	return nil
+}
+
+// Redirect points a live call at new LaML.
+func (s *SignalWire) Redirect(ctx context.Context, providerCallID, path string) error {
+	form := url.Values{}
+	form.Set("Url", s.PublicURL+path)
+	form.Set("Method", http.MethodPost)
+
+	if err := s.post(ctx, s.callsEndpoint()+"/"+url.PathEscape(providerCallID)+".json", form, nil); err != nil {
+		return fmt.Errorf("error redirecting signalwire call %s: %w", providerCallID, err)
+	}
+	return nil
+}
+
+// FetchRecording downloads a recording as mp3. Only recordings in our own
+// space are fetched, since the request carries our credentials.
+func (s *SignalWire) FetchRecording(ctx context.Context, recordingURL string) (io.ReadCloser, string, error) {
+	u, err := url.Parse(recordingURL)
+	if err != nil || u.Scheme != "https" || u.Host != s.SpaceURL {
+		return nil, "", fmt.Errorf("refusing to fetch recording from %q, it isn't in our signalwire space", recordingURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(recordingURL, ".json")+".mp3", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.SetBasicAuth(s.ProjectID, s.Token)
+
+	// recordings can be long, so the download isn't held to the api timeout
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching recording %s: %w", recordingURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("error fetching recording %s: signalwire returned %d", recordingURL, resp.StatusCode)
+	}
+	return resp.Body, "audio/mpeg", nil
+}
+
+// post sends a form to the SignalWire api and decodes the JSON reply into out.
+func (s *SignalWire) post(ctx context.Context, endpoint string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.ProjectID, s.Token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("signalwire returned %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("signalwire returned %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// ParseEvent checks the webhook signature and reads the LaML callback fields.
+func (s *SignalWire) ParseEvent(r *http.Request) (Event, error) {
+	if err := r.ParseForm(); err != nil {
+		return Event{}, fmt.Errorf("error parsing signalwire webhook: %w", err)
+	}
+	if !s.validSignature(r) {
+		return Event{}, errors.New("signalwire webhook signature mismatch")
+	}
+
+	event := Event{
+		ProviderCallID: r.PostForm.Get("CallSid"),
+		Status:         lamlStatus(r.PostForm.Get("CallStatus")),
+		Speech:         strings.TrimSpace(r.PostForm.Get("SpeechResult")),
+		Digits:         r.PostForm.Get("Digits"),
+	}
+	if seconds, err := strconv.Atoi(r.PostForm.Get("CallDuration")); err == nil {
+		event.Duration = time.Duration(seconds) * time.Second
+	}
+	event.AnsweredBy, event.GreetingEnded = lamlAnsweredBy(r.PostForm.Get("AnsweredBy"))
+	event.ParentCallID = r.PostForm.Get("ParentCallSid")
+	event.RecordingURL = r.PostForm.Get("RecordingUrl")
+	event.RecordingID = r.PostForm.Get("RecordingSid")
+	if seconds, err := strconv.Atoi(r.PostForm.Get("RecordingDuration")); err == nil {
+		event.RecordingDuration = time.Duration(seconds) * time.Second
+	}
+	event.DialedStatus = lamlStatus(r.PostForm.Get("DialCallStatus"))
+	if seconds, err := strconv.Atoi(r.PostForm.Get("DialCallDuration")); err == nil {
+		event.DialedDuration = time.Duration(seconds) * time.Second
+	}
+
+	return event, nil
+}
+
+// lamlAnsweredBy maps a machine detection result onto who answered.
+func lamlAnsweredBy(answeredBy string) (string, bool) {
+	switch answeredBy {
+	case "human":
+		return answeredHuman, false
+	case "machine_start", "fax":
+		return answeredMachine, false
+	case "machine_end_beep", "machine_end_silence", "machine_end_other":
+		return answeredMachine, true
+	}
+	return "", false
+}
+
+// validSignature implements the Twilio-compatible request signing SignalWire
+// uses: an HMAC-SHA1 over the full callback url followed by the sorted post
+// parameters, keyed with the api token.
+func (s *SignalWire) validSignature(r *http.Request) bool {
+	signature := r.Header.Get("X-SignalWire-Signature")
+	if signature == "" {
+		signature = r.Header.Get("X-Twilio-Signature")
+	}
+	if signature == "" {
+		return false
+	}
+
+	var payload strings.Builder
+	payload.WriteString(s.PublicURL + r.URL.RequestURI())
+	keys := make([]string, 0, len(r.PostForm))
+	for key := range r.PostForm {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range r.PostForm[key] {
+			payload.WriteString(key)
+			payload.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(s.Token))
+	mac.Write([]byte(payload.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// lamlStatus maps LaML CallStatus values onto our attempt statuses.
+func lamlStatus(status string) string {
+	switch status {
+	case "queued", "initiated":
+		return attemptDialing
+	case "ringing":
+		return attemptRinging
+	case "in-progress", "answered":
+		return attemptInProgress
+	case "completed":
+		return attemptCompleted
+	case "busy":
+		return attemptBusy
+	case "no-answer":
+		return attemptNoAnswer
+	case "canceled":
+		return attemptCanceled
+	case "failed":
+		return attemptFailed
+	}
+	return ""
+}
+
+// LaML verbs. Only what the call engine uses is modelled here.
+type lamlResponse struct {
+	XMLName xml.Name `xml:"Response"`
+	Verbs   []any
+}
+
+type lamlSay struct {
+	XMLName  xml.Name `xml:"Say"`
+	Language string   `xml:"language,attr,omitempty"`
+	Text     string   `xml:",chardata"`
+}
+
+type lamlPause struct {
+	XMLName xml.Name `xml:"Pause"`
+	Length  int      `xml:"length,attr"`
+}
+
+type lamlPlay struct {
+	XMLName xml.Name `xml:"Play"`
+	Digits  string   `xml:"digits,attr"`
+}
+
+type lamlDial struct {
+	XMLName xml.Name   `xml:"Dial"`
+	Action  string     `xml:"action,attr,omitempty"`
+	Method  string     `xml:"method,attr,omitempty"`
+	Number  lamlNumber `xml:"Number"`
+}
+
+type lamlNumber struct {
+	URL    string `xml:"url,attr,omitempty"`
+	Method string `xml:"method,attr,omitempty"`
+	Number string `xml:",chardata"`
+}
+
+type lamlGather struct {
+	XMLName       xml.Name `xml:"Gather"`
+	Input         string   `xml:"input,attr"`
+	Action        string   `xml:"action,attr"`
+	Method        string   `xml:"method,attr"`
+	SpeechTimeout string   `xml:"speechTimeout,attr"`
+	Timeout       int      `xml:"timeout,attr"`
+}
+
+type lamlRedirect struct {
+	XMLName xml.Name `xml:"Redirect"`
+	Method  string   `xml:"method,attr"`
+	URL     string   `xml:",chardata"`
+}
+
+type lamlHangup struct {
+	XMLName xml.Name `xml:"Hangup"`
+}
+
+const defaultListenTimeout = 6 * time.Second
+
+// Respond renders instructions as a LaML document.
+func (s *SignalWire) Respond(w http.ResponseWriter, instructions []Instruction) error {
+	doc := lamlResponse{}
+	for _, instruction := range instructions {
+		switch in := instruction.(type) {
+		case Say:
+			doc.Verbs = append(doc.Verbs, lamlSay{Text: in.Text, Language: in.Language})
+		case Listen:
+			timeout := in.Timeout
+			if timeout <= 0 {
+				timeout = defaultListenTimeout
+			}
+			doc.Verbs = append(doc.Verbs,
+				lamlGather{
+					Input:         "speech dtmf",
+					Action:        in.ActionURL,
+					Method:        http.MethodPost,
+					SpeechTimeout: "auto",
+					Timeout:       int(timeout.Seconds()),
+				},
+				// Gather falls through when it hears nothing, so send the
+				// call back to the same action to handle the silence
+				lamlRedirect{Method: http.MethodPost, URL: in.ActionURL},
+			)
+		case Hangup:
+			doc.Verbs = append(doc.Verbs, lamlHangup{})
+		case Pause:
+			doc.Verbs = append(doc.Verbs, lamlPause{Length: max(1, int(in.Length.Seconds()))})
+		case SendDigits:
+			doc.Verbs = append(doc.Verbs, lamlPlay{Digits: in.Digits})
+		case Transfer:
+			dial := lamlDial{Number: lamlNumber{Number: in.To}}
+			if in.ActionURL != "" {
+				dial.Action, dial.Method = in.ActionURL, http.MethodPost
+			}
+			if in.WhisperURL != "" {
+				dial.Number.URL, dial.Number.Method = in.WhisperURL, http.MethodPost
+			}
+			doc.Verbs = append(doc.Verbs, dial)
+		default:
+			return fmt.Errorf("signalwire cannot render instruction %T", instruction)
+		}
+	}
+
+	out, err := xml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("error rendering laml: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	_, err = w.Write(append([]byte(xml.Header), out...))
+	return err
+}
@@ -0,0 +1,67 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"goDial/internal/calltemplates"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// applyTemplate fills in the call form from the template picked on it, if
+// any, with values for its variables. Anything typed into the objective and
+// other context is added after the template's. A template that isn't the
+// user's, or values it won't take, are a *fieldError.
+func (s *Service) applyTemplate(ctx context.Context, userID int64, form *callForm, templateID string, values map[string]string) error {
+	value := strings.TrimSpace(templateID)
+	if value == "" {
+		return nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return &fieldError{field: "templateId", problem: fmt.Sprintf("There's no call template %q.", value), err: err}
+	}
+
+	t, err := calltemplates.Get(ctx, s.db, userID, id)
+	if errors.Is(err, calltemplates.ErrNotFound) {
+		return &fieldError{field: "templateId", problem: fmt.Sprintf("There's no call template %d.", id), err: err}
+	}
+	if err != nil {
+		return err
+	}
+	rendered, err := calltemplates.Render(t, values)
+	if err != nil {
+		return &fieldError{field: "templateVariables", problem: err.Error() + ".", err: fmt.Errorf("error filling in template %d: %w", t.ID, err)}
+	}
+
+	form.objective = joinNonEmpty(" ", rendered.Objective, form.objective)
+	form.otherContext = joinNonEmpty("\n", rendered.OtherContext, form.otherContext)
+	form.templateID = sql.NullInt64{Int64: t.ID, Valid: true}
+	form.templateVariables = sql.NullString{String: rendered.ValuesJSON(), Valid: true}
+	return nil
+}
+
+// templateValues reads the values of a template's variables from the inputs
+// the call form's template picker adds.
+func templateValues(form url.Values) map[string]string {
+	values := map[string]string{}
+	for key := range form {
+		if name, ok := strings.CutPrefix(key, calltemplates.InputPrefix); ok {
+			values[name] = form.Get(key)
+		}
+	}
+	return values
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
@@ -0,0 +1,239 @@
+package calls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+)
+
+// toolLogPrefix starts the system log line written for each tool the agent
+// uses. The rest of the line is the ai.ToolCall as JSON, so the conversation
+// can be replayed to the agent with its tool results.
+const toolLogPrefix = "tool: "
+
+// maxToolRounds limits how many times in a row the agent is asked again
+// after using tools without saying or doing anything on the line.
+const maxToolRounds = 3
+
+// limits on schedule_callback, matching the tool's schema
+const (
+	minCallbackDelay = 5 * time.Minute
+	maxCallbackDelay = 7 * 24 * time.Hour
+)
+
+var dtmfDigits = regexp.MustCompile(`^[0-9*#w]{1,32}$`)
+
+// toolOutcome is what carrying out a tool did: the call with its result
+// filled in, anything to play on the line, and whether the call should end.
+type toolOutcome struct {
+	call         ai.ToolCall
+	instructions []Instruction
+	end          bool
+}
+
+// runTool carries out one tool the agent used. Bad input and failures are
+// reported back to the agent as error results rather than ending the call.
+func (s *Service) runTool(ctx context.Context, call database.Call, attempt database.CallAttempt, use ai.ToolCall) toolOutcome {
+	var out toolOutcome
+	var err error
+	switch use.Name {
+	case ai.ToolEndCall:
+		out, err = s.endCallTool(ctx, call, use)
+	case ai.ToolSendDTMF:
+		out, err = s.sendDTMFTool(use)
+	case ai.ToolTransferToUser:
+		out, err = s.transferTool(ctx, call, attempt, use)
+	case ai.ToolRecordFact:
+		out, err = s.recordFactTool(ctx, call, use)
+	case ai.ToolScheduleCallback:
+		out, err = s.scheduleCallbackTool(ctx, call, use)
+	default:
+		err = fmt.Errorf("unknown tool %q", use.Name)
+	}
+	if err != nil {
+		out = toolOutcome{}
+		use.Result = err.Error()
+		use.IsError = true
+	} else {
+		use.Result = out.call.Result
+	}
+	out.call = use
+
+	s.logTool(ctx, call.ID, use)
+	return out
+}
+
+// endCallTool hangs up, first putting the recipient on the do-not-call list
+// if they asked not to be called again.
+func (s *Service) endCallTool(ctx context.Context, call database.Call, use ai.ToolCall) (toolOutcome, error) {
+	var input ai.EndCallInput
+	if err := use.Decode(&input); err != nil {
+		return toolOutcome{}, err
+	}
+	if input.OptedOut {
+		s.optOut(ctx, call, dnc.SourceSpokenOptOut)
+	}
+	return toolOutcome{call: ai.ToolCall{Result: "call ended"}, end: true}, nil
+}
+
+func (s *Service) sendDTMFTool(use ai.ToolCall) (toolOutcome, error) {
+	var input ai.SendDTMFInput
+	if err := use.Decode(&input); err != nil {
+		return toolOutcome{}, err
+	}
+	if !dtmfDigits.MatchString(input.Digits) {
+		return toolOutcome{}, fmt.Errorf("digits must be 1 to 32 of 0-9, *, # and w, got %q", input.Digits)
+	}
+	return toolOutcome{
+		call:         ai.ToolCall{Result: "pressed " + input.Digits},
+		instructions: []Instruction{SendDigits{Digits: input.Digits}},
+	}, nil
+}
+
+// transferTool hands the recipient over to the call's owner. Once the
+// transferred leg ends there is nothing left for the agent to do, so the call
+// ends with it.
+func (s *Service) transferTool(ctx context.Context, call database.Call, attempt database.CallAttempt, use ai.ToolCall) (toolOutcome, error) {
+	var input ai.TransferToUserInput
+	if err := use.Decode(&input); err != nil {
+		return toolOutcome{}, err
+	}
+
+	user, err := s.startHandoff(ctx, call, attempt, "the agent transferred the call, "+input.Reason)
+	if errors.Is(err, errNoVerifiedPhone) {
+		return toolOutcome{}, errors.New("the user has no verified phone number to transfer to; offer to take a message or schedule a callback instead")
+	}
+	if err != nil {
+		return toolOutcome{}, err
+	}
+
+	return toolOutcome{
+		call:         ai.ToolCall{Result: "transferring the recipient to the user"},
+		instructions: []Instruction{handoffTransfer(attempt, user)},
+		end:          true,
+	}, nil
+}
+
+func (s *Service) recordFactTool(ctx context.Context, call database.Call, use ai.ToolCall) (toolOutcome, error) {
+	var input ai.RecordFactInput
+	if err := use.Decode(&input); err != nil {
+		return toolOutcome{}, err
+	}
+	key := strings.TrimSpace(input.Key)
+	value := strings.TrimSpace(input.Value)
+	if key == "" || value == "" {
+		return toolOutcome{}, errors.New("key and value are both required")
+	}
+
+	if _, err := s.db.RecordCallFact(ctx, database.RecordCallFactParams{CallID: call.ID, Key: key, Value: value}); err != nil {
+		return toolOutcome{}, fmt.Errorf("error saving fact %s: %w", key, err)
+	}
+	return toolOutcome{call: ai.ToolCall{Result: "saved " + key}}, nil
+}
+
+// scheduleCallbackTool queues a new call to the same recipient with the same
+// plan. The user already approved that plan, so the callback is approved too.
+func (s *Service) scheduleCallbackTool(ctx context.Context, call database.Call, use ai.ToolCall) (toolOutcome, error) {
+	var input ai.ScheduleCallbackInput
+	if err := use.Decode(&input); err != nil {
+		return toolOutcome{}, err
+	}
+	delay := time.Duration(input.DelayMinutes) * time.Minute
+	if delay < minCallbackDelay || delay > maxCallbackDelay {
+		return toolOutcome{}, fmt.Errorf("delay_minutes must be between %d and %d", int(minCallbackDelay.Minutes()), int(maxCallbackDelay.Minutes()))
+	}
+
+	callback, err := s.copyCall(ctx, call)
+	if err != nil {
+		return toolOutcome{}, err
+	}
+	runAt := s.now().Add(delay)
+	if err := s.enqueuePlacement(ctx, callback.ID, runAt); err != nil {
+		return toolOutcome{}, fmt.Errorf("error queueing callback call %d: %w", callback.ID, err)
+	}
+	s.logCall(ctx, callback.ID, logSystem, fmt.Sprintf("callback scheduled from call %d: %s", call.ID, input.Reason))
+
+	when := runAt.Local()
+	if hours, err := s.recipientHours(ctx, call); err == nil {
+		when = runAt.In(hours.Zone())
+	}
+	return toolOutcome{call: ai.ToolCall{Result: "callback scheduled for " + when.Format(deferLayout) + " recipient's time"}}, nil
+}
+
+// copyCall creates an approved copy of a call, to be placed again later. The
+// copy stays linked to the call's contact, template and campaign.
+func (s *Service) copyCall(ctx context.Context, call database.Call) (database.Call, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error starting callback transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+	callback, err := q.CreateCall(ctx, database.CreateCallParams{
+		UserID:              call.UserID,
+		PhoneNumber:         call.PhoneNumber,
+		RecipientContext:    call.RecipientContext,
+		Objective:           call.Objective,
+		BackgroundContext:   call.BackgroundContext,
+		MaxAttempts:         call.MaxAttempts,
+		RetrySpacingMinutes: call.RetrySpacingMinutes,
+		RetryWindowStart:    call.RetryWindowStart,
+		RetryWindowEnd:      call.RetryWindowEnd,
+		Record:              call.Record,
+		OpeningLine:         call.OpeningLine,
+		CallPlan:            call.CallPlan,
+		ContactID:           call.ContactID,
+		TemplateID:          call.TemplateID,
+		TemplateVariables:   call.TemplateVariables,
+		CampaignID:          call.CampaignID,
+	})
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error creating callback of call %d: %w", call.ID, err)
+	}
+	callback, err = q.ApproveCall(ctx, database.ApproveCallParams{
+		OpeningLine: callback.OpeningLine,
+		CallPlan:    callback.CallPlan,
+		ID:          callback.ID,
+	})
+	if err != nil {
+		return database.Call{}, fmt.Errorf("error approving callback call %d: %w", callback.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Call{}, fmt.Errorf("error committing callback of call %d: %w", call.ID, err)
+	}
+	return callback, nil
+}
+
+// logTool writes the tool call, with its result, to the call's transcript.
+func (s *Service) logTool(ctx context.Context, callID int64, use ai.ToolCall) {
+	entry, err := json.Marshal(use)
+	if err != nil {
+		log.Printf("calls: error encoding %s on call %d: %v", use.Name, callID, err)
+		return
+	}
+	s.logCall(ctx, callID, logSystem, toolLogPrefix+string(entry))
+}
+
+// parseToolLog reads a tool call back out of a system log line.
+func parseToolLog(content string) (ai.ToolCall, bool) {
+	rest, ok := strings.CutPrefix(content, toolLogPrefix)
+	if !ok {
+		return ai.ToolCall{}, false
+	}
+	var use ai.ToolCall
+	if err := json.Unmarshal([]byte(rest), &use); err != nil {
+		return ai.ToolCall{}, false
+	}
+	return use, true
+}
@@ -0,0 +1,280 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"goDial/internal/ai"
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolUse builds a tool call the way the model would send it.
+func toolUse(t *testing.T, id, name string, input any) ai.ToolCall {
+	t.Helper()
+	raw, err := json.Marshal(input)
+	require.NoError(t, err)
+	return ai.ToolCall{ID: id, Name: name, Input: raw}
+}
+
+func TestService_AgentTools(t *testing.T) {
+	tests := []struct {
+		name          string
+		userPhone     string
+		replies       func(t *testing.T) []ai.Response
+		expected      func(attempt database.CallAttempt) []Instruction
+		expectedTools []ai.ToolCall
+		check         func(t *testing.T, ts *testService, call database.Call)
+	}{
+		{
+			name: "send_dtmf presses keys and keeps listening",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{{Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolSendDTMF, ai.SendDTMFInput{Digits: "2"})}}}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{SendDigits{Digits: "2"}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolSendDTMF, Result: "pressed 2"}},
+		},
+		{
+			name: "send_dtmf with bad digits is reported back to the agent",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{
+					{Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolSendDTMF, ai.SendDTMFInput{Digits: "two"})}},
+					{Text: "Sorry, could you repeat the options?"},
+				}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "Sorry, could you repeat the options?"}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolSendDTMF, Result: `digits must be 1 to 32 of 0-9, *, # and w, got "two"`, IsError: true}},
+		},
+		{
+			name: "record_fact saves the fact and lets the agent carry on",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{
+					{Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolRecordFact, ai.RecordFactInput{Key: "confirmation_number", Value: "ABC123"})}},
+					{Text: "Great, thank you."},
+				}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "Great, thank you."}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolRecordFact, Result: "saved confirmation_number"}},
+			check: func(t *testing.T, ts *testService, call database.Call) {
+				facts, err := ts.db.ListCallFacts(context.Background(), call.ID)
+				require.NoError(t, err)
+				require.Len(t, facts, 1)
+				assert.Equal(t, "confirmation_number", facts[0].Key)
+				assert.Equal(t, "ABC123", facts[0].Value)
+
+				// the agent saw the result before it spoke
+				turns := ts.agent.turns[len(ts.agent.turns)-1]
+				last := turns[len(turns)-1]
+				assert.Equal(t, ai.SpeakerAgent, last.Speaker)
+				require.Len(t, last.Tools, 1)
+				assert.Equal(t, "saved confirmation_number", last.Tools[0].Result)
+			},
+		},
+		{
+			name:      "transfer_to_user dials the user and ends the call after",
+			userPhone: "+15551234567",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{{
+					Text:  "Let me connect you.",
+					Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolTransferToUser, ai.TransferToUserInput{Reason: "asked for a person"})},
+				}}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "Let me connect you."}, Transfer{To: "+15551234567", WhisperURL: attemptURL(attempt.ID, "whisper"), ActionURL: attemptURL(attempt.ID, "handoff")}, Hangup{}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolTransferToUser, Result: "transferring the recipient to the user"}},
+		},
+		{
+			name: "transfer_to_user without a verified phone number is refused",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{
+					{Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolTransferToUser, ai.TransferToUserInput{Reason: "asked for a person"})}},
+					{Text: "They can't come to the phone, can I take a message?"},
+				}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "They can't come to the phone, can I take a message?"}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolTransferToUser, Result: "the user has no verified phone number to transfer to; offer to take a message or schedule a callback instead", IsError: true}},
+		},
+		{
+			name: "end_call hangs up after the goodbye",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{{
+					Text:  "Thanks, goodbye!",
+					Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolEndCall, ai.EndCallInput{Reason: "done"})},
+				}}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "Thanks, goodbye!"}, Hangup{}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolEndCall, Result: "call ended"}},
+		},
+		{
+			name: "schedule_callback queues an approved copy of the call",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{{
+					Text:  "No problem, I'll call back in an hour.",
+					Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolScheduleCallback, ai.ScheduleCallbackInput{DelayMinutes: 60, Reason: "busy right now"})},
+				}}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "No problem, I'll call back in an hour."}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolScheduleCallback, Result: "callback scheduled for Sun Oct 18 3:00 PM EDT recipient's time"}},
+			check: func(t *testing.T, ts *testService, call database.Call) {
+				ctx := context.Background()
+				callback, err := ts.db.GetCall(ctx, call.ID+1)
+				require.NoError(t, err)
+				assert.Equal(t, call.PhoneNumber, callback.PhoneNumber)
+				assert.Equal(t, call.Objective, callback.Objective)
+				assert.True(t, callback.ApprovedAt.Valid)
+
+				queued, err := ts.db.ListJobsByStatus(ctx, "queued")
+				require.NoError(t, err)
+				var found bool
+				for _, job := range queued {
+					var payload callJob
+					require.NoError(t, json.Unmarshal([]byte(job.Payload), &payload))
+					if job.Kind == jobPlaceCall && payload.CallID == callback.ID {
+						found = true
+						assert.True(t, ts.now().Add(time.Hour).Equal(job.RunAt), "callback should run in an hour, got %s", job.RunAt)
+					}
+				}
+				assert.True(t, found, "callback placement should be queued")
+			},
+		},
+		{
+			name: "schedule_callback outside the allowed delay is refused",
+			replies: func(t *testing.T) []ai.Response {
+				return []ai.Response{
+					{Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolScheduleCallback, ai.ScheduleCallbackInput{DelayMinutes: 1, Reason: "soon"})}},
+					{Text: "When would be a good time?"},
+				}
+			},
+			expected: func(attempt database.CallAttempt) []Instruction {
+				return []Instruction{Say{Text: "When would be a good time?"}, Listen{ActionURL: attemptURL(attempt.ID, "turn")}}
+			},
+			expectedTools: []ai.ToolCall{{ID: "t1", Name: ai.ToolScheduleCallback, Result: "delay_minutes must be between 5 and 10080", IsError: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			if tt.userPhone != "" {
+				_, err := ts.db.ExecContext(ctx, "UPDATE users SET phone_number = ?, phone_verified_at = CURRENT_TIMESTAMP WHERE id = ?", tt.userPhone, ts.user.ID)
+				require.NoError(t, err)
+			}
+			ts.agent.replies = append(lines("Hi, I'm calling on behalf of Test User."), tt.replies(t)...)
+
+			call := ts.createCall(t, 1)
+			ts.runJobs(t)
+			attempt := ts.attempts(t, call.ID)[0]
+			ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+			ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"Hello?"}})
+
+			assert.Equal(t, tt.expected(attempt), ts.provider.lastResponse())
+
+			logs, err := ts.db.ListCallLogs(ctx, call.ID)
+			require.NoError(t, err)
+			var used []ai.ToolCall
+			for _, entry := range logs {
+				if use, ok := parseToolLog(entry.Content); ok {
+					assert.Equal(t, logSystem, entry.MessageType)
+					use.Input = nil
+					used = append(used, use)
+				}
+			}
+			assert.Equal(t, tt.expectedTools, used)
+
+			if tt.check != nil {
+				tt.check(t, ts, call)
+			}
+		})
+	}
+}
+
+func TestService_ScheduleCallbackKeepsLinks(t *testing.T) {
+	ts := setupCallsTestService(t, 10)
+	ctx := context.Background()
+	contact, err := ts.db.CreateContact(ctx, database.CreateContactParams{UserID: ts.user.ID, Name: "Sam", PhoneNumber: "+13336664444"})
+	require.NoError(t, err)
+	template := ts.campaignTemplate(t)
+	campaign, err := ts.db.CreateCampaign(ctx, database.CreateCampaignParams{
+		UserID:         ts.user.ID,
+		Name:           "Birthdays",
+		TemplateID:     sql.NullInt64{Int64: template.ID, Valid: true},
+		Columns:        `["phone","name"]`,
+		MaxConcurrent:  1,
+		CallsPerMinute: 1,
+		MaxAttempts:    1,
+	})
+	require.NoError(t, err)
+
+	call, err := ts.db.CreateCall(ctx, database.CreateCallParams{
+		UserID:            ts.user.ID,
+		PhoneNumber:       contact.PhoneNumber,
+		Objective:         "say happy birthday to Sam",
+		MaxAttempts:       1,
+		ContactID:         sql.NullInt64{Int64: contact.ID, Valid: true},
+		TemplateID:        sql.NullInt64{Int64: template.ID, Valid: true},
+		TemplateVariables: sql.NullString{String: `{"name":"Sam","date":"2026-10-20"}`, Valid: true},
+		CampaignID:        sql.NullInt64{Int64: campaign.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	ts.approve(t, call)
+	ts.agent.replies = append(lines("Hi, I'm calling on behalf of Test User."), ai.Response{
+		Text:  "I'll call back tomorrow.",
+		Tools: []ai.ToolCall{toolUse(t, "t1", ai.ToolScheduleCallback, ai.ScheduleCallbackInput{DelayMinutes: 24 * 60, Reason: "driving"})},
+	})
+	ts.runJobs(t)
+	attempt := ts.attempts(t, call.ID)[0]
+	ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, url.Values{})
+	ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, url.Values{"speech": {"I'm driving."}})
+
+	calls, err := ts.db.ListCallsByContact(ctx, contact.ID)
+	require.NoError(t, err)
+	require.Len(t, calls, 2, "the callback is listed under the contact")
+	callback, err := ts.db.GetCall(ctx, call.ID+1)
+	require.NoError(t, err)
+	assert.Equal(t, call.ContactID, callback.ContactID)
+	assert.Equal(t, call.TemplateID, callback.TemplateID)
+	assert.Equal(t, call.TemplateVariables, callback.TemplateVariables)
+	assert.Equal(t, call.CampaignID, callback.CampaignID)
+}
+
+func TestTranscriptTurns_ReplaysToolCalls(t *testing.T) {
+	fact := ai.ToolCall{ID: "t1", Name: ai.ToolRecordFact, Input: json.RawMessage(`{"key":"price","value":"$40"}`), Result: "saved price"}
+	entry, err := json.Marshal(fact)
+	require.NoError(t, err)
+
+	logs := []database.CallLog{
+		{MessageType: logAgent, Content: "How much is it?"},
+		{MessageType: logRecipient, Content: "Forty dollars."},
+		{MessageType: logSystem, Content: toolLogPrefix + string(entry)},
+		{MessageType: logAgent, Content: "Thanks!"},
+		{MessageType: logSystem, Content: silenceLog},
+	}
+
+	turns := transcriptTurns(logs)
+	assert.Equal(t, []ai.Turn{
+		{Speaker: ai.SpeakerAgent, Text: "How much is it?"},
+		{Speaker: ai.SpeakerRecipient, Text: "Forty dollars."},
+		{Speaker: ai.SpeakerAgent, Tools: []ai.ToolCall{fact}},
+		{Speaker: ai.SpeakerAgent, Text: "Thanks!"},
+	}, turns)
+}
@@ -0,0 +1,234 @@
+package calls
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/phone"
+	"goDial/internal/templates/pages"
+)
+
+// limits on verifying the phone number calls are handed off to
+const (
+	verificationCodeDigits = 6
+	verificationTTL        = 10 * time.Minute
+	// wrong guesses allowed on a code before a new one is needed
+	maxVerificationGuesses = 5
+	// codes a user can have outstanding at once, so the form can't be used
+	// to ring someone's phone over and over
+	maxActiveVerifications = 3
+)
+
+func verificationURL(verificationID int64, action string) string {
+	return fmt.Sprintf("/webhooks/telephony/verifications/%d/%s", verificationID, action)
+}
+
+// HandleSendPhoneCode starts verifying the phone number the user wants calls
+// handed off to, by calling it and reading out a code.
+func (s *Service) HandleSendPhoneCode(w http.ResponseWriter, r *http.Request) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleSendPhoneCode: %v\n", err)
+		http.Error(w, "could not load settings", http.StatusInternalServerError)
+		return
+	}
+
+	number, err := phone.Parse(r.FormValue("phoneNumber"), defaultPhoneRegion)
+	if err != nil {
+		s.renderSettings(w, r, user, "", http.StatusBadRequest, "Enter a valid phone number, with its country code if it's outside the US.")
+		return
+	}
+	if s.provider == nil {
+		s.renderSettings(w, r, user, "", http.StatusServiceUnavailable, "Calling isn't set up on this server, so phone numbers can't be verified.")
+		return
+	}
+
+	now := s.now().UTC()
+	active, err := s.db.CountActivePhoneVerifications(r.Context(), database.CountActivePhoneVerificationsParams{UserID: user.ID, ExpiresAt: now})
+	if err != nil {
+		fmt.Printf("HandleSendPhoneCode(couldnt count codes of user %d): %v\n", user.ID, err)
+		http.Error(w, "could not send code", http.StatusInternalServerError)
+		return
+	}
+	if active >= maxActiveVerifications {
+		s.renderSettings(w, r, user, "", http.StatusTooManyRequests, "Too many codes requested. Wait a few minutes and try again.")
+		return
+	}
+
+	code, err := verificationCode()
+	if err != nil {
+		fmt.Printf("HandleSendPhoneCode: %v\n", err)
+		http.Error(w, "could not send code", http.StatusInternalServerError)
+		return
+	}
+	verification, err := s.db.CreatePhoneVerification(r.Context(), database.CreatePhoneVerificationParams{
+		UserID:      user.ID,
+		PhoneNumber: number.E164,
+		Code:        code,
+		ExpiresAt:   now.Add(verificationTTL),
+	})
+	if err != nil {
+		fmt.Printf("HandleSendPhoneCode(couldnt save code of user %d): %v\n", user.ID, err)
+		http.Error(w, "could not send code", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.provider.Dial(r.Context(), DialRequest{
+		To:          number.E164,
+		AnswerURL:   verificationURL(verification.ID, "answer"),
+		StatusURL:   verificationURL(verification.ID, "status"),
+		RingTimeout: ringTimeout,
+	}); err != nil {
+		fmt.Printf("HandleSendPhoneCode(couldnt call %s): %v\n", number.E164, err)
+		s.renderSettings(w, r, user, "", http.StatusBadGateway, "We couldn't call that number. Check it and try again.")
+		return
+	}
+
+	s.renderSettings(w, r, user, number.E164, http.StatusOK, fmt.Sprintf("Calling %s now with your code.", number.E164))
+}
+
+// HandleVerifyPhone checks the code the user was read out, and saves the
+// number it was sent to as theirs when it matches.
+func (s *Service) HandleVerifyPhone(w http.ResponseWriter, r *http.Request) {
+	user, err := s.currentUser(r.Context())
+	if err != nil {
+		fmt.Printf("HandleVerifyPhone: %v\n", err)
+		http.Error(w, "could not load settings", http.StatusInternalServerError)
+		return
+	}
+
+	verification, err := s.db.GetLatestPhoneVerification(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.renderSettings(w, r, user, "", http.StatusBadRequest, "Request a code for your phone number first.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("HandleVerifyPhone(couldnt load code of user %d): %v\n", user.ID, err)
+		http.Error(w, "could not verify phone number", http.StatusInternalServerError)
+		return
+	}
+	if !s.now().Before(verification.ExpiresAt) || verification.Attempts >= maxVerificationGuesses {
+		s.renderSettings(w, r, user, "", http.StatusBadRequest, "That code has expired. Request a new one.")
+		return
+	}
+
+	code := strings.Join(strings.Fields(r.FormValue("code")), "")
+	if subtle.ConstantTimeCompare([]byte(code), []byte(verification.Code)) != 1 {
+		if err := s.db.AddPhoneVerificationAttempt(r.Context(), verification.ID); err != nil {
+			fmt.Printf("HandleVerifyPhone(couldnt count guess on code %d): %v\n", verification.ID, err)
+			http.Error(w, "could not verify phone number", http.StatusInternalServerError)
+			return
+		}
+		s.renderSettings(w, r, user, verification.PhoneNumber, http.StatusBadRequest, "That code is incorrect.")
+		return
+	}
+
+	user, err = s.db.SetUserPhoneVerified(r.Context(), database.SetUserPhoneVerifiedParams{
+		PhoneNumber: sql.NullString{String: verification.PhoneNumber, Valid: true},
+		ID:          user.ID,
+	})
+	if err != nil {
+		fmt.Printf("HandleVerifyPhone(couldnt save number of user %d): %v\n", user.ID, err)
+		http.Error(w, "could not verify phone number", http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.DeletePhoneVerifications(r.Context(), user.ID); err != nil {
+		log.Printf("calls: error clearing verification codes of user %d: %v", user.ID, err)
+	}
+
+	s.renderSettings(w, r, user, "", http.StatusOK, "Phone number verified. Calls can now be handed off to it.")
+}
+
+// renderSettings shows the settings page with a notice about the phone
+// number form, as an error unless status is OK.
+func (s *Service) renderSettings(w http.ResponseWriter, r *http.Request, user database.User, pendingNumber string, status int, notice string) {
+	w.WriteHeader(status)
+	pages.Settings(user, pendingNumber, pages.SettingsNotice{Text: notice, Error: status != http.StatusOK}).Render(r.Context(), w)
+}
+
+// HandleVerificationAnswerWebhook is fetched when the user picks up the
+// verification call, and reads them their code.
+func (s *Service) HandleVerificationAnswerWebhook(w http.ResponseWriter, r *http.Request) {
+	verification, ok := s.webhookVerification(w, r)
+	if !ok {
+		return
+	}
+	if !s.now().Before(verification.ExpiresAt) {
+		s.respondWith(w, []Instruction{Hangup{}})
+		return
+	}
+
+	// digits separated by commas are read one at a time, with a pause
+	spoken := strings.Join(strings.Split(verification.Code, ""), ", ")
+	s.respondWith(w, []Instruction{
+		Say{Text: fmt.Sprintf("Your goDial verification code is %s. Again, your code is %s. Goodbye.", spoken, spoken)},
+		Hangup{},
+	})
+}
+
+// HandleVerificationStatusWebhook accepts the provider's progress reports on
+// verification calls. Nothing is done with them: a user who missed the call
+// requests another code.
+func (s *Service) HandleVerificationStatusWebhook(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.webhookVerification(w, r); !ok {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// webhookVerification verifies a provider webhook and loads the verification
+// it is about. It writes the error response itself when ok is false.
+func (s *Service) webhookVerification(w http.ResponseWriter, r *http.Request) (database.PhoneVerification, bool) {
+	if s.provider == nil {
+		http.Error(w, "telephony is not configured", http.StatusServiceUnavailable)
+		return database.PhoneVerification{}, false
+	}
+
+	if _, err := s.provider.ParseEvent(r); err != nil {
+		log.Printf("calls: rejected telephony webhook: %v", err)
+		http.Error(w, "invalid webhook", http.StatusForbidden)
+		return database.PhoneVerification{}, false
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return database.PhoneVerification{}, false
+	}
+
+	verification, err := s.db.GetPhoneVerification(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return database.PhoneVerification{}, false
+	}
+	if err != nil {
+		log.Printf("calls: error loading verification %d for webhook: %v", id, err)
+		http.Error(w, "could not load verification", http.StatusInternalServerError)
+		return database.PhoneVerification{}, false
+	}
+
+	return verification, true
+}
+
+// verificationCode makes a random numeric code.
+func verificationCode() (string, error) {
+	limit := big.NewInt(1)
+	for range verificationCodeDigits {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("error generating verification code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
@@ -0,0 +1,149 @@
+package calls
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *testService) postForm(t *testing.T, handler http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	req := ts.userRequest(t, "POST", "/settings/phone", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestPhoneVerification(t *testing.T) {
+	// readCode stands for the code the verification call read out
+	const readCode = "read code"
+
+	tests := []struct {
+		name     string
+		number   string
+		requests int
+		advance  time.Duration
+		guesses  []string
+		// the response to the last request or guess
+		expectedCode     int
+		expectedBody     string
+		expectedDials    int
+		expectedVerified bool
+	}{
+		{
+			name:             "verifies with the code read out",
+			number:           "(333) 666-5555",
+			requests:         1,
+			guesses:          []string{readCode},
+			expectedCode:     http.StatusOK,
+			expectedBody:     "Phone number verified.",
+			expectedDials:    1,
+			expectedVerified: true,
+		},
+		{
+			name:          "wrong code",
+			number:        "(333) 666-5555",
+			requests:      1,
+			guesses:       []string{"12345"},
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  "That code is incorrect.",
+			expectedDials: 1,
+		},
+		{
+			name:          "codes expire",
+			number:        "(333) 666-5555",
+			requests:      1,
+			advance:       verificationTTL + time.Minute,
+			guesses:       []string{readCode},
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  "That code has expired.",
+			expectedDials: 1,
+		},
+		{
+			name:          "too many wrong guesses use up a code",
+			number:        "(333) 666-5555",
+			requests:      1,
+			guesses:       []string{"12345", "12345", "12345", "12345", "12345", readCode},
+			expectedCode:  http.StatusBadRequest,
+			expectedBody:  "That code has expired.",
+			expectedDials: 1,
+		},
+		{
+			name:          "too many codes requested",
+			number:        "(333) 666-5555",
+			requests:      maxActiveVerifications + 1,
+			expectedCode:  http.StatusTooManyRequests,
+			expectedBody:  "Too many codes requested.",
+			expectedDials: maxActiveVerifications,
+		},
+		{
+			name:         "invalid number",
+			number:       "12",
+			requests:     1,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Enter a valid phone number",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+
+			var w *httptest.ResponseRecorder
+			for range tt.requests {
+				w = ts.postForm(t, ts.HandleSendPhoneCode, url.Values{"phoneNumber": {tt.number}})
+			}
+			require.Len(t, ts.provider.dials, tt.expectedDials)
+
+			var code string
+			if tt.expectedDials > 0 {
+				dial := ts.provider.dials[0]
+				assert.Equal(t, "+13336665555", dial.To)
+
+				verification, err := ts.db.GetLatestPhoneVerification(ctx, ts.user.ID)
+				require.NoError(t, err)
+				code = verification.Code
+				assert.Equal(t, verificationURL(verification.ID, "answer"), ts.provider.dials[len(ts.provider.dials)-1].AnswerURL)
+
+				// the call reads the code out a digit at a time
+				req := httptest.NewRequest("POST", "/webhooks/telephony/verifications/x/answer", nil)
+				req.SetPathValue("id", strconv.FormatInt(verification.ID, 10))
+				ts.HandleVerificationAnswerWebhook(httptest.NewRecorder(), req)
+				spoken := strings.Join(strings.Split(code, ""), ", ")
+				assert.Equal(t, []Instruction{
+					Say{Text: "Your goDial verification code is " + spoken + ". Again, your code is " + spoken + ". Goodbye."},
+					Hangup{},
+				}, ts.provider.lastResponse())
+			}
+
+			start := ts.now()
+			ts.now = func() time.Time { return start.Add(tt.advance) }
+			for _, guess := range tt.guesses {
+				if guess == readCode {
+					guess = code
+				}
+				w = ts.postForm(t, ts.HandleVerifyPhone, url.Values{"code": {guess}})
+			}
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			user, err := ts.db.GetUser(ctx, ts.user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedVerified, user.PhoneVerifiedAt.Valid)
+			if tt.expectedVerified {
+				assert.Equal(t, "+13336665555", user.PhoneNumber.String)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/ivr"
+)
+
+// system log lines about answering machines
+const (
+	machineLog         = "answering machine detected"
+	machineGreetingLog = "answering machine detected, waiting for the greeting to end"
+	voicemailLog       = "voicemail left"
+)
+
+// call outcomes, matching the CHECK constraint on calls.outcome
+const (
+	outcomeConversation = "conversation"
+	outcomeVoicemail    = "voicemail"
+)
+
+// beepPause is how long to wait after a greeting for the beep to play.
+const beepPause = 2 * time.Second
+
+// greetingListenTimeout is how long to let a machine's greeting run before
+// leaving the message anyway.
+const greetingListenTimeout = 30 * time.Second
+
+// machineAnswered handles a call the provider says went to a machine. When
+// the provider waited out the greeting the message starts right away;
+// otherwise the greeting is listened to first, and the message left on the
+// turn after it.
+func (s *Service) machineAnswered(ctx context.Context, call database.Call, attempt database.CallAttempt, greetingEnded bool) []Instruction {
+	s.setAnsweredBy(ctx, attempt, answeredMachine)
+	if greetingEnded {
+		s.logCall(ctx, call.ID, logSystem, machineLog)
+		return s.leaveVoicemail(ctx, call, attempt, true)
+	}
+	s.logCall(ctx, call.ID, logSystem, machineGreetingLog)
+	return []Instruction{Listen{ActionURL: attemptURL(attempt.ID, "turn"), Timeout: greetingListenTimeout}}
+}
+
+// reachedVoicemail reports whether speech, just heard on an attempt with the
+// given logs, is a voicemail greeting. Only the first thing heard counts, or a
+// greeting reached through a phone menu, so a person who mentions voicemail
+// in conversation doesn't get a message left on them.
+func reachedVoicemail(logs []database.CallLog, speech string) bool {
+	if ivr.Classify(speech) != ivr.Voicemail {
+		return false
+	}
+	if navigationOf(logs).mode != modeConversation {
+		return true
+	}
+	heard := 0
+	for _, entry := range logs {
+		if entry.MessageType == logRecipient {
+			heard++
+		}
+	}
+	return heard <= 1
+}
+
+// leaveVoicemail plays the disclosure and a message drafted from the call's
+// objective, then hangs up. afterBeep is false when the greeting has only
+// just finished, so there is a pause for its beep first.
+func (s *Service) leaveVoicemail(ctx context.Context, call database.Call, attempt database.CallAttempt, afterBeep bool) []Instruction {
+	if attempt.VoicemailLeftAt.Valid {
+		return []Instruction{Hangup{}}
+	}
+
+	var instructions []Instruction
+	if !afterBeep {
+		instructions = append(instructions, Pause{Length: beepPause})
+	}
+	instructions = append(instructions, s.announce(ctx, call))
+
+	message := s.voicemailMessage(ctx, call)
+	s.logCall(ctx, call.ID, logAgent, message)
+	if err := s.db.MarkVoicemailLeft(ctx, attempt.ID); err != nil {
+		log.Printf("calls: error marking voicemail left on attempt %d: %v", attempt.ID, err)
+	}
+	s.logCall(ctx, call.ID, logSystem, voicemailLog)
+
+	return append(instructions, Say{Text: message}, Hangup{})
+}
+
+// voicemailMessage drafts the message to leave, falling back to a plain one
+// so a drafting failure doesn't waste the call.
+func (s *Service) voicemailMessage(ctx context.Context, call database.Call) string {
+	caller := s.callerName(ctx, call)
+	recipient := call.RecipientContext.String
+	if recipient == "" {
+		recipient = "the person who answers this number"
+	}
+
+	message, err := s.draftVoicemail(ctx, call.Objective, recipient, caller)
+	if err == nil {
+		return message
+	}
+	log.Printf("calls: error drafting voicemail for call %d: %v", call.ID, err)
+	return fmt.Sprintf("Hi, I'm calling on behalf of %s and was hoping to speak with you. Sorry to have missed you, goodbye.", caller)
+}
+
+// setAnsweredBy records who picked up an attempt. It is only informational,
+// so a failed write is logged and the call carries on.
+func (s *Service) setAnsweredBy(ctx context.Context, attempt database.CallAttempt, answeredBy string) {
+	if err := s.db.SetCallAttemptAnsweredBy(ctx, database.SetCallAttemptAnsweredByParams{
+		AnsweredBy: sql.NullString{String: answeredBy, Valid: true},
+		ID:         attempt.ID,
+	}); err != nil {
+		log.Printf("calls: error recording attempt %d answered by %s: %v", attempt.ID, answeredBy, err)
+	}
+}
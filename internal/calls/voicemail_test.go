@@ -0,0 +1,142 @@
+package calls
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Voicemail(t *testing.T) {
+	disclosure := Say{Text: "Hello, this is an automated AI assistant calling on behalf of Test User.", Language: "en-US"}
+	message := Say{Text: "Hi, this is a message from Test User: say happy birthday."}
+
+	tests := []struct {
+		name string
+		// answer and turns are the webhooks the fake provider posts, in order
+		answer              url.Values
+		turns               []url.Values
+		draftFails          bool
+		voicemailNotSuccess bool
+		expected            []Instruction
+		expectedAnsweredBy  string
+		expectedAttempts    int
+		expectedStatus      string
+		expectedReason      string
+		expectedOutcome     string
+	}{
+		{
+			name:               "provider waited out the greeting",
+			answer:             url.Values{"answered_by": {answeredMachine}, "greeting_ended": {"1"}},
+			expected:           []Instruction{disclosure, message, Hangup{}},
+			expectedAnsweredBy: answeredMachine,
+			expectedStatus:     statusCompleted,
+			expectedReason:     "left a voicemail",
+			expectedOutcome:    outcomeVoicemail,
+		},
+		{
+			name:               "provider detected a machine as the greeting started",
+			answer:             url.Values{"answered_by": {answeredMachine}},
+			turns:              []url.Values{{"speech": {"Hi, it's Sam, leave a message."}}},
+			expected:           []Instruction{Pause{Length: beepPause}, disclosure, message, Hangup{}},
+			expectedAnsweredBy: answeredMachine,
+			expectedStatus:     statusCompleted,
+			expectedReason:     "left a voicemail",
+			expectedOutcome:    outcomeVoicemail,
+		},
+		{
+			name:               "greeting recognized without machine detection",
+			answer:             url.Values{},
+			turns:              []url.Values{{"speech": {"You've reached Sam. I can't take your call right now, please leave a message after the beep."}}},
+			expected:           []Instruction{Pause{Length: beepPause}, disclosure, message, Hangup{}},
+			expectedAnsweredBy: answeredMachine,
+			expectedStatus:     statusCompleted,
+			expectedReason:     "left a voicemail",
+			expectedOutcome:    outcomeVoicemail,
+		},
+		{
+			name:               "a person mentioning voicemail later is still a person",
+			answer:             url.Values{"answered_by": {answeredHuman}},
+			turns:              []url.Values{{"speech": {"Who is this?"}}, {"speech": {"Oh, I thought you'd go to my voicemail."}}},
+			expected:           []Instruction{Say{Text: "It's your birthday call!"}, Listen{}},
+			expectedAnsweredBy: answeredHuman,
+			expectedStatus:     statusCompleted,
+			expectedOutcome:    outcomeConversation,
+		},
+		{
+			name:               "a failed draft falls back to a plain message",
+			answer:             url.Values{"answered_by": {answeredMachine}, "greeting_ended": {"1"}},
+			draftFails:         true,
+			expected:           []Instruction{disclosure, Say{Text: "Hi, I'm calling on behalf of Test User and was hoping to speak with you. Sorry to have missed you, goodbye."}, Hangup{}},
+			expectedAnsweredBy: answeredMachine,
+			expectedStatus:     statusCompleted,
+			expectedReason:     "left a voicemail",
+			expectedOutcome:    outcomeVoicemail,
+		},
+		{
+			name:                "voicemail retried when it doesn't count as success",
+			answer:              url.Values{"answered_by": {answeredMachine}, "greeting_ended": {"1"}},
+			voicemailNotSuccess: true,
+			expected:            []Instruction{disclosure, message, Hangup{}},
+			expectedAnsweredBy:  answeredMachine,
+			expectedAttempts:    2,
+			expectedStatus:      statusInProgress,
+			expectedOutcome:     outcomeVoicemail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupCallsTestService(t, 10)
+			ctx := context.Background()
+			ts.agent.replies = lines("Hi, is this Sam?", "It's me, calling about your birthday.", "It's your birthday call!")
+			if tt.draftFails {
+				ts.draftVoicemail = func(ctx context.Context, objective, recipient, caller string) (string, error) {
+					return "", errors.New("model unavailable")
+				}
+			}
+			if tt.voicemailNotSuccess {
+				_, err := ts.db.UpdateUserSettings(ctx, database.UpdateUserSettingsParams{VoicemailCountsAsSuccess: false, ID: ts.user.ID})
+				require.NoError(t, err)
+			}
+
+			call := ts.createCall(t, 2)
+			ts.runJobs(t)
+			require.True(t, ts.provider.dials[0].DetectMachine)
+			attempt := ts.attempts(t, call.ID)[0]
+
+			ts.webhook(t, ts.HandleAnswerWebhook, attempt.ID, tt.answer)
+			for _, turn := range tt.turns {
+				ts.webhook(t, ts.HandleTurnWebhook, attempt.ID, turn)
+			}
+
+			response := ts.provider.lastResponse()
+			if listen, ok := tt.expected[len(tt.expected)-1].(Listen); ok && listen.ActionURL == "" {
+				tt.expected[len(tt.expected)-1] = Listen{ActionURL: attemptURL(attempt.ID, "turn")}
+			}
+			assert.Equal(t, tt.expected, response)
+
+			ts.webhook(t, ts.HandleStatusWebhook, attempt.ID, url.Values{"status": {attemptCompleted}, "duration": {"40"}})
+			ts.runJobs(t)
+
+			// the test queue runs retries straight away, so a retried call
+			// is already on its next attempt
+			attempts := ts.attempts(t, call.ID)
+			assert.Len(t, attempts, max(tt.expectedAttempts, 1))
+			attempt = attempts[0]
+			assert.Equal(t, tt.expectedAnsweredBy, attempt.AnsweredBy.String)
+			assert.Equal(t, tt.expectedOutcome == outcomeVoicemail, attempt.VoicemailLeftAt.Valid)
+
+			call, err := ts.db.GetCall(ctx, call.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, call.Status.String)
+			assert.Equal(t, tt.expectedReason, call.StatusReason.String)
+			assert.Equal(t, tt.expectedOutcome, call.Outcome.String)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Package calltemplates fills in objectives a user places again and again.
+// A template's objective and other context hold placeholders such as
+// {{name}} or {{date:date}}; the part after the colon is the variable's type,
+// which decides how its value is checked and read out to the agent.
+package calltemplates
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// Type is what kind of value a variable takes.
+type Type string
+
+const (
+	TypeText   Type = "text"
+	TypeDate   Type = "date"
+	TypeTime   Type = "time"
+	TypeNumber Type = "number"
+)
+
+// limits on templates and the values they are given
+const (
+	maxNameLength  = 100
+	maxTextLength  = 2000
+	maxVariables   = 10
+	maxValueLength = 500
+)
+
+// dates and times as the call form's inputs send them, and as the agent is
+// given them
+const (
+	dateLayout         = "2006-01-02"
+	timeLayout         = "15:04"
+	renderedDateLayout = "Monday, January 2, 2006"
+	renderedTimeLayout = "3:04 PM"
+)
+
+// Variable is a placeholder in a template.
+type Variable struct {
+	Name string
+	Type Type
+}
+
+// Label is how the call form asks for the variable.
+func (v Variable) Label() string {
+	label := strings.ReplaceAll(v.Name, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// InputPrefix starts the names of the call form's inputs for variables.
+const InputPrefix = "var_"
+
+// InputName is the name of the call form's input for the variable.
+func (v Variable) InputName() string {
+	return InputPrefix + v.Name
+}
+
+// placeholder matches {{name}} and {{name:type}}, with optional spaces.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*(?::\s*([A-Za-z]+)\s*)?\}\}`)
+
+// number is what a number variable takes: digits, optionally with a sign and
+// decimals.
+var number = regexp.MustCompile(`^[-+]?[0-9]+(\.[0-9]+)?$`)
+
+// ErrNotFound is returned for a template that doesn't exist or belongs to
+// another user.
+var ErrNotFound = errors.New("call template not found")
+
+// Variables returns the variables of a template's texts in the order they
+// first appear. A variable only needs its type where it first appears; giving
+// it a different one later is an error.
+func Variables(texts ...string) ([]Variable, error) {
+	var variables []Variable
+	types := map[string]Type{}
+	for _, text := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+			name, typ := strings.ToLower(match[1]), Type(strings.ToLower(match[2]))
+			if known, ok := types[name]; ok {
+				if typ != "" && typ != known {
+					return nil, fmt.Errorf("{{%s}} is used as both %s and %s", name, known, typ)
+				}
+				continue
+			}
+			if typ == "" {
+				typ = TypeText
+			}
+			switch typ {
+			case TypeText, TypeDate, TypeTime, TypeNumber:
+			default:
+				return nil, fmt.Errorf("{{%s}} has unknown type %q, use text, date, time or number", name, typ)
+			}
+			types[name] = typ
+			variables = append(variables, Variable{Name: name, Type: typ})
+		}
+	}
+	if len(variables) > maxVariables {
+		return nil, fmt.Errorf("templates can have up to %d variables", maxVariables)
+	}
+	return variables, nil
+}
+
+// VariablesOf returns the variables of a saved template. Templates are
+// checked when saved, so an error here means the database was edited by hand.
+func VariablesOf(t database.CallTemplate) []Variable {
+	variables, err := Variables(t.Objective, t.OtherContext)
+	if err != nil {
+		return nil
+	}
+	return variables
+}
+
+// Form is a template as typed by the user.
+type Form struct {
+	Name         string
+	Objective    string
+	OtherContext string
+}
+
+// Validate trims the form and checks its placeholders. It returns a problem
+// to show the user, or "" if the form can be saved.
+func (f *Form) Validate() string {
+	f.Name = strings.TrimSpace(f.Name)
+	f.Objective = strings.TrimSpace(f.Objective)
+	f.OtherContext = strings.TrimSpace(f.OtherContext)
+
+	if f.Name == "" {
+		return "Enter a name."
+	}
+	if len(f.Name) > maxNameLength {
+		return fmt.Sprintf("Keep the name under %d characters.", maxNameLength)
+	}
+	if f.Objective == "" {
+		return "Enter an objective."
+	}
+	if len(f.Objective) > maxTextLength || len(f.OtherContext) > maxTextLength {
+		return fmt.Sprintf("Keep the objective and other context under %d characters each.", maxTextLength)
+	}
+	if _, err := Variables(f.Objective, f.OtherContext); err != nil {
+		return "Check the placeholders: " + err.Error() + "."
+	}
+	return ""
+}
+
+// Create saves a validated form as one of userID's templates.
+func Create(ctx context.Context, q database.Querier, userID int64, f Form) (database.CallTemplate, error) {
+	t, err := q.CreateCallTemplate(ctx, database.CreateCallTemplateParams{
+		UserID:       sql.NullInt64{Int64: userID, Valid: true},
+		Name:         f.Name,
+		Objective:    f.Objective,
+		OtherContext: f.OtherContext,
+	})
+	if err != nil {
+		return database.CallTemplate{}, fmt.Errorf("error saving call template: %w", err)
+	}
+	return t, nil
+}
+
+// Get loads a template userID can use: one of theirs, or one provided to
+// everyone.
+func Get(ctx context.Context, q database.Querier, userID, id int64) (database.CallTemplate, error) {
+	t, err := q.GetCallTemplate(ctx, database.GetCallTemplateParams{ID: id, UserID: sql.NullInt64{Int64: userID, Valid: true}})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.CallTemplate{}, ErrNotFound
+	}
+	if err != nil {
+		return database.CallTemplate{}, fmt.Errorf("error loading call template %d: %w", id, err)
+	}
+	return t, nil
+}
+
+// Rendered is a template filled in with its variables' values.
+type Rendered struct {
+	Objective    string
+	OtherContext string
+	// Values are the values as given, keyed by variable name, to be stored
+	// with the call.
+	Values map[string]string
+}
+
+// ValuesJSON is Values encoded for storing with the call.
+func (r Rendered) ValuesJSON() string {
+	encoded, _ := json.Marshal(r.Values)
+	return string(encoded)
+}
+
+// Render fills in t with values, keyed by variable name. Every variable needs
+// a value of its type; values for variables t doesn't have are ignored.
+func Render(t database.CallTemplate, values map[string]string) (Rendered, error) {
+	variables, err := Variables(t.Objective, t.OtherContext)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("error reading call template %d: %w", t.ID, err)
+	}
+
+	given := make(map[string]string, len(variables))
+	spoken := make(map[string]string, len(variables))
+	for _, v := range variables {
+		value := strings.TrimSpace(values[v.Name])
+		if value == "" {
+			return Rendered{}, fmt.Errorf("%s is required", v.Label())
+		}
+		if len(value) > maxValueLength {
+			return Rendered{}, fmt.Errorf("%s must be under %d characters", v.Label(), maxValueLength)
+		}
+		formatted, err := format(v.Type, value)
+		if err != nil {
+			return Rendered{}, fmt.Errorf("%s: %w", v.Label(), err)
+		}
+		given[v.Name] = value
+		spoken[v.Name] = formatted
+	}
+
+	fill := func(text string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			return spoken[strings.ToLower(placeholder.FindStringSubmatch(match)[1])]
+		})
+	}
+	return Rendered{Objective: fill(t.Objective), OtherContext: fill(t.OtherContext), Values: given}, nil
+}
+
+// format checks value is of typ and writes it the way the agent should say
+// it. Dates and times come from the browser's date and time inputs.
+func format(typ Type, value string) (string, error) {
+	switch typ {
+	case TypeDate:
+		date, err := time.Parse(dateLayout, value)
+		if err != nil {
+			return "", fmt.Errorf("expected a date like 2026-10-20, got %q", value)
+		}
+		return date.Format(renderedDateLayout), nil
+	case TypeTime:
+		clock, err := time.Parse(timeLayout, value)
+		if err != nil {
+			return "", fmt.Errorf("expected a time like 14:30, got %q", value)
+		}
+		return clock.Format(renderedTimeLayout), nil
+	case TypeNumber:
+		if !number.MatchString(value) {
+			return "", fmt.Errorf("expected a number, got %q", value)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,183 @@
+package calltemplates
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name          string
+		texts         []string
+		expected      []Variable
+		expectedError string
+	}{
+		{
+			name:  "in order of first use, text by default",
+			texts: []string{"Confirm {{ name }} on {{date:date}} at {{time:Time}}", "Ask {{name}} about {{guests:number}} guests"},
+			expected: []Variable{
+				{Name: "name", Type: TypeText},
+				{Name: "date", Type: TypeDate},
+				{Name: "time", Type: TypeTime},
+				{Name: "guests", Type: TypeNumber},
+			},
+		},
+		{
+			name:     "the type only needs giving once",
+			texts:    []string{"{{date:date}} and {{date}}"},
+			expected: []Variable{{Name: "date", Type: TypeDate}},
+		},
+		{
+			name:  "no placeholders",
+			texts: []string{"Say happy birthday", "{ not one }"},
+		},
+		{
+			name:          "conflicting types",
+			texts:         []string{"{{when:date}}", "{{when:time}}"},
+			expectedError: "{{when}} is used as both date and time",
+		},
+		{
+			name:          "unknown type",
+			texts:         []string{"{{when:datetime}}"},
+			expectedError: `{{when}} has unknown type "datetime", use text, date, time or number`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variables, err := Variables(tt.texts...)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, variables)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	template := database.CallTemplate{
+		ID:           1,
+		Objective:    "Book a table for {{party_size:number}} on {{date:date}} at {{time:time}}.",
+		OtherContext: "The booking is under {{name}}.",
+	}
+	values := map[string]string{"party_size": "4", "date": "2026-10-20", "time": "19:30", "name": " Sam ", "unused": "x"}
+
+	tests := []struct {
+		name          string
+		change        map[string]string
+		expected      Rendered
+		expectedError string
+	}{
+		{
+			name: "fills in every placeholder",
+			expected: Rendered{
+				Objective:    "Book a table for 4 on Tuesday, October 20, 2026 at 7:30 PM.",
+				OtherContext: "The booking is under Sam.",
+				Values:       map[string]string{"party_size": "4", "date": "2026-10-20", "time": "19:30", "name": "Sam"},
+			},
+		},
+		{
+			name:          "missing value",
+			change:        map[string]string{"name": ""},
+			expectedError: "Name is required",
+		},
+		{
+			name:          "not a date",
+			change:        map[string]string{"date": "next tuesday"},
+			expectedError: `Date: expected a date like 2026-10-20, got "next tuesday"`,
+		},
+		{
+			name:          "not a time",
+			change:        map[string]string{"time": "7:30pm"},
+			expectedError: `Time: expected a time like 14:30, got "7:30pm"`,
+		},
+		{
+			name:          "not a number",
+			change:        map[string]string{"party_size": "Inf"},
+			expectedError: `Party size: expected a number, got "Inf"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			given := map[string]string{}
+			for name, value := range values {
+				given[name] = value
+			}
+			for name, value := range tt.change {
+				given[name] = value
+			}
+
+			rendered, err := Render(template, given)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+			assert.JSONEq(t, `{"party_size":"4","date":"2026-10-20","time":"19:30","name":"Sam"}`, rendered.ValuesJSON())
+		})
+	}
+}
+
+func TestForm_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		form            Form
+		expectedProblem string
+	}{
+		{name: "valid", form: Form{Name: " Confirm ", Objective: "Confirm {{name}} on {{date:date}}"}},
+		{name: "needs a name", form: Form{Objective: "Confirm {{name}}"}, expectedProblem: "Enter a name."},
+		{name: "needs an objective", form: Form{Name: "Confirm", Objective: " "}, expectedProblem: "Enter an objective."},
+		{
+			name:            "bad placeholder",
+			form:            Form{Name: "Confirm", Objective: "Confirm {{when:someday}}"},
+			expectedProblem: `Check the placeholders: {{when}} has unknown type "someday", use text, date, time or number.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := tt.form
+			assert.Equal(t, tt.expectedProblem, form.Validate())
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "calltemplates_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+
+	mine, err := Create(ctx, db, user.ID, Form{Name: "Mine", Objective: "Call {{name}}"})
+	require.NoError(t, err)
+	builtIn, err := db.ListCallTemplates(ctx, sql.NullInt64{})
+	require.NoError(t, err)
+	require.NotEmpty(t, builtIn, "the migrations provide templates to everyone")
+
+	_, err = Get(ctx, db, user.ID, mine.ID)
+	assert.NoError(t, err)
+	_, err = Get(ctx, db, other.ID, builtIn[0].ID)
+	assert.NoError(t, err)
+	_, err = Get(ctx, db, other.ID, mine.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, template := range builtIn {
+		assert.NotEmpty(t, VariablesOf(template), "built in template %q has variables", template.Name)
+	}
+}
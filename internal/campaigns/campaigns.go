@@ -0,0 +1,263 @@
+// Package campaigns reads the CSVs bulk calls are placed from and describes
+// how each of their rows went. Placing the calls is the call service's job.
+package campaigns
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"goDial/internal/database"
+)
+
+// limits on an uploaded CSV
+const (
+	MaxUploadBytes = 1 << 20
+	MaxRows        = 1000
+)
+
+// columns the phone number and recipient's name can be given in, after
+// NormalizeColumn
+var (
+	phoneColumns = []string{"phone", "phone_number", "number"}
+	nameColumns  = []string{"name", "recipient"}
+)
+
+// Sheet is an uploaded CSV: its header, normalized, and its rows, each padded
+// to the header's length.
+type Sheet struct {
+	Columns []string
+	Rows    []Row
+}
+
+// Row is one line of a sheet. Problem is set when the line itself is
+// malformed.
+type Row struct {
+	Number  int
+	Fields  []string
+	Problem string
+}
+
+// NormalizeColumn turns a CSV header cell into the name of a template
+// variable: lowercase, with spaces and dashes as underscores.
+func NormalizeColumn(column string) string {
+	column = strings.ToLower(strings.TrimSpace(column))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(column)
+}
+
+// Parse reads a CSV with a header row. The header needs a phone column; the
+// other columns are the rows' template variables.
+func Parse(r io.Reader) (Sheet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return Sheet{}, errors.New("the CSV is empty")
+	}
+	if err != nil {
+		return Sheet{}, fmt.Errorf("could not read the CSV header: %w", err)
+	}
+
+	sheet := Sheet{Columns: make([]string, len(header))}
+	seen := map[string]bool{}
+	for i, cell := range header {
+		// spreadsheet apps start UTF-8 CSVs with a byte order mark
+		column := NormalizeColumn(strings.TrimPrefix(cell, "\ufeff"))
+		if column == "" {
+			return Sheet{}, fmt.Errorf("column %d of the header is empty", i+1)
+		}
+		if seen[column] {
+			return Sheet{}, fmt.Errorf("the header has %q twice", column)
+		}
+		seen[column] = true
+		sheet.Columns[i] = column
+	}
+	if sheet.column(phoneColumns) < 0 {
+		return Sheet{}, errors.New("the header needs a phone column")
+	}
+
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Sheet{}, fmt.Errorf("could not read the CSV: %w", err)
+		}
+		if len(sheet.Rows) == MaxRows {
+			return Sheet{}, fmt.Errorf("campaigns can have up to %d rows", MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{Number: len(sheet.Rows) + 1, Fields: fields}
+		if len(fields) > len(sheet.Columns) {
+			row.Problem = fmt.Sprintf("line %d has more fields than the header", line)
+			row.Fields = fields[:len(sheet.Columns)]
+		}
+		for len(row.Fields) < len(sheet.Columns) {
+			row.Fields = append(row.Fields, "")
+		}
+		sheet.Rows = append(sheet.Rows, row)
+	}
+	if len(sheet.Rows) == 0 {
+		return Sheet{}, errors.New("the CSV has no rows under its header")
+	}
+	return sheet, nil
+}
+
+func (s Sheet) column(names []string) int {
+	for _, name := range names {
+		for i, column := range s.Columns {
+			if column == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Phone is the phone number given in row.
+func (s Sheet) Phone(row Row) string {
+	return strings.TrimSpace(row.Fields[s.column(phoneColumns)])
+}
+
+// Name is the recipient's name given in row, or "" if the sheet has no name
+// column.
+func (s Sheet) Name(row Row) string {
+	if i := s.column(nameColumns); i >= 0 {
+		return strings.TrimSpace(row.Fields[i])
+	}
+	return ""
+}
+
+// NameColumn is the column the sheet gives names in, or "name" if it has
+// none.
+func (s Sheet) NameColumn() string {
+	if i := s.column(nameColumns); i >= 0 {
+		return s.Columns[i]
+	}
+	return nameColumns[0]
+}
+
+// Values are row's fields keyed by column, for filling in a call template.
+func (s Sheet) Values(row Row) map[string]string {
+	values := make(map[string]string, len(s.Columns))
+	for i, column := range s.Columns {
+		values[column] = row.Fields[i]
+	}
+	return values
+}
+
+// EncodeFields stores a row's fields or a sheet's columns as a JSON array.
+func EncodeFields(fields []string) string {
+	encoded, _ := json.Marshal(fields)
+	return string(encoded)
+}
+
+// DecodeFields reads fields stored by EncodeFields.
+func DecodeFields(encoded string) []string {
+	var fields []string
+	json.Unmarshal([]byte(encoded), &fields)
+	return fields
+}
+
+// row statuses, matching the CHECK constraint on campaign_rows.status
+const (
+	RowInvalid   = "invalid"
+	RowQueued    = "queued"
+	RowPlaced    = "placed"
+	RowCancelled = "cancelled"
+)
+
+// campaign statuses, matching the CHECK constraint on campaigns.status
+const (
+	StatusDraft     = "draft"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// outcomes of a row, as shown on the dashboard and in exports
+const (
+	OutcomeInvalid    = "invalid"
+	OutcomeQueued     = "queued"
+	OutcomeCancelled  = "cancelled"
+	OutcomeCalling    = "calling"
+	OutcomeCompleted  = "completed"
+	OutcomeVoicemail  = "voicemail"
+	OutcomeFailed     = "failed"
+	OutcomeInProgress = "in progress"
+)
+
+// Outcome is how a row went, and why when there's a reason to give.
+func Outcome(row database.ListCampaignRowsRow) (outcome, reason string) {
+	switch row.Status {
+	case RowInvalid:
+		return OutcomeInvalid, row.Problem.String
+	case RowQueued:
+		return OutcomeQueued, ""
+	case RowCancelled:
+		return OutcomeCancelled, ""
+	}
+
+	switch row.CallStatus.String {
+	case "in_progress":
+		return OutcomeInProgress, ""
+	case "completed":
+		if row.CallOutcome.String == "voicemail" {
+			return OutcomeVoicemail, row.CallStatusReason.String
+		}
+		return OutcomeCompleted, row.CallStatusReason.String
+	case "failed":
+		return OutcomeFailed, row.CallStatusReason.String
+	default:
+		// pending, including calls deferred to the recipient's calling
+		// hours, or a call since deleted
+		return OutcomeCalling, row.CallStatusReason.String
+	}
+}
+
+// Summary counts a campaign's rows by outcome.
+func Summary(rows []database.ListCampaignRowsRow) map[string]int {
+	counts := map[string]int{}
+	for _, row := range rows {
+		outcome, _ := Outcome(row)
+		counts[outcome]++
+	}
+	return counts
+}
+
+// Export writes a campaign's rows back out as CSV: the columns as uploaded,
+// then each row's outcome, the reason for it and its call.
+func Export(w io.Writer, columns []string, rows []database.ListCampaignRowsRow) error {
+	out := csv.NewWriter(w)
+	header := append(append([]string{}, columns...), "outcome", "reason", "call_id")
+	if err := out.Write(header); err != nil {
+		return fmt.Errorf("error writing campaign export: %w", err)
+	}
+	for _, row := range rows {
+		outcome, reason := Outcome(row)
+		record := DecodeFields(row.Fields)
+		for len(record) < len(columns) {
+			record = append(record, "")
+		}
+		callID := ""
+		if row.CallID.Valid {
+			callID = strconv.FormatInt(row.CallID.Int64, 10)
+		}
+		if err := out.Write(append(record[:len(columns)], outcome, reason, callID)); err != nil {
+			return fmt.Errorf("error writing campaign export: %w", err)
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("error writing campaign export: %w", err)
+	}
+	return nil
+}
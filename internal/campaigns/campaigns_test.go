@@ -0,0 +1,142 @@
+package campaigns
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		csv           string
+		expected      Sheet
+		expectedError string
+	}{
+		{
+			name: "normalizes the header and pads short rows",
+			csv:  "\ufeffName,Phone Number,Party-Size\nSam,(415) 555-0100,4\nAlex,415 555 0101\n",
+			expected: Sheet{
+				Columns: []string{"name", "phone_number", "party_size"},
+				Rows: []Row{
+					{Number: 1, Fields: []string{"Sam", "(415) 555-0100", "4"}},
+					{Number: 2, Fields: []string{"Alex", "415 555 0101", ""}},
+				},
+			},
+		},
+		{
+			name: "keeps rows with too many fields as problems",
+			csv:  "phone\n4155550100\n4155550101,extra\n",
+			expected: Sheet{
+				Columns: []string{"phone"},
+				Rows: []Row{
+					{Number: 1, Fields: []string{"4155550100"}},
+					{Number: 2, Fields: []string{"4155550101"}, Problem: "line 3 has more fields than the header"},
+				},
+			},
+		},
+		{name: "empty", csv: "", expectedError: "the CSV is empty"},
+		{name: "no rows", csv: "phone\n", expectedError: "the CSV has no rows under its header"},
+		{name: "no phone column", csv: "name\nSam\n", expectedError: "the header needs a phone column"},
+		{name: "empty column", csv: "phone,,name\n1,2,3\n", expectedError: "column 2 of the header is empty"},
+		{name: "duplicate column", csv: "phone,Name,name\n1,2,3\n", expectedError: `the header has "name" twice`},
+		{
+			name:          "too many rows",
+			csv:           "phone\n" + strings.Repeat("4155550100\n", MaxRows+1),
+			expectedError: fmt.Sprintf("campaigns can have up to %d rows", MaxRows),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := Parse(strings.NewReader(tt.csv))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sheet)
+		})
+	}
+}
+
+func TestSheet_Values(t *testing.T) {
+	sheet, err := Parse(strings.NewReader("Number,Recipient,Date\n 4155550100 , Sam ,2026-10-20\n"))
+	require.NoError(t, err)
+	row := sheet.Rows[0]
+
+	assert.Equal(t, "4155550100", sheet.Phone(row))
+	assert.Equal(t, "Sam", sheet.Name(row))
+	assert.Equal(t, map[string]string{"number": "4155550100 ", "recipient": "Sam ", "date": "2026-10-20"}, sheet.Values(row))
+
+	withoutName, err := Parse(strings.NewReader("phone\n4155550100\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "", withoutName.Name(withoutName.Rows[0]))
+	assert.Equal(t, "recipient", sheet.NameColumn())
+	assert.Equal(t, "name", withoutName.NameColumn())
+}
+
+func placed(callStatus, outcome, reason string) database.ListCampaignRowsRow {
+	return database.ListCampaignRowsRow{
+		Status:           RowPlaced,
+		CallID:           sql.NullInt64{Int64: 7, Valid: true},
+		CallStatus:       sql.NullString{String: callStatus, Valid: true},
+		CallOutcome:      sql.NullString{String: outcome, Valid: outcome != ""},
+		CallStatusReason: sql.NullString{String: reason, Valid: reason != ""},
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		name            string
+		row             database.ListCampaignRowsRow
+		expectedOutcome string
+		expectedReason  string
+	}{
+		{
+			name:            "invalid",
+			row:             database.ListCampaignRowsRow{Status: RowInvalid, Problem: sql.NullString{String: "not a valid phone number", Valid: true}},
+			expectedOutcome: OutcomeInvalid,
+			expectedReason:  "not a valid phone number",
+		},
+		{name: "queued", row: database.ListCampaignRowsRow{Status: RowQueued}, expectedOutcome: OutcomeQueued},
+		{name: "cancelled", row: database.ListCampaignRowsRow{Status: RowCancelled}, expectedOutcome: OutcomeCancelled},
+		{name: "waiting to dial", row: placed("pending", "", "outside calling hours"), expectedOutcome: OutcomeCalling, expectedReason: "outside calling hours"},
+		{name: "on the phone", row: placed("in_progress", "", ""), expectedOutcome: OutcomeInProgress},
+		{name: "completed", row: placed("completed", "answered", ""), expectedOutcome: OutcomeCompleted},
+		{name: "voicemail", row: placed("completed", "voicemail", "left a voicemail"), expectedOutcome: OutcomeVoicemail, expectedReason: "left a voicemail"},
+		{name: "failed", row: placed("failed", "", "no answer after 1 attempts"), expectedOutcome: OutcomeFailed, expectedReason: "no answer after 1 attempts"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, reason := Outcome(tt.row)
+			assert.Equal(t, tt.expectedOutcome, outcome)
+			assert.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	invalid := database.ListCampaignRowsRow{
+		Fields:  EncodeFields([]string{"Alex", "555"}),
+		Status:  RowInvalid,
+		Problem: sql.NullString{String: "not a valid phone number", Valid: true},
+	}
+	completed := placed("completed", "answered", "")
+	completed.Fields = EncodeFields([]string{"Sam, Jr.", "+14155550100"})
+
+	var out bytes.Buffer
+	require.NoError(t, Export(&out, []string{"name", "phone"}, []database.ListCampaignRowsRow{invalid, completed}))
+	assert.Equal(t, "name,phone,outcome,reason,call_id\n"+
+		"Alex,555,invalid,not a valid phone number,\n"+
+		"\"Sam, Jr.\",+14155550100,completed,,7\n", out.String())
+	assert.Equal(t, map[string]int{OutcomeInvalid: 1, OutcomeCompleted: 1}, Summary([]database.ListCampaignRowsRow{invalid, completed}))
+}
@@ -0,0 +1,192 @@
+// Package compliance picks the announcement played to a recipient before the
+// AI says anything: that the caller is an AI, and, for recorded calls where
+// the law wants everyone on the line to agree, that the call is recorded.
+package compliance
+
+import (
+	"strings"
+
+	"goDial/internal/phone"
+)
+
+// callerPlaceholder in a script is replaced with the name of the user the
+// call is made for.
+const callerPlaceholder = "{caller}"
+
+// Script is what a disclosure says, in one language.
+type Script struct {
+	// AI tells the recipient they are talking to an AI, and for whom.
+	AI string
+	// Recording asks for consent to record, by staying on the line.
+	Recording string
+}
+
+// Policy holds the disclosure scripts and where recordings need consent.
+type Policy struct {
+	// Scripts are keyed by BCP 47 locale ("es-MX") or bare language ("es").
+	Scripts map[string]Script
+	// Locales maps an ISO 3166-1 country to the locale its recipients are
+	// addressed in.
+	Locales map[string]string
+	// DefaultLocale is used for countries missing from Locales, and for
+	// numbers that couldn't be parsed.
+	DefaultLocale string
+	// AllPartyConsent lists the ISO 3166-2 subdivisions ("US-CA") and
+	// ISO 3166-1 countries ("GB") where recording a call needs the consent of
+	// everyone on it, not just the caller.
+	AllPartyConsent map[string]bool
+}
+
+// DefaultPolicy announces the AI on every call and asks for recording consent
+// in the US states with all-party consent laws and in every other country
+// goDial calls.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Scripts: map[string]Script{
+			"en": {
+				AI:        "Hello, this is an automated AI assistant calling on behalf of {caller}.",
+				Recording: "This call is being recorded. By staying on the line, you consent to the recording.",
+			},
+			"es": {
+				AI:        "Hola, le habla un asistente automatizado de inteligencia artificial en nombre de {caller}.",
+				Recording: "Esta llamada está siendo grabada. Si permanece en la línea, acepta la grabación.",
+			},
+			"fr": {
+				AI:        "Bonjour, vous êtes en communication avec un assistant automatisé à intelligence artificielle qui appelle de la part de {caller}.",
+				Recording: "Cet appel est enregistré. En restant en ligne, vous acceptez l'enregistrement.",
+			},
+			"de": {
+				AI:        "Guten Tag, hier spricht ein automatisierter KI-Assistent im Auftrag von {caller}.",
+				Recording: "Dieses Gespräch wird aufgezeichnet. Wenn Sie in der Leitung bleiben, stimmen Sie der Aufzeichnung zu.",
+			},
+			"it": {
+				AI:        "Buongiorno, sono un assistente automatico di intelligenza artificiale che chiama per conto di {caller}.",
+				Recording: "Questa chiamata viene registrata. Restando in linea, acconsente alla registrazione.",
+			},
+			"pt": {
+				AI:        "Olá, aqui fala um assistente automatizado de inteligência artificial ligando em nome de {caller}.",
+				Recording: "Esta ligação está sendo gravada. Ao permanecer na linha, você concorda com a gravação.",
+			},
+			"ja": {
+				AI:        "突然のお電話失礼いたします。こちらは{caller}の代理でお電話しております、自動AIアシスタントです。",
+				Recording: "この通話は録音されています。通話を続けることで、録音に同意したものとみなされます。",
+			},
+		},
+		Locales: map[string]string{
+			"US": "en-US",
+			"CA": "en-CA",
+			"GB": "en-GB",
+			"IE": "en-IE",
+			"AU": "en-AU",
+			"NZ": "en-NZ",
+			"ZA": "en-ZA",
+			"IN": "en-IN",
+			"DE": "de-DE",
+			"FR": "fr-FR",
+			"ES": "es-ES",
+			"MX": "es-MX",
+			"IT": "it-IT",
+			"BR": "pt-BR",
+			"JP": "ja-JP",
+		},
+		DefaultLocale: "en-US",
+		AllPartyConsent: map[string]bool{
+			"US-CA": true,
+			"US-CT": true,
+			"US-DE": true,
+			"US-FL": true,
+			"US-IL": true,
+			"US-MD": true,
+			"US-MA": true,
+			"US-MI": true,
+			"US-MT": true,
+			"US-NV": true,
+			"US-NH": true,
+			"US-OR": true,
+			"US-PA": true,
+			"US-WA": true,
+
+			"CA": true,
+			"GB": true,
+			"IE": true,
+			"DE": true,
+			"FR": true,
+			"ES": true,
+			"IT": true,
+			"MX": true,
+			"BR": true,
+			"ZA": true,
+			"IN": true,
+			"JP": true,
+			"AU": true,
+			"NZ": true,
+		},
+	}
+}
+
+// Disclosure is what to announce at the start of a call.
+type Disclosure struct {
+	Locale string
+	// RecordingConsent reports whether Text asks for consent to record.
+	RecordingConsent bool
+	Text             string
+}
+
+// For picks the disclosure for a call to number made on behalf of caller.
+// recording is whether the call is recorded.
+func (p *Policy) For(number phone.Number, recording bool, caller string) Disclosure {
+	locale := p.locale(number)
+	script := p.script(locale)
+
+	d := Disclosure{
+		Locale:           locale,
+		RecordingConsent: recording && p.needsConsent(number),
+		Text:             strings.ReplaceAll(script.AI, callerPlaceholder, caller),
+	}
+	if d.RecordingConsent {
+		// Japanese sentences follow each other without a space
+		separator := " "
+		if strings.HasSuffix(d.Text, "。") {
+			separator = ""
+		}
+		d.Text += separator + script.Recording
+	}
+	return d
+}
+
+func (p *Policy) locale(number phone.Number) string {
+	if locale, ok := p.Locales[number.Region]; ok {
+		return locale
+	}
+	return p.DefaultLocale
+}
+
+// script finds the script for locale, falling back to its language and then
+// to the default locale's language.
+func (p *Policy) script(locale string) Script {
+	for _, key := range []string{locale, language(locale), p.DefaultLocale, language(p.DefaultLocale)} {
+		if script, ok := p.Scripts[key]; ok {
+			return script
+		}
+	}
+	return Script{}
+}
+
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
+
+// needsConsent reports whether recording a call to number needs the
+// recipient's consent. A US number whose state can't be told from its area
+// code might be in an all-party state, so it is asked too, as is a number
+// that couldn't be parsed at all.
+func (p *Policy) needsConsent(number phone.Number) bool {
+	if number.Subdivision != "" {
+		return p.AllPartyConsent[number.Subdivision] || p.AllPartyConsent[number.Region]
+	}
+	if number.Region == "US" || number.Region == "" {
+		return true
+	}
+	return p.AllPartyConsent[number.Region]
+}
@@ -0,0 +1,159 @@
+package compliance
+
+import (
+	"testing"
+
+	"goDial/internal/phone"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_For(t *testing.T) {
+	tests := []struct {
+		name           string
+		number         string
+		recording      bool
+		expectLocale   string
+		expectConsent  bool
+		expectContains string
+	}{
+		{
+			name:           "not recorded only discloses the ai",
+			number:         "+14155550123",
+			expectLocale:   "en-US",
+			expectContains: "automated AI assistant calling on behalf of Sam",
+		},
+		{
+			name:           "recorded in an all-party state",
+			number:         "+14155550123", // California
+			recording:      true,
+			expectLocale:   "en-US",
+			expectConsent:  true,
+			expectContains: "you consent to the recording",
+		},
+		{
+			name:         "recorded in a one-party state",
+			number:       "+12125550123", // New York
+			recording:    true,
+			expectLocale: "en-US",
+		},
+		{
+			name:          "recorded where the state is unknown",
+			number:        "+13336664444",
+			recording:     true,
+			expectLocale:  "en-US",
+			expectConsent: true,
+		},
+		{
+			name:           "mexico in spanish",
+			number:         "+525512345678",
+			recording:      true,
+			expectLocale:   "es-MX",
+			expectConsent:  true,
+			expectContains: "en nombre de Sam. Esta llamada está siendo grabada.",
+		},
+		{
+			name:           "germany in german",
+			number:         "+4930123456",
+			expectLocale:   "de-DE",
+			expectContains: "im Auftrag von Sam",
+		},
+	}
+
+	policy := DefaultPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := phone.Parse(tt.number, "US")
+			require.NoError(t, err)
+
+			d := policy.For(number, tt.recording, "Sam")
+			assert.Equal(t, tt.expectLocale, d.Locale)
+			assert.Equal(t, tt.expectConsent, d.RecordingConsent)
+			assert.Contains(t, d.Text, tt.expectContains)
+			assert.NotContains(t, d.Text, callerPlaceholder)
+		})
+	}
+}
+
+func TestPolicy_ScriptFallback(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Scripts["en-GB"] = Script{AI: "Good afternoon, an automated AI assistant here for {caller}.", Recording: "Mind, this call is recorded."}
+	policy.Locales["FR"] = "fr-CA"
+
+	gb, err := phone.Parse("+442079460958", "US")
+	require.NoError(t, err)
+	d := policy.For(gb, true, "Sam")
+	assert.Equal(t, "Good afternoon, an automated AI assistant here for Sam. Mind, this call is recorded.", d.Text)
+
+	// no fr-CA script, so the plain french one is used
+	fr, err := phone.Parse("+33142685300", "US")
+	require.NoError(t, err)
+	d = policy.For(fr, false, "Sam")
+	assert.Equal(t, "fr-CA", d.Locale)
+	assert.Contains(t, d.Text, "de la part de Sam")
+}
+
+// TestDefaultPolicy_Scripts pins each language's disclosure, as the recipient
+// hears it on a recorded call, so a change to one is a deliberate one.
+func TestDefaultPolicy_Scripts(t *testing.T) {
+	tests := []struct {
+		script string
+		number string
+		expect string
+	}{
+		{
+			script: "en",
+			number: "+14155550123",
+			expect: "Hello, this is an automated AI assistant calling on behalf of Sam. This call is being recorded. By staying on the line, you consent to the recording.",
+		},
+		{
+			script: "es",
+			number: "+525512345678",
+			expect: "Hola, le habla un asistente automatizado de inteligencia artificial en nombre de Sam. Esta llamada está siendo grabada. Si permanece en la línea, acepta la grabación.",
+		},
+		{
+			script: "fr",
+			number: "+33142685300",
+			expect: "Bonjour, vous êtes en communication avec un assistant automatisé à intelligence artificielle qui appelle de la part de Sam. Cet appel est enregistré. En restant en ligne, vous acceptez l'enregistrement.",
+		},
+		{
+			script: "de",
+			number: "+4930123456",
+			expect: "Guten Tag, hier spricht ein automatisierter KI-Assistent im Auftrag von Sam. Dieses Gespräch wird aufgezeichnet. Wenn Sie in der Leitung bleiben, stimmen Sie der Aufzeichnung zu.",
+		},
+		{
+			script: "it",
+			number: "+390612345678",
+			expect: "Buongiorno, sono un assistente automatico di intelligenza artificiale che chiama per conto di Sam. Questa chiamata viene registrata. Restando in linea, acconsente alla registrazione.",
+		},
+		{
+			script: "pt",
+			number: "+5511912345678",
+			expect: "Olá, aqui fala um assistente automatizado de inteligência artificial ligando em nome de Sam. Esta ligação está sendo gravada. Ao permanecer na linha, você concorda com a gravação.",
+		},
+		{
+			// an outbound call opens with an apology for calling, not thanks
+			// for being called
+			script: "ja",
+			number: "+81312345678",
+			expect: "突然のお電話失礼いたします。こちらはSamの代理でお電話しております、自動AIアシスタントです。この通話は録音されています。通話を続けることで、録音に同意したものとみなされます。",
+		},
+	}
+
+	policy := DefaultPolicy()
+	var tested []string
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			number, err := phone.Parse(tt.number, "US")
+			require.NoError(t, err)
+			d := policy.For(number, true, "Sam")
+			assert.Equal(t, tt.script, d.Locale[:2])
+			assert.Equal(t, tt.expect, d.Text)
+		})
+		tested = append(tested, tt.script)
+	}
+	for script := range policy.Scripts {
+		assert.Contains(t, tested, script, "the %s script isn't tested", script)
+	}
+}
@@ -0,0 +1,354 @@
+// Package config is goDial's configuration: where it listens and keeps its
+// database, which model it talks to and what minutes cost. Load builds it
+// from the defaults, then a JSON config file, then environment variables,
+// then command line flags, each overriding the one before.
+//
+//	GODIAL_CONFIG=/etc/godial.json GODIAL_ADDR=:9000 goDial --db /var/lib/godial/goDial.db
+//
+// The packages that need a setting are handed it when they are built, rather
+// than reading the environment themselves.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is every setting goDial runs with.
+type Config struct {
+	// Addr is the address the server listens on.
+	Addr string `json:"addr"`
+	// DBPath is the SQLite database's file.
+	DBPath string `json:"db_path"`
+	// Environment is development or production. Development relaxes checks
+	// that get in the way of running goDial on one machine, such as
+	// webhooks to it.
+	Environment string `json:"environment"`
+	// DevUserEmail is the account every request acts as until sign-in
+	// exists.
+	DevUserEmail string `json:"dev_user_email"`
+	// ShutdownTimeoutSeconds is how long calls on the line get to finish
+	// when the server is stopped.
+	ShutdownTimeoutSeconds int64      `json:"shutdown_timeout_seconds"`
+	AI                     AI         `json:"ai"`
+	Billing                Billing    `json:"billing"`
+	Stripe                 Stripe     `json:"stripe"`
+	Telephony              Telephony  `json:"telephony"`
+	Recordings             Recordings `json:"recordings"`
+}
+
+// AI is the Anthropic model the agent, call plans and moderation use.
+type AI struct {
+	APIKey string `json:"api_key"`
+	Model  string `json:"model"`
+	// MaxTokens caps the length of any one response. Each use asks for no
+	// more than it needs, up to this.
+	MaxTokens int64 `json:"max_tokens"`
+}
+
+// Billing is what calling minutes cost.
+type Billing struct {
+	PricePerMinuteCents int64 `json:"price_per_minute_cents"`
+	// PurchaseIncrement is how many minutes at a time can be bought.
+	PurchaseIncrement int64 `json:"purchase_increment"`
+}
+
+// Stripe is the account minute purchases are paid to.
+type Stripe struct {
+	SecretKey string `json:"secret_key"`
+}
+
+// Telephony is the SignalWire project calls are placed through. Until it is
+// set, calls fail.
+type Telephony struct {
+	ProjectID string `json:"project_id"`
+	APIToken  string `json:"api_token"`
+	// SpaceURL is the project's space, like example.signalwire.com.
+	SpaceURL string `json:"space_url"`
+	// FromNumber is the number calls are placed from.
+	FromNumber string `json:"from_number"`
+	// PublicURL is where SignalWire reaches this server's webhooks.
+	PublicURL string `json:"public_url"`
+}
+
+// Recordings is where call recordings are kept, and for how long.
+type Recordings struct {
+	Dir           string `json:"dir"`
+	RetentionDays int64  `json:"retention_days"`
+}
+
+// Retention is RetentionDays as a duration.
+func (r Recordings) Retention() time.Duration {
+	return time.Duration(r.RetentionDays) * 24 * time.Hour
+}
+
+// environments
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// defaults
+const (
+	DefaultAddr                = ":8081"
+	DefaultDBPath              = "goDial.db"
+	DefaultDevUserEmail        = "test@test.com"
+	DefaultShutdownTimeout     = 60
+	DefaultModel               = "claude-3-7-sonnet-latest"
+	DefaultMaxTokens           = 1024
+	DefaultPricePerMinuteCents = 50
+	DefaultPurchaseIncrement   = 10
+	DefaultRecordingsDir       = "recordings"
+	DefaultRetentionDays       = 30
+)
+
+// maxTokensLimit is the most tokens the models will write in one response.
+const maxTokensLimit = 8192
+
+// Default returns the configuration goDial runs with when nothing is set. It
+// is safe for production, which is the default environment; development is
+// opted into with GO_ENV or --env.
+func Default() Config {
+	return Config{
+		Addr:                   DefaultAddr,
+		DBPath:                 DefaultDBPath,
+		Environment:            Production,
+		DevUserEmail:           DefaultDevUserEmail,
+		ShutdownTimeoutSeconds: DefaultShutdownTimeout,
+		AI: AI{
+			Model:     DefaultModel,
+			MaxTokens: DefaultMaxTokens,
+		},
+		Billing: Billing{
+			PricePerMinuteCents: DefaultPricePerMinuteCents,
+			PurchaseIncrement:   DefaultPurchaseIncrement,
+		},
+		Recordings: Recordings{
+			Dir:           DefaultRecordingsDir,
+			RetentionDays: DefaultRetentionDays,
+		},
+	}
+}
+
+// setting is one setting, and where it can be given besides the config file.
+type setting struct {
+	name string
+	// flag is its command line flag, "" for secrets, which would show up in
+	// the process list
+	flag  string
+	env   string
+	usage string
+	// one of these points at its field
+	str *string
+	num *int64
+	// secret settings are redacted when the configuration is shown
+	secret bool
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{name: "addr", flag: "addr", env: "GODIAL_ADDR", usage: "the address to listen on", str: &c.Addr},
+		{name: "db_path", flag: "db", env: "GODIAL_DB_PATH", usage: "the SQLite database file", str: &c.DBPath},
+		{name: "environment", flag: "env", env: "GO_ENV", usage: "development or production", str: &c.Environment},
+		{name: "dev_user_email", flag: "dev-user-email", env: "GODIAL_DEV_USER_EMAIL", usage: "the account every request acts as until sign-in exists", str: &c.DevUserEmail},
+		{name: "shutdown_timeout_seconds", flag: "shutdown-timeout-seconds", env: "GODIAL_SHUTDOWN_TIMEOUT_SECONDS", usage: "how long calls on the line get to finish when the server stops", num: &c.ShutdownTimeoutSeconds},
+		{name: "ai.api_key", env: "ANTHROPIC_API_KEY", str: &c.AI.APIKey, secret: true},
+		{name: "ai.model", flag: "ai-model", env: "GODIAL_AI_MODEL", usage: "the Anthropic model to use", str: &c.AI.Model},
+		{name: "ai.max_tokens", flag: "ai-max-tokens", env: "GODIAL_AI_MAX_TOKENS", usage: "the most tokens in any one model response", num: &c.AI.MaxTokens},
+		{name: "billing.price_per_minute_cents", flag: "price-per-minute-cents", env: "GODIAL_PRICE_PER_MINUTE_CENTS", usage: "what a calling minute costs, in cents", num: &c.Billing.PricePerMinuteCents},
+		{name: "billing.purchase_increment", flag: "purchase-increment", env: "GODIAL_PURCHASE_INCREMENT", usage: "how many minutes at a time can be bought", num: &c.Billing.PurchaseIncrement},
+		{name: "stripe.secret_key", env: "STRIPE_SECRET_API_KEY", str: &c.Stripe.SecretKey, secret: true},
+		{name: "telephony.project_id", flag: "signalwire-project-id", env: "SIGNALWIRE_PROJECT_ID", usage: "the SignalWire project calls are placed through", str: &c.Telephony.ProjectID},
+		{name: "telephony.api_token", env: "SIGNALWIRE_API_TOKEN", str: &c.Telephony.APIToken, secret: true},
+		{name: "telephony.space_url", flag: "signalwire-space-url", env: "SIGNALWIRE_SPACE_URL", usage: "the SignalWire project's space, like example.signalwire.com", str: &c.Telephony.SpaceURL},
+		{name: "telephony.from_number", flag: "from-number", env: "SIGNALWIRE_FROM_NUMBER", usage: "the number calls are placed from", str: &c.Telephony.FromNumber},
+		{name: "telephony.public_url", flag: "public-url", env: "GODIAL_PUBLIC_URL", usage: "where SignalWire reaches this server's webhooks", str: &c.Telephony.PublicURL},
+		{name: "recordings.dir", flag: "recordings-dir", env: "GODIAL_RECORDINGS_DIR", usage: "the directory call recordings are kept in", str: &c.Recordings.Dir},
+		{name: "recordings.retention_days", flag: "recording-retention-days", env: "GODIAL_RECORDING_RETENTION_DAYS", usage: "how many days call recordings are kept", num: &c.Recordings.RetentionDays},
+	}
+}
+
+// value is the setting's value, as it would be given.
+func (s setting) value() string {
+	if s.str != nil {
+		return *s.str
+	}
+	return strconv.FormatInt(*s.num, 10)
+}
+
+func (s setting) set(value string) error {
+	if s.str != nil {
+		*s.str = value
+		return nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s must be a whole number, not %q", s.name, value)
+	}
+	*s.num = n
+	return nil
+}
+
+// Load reads the configuration from the config file at --config or
+// GODIAL_CONFIG, the environment and the command line flags in args, and
+// checks it. It returns the arguments after the flags. Flag problems and
+// -help are written to output.
+func Load(args []string, getenv func(string) string, output io.Writer) (Config, []string, error) {
+	c := Default()
+	settings := c.settings()
+
+	fs := flag.NewFlagSet("goDial", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(output, "Usage: goDial [flags] [command]\n\nRun \"goDial help\" for the commands. Flags override the environment, which\noverrides the config file.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	file := fs.String("config", "", "a JSON config file (env GODIAL_CONFIG)")
+	flags := map[string]*string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			flags[s.flag] = fs.String(s.flag, s.value(), fmt.Sprintf("%s (env %s)", s.usage, s.env))
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	path := *file
+	if path == "" {
+		path = getenv("GODIAL_CONFIG")
+	}
+	if path != "" {
+		if err := c.readFile(path); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				return Config{}, nil, fmt.Errorf("error reading %s: %w", s.env, err)
+			}
+		}
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(*flags[s.flag]); setErr != nil {
+					err = fmt.Errorf("error reading --%s: %w", s.flag, setErr)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+// readFile reads the settings in the JSON config file at path over c.
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	// a misspelt setting would otherwise be quietly ignored
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("error reading config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks every setting, and returns what is wrong with them.
+func (c Config) Validate() error {
+	var errs []error
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil || port == "" {
+		errs = append(errs, fmt.Errorf("addr must be a host and port, like :8081, not %q", c.Addr))
+	}
+	if strings.TrimSpace(c.DBPath) == "" {
+		errs = append(errs, errors.New("db_path must be set"))
+	}
+	if c.Environment != Development && c.Environment != Production {
+		errs = append(errs, fmt.Errorf("environment must be %s or %s, not %q", Development, Production, c.Environment))
+	}
+	if !strings.Contains(c.DevUserEmail, "@") {
+		errs = append(errs, fmt.Errorf("dev_user_email must be an email address, not %q", c.DevUserEmail))
+	}
+	if c.ShutdownTimeoutSeconds < 1 {
+		errs = append(errs, fmt.Errorf("shutdown_timeout_seconds must be at least 1, not %d", c.ShutdownTimeoutSeconds))
+	}
+	if strings.TrimSpace(c.AI.Model) == "" {
+		errs = append(errs, errors.New("ai.model must be set"))
+	}
+	if c.AI.MaxTokens < 1 || c.AI.MaxTokens > maxTokensLimit {
+		errs = append(errs, fmt.Errorf("ai.max_tokens must be between 1 and %d, not %d", maxTokensLimit, c.AI.MaxTokens))
+	}
+	if c.Billing.PricePerMinuteCents < 1 {
+		errs = append(errs, fmt.Errorf("billing.price_per_minute_cents must be at least 1, not %d", c.Billing.PricePerMinuteCents))
+	}
+	if c.Billing.PurchaseIncrement < 1 {
+		errs = append(errs, fmt.Errorf("billing.purchase_increment must be at least 1, not %d", c.Billing.PurchaseIncrement))
+	}
+	if u := c.Telephony.PublicURL; u != "" && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		errs = append(errs, fmt.Errorf("telephony.public_url must be an http(s) URL, not %q", u))
+	}
+	if strings.TrimSpace(c.Recordings.Dir) == "" {
+		errs = append(errs, errors.New("recordings.dir must be set"))
+	}
+	if c.Recordings.RetentionDays < 1 {
+		errs = append(errs, fmt.Errorf("recordings.retention_days must be at least 1, not %d", c.Recordings.RetentionDays))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// Dev reports whether goDial runs in development.
+func (c Config) Dev() bool {
+	return c.Environment == Development
+}
+
+// ShutdownTimeout is ShutdownTimeoutSeconds as a duration.
+func (c Config) ShutdownTimeout() time.Duration {
+	return time.Duration(c.ShutdownTimeoutSeconds) * time.Second
+}
+
+// String lists every setting, one per line, with secrets redacted, so the
+// configuration can be logged at startup. Secrets only show whether they are
+// set.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, s := range c.settings() {
+		var value string
+		switch {
+		case s.secret && *s.str != "":
+			value = "[redacted]"
+		case s.secret:
+			value = "[not set]"
+		case s.str != nil:
+			value = strconv.Quote(*s.str)
+		default:
+			value = s.value()
+		}
+		fmt.Fprintf(&b, "%s = %s\n", s.name, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a config file, and returns its path.
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "godial.json")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	file := writeFile(t, `{
+		"addr": ":9000",
+		"db_path": "/var/lib/godial/goDial.db",
+		"ai": {"model": "file-model", "max_tokens": 2048},
+		"billing": {"price_per_minute_cents": 40}
+	}`)
+
+	tests := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		expected     func(*Config)
+		expectedArgs []string
+	}{
+		{
+			name:     "defaults",
+			expected: func(*Config) {},
+		},
+		{
+			name: "config file from the environment",
+			env:  map[string]string{"GODIAL_CONFIG": file},
+			expected: func(c *Config) {
+				c.Addr = ":9000"
+				c.DBPath = "/var/lib/godial/goDial.db"
+				c.AI.Model = "file-model"
+				c.AI.MaxTokens = 2048
+				c.Billing.PricePerMinuteCents = 40
+			},
+		},
+		{
+			name: "environment overrides the config file",
+			args: []string{"--config", file},
+			env: map[string]string{
+				"GODIAL_ADDR":               "localhost:9001",
+				"GODIAL_AI_MAX_TOKENS":      "512",
+				"GODIAL_PURCHASE_INCREMENT": "30",
+				"ANTHROPIC_API_KEY":         "sk-ant-test",
+				"STRIPE_SECRET_API_KEY":     "sk_test",
+				"SIGNALWIRE_API_TOKEN":      "PT-test",
+				"GODIAL_RECORDINGS_DIR":     "/var/lib/godial/recordings",
+			},
+			expected: func(c *Config) {
+				c.Addr = "localhost:9001"
+				c.DBPath = "/var/lib/godial/goDial.db"
+				c.AI.APIKey = "sk-ant-test"
+				c.AI.Model = "file-model"
+				c.AI.MaxTokens = 512
+				c.Billing.PricePerMinuteCents = 40
+				c.Billing.PurchaseIncrement = 30
+				c.Stripe.SecretKey = "sk_test"
+				c.Telephony.APIToken = "PT-test"
+				c.Recordings.Dir = "/var/lib/godial/recordings"
+			},
+		},
+		{
+			name: "flags override the environment",
+			args: []string{"--config", file, "--addr", ":9002", "--ai-max-tokens", "100", "--recording-retention-days", "7", "migrate", "up"},
+			env:  map[string]string{"GODIAL_ADDR": ":9001", "GODIAL_DEV_USER_EMAIL": "dev@example.com", "GODIAL_RECORDING_RETENTION_DAYS": "90", "GO_ENV": "development"},
+			expected: func(c *Config) {
+				c.Environment = Development
+				c.Addr = ":9002"
+				c.Recordings.RetentionDays = 7
+				c.DBPath = "/var/lib/godial/goDial.db"
+				c.DevUserEmail = "dev@example.com"
+				c.AI.Model = "file-model"
+				c.AI.MaxTokens = 100
+				c.Billing.PricePerMinuteCents = 40
+			},
+			expectedArgs: []string{"migrate", "up"},
+		},
+		{
+			name:     "a flag given as its default still overrides",
+			args:     []string{"--addr", DefaultAddr},
+			env:      map[string]string{"GODIAL_ADDR": ":9001"},
+			expected: func(*Config) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := Default()
+			tt.expected(&expected)
+
+			c, args, err := Load(tt.args, func(name string) string { return tt.env[name] }, &bytes.Buffer{})
+			require.NoError(t, err)
+			assert.Equal(t, expected, c)
+			assert.Equal(t, tt.expectedArgs, append([]string(nil), args...))
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		file          string
+		expectedError string
+	}{
+		{
+			name:          "unknown setting in the config file",
+			file:          `{"ai": {"modle": "typo"}}`,
+			expectedError: `json: unknown field "modle"`,
+		},
+		{
+			name:          "missing config file",
+			args:          []string{"--config", "/nonexistent/godial.json"},
+			expectedError: "error reading config: open /nonexistent/godial.json",
+		},
+		{
+			name:          "number in the environment",
+			env:           map[string]string{"GODIAL_PRICE_PER_MINUTE_CENTS": "fifty"},
+			expectedError: `error reading GODIAL_PRICE_PER_MINUTE_CENTS: billing.price_per_minute_cents must be a whole number, not "fifty"`,
+		},
+		{
+			name:          "number flag",
+			args:          []string{"--ai-max-tokens", "lots"},
+			expectedError: `error reading --ai-max-tokens: ai.max_tokens must be a whole number, not "lots"`,
+		},
+		{
+			name:          "address without a port",
+			args:          []string{"--addr", "localhost"},
+			expectedError: `invalid config: addr must be a host and port, like :8081, not "localhost"`,
+		},
+		{
+			name: "every invalid setting",
+			file: `{"db_path": " ", "environment": "staging", "dev_user_email": "dev", "shutdown_timeout_seconds": 0, "ai": {"model": "", "max_tokens": 100000}, "billing": {"price_per_minute_cents": 0, "purchase_increment": -5}, "telephony": {"public_url": "godial.example.com"}, "recordings": {"dir": "", "retention_days": 0}}`,
+			expectedError: "invalid config: db_path must be set\n" +
+				`environment must be development or production, not "staging"` + "\n" +
+				`dev_user_email must be an email address, not "dev"` + "\n" +
+				"shutdown_timeout_seconds must be at least 1, not 0\n" +
+				"ai.model must be set\n" +
+				"ai.max_tokens must be between 1 and 8192, not 100000\n" +
+				"billing.price_per_minute_cents must be at least 1, not 0\n" +
+				"billing.purchase_increment must be at least 1, not -5\n" +
+				`telephony.public_url must be an http(s) URL, not "godial.example.com"` + "\n" +
+				"recordings.dir must be set\n" +
+				"recordings.retention_days must be at least 1, not 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"--config", writeFile(t, tt.file)}, args...)
+			}
+			_, _, err := Load(args, func(name string) string { return tt.env[name] }, &bytes.Buffer{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestLoad_Help(t *testing.T) {
+	var output bytes.Buffer
+	_, _, err := Load([]string{"--help"}, func(string) string { return "" }, &output)
+	assert.ErrorIs(t, err, flag.ErrHelp)
+	assert.Contains(t, output.String(), "Usage: goDial [flags] [command]")
+	assert.Contains(t, output.String(), "(env GODIAL_DB_PATH)")
+	assert.NotContains(t, output.String(), "ANTHROPIC_API_KEY", "secrets can't be given as flags")
+	assert.NotContains(t, output.String(), "SIGNALWIRE_API_TOKEN", "secrets can't be given as flags")
+}
+
+func TestConfig_String(t *testing.T) {
+	c := Default()
+	c.AI.APIKey = "sk-ant-secret"
+	c.Telephony.APIToken = "PT-secret"
+
+	s := c.String()
+	assert.Contains(t, s, "addr = \":8081\"\n")
+	assert.Contains(t, s, "ai.max_tokens = 1024\n")
+	assert.Contains(t, s, "ai.api_key = [redacted]\n")
+	assert.Contains(t, s, "stripe.secret_key = [not set]\n")
+	assert.Contains(t, s, "telephony.api_token = [redacted]\n")
+	assert.Contains(t, s, "recordings.retention_days = 30\n")
+	assert.NotContains(t, s, "sk-ant-secret")
+	assert.NotContains(t, s, "PT-secret")
+}
@@ -0,0 +1,200 @@
+// Package contacts is each user's address book of the people they call, so a
+// number and what the agent should know about someone are typed once and
+// every call to them shows up in one history.
+package contacts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/phone"
+)
+
+// defaultRegion is where numbers typed without a country code are from,
+// matching the call form.
+const defaultRegion = "US"
+
+// limits on what is saved on a contact
+const (
+	maxNameLength  = 200
+	maxNotesLength = 2000
+)
+
+// ErrDuplicate is returned when the user already has a contact with the
+// number being saved.
+var ErrDuplicate = errors.New("a contact with this number already exists")
+
+// Form is a contact as typed by the user.
+type Form struct {
+	Name              string
+	PhoneNumber       string
+	Notes             string
+	Timezone          string
+	PreferredLanguage string
+}
+
+// languageTag loosely matches a BCP 47 tag such as en, es or pt-BR.
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Validate trims the form and normalizes its number to E.164. It returns a
+// problem to show the user, or "" if the form can be saved.
+func (f *Form) Validate() string {
+	f.Name = strings.TrimSpace(f.Name)
+	f.Notes = strings.TrimSpace(f.Notes)
+	f.Timezone = strings.TrimSpace(f.Timezone)
+	f.PreferredLanguage = strings.TrimSpace(f.PreferredLanguage)
+
+	if f.Name == "" {
+		return "Enter a name."
+	}
+	if len(f.Name) > maxNameLength {
+		return fmt.Sprintf("Keep the name under %d characters.", maxNameLength)
+	}
+	if len(f.Notes) > maxNotesLength {
+		return fmt.Sprintf("Keep the notes under %d characters.", maxNotesLength)
+	}
+
+	number, err := phone.Parse(f.PhoneNumber, defaultRegion)
+	if err != nil {
+		return "Enter a valid phone number, e.g. (333) 666-4444 or +44 20 7946 0958."
+	}
+	f.PhoneNumber = number.E164
+
+	if f.Timezone != "" {
+		if _, err := time.LoadLocation(f.Timezone); err != nil || f.Timezone == "Local" {
+			return "Enter a time zone such as America/Chicago, or leave it empty."
+		}
+	}
+	if f.PreferredLanguage != "" && !languageTag.MatchString(f.PreferredLanguage) {
+		return "Enter a language code such as en or es, or leave it empty."
+	}
+	return ""
+}
+
+// Create saves a validated form as a new contact of userID's, and links the
+// calls already placed to its number.
+func Create(ctx context.Context, q database.Querier, userID int64, f Form) (database.Contact, error) {
+	if _, err := q.GetContactByNumber(ctx, database.GetContactByNumberParams{UserID: userID, PhoneNumber: f.PhoneNumber}); err == nil {
+		return database.Contact{}, ErrDuplicate
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return database.Contact{}, fmt.Errorf("error checking for a contact with %s: %w", f.PhoneNumber, err)
+	}
+
+	contact, err := q.CreateContact(ctx, database.CreateContactParams{
+		UserID:            userID,
+		Name:              f.Name,
+		PhoneNumber:       f.PhoneNumber,
+		Notes:             f.Notes,
+		Timezone:          sql.NullString{String: f.Timezone, Valid: f.Timezone != ""},
+		PreferredLanguage: sql.NullString{String: f.PreferredLanguage, Valid: f.PreferredLanguage != ""},
+	})
+	if err != nil {
+		return database.Contact{}, fmt.Errorf("error saving contact: %w", err)
+	}
+	return contact, linkCalls(ctx, q, contact)
+}
+
+// Update saves a validated form over one of userID's contacts. Calls to the
+// old number stay linked; calls to a new one are linked too.
+func Update(ctx context.Context, q database.Querier, userID, id int64, f Form) (database.Contact, error) {
+	existing, err := q.GetContactByNumber(ctx, database.GetContactByNumberParams{UserID: userID, PhoneNumber: f.PhoneNumber})
+	if err == nil && existing.ID != id {
+		return database.Contact{}, ErrDuplicate
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.Contact{}, fmt.Errorf("error checking for a contact with %s: %w", f.PhoneNumber, err)
+	}
+
+	contact, err := q.UpdateContact(ctx, database.UpdateContactParams{
+		Name:              f.Name,
+		PhoneNumber:       f.PhoneNumber,
+		Notes:             f.Notes,
+		Timezone:          sql.NullString{String: f.Timezone, Valid: f.Timezone != ""},
+		PreferredLanguage: sql.NullString{String: f.PreferredLanguage, Valid: f.PreferredLanguage != ""},
+		ID:                id,
+		UserID:            userID,
+	})
+	if err != nil {
+		return database.Contact{}, fmt.Errorf("error saving contact %d: %w", id, err)
+	}
+	return contact, linkCalls(ctx, q, contact)
+}
+
+func linkCalls(ctx context.Context, q database.Querier, contact database.Contact) error {
+	if err := q.LinkCallsToContact(ctx, database.LinkCallsToContactParams{
+		ContactID:   sql.NullInt64{Int64: contact.ID, Valid: true},
+		UserID:      contact.UserID,
+		PhoneNumber: contact.PhoneNumber,
+	}); err != nil {
+		return fmt.Errorf("error linking calls to contact %d: %w", contact.ID, err)
+	}
+	return nil
+}
+
+// FormOf is the form that edits contact.
+func FormOf(contact database.Contact) Form {
+	return Form{
+		Name:              contact.Name,
+		PhoneNumber:       contact.PhoneNumber,
+		Notes:             contact.Notes,
+		Timezone:          contact.Timezone.String,
+		PreferredLanguage: contact.PreferredLanguage.String,
+	}
+}
+
+// RecipientContext is what the call form's "Recipient Name & Info About Them"
+// is filled with for contact.
+func RecipientContext(contact database.Contact) string {
+	parts := []string{contact.Name}
+	if notes := strings.TrimRight(contact.Notes, ". "); notes != "" {
+		parts = append(parts, notes)
+	}
+	if contact.PreferredLanguage.Valid {
+		parts = append(parts, "Preferred language: "+contact.PreferredLanguage.String)
+	}
+	return strings.Join(parts, ". ")
+}
+
+// suggestLimit is how many contacts the call form suggests at once.
+const suggestLimit = 8
+
+// Suggest returns userID's contacts whose name or number contains what was
+// typed, for the call form's autocomplete.
+func Suggest(ctx context.Context, q database.Querier, userID int64, typed string) ([]database.Contact, error) {
+	typed = strings.TrimSpace(typed)
+	if typed == "" {
+		return nil, nil
+	}
+
+	// numbers are stored in E.164, so only the digits typed are matched
+	numberPattern := "-" // matches nothing
+	if digits := strings.Map(keepDigits, typed); digits != "" {
+		numberPattern = "%" + digits + "%"
+	}
+
+	suggestions, err := q.SuggestContacts(ctx, database.SuggestContactsParams{
+		UserID:      userID,
+		Name:        "%" + escapeLike.Replace(typed) + "%",
+		PhoneNumber: numberPattern,
+		Limit:       suggestLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error suggesting contacts: %w", err)
+	}
+	return suggestions, nil
+}
+
+func keepDigits(r rune) rune {
+	if r >= '0' && r <= '9' {
+		return r
+	}
+	return -1
+}
+
+// escapeLike stops what was typed from being read as LIKE wildcards.
+var escapeLike = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
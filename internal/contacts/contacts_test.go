@@ -0,0 +1,189 @@
+package contacts
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForm_Validate(t *testing.T) {
+	tests := []struct {
+		name            string
+		form            Form
+		expectedProblem string
+		expectedNumber  string
+	}{
+		{
+			name:           "normalizes the number",
+			form:           Form{Name: " Sam ", PhoneNumber: "(333) 666-4444", Timezone: "America/Chicago", PreferredLanguage: "pt-BR"},
+			expectedNumber: "+13336664444",
+		},
+		{
+			name:            "needs a name",
+			form:            Form{Name: "  ", PhoneNumber: "(333) 666-4444"},
+			expectedProblem: "Enter a name.",
+		},
+		{
+			name:            "needs a valid number",
+			form:            Form{Name: "Sam", PhoneNumber: "12"},
+			expectedProblem: "Enter a valid phone number, e.g. (333) 666-4444 or +44 20 7946 0958.",
+		},
+		{
+			name:            "unknown time zone",
+			form:            Form{Name: "Sam", PhoneNumber: "(333) 666-4444", Timezone: "Mars/Olympus"},
+			expectedProblem: "Enter a time zone such as America/Chicago, or leave it empty.",
+		},
+		{
+			name:            "server's local time isn't a time zone",
+			form:            Form{Name: "Sam", PhoneNumber: "(333) 666-4444", Timezone: "Local"},
+			expectedProblem: "Enter a time zone such as America/Chicago, or leave it empty.",
+		},
+		{
+			name:            "not a language code",
+			form:            Form{Name: "Sam", PhoneNumber: "(333) 666-4444", PreferredLanguage: "Spanish please"},
+			expectedProblem: "Enter a language code such as en or es, or leave it empty.",
+		},
+		{
+			name:            "notes too long",
+			form:            Form{Name: "Sam", PhoneNumber: "(333) 666-4444", Notes: strings.Repeat("a", maxNotesLength+1)},
+			expectedProblem: "Keep the notes under 2000 characters.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := tt.form
+			assert.Equal(t, tt.expectedProblem, form.Validate())
+			if tt.expectedNumber != "" {
+				assert.Equal(t, tt.expectedNumber, form.PhoneNumber)
+				assert.Equal(t, "Sam", form.Name)
+			}
+		})
+	}
+}
+
+func setupTestDB(t *testing.T) (*database.DB, database.User) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "contacts_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	user, err := db.CreateUser(context.Background(), database.CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	return db, user
+}
+
+func TestCreateAndUpdate(t *testing.T) {
+	db, user := setupTestDB(t)
+	ctx := context.Background()
+
+	earlier, err := db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "say hi"})
+	require.NoError(t, err)
+	other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	othersCall, err := db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "+13336664444", Objective: "say hi"})
+	require.NoError(t, err)
+
+	sam, err := Create(ctx, db, user.ID, Form{Name: "Sam", PhoneNumber: "+13336664444"})
+	require.NoError(t, err)
+
+	calls, err := db.ListCallsByContact(ctx, sam.ID)
+	require.NoError(t, err)
+	require.Len(t, calls, 1, "calls placed before the contact was added are linked")
+	assert.Equal(t, earlier.ID, calls[0].ID)
+	othersCall, err = db.GetCall(ctx, othersCall.ID)
+	require.NoError(t, err)
+	assert.False(t, othersCall.ContactID.Valid, "other users' calls are never linked")
+
+	_, err = Create(ctx, db, user.ID, Form{Name: "Sam again", PhoneNumber: "+13336664444"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	_, err = Create(ctx, db, other.ID, Form{Name: "Sam", PhoneNumber: "+13336664444"})
+	assert.NoError(t, err, "numbers are only unique per user")
+
+	alex, err := Create(ctx, db, user.ID, Form{Name: "Alex", PhoneNumber: "+13336665555"})
+	require.NoError(t, err)
+	_, err = Update(ctx, db, user.ID, alex.ID, Form{Name: "Alex", PhoneNumber: "+13336664444"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	sam, err = Update(ctx, db, user.ID, sam.ID, Form{Name: "Sam Smith", PhoneNumber: "+13336664444", PreferredLanguage: "es"})
+	require.NoError(t, err)
+	assert.Equal(t, "Sam Smith", sam.Name)
+	assert.Equal(t, sql.NullString{String: "es", Valid: true}, sam.PreferredLanguage)
+
+	_, err = Update(ctx, db, other.ID, sam.ID, Form{Name: "Mine now", PhoneNumber: "+13336667777"})
+	assert.ErrorIs(t, err, sql.ErrNoRows, "only the owner can edit a contact")
+}
+
+func TestSuggest(t *testing.T) {
+	db, user := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, f := range []Form{
+		{Name: "Sam Smith", PhoneNumber: "+13336664444"},
+		{Name: "Grandma Jo", PhoneNumber: "+13336665555"},
+		{Name: "100% Plumbing", PhoneNumber: "+442079460958"},
+	} {
+		_, err := Create(ctx, db, user.ID, f)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		typed    string
+		expected []string
+	}{
+		{name: "part of a name", typed: "sam", expected: []string{"Sam Smith"}},
+		{name: "digits of a number however they're typed", typed: "(333) 666-55", expected: []string{"Grandma Jo"}},
+		{name: "matches both", typed: "333", expected: []string{"Grandma Jo", "Sam Smith"}},
+		{name: "wildcards are literal", typed: "0%", expected: []string{"100% Plumbing"}},
+		{name: "underscore is literal", typed: "_", expected: nil},
+		{name: "nothing typed", typed: "  ", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions, err := Suggest(ctx, db, user.ID, tt.typed)
+			require.NoError(t, err)
+			var names []string
+			for _, contact := range suggestions {
+				names = append(names, contact.Name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
+
+func TestRecipientContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		contact  database.Contact
+		expected string
+	}{
+		{
+			name:     "name only",
+			contact:  database.Contact{Name: "Sam"},
+			expected: "Sam",
+		},
+		{
+			name: "notes and language",
+			contact: database.Contact{
+				Name:              "Grandma Jo",
+				Notes:             "Hard of hearing, speak slowly.",
+				PreferredLanguage: sql.NullString{String: "es", Valid: true},
+			},
+			expected: "Grandma Jo. Hard of hearing, speak slowly. Preferred language: es",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RecipientContext(tt.contact))
+		})
+	}
+}
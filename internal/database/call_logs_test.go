@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallLogQueries(t *testing.T) {
+	db, err := InitDB(filepath.Join(t.TempDir(), "call_logs_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	call, err := db.CreateCall(ctx, CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "order a cake"})
+	require.NoError(t, err)
+	other, err := db.CreateCall(ctx, CreateCallParams{UserID: user.ID, PhoneNumber: "+13336665555", Objective: "cancel the cake"})
+	require.NoError(t, err)
+
+	start := time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC)
+	entries := []struct {
+		callID      int64
+		messageType string
+		content     string
+		at          time.Time
+	}{
+		{call.ID, "system", "attempt 1 answered", start},
+		{call.ID, "ai_response", "Hi, is this the bakery?", start},
+		{call.ID, "user_speech", "Yes, how can I help?", start.Add(3 * time.Second)},
+		{other.ID, "ai_response", "Hi, I'd like to cancel an order.", start.Add(3 * time.Second)},
+		{call.ID, "ai_response", "I'd like to order a cake.", start.Add(5 * time.Second)},
+	}
+	var logs []CallLog
+	for _, entry := range entries {
+		log, err := db.CreateCallLog(ctx, CreateCallLogParams{CallID: entry.callID, MessageType: entry.messageType, Content: entry.content})
+		require.NoError(t, err)
+		// timestamps default to the time of the insert; pin them
+		_, err = db.ExecContext(ctx, "UPDATE call_logs SET timestamp = ? WHERE id = ?", entry.at.Format(time.DateTime), log.ID)
+		require.NoError(t, err)
+		if entry.callID == call.ID {
+			logs = append(logs, log)
+		}
+	}
+
+	transcript, err := db.ListCallLogs(ctx, call.ID)
+	require.NoError(t, err)
+	require.Len(t, transcript, 4)
+	assert.Equal(t, "attempt 1 answered", transcript[0].Content)
+	assert.Equal(t, start.Add(5*time.Second), transcript[3].Timestamp.Time.UTC())
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		afterID  int64
+		expected []string
+	}{
+		{
+			name:     "everything",
+			since:    start,
+			expected: []string{"attempt 1 answered", "Hi, is this the bakery?", "Yes, how can I help?", "I'd like to order a cake."},
+		},
+		{
+			name:     "from a timestamp",
+			since:    start.Add(3 * time.Second),
+			expected: []string{"Yes, how can I help?", "I'd like to order a cake."},
+		},
+		{
+			name:     "after the last entry seen, logged the same second as the next",
+			since:    start,
+			afterID:  logs[0].ID,
+			expected: []string{"Hi, is this the bakery?", "Yes, how can I help?", "I'd like to order a cake."},
+		},
+		{
+			name:    "nothing new",
+			since:   start.Add(5 * time.Second),
+			afterID: logs[3].ID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, err := db.ListCallLogsSince(ctx, ListCallLogsSinceParams{CallID: call.ID, Since: tt.since, AfterID: tt.afterID})
+			require.NoError(t, err)
+			var contents []string
+			for _, log := range since {
+				contents = append(contents, log.Content)
+			}
+			assert.Equal(t, tt.expected, contents)
+		})
+	}
+
+	turns, err := db.CountCallTurns(ctx, call.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), turns, "system entries aren't turns")
+}
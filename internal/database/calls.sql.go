@@ -10,11 +10,75 @@ import (
 	"database/sql"
 )
 
+const approveCall = `-- name: ApproveCall :one
+UPDATE calls
+SET opening_line = ?, call_plan = ?, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND approved_at IS NULL
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
+`
+
+type ApproveCallParams struct {
+	OpeningLine sql.NullString `json:"opening_line"`
+	CallPlan    sql.NullString `json:"call_plan"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) ApproveCall(ctx context.Context, arg ApproveCallParams) (Call, error) {
+	row := q.db.QueryRowContext(ctx, approveCall, arg.OpeningLine, arg.CallPlan, arg.ID)
+	var i Call
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PhoneNumber,
+		&i.RecipientContext,
+		&i.Objective,
+		&i.BackgroundContext,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
+	)
+	return i, err
+}
+
+const cancelCall = `-- name: CancelCall :execrows
+UPDATE calls
+SET status = 'failed', status_reason = ?, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('pending', 'in_progress')
+`
+
+type CancelCallParams struct {
+	StatusReason sql.NullString `json:"status_reason"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) CancelCall(ctx context.Context, arg CancelCallParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelCall, arg.StatusReason, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const completeCall = `-- name: CompleteCall :one
 UPDATE calls
 SET status = 'completed', completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
 `
 
 func (q *Queries) CompleteCall(ctx context.Context, id int64) (Call, error) {
@@ -31,22 +95,52 @@ func (q *Queries) CompleteCall(ctx context.Context, id int64) (Call, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
 	)
 	return i, err
 }
 
 const createCall = `-- name: CreateCall :one
-INSERT INTO calls (user_id, phone_number, recipient_context, objective, background_context)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at
+INSERT INTO calls (
+    user_id, phone_number, recipient_context, objective, background_context,
+    max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end,
+    record, opening_line, call_plan, contact_id, template_id, template_variables,
+    campaign_id
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
 `
 
 type CreateCallParams struct {
-	UserID            int64          `json:"user_id"`
-	PhoneNumber       string         `json:"phone_number"`
-	RecipientContext  sql.NullString `json:"recipient_context"`
-	Objective         string         `json:"objective"`
-	BackgroundContext sql.NullString `json:"background_context"`
+	UserID              int64          `json:"user_id"`
+	PhoneNumber         string         `json:"phone_number"`
+	RecipientContext    sql.NullString `json:"recipient_context"`
+	Objective           string         `json:"objective"`
+	BackgroundContext   sql.NullString `json:"background_context"`
+	MaxAttempts         int64          `json:"max_attempts"`
+	RetrySpacingMinutes int64          `json:"retry_spacing_minutes"`
+	RetryWindowStart    sql.NullString `json:"retry_window_start"`
+	RetryWindowEnd      sql.NullString `json:"retry_window_end"`
+	Record              bool           `json:"record"`
+	OpeningLine         sql.NullString `json:"opening_line"`
+	CallPlan            sql.NullString `json:"call_plan"`
+	ContactID           sql.NullInt64  `json:"contact_id"`
+	TemplateID          sql.NullInt64  `json:"template_id"`
+	TemplateVariables   sql.NullString `json:"template_variables"`
+	CampaignID          sql.NullInt64  `json:"campaign_id"`
 }
 
 func (q *Queries) CreateCall(ctx context.Context, arg CreateCallParams) (Call, error) {
@@ -56,6 +150,17 @@ func (q *Queries) CreateCall(ctx context.Context, arg CreateCallParams) (Call, e
 		arg.RecipientContext,
 		arg.Objective,
 		arg.BackgroundContext,
+		arg.MaxAttempts,
+		arg.RetrySpacingMinutes,
+		arg.RetryWindowStart,
+		arg.RetryWindowEnd,
+		arg.Record,
+		arg.OpeningLine,
+		arg.CallPlan,
+		arg.ContactID,
+		arg.TemplateID,
+		arg.TemplateVariables,
+		arg.CampaignID,
 	)
 	var i Call
 	err := row.Scan(
@@ -69,6 +174,20 @@ func (q *Queries) CreateCall(ctx context.Context, arg CreateCallParams) (Call, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
 	)
 	return i, err
 }
@@ -83,8 +202,53 @@ func (q *Queries) DeleteCall(ctx context.Context, id int64) error {
 	return err
 }
 
+const finishCall = `-- name: FinishCall :one
+UPDATE calls
+SET status = ?, status_reason = ?, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
+`
+
+type FinishCallParams struct {
+	Status       sql.NullString `json:"status"`
+	StatusReason sql.NullString `json:"status_reason"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) FinishCall(ctx context.Context, arg FinishCallParams) (Call, error) {
+	row := q.db.QueryRowContext(ctx, finishCall, arg.Status, arg.StatusReason, arg.ID)
+	var i Call
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PhoneNumber,
+		&i.RecipientContext,
+		&i.Objective,
+		&i.BackgroundContext,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
+	)
+	return i, err
+}
+
 const getCall = `-- name: GetCall :one
-SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at FROM calls
+SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id FROM calls
 WHERE id = ?
 `
 
@@ -102,12 +266,26 @@ func (q *Queries) GetCall(ctx context.Context, id int64) (Call, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
 	)
 	return i, err
 }
 
 const listCallsByStatus = `-- name: ListCallsByStatus :many
-SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at FROM calls
+SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id FROM calls
 WHERE status = ?
 ORDER BY created_at DESC
 `
@@ -132,6 +310,20 @@ func (q *Queries) ListCallsByStatus(ctx context.Context, status sql.NullString)
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.MaxAttempts,
+			&i.RetrySpacingMinutes,
+			&i.RetryWindowStart,
+			&i.RetryWindowEnd,
+			&i.StatusReason,
+			&i.Record,
+			&i.OpeningLine,
+			&i.CallPlan,
+			&i.ApprovedAt,
+			&i.Outcome,
+			&i.ContactID,
+			&i.TemplateID,
+			&i.TemplateVariables,
+			&i.CampaignID,
 		); err != nil {
 			return nil, err
 		}
@@ -147,7 +339,7 @@ func (q *Queries) ListCallsByStatus(ctx context.Context, status sql.NullString)
 }
 
 const listCallsByUser = `-- name: ListCallsByUser :many
-SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at FROM calls
+SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id FROM calls
 WHERE user_id = ?
 ORDER BY created_at DESC
 `
@@ -172,6 +364,89 @@ func (q *Queries) ListCallsByUser(ctx context.Context, userID int64) ([]Call, er
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.CompletedAt,
+			&i.MaxAttempts,
+			&i.RetrySpacingMinutes,
+			&i.RetryWindowStart,
+			&i.RetryWindowEnd,
+			&i.StatusReason,
+			&i.Record,
+			&i.OpeningLine,
+			&i.CallPlan,
+			&i.ApprovedAt,
+			&i.Outcome,
+			&i.ContactID,
+			&i.TemplateID,
+			&i.TemplateVariables,
+			&i.CampaignID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCallsPage = `-- name: ListCallsPage :many
+SELECT id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id FROM calls
+WHERE user_id = ?1
+  AND (?2 IS NULL OR status = ?2)
+  AND (?3 IS NULL OR id < ?3)
+ORDER BY id DESC
+LIMIT ?4
+`
+
+type ListCallsPageParams struct {
+	UserID   int64          `json:"user_id"`
+	Status   sql.NullString `json:"status"`
+	BeforeID sql.NullInt64  `json:"before_id"`
+	Limit    int64          `json:"limit"`
+}
+
+func (q *Queries) ListCallsPage(ctx context.Context, arg ListCallsPageParams) ([]Call, error) {
+	rows, err := q.db.QueryContext(ctx, listCallsPage,
+		arg.UserID,
+		arg.Status,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Call{}
+	for rows.Next() {
+		var i Call
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PhoneNumber,
+			&i.RecipientContext,
+			&i.Objective,
+			&i.BackgroundContext,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CompletedAt,
+			&i.MaxAttempts,
+			&i.RetrySpacingMinutes,
+			&i.RetryWindowStart,
+			&i.RetryWindowEnd,
+			&i.StatusReason,
+			&i.Record,
+			&i.OpeningLine,
+			&i.CallPlan,
+			&i.ApprovedAt,
+			&i.Outcome,
+			&i.ContactID,
+			&i.TemplateID,
+			&i.TemplateVariables,
+			&i.CampaignID,
 		); err != nil {
 			return nil, err
 		}
@@ -186,11 +461,72 @@ func (q *Queries) ListCallsByUser(ctx context.Context, userID int64) ([]Call, er
 	return items, nil
 }
 
+const setCallOutcome = `-- name: SetCallOutcome :exec
+UPDATE calls
+SET outcome = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetCallOutcomeParams struct {
+	Outcome sql.NullString `json:"outcome"`
+	ID      int64          `json:"id"`
+}
+
+func (q *Queries) SetCallOutcome(ctx context.Context, arg SetCallOutcomeParams) error {
+	_, err := q.db.ExecContext(ctx, setCallOutcome, arg.Outcome, arg.ID)
+	return err
+}
+
+const setCallStatus = `-- name: SetCallStatus :one
+UPDATE calls
+SET status = ?, status_reason = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
+`
+
+type SetCallStatusParams struct {
+	Status       sql.NullString `json:"status"`
+	StatusReason sql.NullString `json:"status_reason"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) SetCallStatus(ctx context.Context, arg SetCallStatusParams) (Call, error) {
+	row := q.db.QueryRowContext(ctx, setCallStatus, arg.Status, arg.StatusReason, arg.ID)
+	var i Call
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PhoneNumber,
+		&i.RecipientContext,
+		&i.Objective,
+		&i.BackgroundContext,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
+	)
+	return i, err
+}
+
 const updateCallStatus = `-- name: UpdateCallStatus :one
 UPDATE calls
 SET status = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at
+RETURNING id, user_id, phone_number, recipient_context, objective, background_context, status, created_at, updated_at, completed_at, max_attempts, retry_spacing_minutes, retry_window_start, retry_window_end, status_reason, record, opening_line, call_plan, approved_at, outcome, contact_id, template_id, template_variables, campaign_id
 `
 
 type UpdateCallStatusParams struct {
@@ -212,6 +548,20 @@ func (q *Queries) UpdateCallStatus(ctx context.Context, arg UpdateCallStatusPara
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.CompletedAt,
+		&i.MaxAttempts,
+		&i.RetrySpacingMinutes,
+		&i.RetryWindowStart,
+		&i.RetryWindowEnd,
+		&i.StatusReason,
+		&i.Record,
+		&i.OpeningLine,
+		&i.CallPlan,
+		&i.ApprovedAt,
+		&i.Outcome,
+		&i.ContactID,
+		&i.TemplateID,
+		&i.TemplateVariables,
+		&i.CampaignID,
 	)
 	return i, err
 }
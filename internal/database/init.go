@@ -1,20 +1,16 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/pressly/goose/v3"
 )
 
-//go:embed migrations/*.sql
-var embedMigrations embed.FS
-
 type DB struct {
 	*sql.DB
 	*Queries
@@ -22,6 +18,24 @@ type DB struct {
 
 // InitDB initializes the database connection and runs migrations
 func InitDB(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations
+	if _, err := db.MigrateUp(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	log.Println("Database migrations completed successfully")
+
+	return db, nil
+}
+
+// Open opens the database at dbPath without migrating it, for tools that
+// look at or change its migrations.
+func Open(dbPath string) (*DB, error) {
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -29,21 +43,22 @@ func InitDB(dbPath string) (*DB, error) {
 	}
 
 	// Open database connection
-	sqlDB, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
+	sqlDB, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_busy_timeout=5000")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	// SQLite allows a single writer at a time. Funnel everything through one
+	// connection so concurrent job workers and handlers queue up in Go instead
+	// of failing with "database is locked".
+	sqlDB.SetMaxOpenConns(1)
+
 	// Test the connection
 	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Run migrations
-	if err := runMigrations(sqlDB); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
-	}
-
 	// Create queries instance
 	queries := New(sqlDB)
 
@@ -53,28 +68,16 @@ func InitDB(dbPath string) (*DB, error) {
 	}, nil
 }
 
-// runMigrations runs the database migrations using goose
-func runMigrations(db *sql.DB) error {
-	goose.SetBaseFS(embedMigrations)
-
-	if err := goose.SetDialect("sqlite3"); err != nil {
-		return fmt.Errorf("failed to set goose dialect: %w", err)
-	}
-
-	if err := goose.Up(db, "migrations"); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-
-	log.Println("Database migrations completed successfully")
-	return nil
-}
-
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
-// GetDBPath returns the default database path
-func GetDBPath() string {
-	return "goDial.db"
+// Backup writes a consistent copy of the database to path, which mustn't
+// exist, while it may still be in use.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("error backing up database to %s: %w", path, err)
+	}
+	return nil
 }
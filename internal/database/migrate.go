@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
+
+// Migration is a migration built into the binary, and whether the database
+// has it.
+type Migration struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrations returns a goose provider for the embedded migrations.
+func (db *DB) migrations() (*goose.Provider, error) {
+	fsys, err := fs.Sub(embedMigrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded migrations: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db.DB, fsys)
+	if err != nil {
+		return nil, fmt.Errorf("error loading migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// MigrateUp applies every migration the database doesn't have yet, and
+// returns the names of those it applied.
+func (db *DB) MigrateUp(ctx context.Context) ([]string, error) {
+	// the search index needs FTS5, so say how to get it rather than fail
+	// halfway through with "no such module"
+	var fts5 bool
+	if err := db.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return nil, fmt.Errorf("error checking for FTS5: %w", err)
+	}
+	if !fts5 {
+		return nil, errors.New("this build's SQLite has no FTS5 for call search: build and test with -tags sqlite_fts5")
+	}
+
+	provider, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	applied := make([]string, 0, len(results))
+	for _, result := range results {
+		applied = append(applied, migrationName(result.Source))
+	}
+	return applied, nil
+}
+
+// MigrateDown rolls back the last migration applied, and returns its name, or
+// "" when there was none to roll back.
+func (db *DB) MigrateDown(ctx context.Context) (string, error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return "", err
+	}
+	result, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error rolling back migration: %w", err)
+	}
+	return migrationName(result.Source), nil
+}
+
+// MigrationStatus lists the binary's migrations by version.
+func (db *DB) MigrationStatus(ctx context.Context) ([]Migration, error) {
+	provider, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading migration status: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(statuses))
+	for _, status := range statuses {
+		migrations = append(migrations, Migration{
+			Version:   status.Source.Version,
+			Name:      migrationName(status.Source),
+			Applied:   status.State == goose.StateApplied,
+			AppliedAt: status.AppliedAt,
+		})
+	}
+	return migrations, nil
+}
+
+// migrationName is a migration's file name without its version or extension,
+// like create_users.
+func migrationName(source *goose.Source) string {
+	name := strings.TrimSuffix(filepath.Base(source.Path), filepath.Ext(source.Path))
+	_, name, _ = strings.Cut(name, "_")
+	return name
+}
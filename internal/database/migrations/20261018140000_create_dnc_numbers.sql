@@ -0,0 +1,27 @@
+-- +goose Up
+-- Numbers that must not be called. A NULL user_id blocks the number for
+-- everyone (recipient opt-outs, imported registries); otherwise only that
+-- user is blocked from calling it.
+CREATE TABLE dnc_numbers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    phone_number TEXT NOT NULL,
+    user_id INTEGER,
+    source TEXT NOT NULL CHECK (source IN ('spoken_opt_out', 'keypress_opt_out', 'import', 'manual')),
+    reason TEXT,
+    call_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_dnc_numbers_global ON dnc_numbers(phone_number) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX idx_dnc_numbers_user ON dnc_numbers(phone_number, user_id) WHERE user_id IS NOT NULL;
+
+-- Admins can manage the do-not-call registry
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN is_admin;
+DROP INDEX IF EXISTS idx_dnc_numbers_user;
+DROP INDEX IF EXISTS idx_dnc_numbers_global;
+DROP TABLE IF EXISTS dnc_numbers;
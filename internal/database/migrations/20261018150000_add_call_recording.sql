@@ -0,0 +1,7 @@
+-- +goose Up
+-- Whether the provider records a call. Recorded calls announce it to the
+-- recipient where the law requires their consent.
+ALTER TABLE calls ADD COLUMN record BOOLEAN NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE calls DROP COLUMN record;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Recordings of call attempts, downloaded from the telephony provider into
+-- recording storage. Once a recording is past retention its file is deleted
+-- and purged_at set, keeping the row as a record that it existed.
+CREATE TABLE recordings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    call_id INTEGER NOT NULL,
+    call_attempt_id INTEGER NOT NULL UNIQUE,
+    provider_recording_id TEXT,
+    storage_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    duration_seconds INTEGER NOT NULL DEFAULT 0,
+    recorded_at DATETIME NOT NULL,
+    purged_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE CASCADE,
+    FOREIGN KEY (call_attempt_id) REFERENCES call_attempts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recordings_call ON recordings(call_id);
+CREATE INDEX idx_recordings_unpurged ON recordings(recorded_at) WHERE purged_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS recordings;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- People a user calls again and again. The number is stored in E.164, so a
+-- call is linked to a contact by the number it was placed to.
+CREATE TABLE contacts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    timezone TEXT,
+    preferred_language TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, phone_number)
+);
+
+ALTER TABLE calls ADD COLUMN contact_id INTEGER REFERENCES contacts(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_calls_contact ON calls(contact_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calls_contact;
+ALTER TABLE calls DROP COLUMN contact_id;
+DROP TABLE IF EXISTS contacts;
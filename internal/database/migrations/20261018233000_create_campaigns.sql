@@ -0,0 +1,57 @@
+-- +goose Up
+-- Campaigns place a call template to every row of an uploaded CSV, a few
+-- calls at a time. columns is the CSV's header as a JSON array.
+CREATE TABLE campaigns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    template_id INTEGER,
+    columns TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'running', 'completed', 'cancelled')),
+    max_concurrent INTEGER NOT NULL,
+    calls_per_minute INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL DEFAULT 1,
+    last_dispatched_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME,
+    completed_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (template_id) REFERENCES call_templates(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_campaigns_user ON campaigns(user_id);
+
+-- One row of a campaign's CSV, rendered into what its call will be about.
+-- fields is the row as uploaded, a JSON array in the order of the columns.
+-- Invalid rows keep the problem found with them and are never dialed.
+CREATE TABLE campaign_rows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id INTEGER NOT NULL,
+    row_number INTEGER NOT NULL,
+    fields TEXT NOT NULL,
+    phone_number TEXT NOT NULL,
+    recipient_context TEXT NOT NULL DEFAULT '',
+    objective TEXT NOT NULL DEFAULT '',
+    other_context TEXT NOT NULL DEFAULT '',
+    template_variables TEXT,
+    status TEXT NOT NULL CHECK (status IN ('invalid', 'queued', 'placed', 'cancelled')),
+    problem TEXT,
+    call_id INTEGER,
+    FOREIGN KEY (campaign_id) REFERENCES campaigns(id) ON DELETE CASCADE,
+    FOREIGN KEY (call_id) REFERENCES calls(id) ON DELETE SET NULL,
+    UNIQUE (campaign_id, row_number)
+);
+
+CREATE INDEX idx_campaign_rows_status ON campaign_rows(campaign_id, status, row_number);
+
+ALTER TABLE calls ADD COLUMN campaign_id INTEGER REFERENCES campaigns(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_calls_campaign ON calls(campaign_id, status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_calls_campaign;
+ALTER TABLE calls DROP COLUMN campaign_id;
+DROP INDEX IF EXISTS idx_campaign_rows_status;
+DROP TABLE IF EXISTS campaign_rows;
+DROP INDEX IF EXISTS idx_campaigns_user;
+DROP TABLE IF EXISTS campaigns;
@@ -6,19 +6,77 @@ package database
 
 import (
 	"database/sql"
+	"time"
 )
 
+type ApiKey struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     string       `json:"scopes"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
 type Call struct {
-	ID                int64          `json:"id"`
-	UserID            int64          `json:"user_id"`
-	PhoneNumber       string         `json:"phone_number"`
-	RecipientContext  sql.NullString `json:"recipient_context"`
-	Objective         string         `json:"objective"`
-	BackgroundContext sql.NullString `json:"background_context"`
-	Status            sql.NullString `json:"status"`
-	CreatedAt         sql.NullTime   `json:"created_at"`
-	UpdatedAt         sql.NullTime   `json:"updated_at"`
-	CompletedAt       sql.NullTime   `json:"completed_at"`
+	ID                  int64          `json:"id"`
+	UserID              int64          `json:"user_id"`
+	PhoneNumber         string         `json:"phone_number"`
+	RecipientContext    sql.NullString `json:"recipient_context"`
+	Objective           string         `json:"objective"`
+	BackgroundContext   sql.NullString `json:"background_context"`
+	Status              sql.NullString `json:"status"`
+	CreatedAt           sql.NullTime   `json:"created_at"`
+	UpdatedAt           sql.NullTime   `json:"updated_at"`
+	CompletedAt         sql.NullTime   `json:"completed_at"`
+	MaxAttempts         int64          `json:"max_attempts"`
+	RetrySpacingMinutes int64          `json:"retry_spacing_minutes"`
+	RetryWindowStart    sql.NullString `json:"retry_window_start"`
+	RetryWindowEnd      sql.NullString `json:"retry_window_end"`
+	StatusReason        sql.NullString `json:"status_reason"`
+	Record              bool           `json:"record"`
+	OpeningLine         sql.NullString `json:"opening_line"`
+	CallPlan            sql.NullString `json:"call_plan"`
+	ApprovedAt          sql.NullTime   `json:"approved_at"`
+	Outcome             sql.NullString `json:"outcome"`
+	ContactID           sql.NullInt64  `json:"contact_id"`
+	TemplateID          sql.NullInt64  `json:"template_id"`
+	TemplateVariables   sql.NullString `json:"template_variables"`
+	CampaignID          sql.NullInt64  `json:"campaign_id"`
+}
+
+type CallAttempt struct {
+	ID                   int64          `json:"id"`
+	CallID               int64          `json:"call_id"`
+	AttemptNumber        int64          `json:"attempt_number"`
+	ProviderCallID       sql.NullString `json:"provider_call_id"`
+	Status               string         `json:"status"`
+	Error                sql.NullString `json:"error"`
+	DurationSeconds      int64          `json:"duration_seconds"`
+	BilledMinutes        int64          `json:"billed_minutes"`
+	ScheduledFor         time.Time      `json:"scheduled_for"`
+	StartedAt            sql.NullTime   `json:"started_at"`
+	AnsweredAt           sql.NullTime   `json:"answered_at"`
+	EndedAt              sql.NullTime   `json:"ended_at"`
+	CreatedAt            sql.NullTime   `json:"created_at"`
+	UpdatedAt            sql.NullTime   `json:"updated_at"`
+	AnsweredBy           sql.NullString `json:"answered_by"`
+	VoicemailLeftAt      sql.NullTime   `json:"voicemail_left_at"`
+	HandoffAt            sql.NullTime   `json:"handoff_at"`
+	HandoffSeconds       int64          `json:"handoff_seconds"`
+	HandoffBilledMinutes int64          `json:"handoff_billed_minutes"`
+}
+
+type CallFact struct {
+	ID        int64        `json:"id"`
+	CallID    int64        `json:"call_id"`
+	Key       string       `json:"key"`
+	Value     string       `json:"value"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
 }
 
 type CallLog struct {
@@ -29,11 +87,151 @@ type CallLog struct {
 	Timestamp   sql.NullTime `json:"timestamp"`
 }
 
+type CallTemplate struct {
+	ID           int64         `json:"id"`
+	UserID       sql.NullInt64 `json:"user_id"`
+	Name         string        `json:"name"`
+	Objective    string        `json:"objective"`
+	OtherContext string        `json:"other_context"`
+	CreatedAt    sql.NullTime  `json:"created_at"`
+	UpdatedAt    sql.NullTime  `json:"updated_at"`
+}
+
+type Campaign struct {
+	ID               int64         `json:"id"`
+	UserID           int64         `json:"user_id"`
+	Name             string        `json:"name"`
+	TemplateID       sql.NullInt64 `json:"template_id"`
+	Columns          string        `json:"columns"`
+	Status           string        `json:"status"`
+	MaxConcurrent    int64         `json:"max_concurrent"`
+	CallsPerMinute   int64         `json:"calls_per_minute"`
+	MaxAttempts      int64         `json:"max_attempts"`
+	LastDispatchedAt sql.NullTime  `json:"last_dispatched_at"`
+	CreatedAt        sql.NullTime  `json:"created_at"`
+	StartedAt        sql.NullTime  `json:"started_at"`
+	CompletedAt      sql.NullTime  `json:"completed_at"`
+}
+
+type CampaignRow struct {
+	ID                int64          `json:"id"`
+	CampaignID        int64          `json:"campaign_id"`
+	RowNumber         int64          `json:"row_number"`
+	Fields            string         `json:"fields"`
+	PhoneNumber       string         `json:"phone_number"`
+	RecipientContext  string         `json:"recipient_context"`
+	Objective         string         `json:"objective"`
+	OtherContext      string         `json:"other_context"`
+	TemplateVariables sql.NullString `json:"template_variables"`
+	Status            string         `json:"status"`
+	Problem           sql.NullString `json:"problem"`
+	CallID            sql.NullInt64  `json:"call_id"`
+}
+
+type Contact struct {
+	ID                int64          `json:"id"`
+	UserID            int64          `json:"user_id"`
+	Name              string         `json:"name"`
+	PhoneNumber       string         `json:"phone_number"`
+	Notes             string         `json:"notes"`
+	Timezone          sql.NullString `json:"timezone"`
+	PreferredLanguage sql.NullString `json:"preferred_language"`
+	CreatedAt         sql.NullTime   `json:"created_at"`
+	UpdatedAt         sql.NullTime   `json:"updated_at"`
+}
+
+type DncNumber struct {
+	ID          int64          `json:"id"`
+	PhoneNumber string         `json:"phone_number"`
+	UserID      sql.NullInt64  `json:"user_id"`
+	Source      string         `json:"source"`
+	Reason      sql.NullString `json:"reason"`
+	CallID      sql.NullInt64  `json:"call_id"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+}
+
+type Job struct {
+	ID          int64          `json:"id"`
+	Kind        string         `json:"kind"`
+	Payload     string         `json:"payload"`
+	Status      string         `json:"status"`
+	Attempts    int64          `json:"attempts"`
+	MaxAttempts int64          `json:"max_attempts"`
+	RunAt       time.Time      `json:"run_at"`
+	LockedBy    sql.NullString `json:"locked_by"`
+	LockedUntil sql.NullTime   `json:"locked_until"`
+	LastError   sql.NullString `json:"last_error"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	FinishedAt  sql.NullTime   `json:"finished_at"`
+}
+
+type MinuteLedger struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	CallID    sql.NullInt64 `json:"call_id"`
+	Minutes   int64         `json:"minutes"`
+	Reason    string        `json:"reason"`
+	Reference string        `json:"reference"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+}
+
+type PhoneVerification struct {
+	ID          int64        `json:"id"`
+	UserID      int64        `json:"user_id"`
+	PhoneNumber string       `json:"phone_number"`
+	Code        string       `json:"code"`
+	Attempts    int64        `json:"attempts"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type Recording struct {
+	ID                  int64          `json:"id"`
+	CallID              int64          `json:"call_id"`
+	CallAttemptID       int64          `json:"call_attempt_id"`
+	ProviderRecordingID sql.NullString `json:"provider_recording_id"`
+	StorageKey          string         `json:"storage_key"`
+	ContentType         string         `json:"content_type"`
+	SizeBytes           int64          `json:"size_bytes"`
+	DurationSeconds     int64          `json:"duration_seconds"`
+	RecordedAt          time.Time      `json:"recorded_at"`
+	PurgedAt            sql.NullTime   `json:"purged_at"`
+	CreatedAt           sql.NullTime   `json:"created_at"`
+}
+
 type User struct {
+	ID                       int64          `json:"id"`
+	Email                    string         `json:"email"`
+	Name                     string         `json:"name"`
+	CreatedAt                sql.NullTime   `json:"created_at"`
+	UpdatedAt                sql.NullTime   `json:"updated_at"`
+	Minutes                  interface{}    `json:"minutes"`
+	IsAdmin                  bool           `json:"is_admin"`
+	PhoneNumber              sql.NullString `json:"phone_number"`
+	VoicemailCountsAsSuccess bool           `json:"voicemail_counts_as_success"`
+	PhoneVerifiedAt          sql.NullTime   `json:"phone_verified_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64          `json:"id"`
+	EndpointID     int64          `json:"endpoint_id"`
+	Event          string         `json:"event"`
+	Payload        string         `json:"payload"`
+	Status         string         `json:"status"`
+	Attempts       int64          `json:"attempts"`
+	ResponseStatus sql.NullInt64  `json:"response_status"`
+	LastError      sql.NullString `json:"last_error"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	LastAttemptAt  sql.NullTime   `json:"last_attempt_at"`
+	DeliveredAt    sql.NullTime   `json:"delivered_at"`
+}
+
+type WebhookEndpoint struct {
 	ID        int64        `json:"id"`
-	Email     string       `json:"email"`
-	Name      string       `json:"name"`
+	UserID    int64        `json:"user_id"`
+	Url       string       `json:"url"`
+	Secret    string       `json:"secret"`
+	Events    string       `json:"events"`
 	CreatedAt sql.NullTime `json:"created_at"`
-	UpdatedAt sql.NullTime `json:"updated_at"`
-	Minutes   interface{}  `json:"minutes"`
 }
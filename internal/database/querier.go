@@ -7,23 +7,129 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type Querier interface {
+	AddPhoneVerificationAttempt(ctx context.Context, id int64) error
+	AdjustUserMinutes(ctx context.Context, arg AdjustUserMinutesParams) error
+	ApproveCall(ctx context.Context, arg ApproveCallParams) (Call, error)
+	CancelCall(ctx context.Context, arg CancelCallParams) (int64, error)
+	CancelCampaign(ctx context.Context, id int64) (int64, error)
+	CancelHandoff(ctx context.Context, id int64) error
+	CancelQueuedCampaignRows(ctx context.Context, campaignID int64) error
+	ClaimCampaignDispatch(ctx context.Context, arg ClaimCampaignDispatchParams) (int64, error)
+	ClaimJob(ctx context.Context, arg ClaimJobParams) (Job, error)
 	CompleteCall(ctx context.Context, id int64) (Call, error)
+	CompleteCampaign(ctx context.Context, id int64) (int64, error)
+	CompleteJob(ctx context.Context, arg CompleteJobParams) (int64, error)
+	CountActivePhoneVerifications(ctx context.Context, arg CountActivePhoneVerificationsParams) (int64, error)
+	CountCallAttempts(ctx context.Context, callID int64) (int64, error)
+	CountCallTurns(ctx context.Context, callID int64) (int64, error)
+	CountCampaignCallsInFlight(ctx context.Context, campaignID int64) (int64, error)
+	CountDNCEntries(ctx context.Context) (int64, error)
+	CountDueJobs(ctx context.Context, arg CountDueJobsParams) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateCall(ctx context.Context, arg CreateCallParams) (Call, error)
+	CreateCallAttempt(ctx context.Context, arg CreateCallAttemptParams) (CallAttempt, error)
+	CreateCallLog(ctx context.Context, arg CreateCallLogParams) (CallLog, error)
+	CreateCallTemplate(ctx context.Context, arg CreateCallTemplateParams) (CallTemplate, error)
+	CreateCampaign(ctx context.Context, arg CreateCampaignParams) (Campaign, error)
+	CreateCampaignRow(ctx context.Context, arg CreateCampaignRowParams) error
+	CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error)
+	CreateDNCEntry(ctx context.Context, arg CreateDNCEntryParams) (int64, error)
+	CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) (int64, error)
+	CreatePhoneVerification(ctx context.Context, arg CreatePhoneVerificationParams) (PhoneVerification, error)
+	CreateRecording(ctx context.Context, arg CreateRecordingParams) (Recording, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DeadLetterAbandonedJobs(ctx context.Context, leaseExpiredBefore sql.NullTime) ([]Job, error)
+	DeadLetterJob(ctx context.Context, arg DeadLetterJobParams) (int64, error)
 	DeleteCall(ctx context.Context, id int64) error
+	DeleteCallTemplate(ctx context.Context, arg DeleteCallTemplateParams) (int64, error)
+	DeleteContact(ctx context.Context, arg DeleteContactParams) (int64, error)
+	DeleteDNCEntry(ctx context.Context, id int64) error
+	DeletePhoneVerifications(ctx context.Context, userID int64) error
 	DeleteUser(ctx context.Context, id int64) error
+	DeleteWebhookEndpoint(ctx context.Context, arg DeleteWebhookEndpointParams) (int64, error)
+	EndCallAttempt(ctx context.Context, arg EndCallAttemptParams) (CallAttempt, error)
+	EndHandoff(ctx context.Context, arg EndHandoffParams) error
+	EnqueueJob(ctx context.Context, arg EnqueueJobParams) (Job, error)
+	ExtendJobLease(ctx context.Context, arg ExtendJobLeaseParams) (int64, error)
+	FindDNCEntry(ctx context.Context, arg FindDNCEntryParams) (DncNumber, error)
+	FinishCall(ctx context.Context, arg FinishCallParams) (Call, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
 	GetCall(ctx context.Context, id int64) (Call, error)
+	GetCallAttempt(ctx context.Context, id int64) (CallAttempt, error)
+	GetCallTemplate(ctx context.Context, arg GetCallTemplateParams) (CallTemplate, error)
+	GetCampaign(ctx context.Context, arg GetCampaignParams) (Campaign, error)
+	GetCampaignByID(ctx context.Context, id int64) (Campaign, error)
+	GetContact(ctx context.Context, arg GetContactParams) (Contact, error)
+	GetContactByNumber(ctx context.Context, arg GetContactByNumberParams) (Contact, error)
+	GetFirstCallLog(ctx context.Context, callID int64) (CallLog, error)
+	GetJob(ctx context.Context, id int64) (Job, error)
+	GetLatestPhoneVerification(ctx context.Context, userID int64) (PhoneVerification, error)
+	GetPhoneVerification(ctx context.Context, id int64) (PhoneVerification, error)
+	GetRecording(ctx context.Context, id int64) (Recording, error)
+	GetRecordingByAttempt(ctx context.Context, callAttemptID int64) (Recording, error)
 	GetUser(ctx context.Context, id int64) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserMinutes(ctx context.Context, email string) (interface{}, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (GetWebhookDeliveryRow, error)
+	GetWebhookDeliveryStatus(ctx context.Context, arg GetWebhookDeliveryStatusParams) (string, error)
+	LinkCallsToContact(ctx context.Context, arg LinkCallsToContactParams) error
+	ListAPIKeys(ctx context.Context, userID int64) ([]ApiKey, error)
+	ListCallAttempts(ctx context.Context, callID int64) ([]CallAttempt, error)
+	ListCallFacts(ctx context.Context, callID int64) ([]CallFact, error)
+	ListCallLogs(ctx context.Context, callID int64) ([]CallLog, error)
+	ListCallLogsSince(ctx context.Context, arg ListCallLogsSinceParams) ([]CallLog, error)
+	ListCallTemplateStats(ctx context.Context, userID int64) ([]ListCallTemplateStatsRow, error)
+	ListCallTemplates(ctx context.Context, userID sql.NullInt64) ([]CallTemplate, error)
+	ListCallsByContact(ctx context.Context, contactID int64) ([]Call, error)
 	ListCallsByStatus(ctx context.Context, status sql.NullString) ([]Call, error)
 	ListCallsByUser(ctx context.Context, userID int64) ([]Call, error)
+	ListCallsPage(ctx context.Context, arg ListCallsPageParams) ([]Call, error)
+	ListCampaignRows(ctx context.Context, campaignID int64) ([]ListCampaignRowsRow, error)
+	ListCampaigns(ctx context.Context, userID int64) ([]Campaign, error)
+	ListContacts(ctx context.Context, userID int64) ([]Contact, error)
+	ListDNCEntries(ctx context.Context, limit int64) ([]DncNumber, error)
+	ListJobsByStatus(ctx context.Context, status string) ([]Job, error)
+	ListLedgerEntriesByUser(ctx context.Context, userID int64) ([]MinuteLedger, error)
+	ListLiveCallAttempts(ctx context.Context) ([]CallAttempt, error)
+	ListRecordingsByCall(ctx context.Context, callID int64) ([]Recording, error)
+	ListRecordingsToPurge(ctx context.Context, recordedAt time.Time) ([]Recording, error)
 	ListUsers(ctx context.Context) ([]User, error)
+	ListWebhookDeliveries(ctx context.Context, userID int64) ([]ListWebhookDeliveriesRow, error)
+	ListWebhookEndpoints(ctx context.Context, userID int64) ([]WebhookEndpoint, error)
+	ListWebhookEndpointsForEvent(ctx context.Context, arg ListWebhookEndpointsForEventParams) ([]WebhookEndpoint, error)
+	MarkCallAttemptAnswered(ctx context.Context, id int64) (CallAttempt, error)
+	MarkCallAttemptDialing(ctx context.Context, arg MarkCallAttemptDialingParams) (CallAttempt, error)
+	MarkCampaignRowPlaced(ctx context.Context, arg MarkCampaignRowPlacedParams) (int64, error)
+	MarkRecordingPurged(ctx context.Context, id int64) error
+	MarkVoicemailLeft(ctx context.Context, id int64) error
+	NextQueuedCampaignRow(ctx context.Context, campaignID int64) (CampaignRow, error)
+	RecordCallFact(ctx context.Context, arg RecordCallFactParams) (CallFact, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
+	ReplayWebhookDelivery(ctx context.Context, arg ReplayWebhookDeliveryParams) (int64, error)
+	RequeueDeadJob(ctx context.Context, arg RequeueDeadJobParams) (Job, error)
+	RetryJob(ctx context.Context, arg RetryJobParams) (int64, error)
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (int64, error)
+	SetCallAttemptAnsweredBy(ctx context.Context, arg SetCallAttemptAnsweredByParams) error
+	SetCallAttemptBilledMinutes(ctx context.Context, arg SetCallAttemptBilledMinutesParams) error
+	SetCallOutcome(ctx context.Context, arg SetCallOutcomeParams) error
+	SetCallStatus(ctx context.Context, arg SetCallStatusParams) (Call, error)
+	SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error)
+	SetUserPhoneVerified(ctx context.Context, arg SetUserPhoneVerifiedParams) (User, error)
+	StartCampaign(ctx context.Context, id int64) (int64, error)
+	StartHandoff(ctx context.Context, id int64) (CallAttempt, error)
+	SuggestContacts(ctx context.Context, arg SuggestContactsParams) ([]Contact, error)
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
+	UpdateCallAttemptStatus(ctx context.Context, arg UpdateCallAttemptStatusParams) (CallAttempt, error)
 	UpdateCallStatus(ctx context.Context, arg UpdateCallStatusParams) (Call, error)
+	UpdateContact(ctx context.Context, arg UpdateContactParams) (Contact, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,12 +7,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, name)
 VALUES (?, ?)
-RETURNING id, email, name, created_at, updated_at, minutes
+RETURNING id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at
 `
 
 type CreateUserParams struct {
@@ -30,6 +31,10 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
 	)
 	return i, err
 }
@@ -45,7 +50,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 }
 
 const getUser = `-- name: GetUser :one
-SELECT id, email, name, created_at, updated_at, minutes FROM users
+SELECT id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at FROM users
 WHERE id = ?
 `
 
@@ -59,12 +64,16 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, created_at, updated_at, minutes FROM users
+SELECT id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at FROM users
 WHERE email = ?
 `
 
@@ -78,6 +87,10 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
 	)
 	return i, err
 }
@@ -95,7 +108,7 @@ func (q *Queries) GetUserMinutes(ctx context.Context, email string) (interface{}
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, name, created_at, updated_at, minutes FROM users
+SELECT id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at FROM users
 ORDER BY created_at DESC
 `
 
@@ -115,6 +128,10 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Minutes,
+			&i.IsAdmin,
+			&i.PhoneNumber,
+			&i.VoicemailCountsAsSuccess,
+			&i.PhoneVerifiedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,11 +146,71 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const setUserAdmin = `-- name: SetUserAdmin :one
+UPDATE users
+SET is_admin = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at
+`
+
+type SetUserAdminParams struct {
+	IsAdmin bool  `json:"is_admin"`
+	ID      int64 `json:"id"`
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAdmin, arg.IsAdmin, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
+	)
+	return i, err
+}
+
+const setUserPhoneVerified = `-- name: SetUserPhoneVerified :one
+UPDATE users
+SET phone_number = ?, phone_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at
+`
+
+type SetUserPhoneVerifiedParams struct {
+	PhoneNumber sql.NullString `json:"phone_number"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) SetUserPhoneVerified(ctx context.Context, arg SetUserPhoneVerifiedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPhoneVerified, arg.PhoneNumber, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET name = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, email, name, created_at, updated_at, minutes
+RETURNING id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at
 `
 
 type UpdateUserParams struct {
@@ -151,6 +228,40 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
+	)
+	return i, err
+}
+
+const updateUserSettings = `-- name: UpdateUserSettings :one
+UPDATE users
+SET voicemail_counts_as_success = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, email, name, created_at, updated_at, minutes, is_admin, phone_number, voicemail_counts_as_success, phone_verified_at
+`
+
+type UpdateUserSettingsParams struct {
+	VoicemailCountsAsSuccess bool  `json:"voicemail_counts_as_success"`
+	ID                       int64 `json:"id"`
+}
+
+func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserSettings, arg.VoicemailCountsAsSuccess, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Minutes,
+		&i.IsAdmin,
+		&i.PhoneNumber,
+		&i.VoicemailCountsAsSuccess,
+		&i.PhoneVerifiedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,60 @@
+// Package dnc is the do-not-call registry. Numbers land on it when a recipient
+// asks not to be called again, or when an admin imports a registry list, and
+// every call is checked against it before anything is spent on it.
+package dnc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"goDial/internal/database"
+)
+
+// where an entry came from, matching the CHECK constraint on dnc_numbers.source
+const (
+	SourceSpokenOptOut   = "spoken_opt_out"
+	SourceKeypressOptOut = "keypress_opt_out"
+	SourceImport         = "import"
+	SourceManual         = "manual"
+)
+
+// ErrBlocked is returned by Check for numbers that may not be called.
+var ErrBlocked = errors.New("number is on the do-not-call list")
+
+// Check returns an error wrapping ErrBlocked if userID may not call number,
+// either because it is blocked for everyone or for that user. number must be
+// in E.164.
+func Check(ctx context.Context, q database.Querier, userID int64, number string) error {
+	entry, err := q.FindDNCEntry(ctx, database.FindDNCEntryParams{
+		PhoneNumber: number,
+		UserID:      sql.NullInt64{Int64: userID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error checking do-not-call list for %s: %w", number, err)
+	}
+
+	if entry.UserID.Valid {
+		return fmt.Errorf("%s is blocked for this account: %w", number, ErrBlocked)
+	}
+	return fmt.Errorf("%s asked not to be called: %w", number, ErrBlocked)
+}
+
+// OptOut adds a recipient's number to the global list, so no user can call it
+// again. callID is the call they opted out on.
+func OptOut(ctx context.Context, q database.Querier, number, source string, callID int64) error {
+	_, err := q.CreateDNCEntry(ctx, database.CreateDNCEntryParams{
+		PhoneNumber: number,
+		Source:      source,
+		Reason:      sql.NullString{String: "recipient opted out during a call", Valid: true},
+		CallID:      sql.NullInt64{Int64: callID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error adding %s to the do-not-call list: %w", number, err)
+	}
+	return nil
+}
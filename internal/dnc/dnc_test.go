@@ -0,0 +1,147 @@
+package dnc
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goDial/internal/database"
+	"goDial/internal/phone"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDNCTestDB(t *testing.T) (*database.DB, database.User, database.User) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "dnc_test.db")
+
+	db, err := database.InitDB(dbPath)
+	require.NoError(t, err, "Failed to initialize test database")
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	ctx := context.Background()
+	alice, err := db.CreateUser(ctx, database.CreateUserParams{Email: "alice@example.com", Name: "Alice"})
+	require.NoError(t, err)
+	bob, err := db.CreateUser(ctx, database.CreateUserParams{Email: "bob@example.com", Name: "Bob"})
+	require.NoError(t, err)
+
+	return db, alice, bob
+}
+
+func TestCheck_Scopes(t *testing.T) {
+	db, alice, bob := setupDNCTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.CreateDNCEntry(ctx, database.CreateDNCEntryParams{PhoneNumber: "+13336664444", Source: SourceManual})
+	require.NoError(t, err)
+	_, err = db.CreateDNCEntry(ctx, database.CreateDNCEntryParams{
+		PhoneNumber: "+15556667777",
+		UserID:      sql.NullInt64{Int64: alice.ID, Valid: true},
+		Source:      SourceManual,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		userID      int64
+		number      string
+		expectBlock bool
+	}{
+		{name: "global entry blocks alice", userID: alice.ID, number: "+13336664444", expectBlock: true},
+		{name: "global entry blocks bob", userID: bob.ID, number: "+13336664444", expectBlock: true},
+		{name: "alice's entry blocks alice", userID: alice.ID, number: "+15556667777", expectBlock: true},
+		{name: "alice's entry doesn't block bob", userID: bob.ID, number: "+15556667777", expectBlock: false},
+		{name: "unlisted number", userID: alice.ID, number: "+12025550123", expectBlock: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(ctx, db, tt.userID, tt.number)
+			if tt.expectBlock {
+				assert.ErrorIs(t, err, ErrBlocked)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOptOut_BlocksEveryoneAndIsIdempotent(t *testing.T) {
+	db, alice, bob := setupDNCTestDB(t)
+	ctx := context.Background()
+
+	call, err := db.CreateCall(ctx, database.CreateCallParams{UserID: alice.ID, PhoneNumber: "+13336664444", Objective: "sell things"})
+	require.NoError(t, err)
+
+	require.NoError(t, OptOut(ctx, db, "+13336664444", SourceSpokenOptOut, call.ID))
+	require.NoError(t, OptOut(ctx, db, "+13336664444", SourceKeypressOptOut, call.ID))
+
+	assert.ErrorIs(t, Check(ctx, db, alice.ID, "+13336664444"), ErrBlocked)
+	assert.ErrorIs(t, Check(ctx, db, bob.ID, "+13336664444"), ErrBlocked)
+
+	count, err := db.CountDNCEntries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestImport(t *testing.T) {
+	db, alice, bob := setupDNCTestDB(t)
+	ctx := context.Background()
+
+	csv := strings.Join([]string{
+		"phone_number,reason",
+		"(333) 666-4444,registry",
+		"+44 20 7946 0958",
+		"not a number,oops",
+		"333.666.4444,duplicate of line 2",
+		"",
+		"911,service code",
+	}, "\n")
+
+	result, err := Import(ctx, db, strings.NewReader(csv), sql.NullInt64{}, "US")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.Added)
+	assert.Equal(t, 1, result.Duplicates)
+	require.Len(t, result.Invalid, 2)
+	assert.Equal(t, 4, result.Invalid[0].Line)
+	assert.ErrorIs(t, result.Invalid[0].Err, phone.ErrInvalidCharacters)
+	assert.Equal(t, 7, result.Invalid[1].Line)
+
+	assert.ErrorIs(t, Check(ctx, db, bob.ID, "+13336664444"), ErrBlocked)
+	assert.ErrorIs(t, Check(ctx, db, bob.ID, "+442079460958"), ErrBlocked)
+
+	entries, err := db.ListDNCEntries(ctx, 10)
+	require.NoError(t, err)
+	reasons := map[string]string{}
+	for _, entry := range entries {
+		reasons[entry.PhoneNumber] = entry.Reason.String
+		assert.Equal(t, SourceImport, entry.Source)
+	}
+	assert.Equal(t, "registry", reasons["+13336664444"])
+
+	// a user-scoped import only blocks that user
+	result, err = Import(ctx, db, strings.NewReader("202-555-0123\n"), sql.NullInt64{Int64: alice.ID, Valid: true}, "US")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Added)
+	assert.ErrorIs(t, Check(ctx, db, alice.ID, "+12025550123"), ErrBlocked)
+	assert.NoError(t, Check(ctx, db, bob.ID, "+12025550123"))
+}
+
+func TestImport_MalformedCSVAddsNothing(t *testing.T) {
+	db, _, _ := setupDNCTestDB(t)
+	ctx := context.Background()
+
+	_, err := Import(ctx, db, strings.NewReader("3336664444\n\"5556667777\n"), sql.NullInt64{}, "US")
+	require.Error(t, err)
+
+	count, err := db.CountDNCEntries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count, "a failed import is rolled back")
+}
@@ -0,0 +1,107 @@
+package dnc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"goDial/internal/database"
+	"goDial/internal/phone"
+)
+
+// ImportResult summarizes a CSV import.
+type ImportResult struct {
+	Added      int
+	Duplicates int
+	Invalid    []ImportError
+}
+
+// ImportError is a row of an import that couldn't be read as a phone number.
+type ImportError struct {
+	Line  int
+	Value string
+	Err   error
+}
+
+func (e ImportError) Error() string {
+	return fmt.Sprintf("line %d (%q): %v", e.Line, e.Value, e.Err)
+}
+
+// maxInvalidRows caps how many bad rows an import reports back.
+const maxInvalidRows = 100
+
+// Import adds every number in a CSV to the list. The first column is the phone
+// number and an optional second column the reason; a header row is skipped.
+// Numbers without a country code are read as dialed from defaultRegion.
+// userID scopes the entries to one user, or blocks them for everyone if it is
+// not Valid. Rows that aren't valid numbers are reported, not fatal.
+func Import(ctx context.Context, db *database.DB, r io.Reader, userID sql.NullInt64, defaultRegion string) (ImportResult, error) {
+	var result ImportResult
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("error starting do-not-call import: %w", err)
+	}
+	defer tx.Rollback()
+	q := db.WithTx(tx)
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("error reading do-not-call csv: %w", err)
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := strings.TrimSpace(record[0])
+		number, err := phone.Parse(value, defaultRegion)
+		if err != nil {
+			if first {
+				// header row
+				continue
+			}
+			if len(result.Invalid) < maxInvalidRows {
+				result.Invalid = append(result.Invalid, ImportError{Line: line, Value: value, Err: err})
+			}
+			continue
+		}
+
+		var reason string
+		if len(record) > 1 {
+			reason = strings.TrimSpace(record[1])
+		}
+
+		added, err := q.CreateDNCEntry(ctx, database.CreateDNCEntryParams{
+			PhoneNumber: number.E164,
+			UserID:      userID,
+			Source:      SourceImport,
+			Reason:      sql.NullString{String: reason, Valid: reason != ""},
+		})
+		if err != nil {
+			return result, fmt.Errorf("error importing %s on line %d: %w", number.E164, line, err)
+		}
+		if added == 0 {
+			result.Duplicates++
+		} else {
+			result.Added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("error committing do-not-call import: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,290 @@
+// Package ivr recognizes automated phone systems from what a call hears:
+// menus that ask for a key press, hold messages and voicemail greetings. It
+// reads menu options out of a prompt and picks the one that best fits what
+// the call is for.
+package ivr
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is what a line heard on a call sounds like.
+type Kind int
+
+const (
+	// Human is anything that isn't recognizably automated.
+	Human Kind = iota
+	// Menu is a prompt asking the caller to press or say something.
+	Menu
+	// Hold is a message played while waiting for someone to pick up.
+	Hold
+	// Voicemail is a greeting asking the caller to leave a message.
+	Voicemail
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Menu:
+		return "menu"
+	case Hold:
+		return "hold"
+	case Voicemail:
+		return "voicemail"
+	}
+	return "human"
+}
+
+// Option is one choice offered by a menu.
+type Option struct {
+	Digit string
+	Label string
+}
+
+// holdPhrases are said by hold messages, and rarely by people.
+var holdPhrases = []string{
+	"please hold",
+	"please continue to hold",
+	"please stay on the line",
+	"please remain on the line",
+	"your call is important",
+	"your call will be answered",
+	"calls will be answered in the order",
+	"calls are answered in the order",
+	"next available",
+	"estimated wait time",
+	"all of our representatives are",
+	"all of our agents are",
+	"currently assisting other",
+	"experiencing higher than normal",
+	"experiencing high call volume",
+	"thank you for your patience",
+	"thank you for holding",
+}
+
+// voicemailPhrases are said by voicemail greetings. A person offering to take
+// a message doesn't use them, so "leave a message" alone isn't one.
+var voicemailPhrases = []string{
+	"after the tone",
+	"after the beep",
+	"at the tone",
+	"at the beep",
+	"voicemail",
+	"voice mail",
+	"mailbox",
+	"voice messaging system",
+	"record your message",
+	"leave your message",
+	"please leave a message",
+	"please leave a brief message",
+	"leave your name and number",
+	"leave a name and number",
+	"the person you are trying to reach",
+	"the party you are trying to reach",
+	"can't come to the phone",
+	"cannot come to the phone",
+	"can't take your call",
+	"cannot take your call",
+}
+
+// menuPhrases mark a menu whose options couldn't be read.
+var menuPhrases = []string{
+	"main menu",
+	"following options",
+	"listen carefully",
+	"menu options have changed",
+	"please say or press",
+	"para español",
+	"to repeat these options",
+	"enter your",
+	"using your keypad",
+	"pound sign",
+	"pound key",
+	"star key",
+}
+
+// Classify says whether text sounds like a menu, a hold message, a voicemail
+// greeting or a person.
+func Classify(text string) Kind {
+	lower := normalize(text)
+	if len(ParseMenu(text)) > 0 {
+		return Menu
+	}
+	for _, phrase := range voicemailPhrases {
+		if strings.Contains(lower, phrase) {
+			return Voicemail
+		}
+	}
+	for _, phrase := range holdPhrases {
+		if strings.Contains(lower, phrase) {
+			return Hold
+		}
+	}
+	for _, phrase := range menuPhrases {
+		if strings.Contains(lower, phrase) {
+			return Menu
+		}
+	}
+	return Human
+}
+
+// digitWords are how speech recognition sometimes writes keys out.
+var digitWords = map[string]string{
+	"zero": "0", "oh": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"star": "*", "pound": "#", "hash": "#",
+}
+
+const keyPattern = `([0-9*#]|(?:zero|one|two|three|four|five|six|seven|eight|nine|star|pound|hash)\b)`
+
+// the two ways menus put options: "press 1 for billing" and "for billing,
+// press 1"
+var (
+	pressFirst = regexp.MustCompile(`\b(?:press|dial|enter)\s+` + keyPattern + `\s*,?\s*(?:for|to|if)\s+([^.;,]+)`)
+	labelFirst = regexp.MustCompile(`\b(?:for|to|if)\s+([^.;,]+?)\s*,?\s*(?:please\s+)?(?:press|dial|enter)\s+` + keyPattern)
+)
+
+// ParseMenu reads the options out of a menu prompt, in the order they were
+// offered. A digit offered twice keeps its first label.
+func ParseMenu(text string) []Option {
+	lower := normalize(text)
+
+	type found struct {
+		at     int
+		option Option
+	}
+	var pressed, labeled []found
+	for _, m := range pressFirst.FindAllStringSubmatchIndex(lower, -1) {
+		pressed = append(pressed, found{m[0], Option{Digit: key(lower[m[2]:m[3]]), Label: cleanLabel(lower[m[4]:m[5]])}})
+	}
+	for _, m := range labelFirst.FindAllStringSubmatchIndex(lower, -1) {
+		labeled = append(labeled, found{m[0], Option{Digit: key(lower[m[4]:m[5]]), Label: cleanLabel(lower[m[2]:m[3]])}})
+	}
+
+	// "press 1 for billing, press 2 for support" also reads as "for billing,
+	// press 2", so the style the menu starts with wins any disagreement
+	all := append(labeled, pressed...)
+	if len(pressed) > 0 && (len(labeled) == 0 || pressed[0].at < labeled[0].at) {
+		all = append(pressed, labeled...)
+	}
+
+	var options []found
+	seen := map[string]bool{}
+	for _, f := range all {
+		if seen[f.option.Digit] || f.option.Label == "" {
+			continue
+		}
+		seen[f.option.Digit] = true
+		options = append(options, f)
+	}
+	sort.SliceStable(options, func(i, j int) bool { return options[i].at < options[j].at })
+
+	var menu []Option
+	for _, f := range options {
+		menu = append(menu, f.option)
+	}
+	return menu
+}
+
+// operatorWords are labels of options that reach a person, the fallback when
+// nothing on the menu matches the objective.
+var operatorWords = []string{"representative", "operator", "agent", "speak to", "talk to", "customer service", "all other", "other questions", "other inquiries"}
+
+// Choose picks the option that best fits the objective: the one sharing the
+// most words with it, or else one that reaches a person. ok is false when
+// neither is on the menu.
+func Choose(options []Option, objective string) (Option, bool) {
+	goal := words(objective)
+
+	best, bestScore := Option{}, 0
+	for _, option := range options {
+		score := 0
+		for _, w := range words(option.Label) {
+			for _, g := range goal {
+				if related(w, g) {
+					score++
+					break
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore = option, score
+		}
+	}
+	if bestScore > 0 {
+		return best, true
+	}
+
+	for _, option := range options {
+		for _, w := range operatorWords {
+			if strings.Contains(option.Label, w) {
+				return option, true
+			}
+		}
+	}
+	return Option{}, false
+}
+
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+func key(word string) string {
+	if digit, ok := digitWords[word]; ok {
+		return digit
+	}
+	return word
+}
+
+// cleanLabel trims the filler around an option's label, e.g. "questions
+// about your bill" from "if you have questions about your bill".
+func cleanLabel(label string) string {
+	// without punctuation a label can run into the next option
+	for _, next := range []string{" press ", " dial ", " enter "} {
+		label, _, _ = strings.Cut(label, next)
+	}
+	label = strings.TrimSpace(label)
+	for _, filler := range []string{"you have ", "you'd like to ", "you would like to ", "you want to ", "you are ", "you're ", "a ", "an ", "the "} {
+		label = strings.TrimPrefix(label, filler)
+	}
+	return strings.TrimSpace(label)
+}
+
+// stopWords carry no meaning when matching labels against an objective.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "about": true, "for": true, "from": true,
+	"have": true, "i": true, "if": true, "in": true, "is": true, "it": true, "me": true,
+	"my": true, "of": true, "on": true, "or": true, "our": true, "please": true, "the": true,
+	"their": true, "them": true, "to": true, "with": true, "you": true, "your": true,
+	"call": true, "calling": true, "ask": true, "get": true, "want": true, "would": true, "like": true,
+}
+
+func words(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if !stopWords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// related reports whether two words look like forms of the same word, such
+// as bill and billing, by sharing a prefix of at least four letters.
+func related(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	n := min(len(a), len(b))
+	shared := 0
+	for shared < n && a[shared] == b[shared] {
+		shared++
+	}
+	return shared >= 4 && shared >= n-1
+}
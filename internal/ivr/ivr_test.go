@@ -0,0 +1,114 @@
+package ivr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected Kind
+	}{
+		{name: "menu with options", text: "Thank you for calling Acme. For billing, press 1. For technical support, press 2.", expected: Menu},
+		{name: "menu without punctuation", text: "for billing press one for support press two", expected: Menu},
+		{name: "menu options read first", text: "Press 1 for billing, press 2 for support.", expected: Menu},
+		{name: "menu without readable options", text: "Please listen carefully, as our menu options have changed.", expected: Menu},
+		{name: "account number prompt", text: "Please enter your account number followed by the pound sign.", expected: Menu},
+		{name: "hold message", text: "All of our representatives are currently assisting other callers. Please stay on the line.", expected: Hold},
+		{name: "queue message", text: "Your call is important to us and will be answered in the order it was received.", expected: Hold},
+		{name: "voicemail greeting", text: "Hi, you've reached Sam. I can't take your call right now, leave a message after the beep.", expected: Voicemail},
+		{name: "carrier voicemail", text: "The person you are trying to reach is not available. At the tone, please record your message.", expected: Voicemail},
+		{name: "voicemail with a menu", text: "To leave a message, press 1. To page Sam, press 2.", expected: Menu},
+		{name: "person offering to take a message", text: "She's not in right now, do you want to leave a message?", expected: Human},
+		{name: "person", text: "Hi, this is Dana in billing, how can I help you?", expected: Human},
+		{name: "person saying hello", text: "Hello?", expected: Human},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Classify(tt.text))
+		})
+	}
+}
+
+func TestParseMenu(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []Option
+	}{
+		{
+			name: "label first",
+			text: "Thank you for calling Acme. For billing, press 1. For technical support, press 2. To speak to a representative, press 0.",
+			expected: []Option{
+				{Digit: "1", Label: "billing"},
+				{Digit: "2", Label: "technical support"},
+				{Digit: "0", Label: "speak to a representative"},
+			},
+		},
+		{
+			name: "press first",
+			text: "Press 1 for new appointments, press 2 to cancel an appointment, or press star to repeat.",
+			expected: []Option{
+				{Digit: "1", Label: "new appointments"},
+				{Digit: "2", Label: "cancel an appointment"},
+				{Digit: "*", Label: "repeat"},
+			},
+		},
+		{
+			name: "spoken digits without punctuation",
+			text: "for pharmacy press three for all other questions press nine",
+			expected: []Option{
+				{Digit: "3", Label: "pharmacy"},
+				{Digit: "9", Label: "all other questions"},
+			},
+		},
+		{
+			name: "if you have",
+			text: "If you have a question about your bill, press 4. Para español, oprima nueve.",
+			expected: []Option{
+				{Digit: "4", Label: "question about your bill"},
+			},
+		},
+		{name: "no options", text: "Hi, how can I help?", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseMenu(tt.text))
+		})
+	}
+}
+
+func TestChoose(t *testing.T) {
+	menu := []Option{
+		{Digit: "1", Label: "billing"},
+		{Digit: "2", Label: "technical support"},
+		{Digit: "3", Label: "new appointments"},
+		{Digit: "0", Label: "speak to representative"},
+	}
+
+	tests := []struct {
+		name      string
+		options   []Option
+		objective string
+		expected  string
+		expectOK  bool
+	}{
+		{name: "matches a related word", options: menu, objective: "Dispute a charge on my bill from March", expected: "1", expectOK: true},
+		{name: "matches several words", options: menu, objective: "Book a new appointment for a cleaning", expected: "3", expectOK: true},
+		{name: "falls back to a person", options: menu, objective: "Wish Sam a happy birthday", expected: "0", expectOK: true},
+		{name: "nothing fits", options: menu[:3], objective: "Wish Sam a happy birthday", expectOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			option, ok := Choose(tt.options, tt.objective)
+			assert.Equal(t, tt.expectOK, ok)
+			assert.Equal(t, tt.expected, option.Digit)
+		})
+	}
+}
@@ -0,0 +1,426 @@
+// Package jobs runs background work (placing calls, summaries, notifications,
+// webhook deliveries) outside of the HTTP request, backed by the jobs table.
+//
+// Jobs are claimed with a lease. While a handler runs, its worker keeps
+// pushing the lease forward; if the process dies the lease expires and another
+// worker picks the job up again, so handlers must be safe to run more than once.
+// Each pickup counts as an attempt, so a job that keeps killing its worker is
+// dead-lettered too. Failed jobs are retried with exponential backoff until they run out of
+// attempts, then they are dead-lettered for inspection.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// Handler processes a single job. Returning an error schedules a retry, unless
+// the error is wrapped with Permanent or the job is out of attempts.
+type Handler func(ctx context.Context, job database.Job) error
+
+// Options tunes the worker pool. Zero values fall back to the defaults below.
+type Options struct {
+	Workers      int           // number of concurrent workers
+	PollInterval time.Duration // how often idle workers look for due jobs
+	Lease        time.Duration // visibility timeout for a claimed job
+	BaseBackoff  time.Duration // delay before the first retry
+	MaxBackoff   time.Duration // upper bound for retry delays
+	MaxAttempts  int64         // default attempts for newly enqueued jobs
+}
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = time.Second
+	defaultLease        = 2 * time.Minute
+	defaultBaseBackoff  = 5 * time.Second
+	defaultMaxBackoff   = 30 * time.Minute
+	defaultMaxAttempts  = 5
+)
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = defaultWorkers
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+	if o.Lease <= 0 {
+		o.Lease = defaultLease
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = defaultBaseBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultMaxBackoff
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = defaultMaxAttempts
+	}
+	return o
+}
+
+// Queue enqueues jobs and runs the worker pool that processes them.
+type Queue struct {
+	db   database.Querier
+	opts Options
+	now  func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	wake     chan struct{}
+	stop     chan struct{}
+	started  bool
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+
+	// handlerCtx is handed to running handlers. It is only cancelled when a
+	// drain runs out of time, so in-flight work gets a chance to finish.
+	handlerCtx    context.Context
+	cancelHandler context.CancelFunc
+}
+
+// New creates a queue over the given querier. Call Register for each job kind
+// and then Start to begin processing.
+func New(db database.Querier, opts Options) *Queue {
+	return &Queue{
+		db:       db,
+		opts:     opts.withDefaults(),
+		now:      time.Now,
+		handlers: make(map[string]Handler),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job kind, replacing any previous one.
+func (q *Queue) Register(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+func (q *Queue) handler(kind string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[kind]
+	return h, ok
+}
+
+// Enqueue adds a job that is due immediately. payload is stored as JSON.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (database.Job, error) {
+	return q.EnqueueAt(ctx, kind, payload, q.now())
+}
+
+// EnqueueAt adds a job that becomes due at runAt.
+func (q *Queue) EnqueueAt(ctx context.Context, kind string, payload any, runAt time.Time) (database.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return database.Job{}, fmt.Errorf("error encoding payload for %s job: %w", kind, err)
+	}
+
+	job, err := q.db.EnqueueJob(ctx, database.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     string(body),
+		MaxAttempts: q.opts.MaxAttempts,
+		RunAt:       runAt.UTC(),
+	})
+	if err != nil {
+		return database.Job{}, fmt.Errorf("error enqueueing %s job: %w", kind, err)
+	}
+
+	// nudge an idle worker so immediate jobs don't wait for the next poll
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Decode unmarshals a job's JSON payload into v.
+func Decode(job database.Job, v any) error {
+	if err := json.Unmarshal([]byte(job.Payload), v); err != nil {
+		return fmt.Errorf("error decoding payload of %s job %d: %w", job.Kind, job.ID, err)
+	}
+	return nil
+}
+
+// Start launches the worker pool. Workers keep running until Shutdown.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return
+	}
+	q.started = true
+	q.handlerCtx, q.cancelHandler = context.WithCancel(context.Background())
+
+	host, _ := os.Hostname()
+	for i := 0; i < q.opts.Workers; i++ {
+		q.workers.Add(1)
+		go q.work(fmt.Sprintf("%s:%d:%d", host, os.Getpid(), i))
+	}
+}
+
+// Shutdown stops workers from claiming new jobs and waits for in-flight jobs
+// to finish. If ctx expires first, running handlers are cancelled; whatever
+// they return is recorded like any other failure, so the job is retried later.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.mu.RLock()
+		if q.cancelHandler != nil {
+			q.cancelHandler()
+		}
+		q.mu.RUnlock()
+		<-done
+		return fmt.Errorf("jobs did not drain before shutdown deadline: %w", ctx.Err())
+	}
+}
+
+// Flush waits until no job of kind is running or due, so work that must not
+// wait for the next start, such as billing, is done before Shutdown. Jobs
+// scheduled for later are left queued.
+func (q *Queue) Flush(ctx context.Context, kind string) error {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	// counted without ctx, so the deadline is reported as jobs left over
+	// rather than a failed count
+	count := context.WithoutCancel(ctx)
+	for {
+		due, err := q.db.CountDueJobs(count, database.CountDueJobsParams{Kind: kind, Now: q.now().UTC()})
+		if err != nil {
+			return fmt.Errorf("error counting due %s jobs: %w", kind, err)
+		}
+		if due == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d %s job(s) did not finish before the flush deadline: %w", due, kind, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// work is a single worker loop: claim a due job, run it, repeat. When there is
+// nothing to do it sleeps until the next poll or an Enqueue wakes it up.
+func (q *Queue) work(workerID string) {
+	defer q.workers.Done()
+
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		ran, err := q.runOne(workerID)
+		if err != nil {
+			log.Printf("jobs: worker %s: %v", workerID, err)
+		}
+		if ran {
+			continue
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOne claims and processes at most one job, reporting whether it found one.
+func (q *Queue) runOne(workerID string) (bool, error) {
+	now := q.now().UTC()
+	worker := sql.NullString{String: workerID, Valid: true}
+	leaseExpired := sql.NullTime{Time: now, Valid: true}
+
+	// a job whose worker keeps dying on it, say by crashing the process,
+	// isn't reclaimed once it has used its attempts
+	abandoned, err := q.db.DeadLetterAbandonedJobs(context.Background(), leaseExpired)
+	if err != nil {
+		return false, fmt.Errorf("error dead-lettering abandoned jobs: %w", err)
+	}
+	for _, job := range abandoned {
+		log.Printf("jobs: dead-lettering %s job %d after %d attempts: %s", job.Kind, job.ID, job.Attempts, job.LastError.String)
+	}
+
+	// claiming is a single UPDATE ... WHERE id = (SELECT ...) statement, so
+	// SQLite's single writer guarantees two workers never get the same job
+	job, err := q.db.ClaimJob(context.Background(), database.ClaimJobParams{
+		Worker:             worker,
+		LeaseUntil:         sql.NullTime{Time: now.Add(q.opts.Lease), Valid: true},
+		Now:                now,
+		LeaseExpiredBefore: leaseExpired,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error claiming job: %w", err)
+	}
+
+	runErr := q.execute(job, worker)
+
+	// settle the job with a fresh context: the handler context may be gone
+	ctx := context.Background()
+	var rows int64
+	switch {
+	case runErr == nil:
+		rows, err = q.db.CompleteJob(ctx, database.CompleteJobParams{ID: job.ID, LockedBy: worker})
+	case isPermanent(runErr) || job.Attempts >= job.MaxAttempts:
+		log.Printf("jobs: dead-lettering %s job %d after %d attempts: %v", job.Kind, job.ID, job.Attempts, runErr)
+		rows, err = q.db.DeadLetterJob(ctx, database.DeadLetterJobParams{
+			LastError: sql.NullString{String: runErr.Error(), Valid: true},
+			ID:        job.ID,
+			LockedBy:  worker,
+		})
+	default:
+		rows, err = q.db.RetryJob(ctx, database.RetryJobParams{
+			RunAt:     q.now().UTC().Add(q.backoff(job.Attempts)),
+			LastError: sql.NullString{String: runErr.Error(), Valid: true},
+			ID:        job.ID,
+			LockedBy:  worker,
+		})
+	}
+	if err != nil {
+		return true, fmt.Errorf("error settling %s job %d: %w", job.Kind, job.ID, err)
+	}
+	if rows == 0 {
+		return true, fmt.Errorf("lost lease on %s job %d before it could be settled", job.Kind, job.ID)
+	}
+
+	return true, nil
+}
+
+// execute runs the job's handler while a heartbeat keeps its lease alive.
+func (q *Queue) execute(job database.Job, worker sql.NullString) (err error) {
+	h, ok := q.handler(job.Kind)
+	if !ok {
+		return fmt.Errorf("no handler registered for job kind %q", job.Kind)
+	}
+
+	q.mu.RLock()
+	parent := q.handlerCtx
+	q.mu.RUnlock()
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		q.heartbeat(ctx, cancel, job.ID, worker)
+	}()
+	defer func() { <-heartbeatDone }()
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in %s job handler: %v", job.Kind, r)
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+// heartbeat extends the job's lease until ctx is done. If the lease has been
+// taken over by someone else the handler is cancelled.
+func (q *Queue) heartbeat(ctx context.Context, cancel context.CancelFunc, jobID int64, worker sql.NullString) {
+	ticker := time.NewTicker(q.opts.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rows, err := q.db.ExtendJobLease(context.Background(), database.ExtendJobLeaseParams{
+				LockedUntil: sql.NullTime{Time: q.now().UTC().Add(q.opts.Lease), Valid: true},
+				ID:          jobID,
+				LockedBy:    worker,
+			})
+			if err != nil {
+				log.Printf("jobs: error extending lease on job %d: %v", jobID, err)
+				continue
+			}
+			if rows == 0 {
+				log.Printf("jobs: lease on job %d was lost, cancelling handler", jobID)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// backoff returns the delay before retrying a job that has failed attempts
+// times: BaseBackoff doubled per attempt, capped at MaxBackoff.
+func (q *Queue) backoff(attempts int64) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := int64(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= q.opts.MaxBackoff {
+			return q.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Requeue moves a dead-lettered job back onto the queue with a fresh set of
+// attempts.
+func (q *Queue) Requeue(ctx context.Context, jobID int64) (database.Job, error) {
+	job, err := q.db.RequeueDeadJob(ctx, database.RequeueDeadJobParams{RunAt: q.now().UTC(), ID: jobID})
+	if err != nil {
+		return database.Job{}, fmt.Errorf("error requeueing job %d: %w", jobID, err)
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the job is dead-lettered at once.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
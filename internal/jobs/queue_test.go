@@ -0,0 +1,344 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupJobsTestDB(t *testing.T) *database.DB {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "jobs_test.db")
+
+	db, err := database.InitDB(dbPath)
+	require.NoError(t, err, "Failed to initialize test database")
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+// fast options so tests don't sit waiting on production-sized timers
+func testOptions(workers int) Options {
+	return Options{
+		Workers:      workers,
+		PollInterval: 10 * time.Millisecond,
+		Lease:        time.Second,
+		BaseBackoff:  10 * time.Millisecond,
+		MaxBackoff:   50 * time.Millisecond,
+		MaxAttempts:  3,
+	}
+}
+
+func waitForStatus(t *testing.T, db *database.DB, jobID int64, status string) database.Job {
+	t.Helper()
+	var job database.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = db.GetJob(context.Background(), jobID)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 5*time.Second, 10*time.Millisecond, "job %d never reached status %s", jobID, status)
+	return job
+}
+
+func TestQueue_ConcurrentWorkersProcessEachJobOnce(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(8))
+
+	var mu sync.Mutex
+	seen := map[int]int{}
+	q.Register("count", func(ctx context.Context, job database.Job) error {
+		var payload struct{ N int }
+		if err := Decode(job, &payload); err != nil {
+			return err
+		}
+		mu.Lock()
+		seen[payload.N]++
+		mu.Unlock()
+		return nil
+	})
+
+	const total = 100
+	for i := 0; i < total; i++ {
+		_, err := q.Enqueue(ctx, "count", struct{ N int }{N: i})
+		require.NoError(t, err)
+	}
+
+	q.Start()
+	require.Eventually(t, func() bool {
+		jobs, err := db.ListJobsByStatus(ctx, "succeeded")
+		require.NoError(t, err)
+		return len(jobs) == total
+	}, 10*time.Second, 20*time.Millisecond)
+	require.NoError(t, q.Shutdown(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, seen, total)
+	for n, count := range seen {
+		assert.Equal(t, 1, count, "job %d should run exactly once", n)
+	}
+}
+
+func TestQueue_RetriesWithBackoffThenSucceeds(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(2))
+
+	var calls atomic.Int32
+	q.Register("flaky", func(ctx context.Context, job database.Job) error {
+		if calls.Add(1) < 3 {
+			return errors.New("provider unavailable")
+		}
+		return nil
+	})
+
+	job, err := q.Enqueue(ctx, "flaky", nil)
+	require.NoError(t, err)
+
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	done := waitForStatus(t, db, job.ID, "succeeded")
+	assert.Equal(t, int64(3), done.Attempts)
+	assert.False(t, done.LastError.Valid, "last error should be cleared on success")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestQueue_DeadLettersAfterMaxAttempts(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(2))
+
+	q.Register("broken", func(ctx context.Context, job database.Job) error {
+		return errors.New("always fails")
+	})
+
+	job, err := q.Enqueue(ctx, "broken", nil)
+	require.NoError(t, err)
+
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	dead := waitForStatus(t, db, job.ID, "dead")
+	assert.Equal(t, int64(3), dead.Attempts)
+	assert.Equal(t, "always fails", dead.LastError.String)
+
+	requeued, err := q.Requeue(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "queued", requeued.Status)
+	assert.Equal(t, int64(0), requeued.Attempts)
+}
+
+func TestQueue_PermanentErrorSkipsRetries(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(1))
+
+	q.Register("invalid", func(ctx context.Context, job database.Job) error {
+		return Permanent(errors.New("bad payload"))
+	})
+
+	job, err := q.Enqueue(ctx, "invalid", nil)
+	require.NoError(t, err)
+
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	dead := waitForStatus(t, db, job.ID, "dead")
+	assert.Equal(t, int64(1), dead.Attempts)
+}
+
+func TestQueue_ReclaimsJobsWithExpiredLease(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+
+	job, err := New(db, testOptions(1)).Enqueue(ctx, "orphan", nil)
+	require.NoError(t, err)
+
+	// simulate a worker that claimed the job and then crashed
+	past := time.Now().UTC().Add(-time.Minute)
+	claimed, err := db.ClaimJob(ctx, database.ClaimJobParams{
+		Worker:             sql.NullString{String: "crashed-worker", Valid: true},
+		LeaseUntil:         sql.NullTime{Time: past, Valid: true},
+		Now:                time.Now().UTC(),
+		LeaseExpiredBefore: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, claimed.ID)
+
+	q := New(db, testOptions(1))
+	var ran atomic.Bool
+	q.Register("orphan", func(ctx context.Context, job database.Job) error {
+		ran.Store(true)
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	done := waitForStatus(t, db, job.ID, "succeeded")
+	assert.True(t, ran.Load())
+	assert.Equal(t, int64(2), done.Attempts)
+}
+
+func TestQueue_DeadLettersJobsThatKeepLosingTheirWorker(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+
+	job, err := New(db, testOptions(1)).Enqueue(ctx, "crasher", nil)
+	require.NoError(t, err)
+
+	// simulate a worker crashing on every one of the job's attempts
+	past := time.Now().UTC().Add(-time.Minute)
+	for range testOptions(1).MaxAttempts {
+		_, err := db.ClaimJob(ctx, database.ClaimJobParams{
+			Worker:             sql.NullString{String: "crashed-worker", Valid: true},
+			LeaseUntil:         sql.NullTime{Time: past, Valid: true},
+			Now:                time.Now().UTC(),
+			LeaseExpiredBefore: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		})
+		require.NoError(t, err)
+	}
+
+	q := New(db, testOptions(1))
+	var ran atomic.Bool
+	q.Register("crasher", func(ctx context.Context, job database.Job) error {
+		ran.Store(true)
+		return nil
+	})
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	dead := waitForStatus(t, db, job.ID, "dead")
+	assert.False(t, ran.Load(), "a job out of attempts isn't run again")
+	assert.Equal(t, int64(3), dead.Attempts)
+	assert.Contains(t, dead.LastError.String, "stopped without finishing")
+	assert.False(t, dead.LockedBy.Valid)
+}
+
+func TestQueue_ShutdownDrainsInFlightJobs(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(1))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, job database.Job) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	job, err := q.Enqueue(ctx, "slow", nil)
+	require.NoError(t, err)
+	q.Start()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- q.Shutdown(ctx) }()
+
+	select {
+	case <-shutdownErr:
+		t.Fatal("shutdown returned while a job was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-shutdownErr)
+
+	done, err := db.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "succeeded", done.Status)
+}
+
+func TestQueue_ShutdownDeadlineCancelsHandlers(t *testing.T) {
+	db := setupJobsTestDB(t)
+	q := New(db, testOptions(1))
+
+	started := make(chan struct{})
+	q.Register("stuck", func(ctx context.Context, job database.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	_, err := q.Enqueue(context.Background(), "stuck", nil)
+	require.NoError(t, err)
+	q.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = q.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestQueue_FlushWaitsForDueJobsOfKind(t *testing.T) {
+	db := setupJobsTestDB(t)
+	ctx := context.Background()
+	q := New(db, testOptions(1))
+
+	var ran atomic.Int32
+	q.Register("bill", func(ctx context.Context, job database.Job) error {
+		ran.Add(1)
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		_, err := q.Enqueue(ctx, "bill", nil)
+		require.NoError(t, err)
+	}
+	// neither a later job nor one of another kind holds up the flush
+	later, err := q.EnqueueAt(ctx, "bill", nil, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = q.Enqueue(ctx, "unhandled", nil)
+	require.NoError(t, err)
+
+	q.Start()
+	defer q.Shutdown(ctx)
+
+	require.NoError(t, q.Flush(ctx, "bill"))
+	assert.Equal(t, int32(3), ran.Load())
+	job, err := db.GetJob(ctx, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "queued", job.Status)
+}
+
+func TestQueue_FlushDeadline(t *testing.T) {
+	db := setupJobsTestDB(t)
+	q := New(db, testOptions(1))
+
+	// never started, so the job stays due
+	_, err := q.Enqueue(context.Background(), "bill", nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = q.Flush(ctx, "bill")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "1 bill job(s) did not finish")
+}
+
+func TestBackoff(t *testing.T) {
+	q := New(nil, Options{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, q.backoff(1))
+	assert.Equal(t, 2*time.Second, q.backoff(2))
+	assert.Equal(t, 4*time.Second, q.backoff(3))
+	assert.Equal(t, 8*time.Second, q.backoff(4))
+	assert.Equal(t, 10*time.Second, q.backoff(5), "backoff should be capped")
+	assert.Equal(t, 10*time.Second, q.backoff(50))
+}
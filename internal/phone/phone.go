@@ -0,0 +1,122 @@
+// Package phone parses phone numbers typed by users into E.164, the
+// "+<country code><number>" form telephony providers dial.
+//
+// It only knows the countries listed in regions.go. That keeps the metadata
+// small enough to maintain by hand, and doubles as the list of countries
+// goDial will place calls to.
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Type is the kind of line a number belongs to, as far as its prefix tells.
+type Type string
+
+const (
+	TypeUnknown       Type = "unknown"
+	TypeFixedLine     Type = "fixed_line"
+	TypeMobile        Type = "mobile"
+	TypeFixedOrMobile Type = "fixed_line_or_mobile" // NANP and others don't say
+	TypeTollFree      Type = "toll_free"
+	TypePremium       Type = "premium_rate"
+)
+
+// Number is a parsed, valid phone number.
+type Number struct {
+	E164        string // e.g. +13336664444
+	CountryCode string // e.g. 1
+	Region      string // ISO 3166-1 alpha-2, e.g. US
+	Subdivision string // ISO 3166-2 state or province where known, e.g. US-NY
+	National    string // the national significant number, e.g. 3336664444
+	Type        Type
+}
+
+// String returns the number in E.164.
+func (n Number) String() string {
+	return n.E164
+}
+
+var (
+	ErrEmpty              = errors.New("phone number is empty")
+	ErrInvalidCharacters  = errors.New("phone number has characters other than digits and formatting")
+	ErrUnsupportedCountry = errors.New("phone number is for a country we don't call")
+	ErrInvalidLength      = errors.New("phone number has the wrong number of digits")
+	ErrInvalidNumber      = errors.New("phone number is not a valid number for its country")
+)
+
+// Parse reads a number the way a person would type it: "+1 (333) 666-4444",
+// "333.666.4444", "+44 20 7946 0958", "00 33 1 42 68 53 00", or a national
+// number such as "020 7946 0958" that is dialed from defaultRegion.
+func Parse(input, defaultRegion string) (Number, error) {
+	digits, international, err := clean(input)
+	if err != nil {
+		return Number{}, err
+	}
+
+	home, ok := regionsByCode[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return Number{}, fmt.Errorf("unknown default region %q: %w", defaultRegion, ErrUnsupportedCountry)
+	}
+
+	// a number dialed with the international prefix of the caller's country
+	// is the same as one written with a +
+	if !international && home.intlPrefix != "" && strings.HasPrefix(digits, home.intlPrefix) {
+		digits = strings.TrimPrefix(digits, home.intlPrefix)
+		international = true
+	}
+
+	if !international {
+		return home.parseNational(digits)
+	}
+
+	for length := 1; length <= 3 && length < len(digits); length++ {
+		if r, ok := regionsByCountryCode[digits[:length]]; ok {
+			return r.parseNational(digits[length:])
+		}
+	}
+	return Number{}, fmt.Errorf("+%s: %w", digits, ErrUnsupportedCountry)
+}
+
+// clean strips formatting from input. international reports whether the
+// number was written with a leading +.
+func clean(input string) (digits string, international bool, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", false, ErrEmpty
+	}
+
+	if strings.HasPrefix(input, "+") {
+		international = true
+		input = input[1:]
+	}
+	// formatting may separate digits, but may not lead, so "-123" isn't read
+	// as a number
+	if input == "" || !(isDigit(input[0]) || input[0] == '(') {
+		return "", false, fmt.Errorf("%q: %w", input, ErrInvalidCharacters)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case isDigit(c):
+			b.WriteByte(c)
+		case c == ' ' || c == '-' || c == '.' || c == '(' || c == ')' || c == '/':
+		default:
+			return "", false, fmt.Errorf("%q: %w", input, ErrInvalidCharacters)
+		}
+	}
+
+	// E.164 allows at most 15 digits, country code included
+	if b.Len() > 15 {
+		return "", false, fmt.Errorf("%d digits: %w", b.Len(), ErrInvalidLength)
+	}
+	return b.String(), international, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,126 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		defaultRegion  string
+		expectedE164   string
+		expectedRegion string
+		expectedType   Type
+	}{
+		// NANP
+		{name: "US bare ten digits", input: "3336664444", defaultRegion: "US", expectedE164: "+13336664444", expectedRegion: "US", expectedType: TypeFixedOrMobile},
+		{name: "US formatted with country code", input: "+1 (333) 666-4444", defaultRegion: "US", expectedE164: "+13336664444", expectedRegion: "US", expectedType: TypeFixedOrMobile},
+		{name: "US dotted", input: "333.666.4444", defaultRegion: "US", expectedE164: "+13336664444", expectedRegion: "US", expectedType: TypeFixedOrMobile},
+		{name: "US with trunk 1", input: "1-333-666-4444", defaultRegion: "US", expectedE164: "+13336664444", expectedRegion: "US", expectedType: TypeFixedOrMobile},
+		{name: "US toll free", input: "(800) 555-1212", defaultRegion: "US", expectedE164: "+18005551212", expectedRegion: "US", expectedType: TypeTollFree},
+		{name: "US premium", input: "900 222 3333", defaultRegion: "US", expectedE164: "+19002223333", expectedRegion: "US", expectedType: TypePremium},
+		{name: "Canada by area code", input: "+1 416 555 0199", defaultRegion: "US", expectedE164: "+14165550199", expectedRegion: "CA", expectedType: TypeFixedOrMobile},
+		{name: "Canada as default region", input: "604-555-0100", defaultRegion: "CA", expectedE164: "+16045550100", expectedRegion: "CA", expectedType: TypeFixedOrMobile},
+		{name: "US dialing out with 011", input: "011 44 20 7946 0958", defaultRegion: "US", expectedE164: "+442079460958", expectedRegion: "GB", expectedType: TypeFixedLine},
+
+		// Europe
+		{name: "GB landline", input: "+44 20 7946 0958", defaultRegion: "US", expectedE164: "+442079460958", expectedRegion: "GB", expectedType: TypeFixedLine},
+		{name: "GB mobile national", input: "07700 900123", defaultRegion: "GB", expectedE164: "+447700900123", expectedRegion: "GB", expectedType: TypeMobile},
+		{name: "GB with (0) in international form", input: "+44 (0)20 7946 0958", defaultRegion: "US", expectedE164: "+442079460958", expectedRegion: "GB", expectedType: TypeFixedLine},
+		{name: "GB freephone", input: "0800 123 4567", defaultRegion: "GB", expectedE164: "+448001234567", expectedRegion: "GB", expectedType: TypeTollFree},
+		{name: "IE mobile", input: "+353 87 123 4567", defaultRegion: "US", expectedE164: "+353871234567", expectedRegion: "IE", expectedType: TypeMobile},
+		{name: "DE mobile", input: "+49 151 23456789", defaultRegion: "US", expectedE164: "+4915123456789", expectedRegion: "DE", expectedType: TypeMobile},
+		{name: "DE landline national", input: "030 123456", defaultRegion: "DE", expectedE164: "+4930123456", expectedRegion: "DE", expectedType: TypeFixedLine},
+		{name: "FR with 00 prefix", input: "00 33 1 42 68 53 00", defaultRegion: "GB", expectedE164: "+33142685300", expectedRegion: "FR", expectedType: TypeFixedLine},
+		{name: "FR mobile", input: "06 12 34 56 78", defaultRegion: "FR", expectedE164: "+33612345678", expectedRegion: "FR", expectedType: TypeMobile},
+		{name: "ES mobile", input: "+34 612 345 678", defaultRegion: "US", expectedE164: "+34612345678", expectedRegion: "ES", expectedType: TypeMobile},
+		{name: "ES toll free", input: "+34 900 123 456", defaultRegion: "US", expectedE164: "+34900123456", expectedRegion: "ES", expectedType: TypeTollFree},
+		{name: "IT landline keeps its zero", input: "+39 06 6988 3112", defaultRegion: "US", expectedE164: "+390669883112", expectedRegion: "IT", expectedType: TypeFixedLine},
+		{name: "IT mobile", input: "+39 312 345 6789", defaultRegion: "US", expectedE164: "+393123456789", expectedRegion: "IT", expectedType: TypeMobile},
+
+		// elsewhere
+		{name: "MX", input: "+52 55 1234 5678", defaultRegion: "US", expectedE164: "+525512345678", expectedRegion: "MX", expectedType: TypeFixedOrMobile},
+		{name: "BR mobile", input: "+55 11 91234-5678", defaultRegion: "US", expectedE164: "+5511912345678", expectedRegion: "BR", expectedType: TypeMobile},
+		{name: "BR landline", input: "(011) 3456-7890", defaultRegion: "BR", expectedE164: "+551134567890", expectedRegion: "BR", expectedType: TypeFixedLine},
+		{name: "IN mobile", input: "+91 98765 43210", defaultRegion: "US", expectedE164: "+919876543210", expectedRegion: "IN", expectedType: TypeMobile},
+		{name: "JP mobile", input: "090-1234-5678", defaultRegion: "JP", expectedE164: "+819012345678", expectedRegion: "JP", expectedType: TypeMobile},
+		{name: "JP freedial", input: "+81 120 123 456", defaultRegion: "US", expectedE164: "+81120123456", expectedRegion: "JP", expectedType: TypeTollFree},
+		{name: "AU mobile", input: "0412 345 678", defaultRegion: "AU", expectedE164: "+61412345678", expectedRegion: "AU", expectedType: TypeMobile},
+		{name: "AU freecall", input: "1800 123 456", defaultRegion: "AU", expectedE164: "+611800123456", expectedRegion: "AU", expectedType: TypeTollFree},
+		{name: "NZ mobile", input: "+64 21 123 4567", defaultRegion: "US", expectedE164: "+64211234567", expectedRegion: "NZ", expectedType: TypeMobile},
+		{name: "ZA mobile", input: "+27 82 123 4567", defaultRegion: "US", expectedE164: "+27821234567", expectedRegion: "ZA", expectedType: TypeMobile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, err := Parse(tt.input, tt.defaultRegion)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedE164, number.E164)
+			assert.Equal(t, tt.expectedE164, number.String())
+			assert.Equal(t, tt.expectedRegion, number.Region)
+			assert.Equal(t, tt.expectedType, number.Type)
+		})
+	}
+}
+
+func TestParse_Subdivision(t *testing.T) {
+	tests := []struct {
+		input       string
+		subdivision string
+	}{
+		{input: "+1 212 555 0123", subdivision: "US-NY"},
+		{input: "+1 850 555 0123", subdivision: "US-FL"},
+		{input: "+1 787 555 0123", subdivision: "US-PR"},
+		{input: "+1 416 555 0199", subdivision: "CA-ON"},
+		{input: "+1 333 666 4444", subdivision: ""},
+		{input: "+1 800 555 1212", subdivision: ""},
+		{input: "+44 20 7946 0958", subdivision: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			number, err := Parse(tt.input, "US")
+			require.NoError(t, err)
+			assert.Equal(t, tt.subdivision, number.Subdivision)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		defaultRegion string
+		expectedErr   error
+	}{
+		{name: "empty", input: "   ", defaultRegion: "US", expectedErr: ErrEmpty},
+		{name: "negative number", input: "-123456789", defaultRegion: "US", expectedErr: ErrInvalidCharacters},
+		{name: "letters", input: "333-DIAL-NOW", defaultRegion: "US", expectedErr: ErrInvalidCharacters},
+		{name: "extension", input: "333 666 4444 x12", defaultRegion: "US", expectedErr: ErrInvalidCharacters},
+		{name: "lone plus", input: "+", defaultRegion: "US", expectedErr: ErrInvalidCharacters},
+		{name: "too long for E.164", input: "+1234567890123456", defaultRegion: "US", expectedErr: ErrInvalidLength},
+		{name: "US too short", input: "666-4444", defaultRegion: "US", expectedErr: ErrInvalidLength},
+		{name: "US too long", input: "33366644445", defaultRegion: "US", expectedErr: ErrInvalidLength},
+		{name: "US area code starting with 0", input: "033 666 4444", defaultRegion: "US", expectedErr: ErrInvalidNumber},
+		{name: "US exchange starting with 1", input: "333 166 4444", defaultRegion: "US", expectedErr: ErrInvalidNumber},
+		{name: "US N11 area code", input: "911 555 1234", defaultRegion: "US", expectedErr: ErrInvalidNumber},
+		{name: "FR too short", input: "+33 1 42 68 53", defaultRegion: "US", expectedErr: ErrInvalidLength},
+		{name: "AU ten digit non-freecall", input: "+61 4123 456 789", defaultRegion: "US", expectedErr: ErrInvalidNumber},
+		{name: "BR eleven digits without mobile 9", input: "+55 11 81234 5678", defaultRegion: "US", expectedErr: ErrInvalidNumber},
+		{name: "unsupported country", input: "+7 495 123 4567", defaultRegion: "US", expectedErr: ErrUnsupportedCountry},
+		{name: "unknown default region", input: "3336664444", defaultRegion: "XX", expectedErr: ErrUnsupportedCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input, tt.defaultRegion)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
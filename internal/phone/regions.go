@@ -0,0 +1,301 @@
+package phone
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// region is the numbering plan of one country, trimmed to what Parse needs.
+type region struct {
+	code        string // ISO 3166-1 alpha-2
+	countryCode string
+	intlPrefix  string // dialed before a country code when calling out of this region
+	trunkPrefix string // dialed before national numbers, not part of E.164
+	lengths     []int  // valid lengths of the national significant number
+	valid       func(nsn string) bool
+	types       []typeRule // checked in order, first match wins
+	defaultType Type
+	subregion   func(nsn string) string // for country codes shared by several regions
+}
+
+// typeRule classifies numbers starting with any of prefixes. lengths, if set,
+// narrows the rule to numbers of those lengths.
+type typeRule struct {
+	prefixes []string
+	lengths  []int
+	typ      Type
+}
+
+func (r region) parseNational(digits string) (Number, error) {
+	nsn := digits
+	if r.trunkPrefix != "" {
+		nsn = strings.TrimPrefix(nsn, r.trunkPrefix)
+	}
+
+	if !slices.Contains(r.lengths, len(nsn)) {
+		return Number{}, fmt.Errorf("%s number %s has %d digits: %w", r.code, nsn, len(nsn), ErrInvalidLength)
+	}
+	if r.valid != nil && !r.valid(nsn) {
+		return Number{}, fmt.Errorf("%s number %s: %w", r.code, nsn, ErrInvalidNumber)
+	}
+
+	number := Number{
+		E164:        "+" + r.countryCode + nsn,
+		CountryCode: r.countryCode,
+		Region:      r.code,
+		National:    nsn,
+		Type:        r.classify(nsn),
+	}
+	if r.subregion != nil {
+		number.Region = r.subregion(nsn)
+	}
+	if r.countryCode == "1" {
+		number.Subdivision = nanpSubdivision(nsn)
+	}
+	return number, nil
+}
+
+func (r region) classify(nsn string) Type {
+	for _, rule := range r.types {
+		if len(rule.lengths) > 0 && !slices.Contains(rule.lengths, len(nsn)) {
+			continue
+		}
+		for _, prefix := range rule.prefixes {
+			if strings.HasPrefix(nsn, prefix) {
+				return rule.typ
+			}
+		}
+	}
+	if r.defaultType == "" {
+		return TypeUnknown
+	}
+	return r.defaultType
+}
+
+var regions = []region{
+	{
+		// the North American Numbering Plan: US, Canada and much of the
+		// Caribbean share +1 and ten digit numbers
+		code:        "US",
+		countryCode: "1",
+		intlPrefix:  "011",
+		trunkPrefix: "1",
+		lengths:     []int{10},
+		valid:       validNANP,
+		types: []typeRule{
+			{prefixes: []string{"800", "833", "844", "855", "866", "877", "888"}, typ: TypeTollFree},
+			{prefixes: []string{"900"}, typ: TypePremium},
+		},
+		defaultType: TypeFixedOrMobile,
+		subregion:   nanpRegion,
+	},
+	{
+		code:        "GB",
+		countryCode: "44",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{9, 10},
+		types: []typeRule{
+			{prefixes: []string{"71", "72", "73", "74", "75", "77", "78", "79"}, lengths: []int{10}, typ: TypeMobile},
+			{prefixes: []string{"800", "808"}, typ: TypeTollFree},
+			{prefixes: []string{"9"}, typ: TypePremium},
+			{prefixes: []string{"1", "2"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "IE",
+		countryCode: "353",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{7, 8, 9},
+		types: []typeRule{
+			{prefixes: []string{"83", "85", "86", "87", "89"}, lengths: []int{9}, typ: TypeMobile},
+			{prefixes: []string{"1800"}, typ: TypeTollFree},
+			{prefixes: []string{"15"}, typ: TypePremium},
+			{prefixes: []string{"1", "2", "4", "5", "6", "7", "9"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "DE",
+		countryCode: "49",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{6, 7, 8, 9, 10, 11, 12, 13},
+		types: []typeRule{
+			{prefixes: []string{"15", "16", "17"}, lengths: []int{10, 11}, typ: TypeMobile},
+			{prefixes: []string{"800"}, typ: TypeTollFree},
+			{prefixes: []string{"900"}, typ: TypePremium},
+			{prefixes: []string{"2", "3", "4", "5", "6", "7", "8", "9"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "FR",
+		countryCode: "33",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{9},
+		types: []typeRule{
+			{prefixes: []string{"6", "7"}, typ: TypeMobile},
+			{prefixes: []string{"80"}, typ: TypeTollFree},
+			{prefixes: []string{"89"}, typ: TypePremium},
+			{prefixes: []string{"1", "2", "3", "4", "5", "9"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		// Spain has no trunk prefix
+		code:        "ES",
+		countryCode: "34",
+		intlPrefix:  "00",
+		lengths:     []int{9},
+		types: []typeRule{
+			{prefixes: []string{"6", "7"}, typ: TypeMobile},
+			{prefixes: []string{"800", "900"}, typ: TypeTollFree},
+			{prefixes: []string{"803", "806", "807", "905"}, typ: TypePremium},
+			{prefixes: []string{"8", "9"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		// Italian landlines keep their leading 0 in E.164, so there is no
+		// trunk prefix to strip
+		code:        "IT",
+		countryCode: "39",
+		intlPrefix:  "00",
+		lengths:     []int{6, 7, 8, 9, 10, 11},
+		types: []typeRule{
+			{prefixes: []string{"3"}, lengths: []int{9, 10}, typ: TypeMobile},
+			{prefixes: []string{"80"}, typ: TypeTollFree},
+			{prefixes: []string{"89"}, typ: TypePremium},
+			{prefixes: []string{"0"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "MX",
+		countryCode: "52",
+		intlPrefix:  "00",
+		lengths:     []int{10},
+		types: []typeRule{
+			{prefixes: []string{"800"}, typ: TypeTollFree},
+			{prefixes: []string{"900"}, typ: TypePremium},
+		},
+		defaultType: TypeFixedOrMobile,
+	},
+	{
+		code:        "BR",
+		countryCode: "55",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{10, 11},
+		types: []typeRule{
+			{prefixes: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, lengths: []int{11}, typ: TypeMobile},
+			{prefixes: []string{"800"}, typ: TypeTollFree},
+		},
+		defaultType: TypeFixedLine,
+		valid: func(nsn string) bool {
+			// two digit area code, then eight digit landlines or nine digit
+			// mobiles starting with 9
+			return len(nsn) == 10 || nsn[2] == '9'
+		},
+	},
+	{
+		code:        "ZA",
+		countryCode: "27",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{9},
+		types: []typeRule{
+			{prefixes: []string{"6", "7", "81", "82", "83", "84"}, typ: TypeMobile},
+			{prefixes: []string{"80"}, typ: TypeTollFree},
+			{prefixes: []string{"86"}, typ: TypePremium},
+			{prefixes: []string{"1", "2", "3", "4", "5"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "IN",
+		countryCode: "91",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{10},
+		types: []typeRule{
+			{prefixes: []string{"6", "7", "8", "9"}, typ: TypeMobile},
+			{prefixes: []string{"1", "2", "3", "4", "5"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "JP",
+		countryCode: "81",
+		intlPrefix:  "010",
+		trunkPrefix: "0",
+		lengths:     []int{9, 10},
+		types: []typeRule{
+			{prefixes: []string{"70", "80", "90"}, lengths: []int{10}, typ: TypeMobile},
+			{prefixes: []string{"120"}, lengths: []int{9}, typ: TypeTollFree},
+			{prefixes: []string{"800"}, lengths: []int{10}, typ: TypeTollFree},
+			{prefixes: []string{"990"}, typ: TypePremium},
+			{prefixes: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, lengths: []int{9}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "AU",
+		countryCode: "61",
+		intlPrefix:  "0011",
+		trunkPrefix: "0",
+		lengths:     []int{9, 10},
+		valid: func(nsn string) bool {
+			// only 1800 freephone numbers run to ten digits
+			return len(nsn) == 9 || strings.HasPrefix(nsn, "1800")
+		},
+		types: []typeRule{
+			{prefixes: []string{"4"}, typ: TypeMobile},
+			{prefixes: []string{"1800"}, typ: TypeTollFree},
+			{prefixes: []string{"190"}, typ: TypePremium},
+			{prefixes: []string{"2", "3", "7", "8"}, typ: TypeFixedLine},
+		},
+	},
+	{
+		code:        "NZ",
+		countryCode: "64",
+		intlPrefix:  "00",
+		trunkPrefix: "0",
+		lengths:     []int{8, 9, 10},
+		types: []typeRule{
+			{prefixes: []string{"2"}, typ: TypeMobile},
+			{prefixes: []string{"800", "508"}, typ: TypeTollFree},
+			{prefixes: []string{"900"}, typ: TypePremium},
+			{prefixes: []string{"3", "4", "6", "7", "9"}, lengths: []int{8}, typ: TypeFixedLine},
+		},
+	},
+}
+
+var (
+	regionsByCode        = map[string]region{}
+	regionsByCountryCode = map[string]region{}
+)
+
+func init() {
+	for _, r := range regions {
+		regionsByCode[r.code] = r
+		regionsByCountryCode[r.countryCode] = r
+	}
+	// Canada shares the NANP plan
+	regionsByCode["CA"] = regionsByCode["US"]
+}
+
+// validNANP checks the NXX-NXX-XXXX shape of a NANP number: neither the area
+// code nor the exchange may start with 0 or 1, and N11 codes like 911 are
+// service codes, not area codes.
+func validNANP(nsn string) bool {
+	if nsn[0] < '2' || nsn[3] < '2' {
+		return false
+	}
+	return nsn[1:3] != "11"
+}
+
+// nanpRegion tells US and Canadian numbers apart by area code. Other NANP
+// countries share +1 too, but aren't told apart yet.
+func nanpRegion(nsn string) string {
+	if strings.HasPrefix(nanpSubdivision(nsn), "CA-") {
+		return "CA"
+	}
+	return "US"
+}
@@ -0,0 +1,134 @@
+// Package recordings stores call recordings once they are downloaded from the
+// telephony provider. Stores are addressed by key and hand recordings back as
+// seekable objects, so they can be played back with Range requests whether
+// they live on local disk or, later, in an S3-compatible bucket.
+package recordings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Store keeps recordings by key. Keys are slash separated paths such as
+// calls/12/attempt-3.mp3.
+type Store interface {
+	// Save writes everything read from r under key, replacing anything
+	// already there, and returns how many bytes were written.
+	Save(ctx context.Context, key string, r io.Reader) (int64, error)
+
+	// Open returns the recording saved under key, or an error wrapping
+	// ErrNotFound.
+	Open(ctx context.Context, key string) (*Object, error)
+
+	// Delete removes the recording saved under key. Deleting a key that
+	// doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Object is an open recording. The caller closes it.
+type Object struct {
+	io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+var (
+	// ErrNotFound is returned when nothing is saved under a key.
+	ErrNotFound = errors.New("recording not found")
+	// ErrInvalidKey is returned for keys that are empty or would escape
+	// the store, such as ../x.
+	ErrInvalidKey = errors.New("invalid recording key")
+)
+
+// DefaultRetention is how long recordings are kept unless the service is
+// told otherwise.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// LocalStore keeps recordings as files under a directory.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store in dir. The directory is created on the first
+// Save.
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || clean != "/"+key || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean[1:])), nil
+}
+
+// Save writes the recording to a temporary file first, so a failed download
+// never leaves half a recording under key.
+func (s *LocalStore) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o750); err != nil {
+		return 0, fmt.Errorf("error creating directory for recording %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".partial-*")
+	if err != nil {
+		return 0, fmt.Errorf("error creating file for recording %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error writing recording %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return 0, fmt.Errorf("error saving recording %s: %w", key, err)
+	}
+	return written, nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (*Object, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening recording %s: %w", key, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading recording %s: %w", key, err)
+	}
+
+	return &Object{ReadSeekCloser: f, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	file, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting recording %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package recordings
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := NewLocalStore(dir)
+
+	written, err := store.Save(ctx, "calls/1/attempt-1.mp3", strings.NewReader("hello recording"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), written)
+
+	object, err := store.Open(ctx, "calls/1/attempt-1.mp3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), object.Size)
+	_, err = object.Seek(6, io.SeekStart)
+	require.NoError(t, err)
+	rest, err := io.ReadAll(object)
+	require.NoError(t, err)
+	assert.Equal(t, "recording", string(rest))
+	require.NoError(t, object.Close())
+
+	// saving again replaces the recording, without leaving partial files
+	_, err = store.Save(ctx, "calls/1/attempt-1.mp3", strings.NewReader("new"))
+	require.NoError(t, err)
+	entries, err := os.ReadDir(filepath.Join(dir, "calls", "1"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	require.NoError(t, store.Delete(ctx, "calls/1/attempt-1.mp3"))
+	_, err = store.Open(ctx, "calls/1/attempt-1.mp3")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "calls/1/attempt-1.mp3"), "deleting twice is fine")
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+
+	for _, key := range []string{"", "/", "../outside.mp3", "calls/../../outside.mp3", "/calls/1.mp3", "calls//1.mp3", `calls\1.mp3`} {
+		t.Run(key, func(t *testing.T) {
+			_, err := store.Save(ctx, key, strings.NewReader("x"))
+			assert.ErrorIs(t, err, ErrInvalidKey)
+			_, err = store.Open(ctx, key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+			assert.ErrorIs(t, store.Delete(ctx, key), ErrInvalidKey)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/dnc"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"strings"
+)
+
+// maxDNCUpload caps the size of an uploaded do-not-call csv.
+const maxDNCUpload = 10 << 20
+
+// recentDNCEntries is how many entries the admin page lists.
+const recentDNCEntries = 50
+
+// requireAdmin only lets admins through to next.
+func requireAdmin(sessions *auth.Sessions, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := sessions.User(r.Context())
+		if err != nil || !user.IsAdmin {
+			http.NotFound(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func handleDNCAdminPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		renderDNCAdminPage(w, r, db, nil)
+	}
+}
+
+// handleDNCImport adds the numbers of an uploaded csv to the do-not-call list,
+// either for everyone or for the user named in userEmail.
+func handleDNCImport(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxDNCUpload)
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			fmt.Printf("handleDNCImport(no file uploaded): %v\n", err)
+			http.Error(w, "upload a csv file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		var scope sql.NullInt64
+		if email := strings.TrimSpace(r.FormValue("userEmail")); email != "" {
+			user, err := db.GetUserByEmail(r.Context(), email)
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "no user with that email", http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				fmt.Printf("handleDNCImport(couldnt find user %s): %v\n", email, err)
+				http.Error(w, "could not find user", http.StatusInternalServerError)
+				return
+			}
+			scope = sql.NullInt64{Int64: user.ID, Valid: true}
+		}
+
+		result, err := dnc.Import(r.Context(), db, file, scope, "US")
+		if err != nil {
+			fmt.Printf("handleDNCImport(import failed): %v\n", err)
+			http.Error(w, "could not import csv: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		renderDNCAdminPage(w, r, db, &result)
+	}
+}
+
+func renderDNCAdminPage(w http.ResponseWriter, r *http.Request, db *database.DB, result *dnc.ImportResult) {
+	entries, err := db.ListDNCEntries(r.Context(), recentDNCEntries)
+	if err != nil {
+		fmt.Printf("renderDNCAdminPage(couldnt list entries): %v\n", err)
+		http.Error(w, "could not load do-not-call list", http.StatusInternalServerError)
+		return
+	}
+	total, err := db.CountDNCEntries(r.Context())
+	if err != nil {
+		fmt.Printf("renderDNCAdminPage(couldnt count entries): %v\n", err)
+		http.Error(w, "could not load do-not-call list", http.StatusInternalServerError)
+		return
+	}
+
+	pages.AdminDNC(entries, total, result).Render(r.Context(), w)
+}
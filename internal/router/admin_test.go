@@ -0,0 +1,100 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"goDial/internal/database"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dncImportRequest(t *testing.T, csv string, userEmail string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "dnc.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csv))
+	require.NoError(t, err)
+	require.NoError(t, form.WriteField("userEmail", userEmail))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest("POST", "/admin/dnc/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestDNCAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		isAdmin       bool
+		request       func(t *testing.T) *http.Request
+		expectedCode  int
+		expectedBody  string
+		expectedCount int64
+	}{
+		{
+			name:         "non-admin cannot see the page",
+			request:      func(t *testing.T) *http.Request { return httptest.NewRequest("GET", "/admin/dnc", nil) },
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "non-admin cannot import",
+			request:      func(t *testing.T) *http.Request { return dncImportRequest(t, "3336664444\n", "") },
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "admin sees the page",
+			isAdmin:      true,
+			request:      func(t *testing.T) *http.Request { return httptest.NewRequest("GET", "/admin/dnc", nil) },
+			expectedCode: http.StatusOK,
+			expectedBody: "Do-Not-Call",
+		},
+		{
+			name:    "admin imports a csv",
+			isAdmin: true,
+			request: func(t *testing.T) *http.Request {
+				return dncImportRequest(t, "number\n3336664444\n+44 20 7946 0958\nnope\n", "")
+			},
+			expectedCode:  http.StatusOK,
+			expectedBody:  "Imported 2 new numbers, 0 already listed, 1 rejected.",
+			expectedCount: 2,
+		},
+		{
+			name:    "import scoped to an unknown user",
+			isAdmin: true,
+			request: func(t *testing.T) *http.Request {
+				return dncImportRequest(t, "3336664444\n", "nobody@example.com")
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+			user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			if tt.isAdmin {
+				_, err := db.ExecContext(ctx, "UPDATE users SET is_admin = 1 WHERE id = ?", user.ID)
+				require.NoError(t, err)
+			}
+			router := NewRouter(testConfig, db, newTestCallService(db))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.request(t))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			count, err := db.CountDNCEntries(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
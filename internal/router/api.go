@@ -0,0 +1,43 @@
+package router
+
+import (
+	"fmt"
+	"goDial/internal/api"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"net/http"
+)
+
+// handleAPIBalance returns the calling time the current user has left.
+func handleAPIBalance(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.UserFromContext(r.Context())
+
+	var minutes int64
+	switch m := user.Minutes.(type) {
+	case int64:
+		minutes = m
+	case float64:
+		minutes = int64(m)
+	}
+
+	api.WriteJSON(w, http.StatusOK, api.Balance{Minutes: minutes})
+}
+
+// handleAPIContacts lists the current user's contacts.
+func handleAPIContacts(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		contacts, err := db.ListContacts(r.Context(), user.ID)
+		if err != nil {
+			fmt.Printf("handleAPIContacts(couldnt list contacts of user %d): %v\n", user.ID, err)
+			api.WriteError(w, http.StatusInternalServerError, api.CodeInternal, "Could not list your contacts.")
+			return
+		}
+
+		list := api.ContactList{Contacts: make([]api.Contact, len(contacts))}
+		for i, contact := range contacts {
+			list.Contacts[i] = api.NewContact(contact)
+		}
+		api.WriteJSON(w, http.StatusOK, list)
+	}
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"goDial/internal/apikeys"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"strconv"
+)
+
+// handleAPIKeysPage lists the current user's API keys.
+func handleAPIKeysPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		renderAPIKeys(w, r, db, user.ID, http.StatusOK, pages.APIKeysForm{})
+	}
+}
+
+// handleCreateAPIKey makes an API key for the current user and shows it, the
+// only time it can be seen, or sends the form back with the problem.
+func handleCreateAPIKey(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "could not read form", http.StatusBadRequest)
+			return
+		}
+		form := apikeys.Form{Name: r.PostFormValue("name"), Scopes: r.PostForm["scopes"]}
+		if problem := form.Validate(); problem != "" {
+			renderAPIKeys(w, r, db, user.ID, http.StatusBadRequest, pages.APIKeysForm{Form: form, Problem: problem})
+			return
+		}
+
+		key, created, err := apikeys.Create(r.Context(), db, user.ID, form)
+		if err != nil {
+			fmt.Printf("handleCreateAPIKey(couldnt make key for user %d): %v\n", user.ID, err)
+			http.Error(w, "could not make API key", http.StatusInternalServerError)
+			return
+		}
+
+		// shown in the page rather than after a redirect, so it is never
+		// stored anywhere it could be seen again
+		w.Header().Set("Cache-Control", "no-store")
+		renderAPIKeys(w, r, db, user.ID, http.StatusOK, pages.APIKeysForm{NewKey: key, NewKeyName: created.Name})
+	}
+}
+
+// handleRevokeAPIKey stops one of the current user's API keys working.
+func handleRevokeAPIKey(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		err = apikeys.Revoke(r.Context(), db, user.ID, id)
+		if errors.Is(err, apikeys.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			fmt.Printf("handleRevokeAPIKey: %v\n", err)
+			http.Error(w, "could not revoke API key", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/settings/api-keys", http.StatusSeeOther)
+	}
+}
+
+func renderAPIKeys(w http.ResponseWriter, r *http.Request, db *database.DB, userID int64, status int, form pages.APIKeysForm) {
+	keys, err := db.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderAPIKeys(couldnt list keys of user %d): %v\n", userID, err)
+		http.Error(w, "could not load API keys", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	pages.APIKeys(keys, form).Render(r.Context(), w)
+}
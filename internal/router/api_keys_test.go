@@ -0,0 +1,153 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"goDial/internal/apikeys"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string // {key} is the user's, {other} someone else's
+		form             url.Values
+		expectedCode     int
+		expectedLocation string
+		expectedBody     []string
+		unexpectedBody   []string
+	}{
+		{
+			name:           "lists the user's keys by prefix",
+			method:         "GET",
+			path:           "/settings/api-keys",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{"CRM sync", "calls:read", "never used", "Revoke"},
+			unexpectedBody: []string{"Their script", "Copy it now"},
+		},
+		{
+			name:         "shows a new key once",
+			method:       "POST",
+			path:         "/settings/api-keys",
+			form:         url.Values{"name": {"Dashboard"}, "scopes": {apikeys.ScopeBillingRead}},
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"Here is your new key, Dashboard. Copy it now: it won't be shown again.", "billing:read"},
+		},
+		{
+			name:         "invalid key is sent back with the problem",
+			method:       "POST",
+			path:         "/settings/api-keys",
+			form:         url.Values{"name": {"Dashboard"}},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: []string{"Pick at least one scope.", `value="Dashboard"`},
+		},
+		{
+			name:             "revokes the user's key",
+			method:           "POST",
+			path:             "/settings/api-keys/{key}/revoke",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/settings/api-keys",
+		},
+		{
+			name:         "can't revoke someone else's key",
+			method:       "POST",
+			path:         "/settings/api-keys/{other}/revoke",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			_, key, err := apikeys.Create(ctx, db, user.ID, apikeys.Form{Name: "CRM sync", Scopes: []string{apikeys.ScopeCallsRead}})
+			require.NoError(t, err)
+
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			_, othersKey, err := apikeys.Create(ctx, db, other.ID, apikeys.Form{Name: "Their script", Scopes: []string{apikeys.ScopeCallsRead}})
+			require.NoError(t, err)
+
+			ids := strings.NewReplacer("{key}", fmt.Sprint(key.ID), "{other}", fmt.Sprint(othersKey.ID))
+			req := httptest.NewRequest(tt.method, ids.Replace(tt.path), strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			NewRouter(testConfig, db, newTestCallService(db)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+		})
+	}
+}
+
+// TestAPIKeys_Bearer makes a key on the settings page and uses it on the API
+// until it is revoked.
+func TestAPIKeys_Bearer(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	router := NewRouter(testConfig, db, newTestCallService(db))
+
+	owner, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET minutes = 42 WHERE id = ?", owner.ID)
+	require.NoError(t, err)
+
+	form := url.Values{"name": {"Dashboard"}, "scopes": {apikeys.ScopeBillingRead}}
+	req := httptest.NewRequest("POST", "/settings/api-keys", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	key := regexp.MustCompile(`gdk_[0-9a-f]{12}_[0-9a-f]{64}`).FindString(w.Body.String())
+	require.NotEmpty(t, key, "the page shows the new key")
+
+	api := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w = api("/api/v1/balance")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"minutes":42}`, w.Body.String())
+
+	w = api("/api/v1/calls")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"insufficient_scope","message":"This API key doesn't have the calls:read scope."}`, w.Body.String())
+
+	keys, err := db.ListAPIKeys(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].LastUsedAt.Valid)
+	require.NoError(t, apikeys.Revoke(ctx, db, owner.ID, keys[0].ID))
+
+	w = api("/api/v1/balance")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error":"unauthorized","message":"This API key is wrong or has been revoked."}`, w.Body.String())
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		noUser bool
+		// production serves the request outside development, where it needs
+		// a key rather than falling back to the session
+		production   bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "balance",
+			method:       "GET",
+			path:         "/api/v1/balance",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"minutes":42}`,
+		},
+		{
+			name:         "contacts, by name",
+			method:       "GET",
+			path:         "/api/v1/contacts",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"contacts":[{"id":2,"name":"Alex Jones","phone_number":"+13336665555","timezone":"America/Chicago"},{"id":1,"name":"Sam Smith","phone_number":"+13336664444","notes":"Prefers mornings"}]}`,
+		},
+		{
+			name:         "calls",
+			method:       "GET",
+			path:         "/api/v1/calls",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"calls":[]}`,
+		},
+		{
+			name:         "someone else's call",
+			method:       "GET",
+			path:         "/api/v1/calls/1",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"not_found","message":"There's no call 1."}`,
+		},
+		{
+			name:         "unknown path",
+			method:       "GET",
+			path:         "/api/v1/callz",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"error":"not_found","message":"There's nothing at GET /api/v1/callz."}`,
+		},
+		{
+			name:         "no user",
+			method:       "GET",
+			path:         "/api/v1/balance",
+			noUser:       true,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"unauthorized","message":"Sign in, or send an API key as a bearer token."}`,
+		},
+		{
+			name:         "no key, outside development",
+			method:       "GET",
+			path:         "/api/v1/balance",
+			production:   true,
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"error":"unauthorized","message":"Send an API key as a bearer token."}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			_, err = db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "+13336664444", Objective: "not yours"})
+			require.NoError(t, err)
+			if !tt.noUser {
+				user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+				require.NoError(t, err)
+				_, err = db.ExecContext(ctx, "UPDATE users SET minutes = 42 WHERE id = ?", user.ID)
+				require.NoError(t, err)
+				_, err = db.CreateContact(ctx, database.CreateContactParams{UserID: user.ID, Name: "Sam Smith", PhoneNumber: "+13336664444", Notes: "Prefers mornings"})
+				require.NoError(t, err)
+				_, err = db.CreateContact(ctx, database.CreateContactParams{UserID: user.ID, Name: "Alex Jones", PhoneNumber: "+13336665555", Timezone: sql.NullString{String: "America/Chicago", Valid: true}})
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			cfg := testConfig
+			if !tt.production {
+				cfg.Environment = config.Development
+			}
+			NewRouter(cfg, db, newTestCallService(db)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
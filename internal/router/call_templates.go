@@ -0,0 +1,141 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/calltemplates"
+	"goDial/internal/database"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"strconv"
+)
+
+// handleCallTemplatesPage lists the call templates the current user can use.
+func handleCallTemplatesPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		renderCallTemplates(w, r, db, user.ID, http.StatusOK, calltemplates.Form{}, "")
+	}
+}
+
+// handleCreateCallTemplate adds a call template for the current user, sending
+// the form back with the problem if it can't be saved.
+func handleCreateCallTemplate(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		form := calltemplates.Form{
+			Name:         r.FormValue("name"),
+			Objective:    r.FormValue("objective"),
+			OtherContext: r.FormValue("otherContext"),
+		}
+		if problem := form.Validate(); problem != "" {
+			renderCallTemplates(w, r, db, user.ID, http.StatusBadRequest, form, problem)
+			return
+		}
+
+		if _, err := calltemplates.Create(r.Context(), db, user.ID, form); err != nil {
+			fmt.Printf("handleCreateCallTemplate(couldnt save template of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not save template", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/templates", http.StatusSeeOther)
+	}
+}
+
+// handleDeleteCallTemplate deletes one of the current user's call templates.
+// Calls made from it keep their objective but are no longer linked to it.
+// Built in templates can't be deleted.
+func handleDeleteCallTemplate(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		deleted, err := db.DeleteCallTemplate(r.Context(), database.DeleteCallTemplateParams{ID: id, UserID: sql.NullInt64{Int64: user.ID, Valid: true}})
+		if err != nil {
+			fmt.Printf("handleDeleteCallTemplate(couldnt delete template %d): %v\n", id, err)
+			http.Error(w, "could not delete template", http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Redirect(w, r, "/templates", http.StatusSeeOther)
+	}
+}
+
+// handleCallTemplatePicker returns the call form's choice of template.
+func handleCallTemplatePicker(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		list, err := db.ListCallTemplates(r.Context(), sql.NullInt64{Int64: user.ID, Valid: true})
+		if err != nil {
+			fmt.Printf("handleCallTemplatePicker(couldnt list templates of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not load templates", http.StatusInternalServerError)
+			return
+		}
+
+		pages.CallTemplatePicker(list).Render(r.Context(), w)
+	}
+}
+
+// handleCallTemplateFields returns the call form's inputs for the variables of
+// the template picked on it, or none if no template is picked.
+func handleCallTemplateFields(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		value := r.URL.Query().Get("templateId")
+		if value == "" {
+			pages.CallTemplateFields(nil, nil).Render(r.Context(), w)
+			return
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		t, err := calltemplates.Get(r.Context(), db, user.ID, id)
+		if errors.Is(err, calltemplates.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			fmt.Printf("handleCallTemplateFields(couldnt load template %d): %v\n", id, err)
+			http.Error(w, "could not load template", http.StatusInternalServerError)
+			return
+		}
+
+		pages.CallTemplateFields(&t, calltemplates.VariablesOf(t)).Render(r.Context(), w)
+	}
+}
+
+func renderCallTemplates(w http.ResponseWriter, r *http.Request, db *database.DB, userID int64, status int, form calltemplates.Form, problem string) {
+	list, err := db.ListCallTemplates(r.Context(), sql.NullInt64{Int64: userID, Valid: true})
+	if err != nil {
+		fmt.Printf("renderCallTemplates(couldnt list templates of user %d): %v\n", userID, err)
+		http.Error(w, "could not load templates", http.StatusInternalServerError)
+		return
+	}
+	rows, err := db.ListCallTemplateStats(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderCallTemplates(couldnt count calls by template of user %d): %v\n", userID, err)
+		http.Error(w, "could not load templates", http.StatusInternalServerError)
+		return
+	}
+	stats := make(map[int64]database.ListCallTemplateStatsRow, len(rows))
+	for _, row := range rows {
+		stats[row.TemplateID] = row
+	}
+
+	w.WriteHeader(status)
+	pages.CallTemplates(list, stats, form, problem).Render(r.Context(), w)
+}
@@ -0,0 +1,146 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallTemplates(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string // {template} is the user's template and {other} someone else's
+		form             url.Values
+		expectedCode     int
+		expectedLocation string
+		expectedBody     []string
+		unexpectedBody   []string
+	}{
+		{
+			name:           "lists built in and the user's templates with how they went",
+			method:         "GET",
+			path:           "/templates",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{"Confirm an appointment", "Built in", "Remind about rent", "2 calls, 1 completed, 0 failed", "Not used yet"},
+			unexpectedBody: []string{"Someone else&#39;s"},
+		},
+		{
+			name:             "adds a template",
+			method:           "POST",
+			path:             "/templates",
+			form:             url.Values{"name": {"Pickup"}, "objective": {"Ask when {{item}} is ready"}},
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/templates",
+		},
+		{
+			name:         "invalid template is sent back with the problem",
+			method:       "POST",
+			path:         "/templates",
+			form:         url.Values{"name": {"Pickup"}, "objective": {"Ask about {{when:someday}}"}},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: []string{"Check the placeholders", `value="Pickup"`},
+		},
+		{
+			name:             "deletes the user's template",
+			method:           "POST",
+			path:             "/templates/{template}/delete",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/templates",
+		},
+		{
+			name:         "can't delete someone else's template",
+			method:       "POST",
+			path:         "/templates/{other}/delete",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:           "picker for the call form",
+			method:         "GET",
+			path:           "/templates/picker",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{`name="templateId"`, "Remind about rent", "Book a table", `id="template-fields"`},
+			unexpectedBody: []string{"Someone else&#39;s", "<html"},
+		},
+		{
+			name:         "inputs for a template's variables",
+			method:       "GET",
+			path:         "/templates/fields?templateId={template}",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`name="var_tenant"`, `type="date" name="var_due_date"`, "Due date:"},
+		},
+		{
+			name:           "no template picked",
+			method:         "GET",
+			path:           "/templates/fields?templateId=",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{`id="template-fields"`},
+			unexpectedBody: []string{"var_"},
+		},
+		{
+			name:         "can't pick someone else's template",
+			method:       "GET",
+			path:         "/templates/fields?templateId={other}",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			template, err := db.CreateCallTemplate(ctx, database.CreateCallTemplateParams{
+				UserID:    sql.NullInt64{Int64: user.ID, Valid: true},
+				Name:      "Remind about rent",
+				Objective: "Remind {{tenant}} that rent is due on {{due_date:date}}.",
+			})
+			require.NoError(t, err)
+			for _, status := range []string{"completed", "pending"} {
+				call, err := db.CreateCall(ctx, database.CreateCallParams{
+					UserID:      user.ID,
+					PhoneNumber: "+13336664444",
+					Objective:   "Remind Sam that rent is due",
+					TemplateID:  sql.NullInt64{Int64: template.ID, Valid: true},
+				})
+				require.NoError(t, err)
+				_, err = db.UpdateCallStatus(ctx, database.UpdateCallStatusParams{ID: call.ID, Status: sql.NullString{String: status, Valid: true}})
+				require.NoError(t, err)
+			}
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			othersTemplate, err := db.CreateCallTemplate(ctx, database.CreateCallTemplateParams{UserID: sql.NullInt64{Int64: other.ID, Valid: true}, Name: "Someone else's", Objective: "Call {{name}}"})
+			require.NoError(t, err)
+
+			ids := strings.NewReplacer("{template}", fmt.Sprint(template.ID), "{other}", fmt.Sprint(othersTemplate.ID))
+			req := httptest.NewRequest(tt.method, ids.Replace(tt.path), strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			NewRouter(testConfig, db, newTestCallService(db)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package router
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/contacts"
+	"goDial/internal/database"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"strconv"
+)
+
+// handleContactsPage lists the current user's contacts.
+func handleContactsPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		renderContacts(w, r, db, user.ID, http.StatusOK, contacts.Form{}, "")
+	}
+}
+
+// handleCreateContact adds a contact for the current user, sending the form
+// back with the problem if it can't be saved.
+func handleCreateContact(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		form := contactForm(r)
+		if problem := form.Validate(); problem != "" {
+			renderContacts(w, r, db, user.ID, http.StatusBadRequest, form, problem)
+			return
+		}
+
+		contact, err := contacts.Create(r.Context(), db, user.ID, form)
+		if errors.Is(err, contacts.ErrDuplicate) {
+			renderContacts(w, r, db, user.ID, http.StatusConflict, form, "You already have a contact with this number.")
+			return
+		}
+		if err != nil {
+			fmt.Printf("handleCreateContact(couldnt save contact of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not save contact", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/contacts/%d", contact.ID), http.StatusSeeOther)
+	}
+}
+
+// handleContactPage shows one of the current user's contacts with the calls
+// placed to them.
+func handleContactPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contact, ok := ownedContact(w, r, db)
+		if !ok {
+			return
+		}
+		renderContact(w, r, db, contact, http.StatusOK, contacts.FormOf(contact), "")
+	}
+}
+
+// handleUpdateContact saves changes to one of the current user's contacts.
+func handleUpdateContact(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contact, ok := ownedContact(w, r, db)
+		if !ok {
+			return
+		}
+
+		form := contactForm(r)
+		if problem := form.Validate(); problem != "" {
+			renderContact(w, r, db, contact, http.StatusBadRequest, form, problem)
+			return
+		}
+
+		_, err := contacts.Update(r.Context(), db, contact.UserID, contact.ID, form)
+		if errors.Is(err, contacts.ErrDuplicate) {
+			renderContact(w, r, db, contact, http.StatusConflict, form, "You already have a contact with this number.")
+			return
+		}
+		if err != nil {
+			fmt.Printf("handleUpdateContact(couldnt save contact %d): %v\n", contact.ID, err)
+			http.Error(w, "could not save contact", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/contacts/%d", contact.ID), http.StatusSeeOther)
+	}
+}
+
+// handleDeleteContact deletes one of the current user's contacts. Calls to
+// them are kept, just no longer linked to a contact.
+func handleDeleteContact(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contact, ok := ownedContact(w, r, db)
+		if !ok {
+			return
+		}
+
+		if _, err := db.DeleteContact(r.Context(), database.DeleteContactParams{ID: contact.ID, UserID: contact.UserID}); err != nil {
+			fmt.Printf("handleDeleteContact(couldnt delete contact %d): %v\n", contact.ID, err)
+			http.Error(w, "could not delete contact", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/contacts", http.StatusSeeOther)
+	}
+}
+
+// handleSuggestContacts lists the current user's contacts matching what was
+// typed into the call form.
+func handleSuggestContacts(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		suggestions, err := contacts.Suggest(r.Context(), db, user.ID, r.URL.Query().Get("q"))
+		if err != nil {
+			fmt.Printf("handleSuggestContacts(couldnt suggest contacts of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not suggest contacts", http.StatusInternalServerError)
+			return
+		}
+
+		pages.ContactSuggestions(suggestions).Render(r.Context(), w)
+	}
+}
+
+// handlePrefillContact returns the call form's recipient fields filled in
+// for one of the current user's contacts.
+func handlePrefillContact(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contact, ok := ownedContact(w, r, db)
+		if !ok {
+			return
+		}
+
+		pages.RecipientFields(contact.PhoneNumber, contacts.RecipientContext(contact)).Render(r.Context(), w)
+	}
+}
+
+func contactForm(r *http.Request) contacts.Form {
+	return contacts.Form{
+		Name:              r.FormValue("name"),
+		PhoneNumber:       r.FormValue("phoneNumber"),
+		Notes:             r.FormValue("notes"),
+		Timezone:          r.FormValue("timezone"),
+		PreferredLanguage: r.FormValue("preferredLanguage"),
+	}
+}
+
+// ownedContact loads the contact in the request path, writing a not found
+// response if it doesn't exist or belongs to someone other than the current
+// user.
+func ownedContact(w http.ResponseWriter, r *http.Request, db *database.DB) (database.Contact, bool) {
+	user, _ := auth.UserFromContext(r.Context())
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return database.Contact{}, false
+	}
+
+	contact, err := db.GetContact(r.Context(), database.GetContactParams{ID: id, UserID: user.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return database.Contact{}, false
+	}
+	if err != nil {
+		fmt.Printf("ownedContact(couldnt load contact %d): %v\n", id, err)
+		http.Error(w, "could not load contact", http.StatusInternalServerError)
+		return database.Contact{}, false
+	}
+	return contact, true
+}
+
+func renderContacts(w http.ResponseWriter, r *http.Request, db *database.DB, userID int64, status int, form contacts.Form, problem string) {
+	list, err := db.ListContacts(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderContacts(couldnt list contacts of user %d): %v\n", userID, err)
+		http.Error(w, "could not load contacts", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	pages.Contacts(list, form, problem).Render(r.Context(), w)
+}
+
+func renderContact(w http.ResponseWriter, r *http.Request, db *database.DB, contact database.Contact, status int, form contacts.Form, problem string) {
+	calls, err := db.ListCallsByContact(r.Context(), contact.ID)
+	if err != nil {
+		fmt.Printf("renderContact(couldnt list calls to contact %d): %v\n", contact.ID, err)
+		http.Error(w, "could not load contact", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	pages.Contact(contact, form, calls, problem).Render(r.Context(), w)
+}
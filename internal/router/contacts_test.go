@@ -0,0 +1,160 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContacts(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string // {contact} is the user's contact and {other} someone else's
+		form             url.Values
+		expectedCode     int
+		expectedLocation string
+		expectedBody     []string
+		unexpectedBody   []string
+	}{
+		{
+			name:           "lists the user's contacts",
+			method:         "GET",
+			path:           "/contacts",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{"Sam Smith", "+13336664444", `href="/contacts/{contact}"`},
+			unexpectedBody: []string{"Someone Else"},
+		},
+		{
+			name:             "adds a contact",
+			method:           "POST",
+			path:             "/contacts",
+			form:             url.Values{"name": {"Grandma Jo"}, "phoneNumber": {"(333) 666-5555"}, "notes": {"Hard of hearing"}},
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/contacts/",
+		},
+		{
+			name:         "invalid contact is sent back with the problem",
+			method:       "POST",
+			path:         "/contacts",
+			form:         url.Values{"name": {"Grandma Jo"}, "phoneNumber": {"12"}},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: []string{"Enter a valid phone number", `value="Grandma Jo"`},
+		},
+		{
+			name:         "number already in contacts",
+			method:       "POST",
+			path:         "/contacts",
+			form:         url.Values{"name": {"Sam again"}, "phoneNumber": {"333-666-4444"}},
+			expectedCode: http.StatusConflict,
+			expectedBody: []string{"You already have a contact with this number."},
+		},
+		{
+			name:         "shows a contact with their calls",
+			method:       "GET",
+			path:         "/contacts/{contact}",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"Sam Smith", "Wish Sam a happy birthday"},
+		},
+		{
+			name:         "someone else's contact",
+			method:       "GET",
+			path:         "/contacts/{other}",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:             "updates a contact",
+			method:           "POST",
+			path:             "/contacts/{contact}",
+			form:             url.Values{"name": {"Sam S."}, "phoneNumber": {"+1 333 666 4444"}},
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/contacts/{contact}",
+		},
+		{
+			name:         "can't update someone else's contact",
+			method:       "POST",
+			path:         "/contacts/{other}",
+			form:         url.Values{"name": {"Mine now"}, "phoneNumber": {"+1 333 666 4444"}},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:             "deletes a contact",
+			method:           "POST",
+			path:             "/contacts/{contact}/delete",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/contacts",
+		},
+		{
+			name:           "suggests contacts for the call form",
+			method:         "GET",
+			path:           "/contacts/suggest?q=sam",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{`id="contact-suggestions"`, "Sam Smith", `hx-get="/contacts/{contact}/prefill"`},
+			unexpectedBody: []string{"Someone Else", "<html"},
+		},
+		{
+			name:         "prefills the call form",
+			method:       "GET",
+			path:         "/contacts/{contact}/prefill",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`id="recipient-fields"`, `value="+13336664444"`, `value="Sam Smith. Likes gardening"`},
+		},
+		{
+			name:         "can't prefill someone else's contact",
+			method:       "GET",
+			path:         "/contacts/{other}/prefill",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			contact, err := db.CreateContact(ctx, database.CreateContactParams{UserID: user.ID, Name: "Sam Smith", PhoneNumber: "+13336664444", Notes: "Likes gardening"})
+			require.NoError(t, err)
+			_, err = db.CreateCall(ctx, database.CreateCallParams{
+				UserID:      user.ID,
+				PhoneNumber: "+13336664444",
+				Objective:   "Wish Sam a happy birthday",
+				ContactID:   sql.NullInt64{Int64: contact.ID, Valid: true},
+			})
+			require.NoError(t, err)
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			othersContact, err := db.CreateContact(ctx, database.CreateContactParams{UserID: other.ID, Name: "Someone Else", PhoneNumber: "+13336664444"})
+			require.NoError(t, err)
+
+			ids := strings.NewReplacer("{contact}", fmt.Sprint(contact.ID), "{other}", fmt.Sprint(othersContact.ID))
+			req := httptest.NewRequest(tt.method, ids.Replace(tt.path), strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			NewRouter(testConfig, db, newTestCallService(db)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedLocation != "" {
+				assert.True(t, strings.HasPrefix(w.Header().Get("Location"), ids.Replace(tt.expectedLocation)), w.Header().Get("Location"))
+			}
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), ids.Replace(expected))
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+		})
+	}
+}
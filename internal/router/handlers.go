@@ -2,7 +2,8 @@ package router
 
 import (
 	"fmt"
-	"goDial/internal/database"
+	"goDial/internal/auth"
+	"goDial/internal/stripe"
 	"goDial/internal/templates/pages"
 	"net/http"
 )
@@ -11,20 +12,18 @@ func handleHomePage(w http.ResponseWriter, r *http.Request) {
 	pages.Home().Render(r.Context(), w)
 }
 
-func handleStripePage(db *database.DB) http.HandlerFunc {
+// handleStripePage shows the user's minutes, and the form to buy more at
+// checkout's prices. A user who can't be found has none.
+func handleStripePage(sessions *auth.Sessions, checkout *stripe.Checkout) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		minutes, err := db.GetUserMinutes(r.Context(), "test@test.com")
+		minutesInt := 0
+		user, err := sessions.User(r.Context())
 		if err != nil {
 			fmt.Printf("handleStripePage(couldnt get minutes for user): %v\n", err)
-			minutes = 0
-		}
-
-		minutesInt := 0
-
-		if m, ok := minutes.(int64); ok {
+		} else if m, ok := user.Minutes.(int64); ok {
 			minutesInt = int(m)
 		}
 
-		pages.Stripe(minutesInt).Render(r.Context(), w)
+		pages.Stripe(minutesInt, checkout).Render(r.Context(), w)
 	}
 }
@@ -3,7 +3,9 @@ package router
 import (
 	"context"
 	"fmt"
+	"goDial/internal/auth"
 	"goDial/internal/database"
+	"goDial/internal/stripe"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
@@ -23,6 +25,12 @@ type stripePageTestConfig struct {
 	description      string
 }
 
+// newStripePage is the stripe page handler at the test configuration's prices,
+// for its development user.
+func newStripePage(db *database.DB) http.HandlerFunc {
+	return handleStripePage(auth.NewSessions(db, testConfig.DevUserEmail, testConfig.Dev()), stripe.New(testConfig))
+}
+
 // Helper function to setup test database with optional user data
 func setupHandlerTestDB(t *testing.T, config *stripePageTestConfig) *database.DB {
 	tempDir := t.TempDir()
@@ -158,7 +166,7 @@ func TestHandleStripePage(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
 			db := setupHandlerTestDB(t, tt.config)
-			handler := handleStripePage(db)
+			handler := newStripePage(db)
 
 			// Create request
 			req := httptest.NewRequest(tt.method, "/stripePage", nil)
@@ -202,7 +210,7 @@ func TestHandleStripePageErrorHandling(t *testing.T) {
 	// Close the database to simulate connection errors
 	db.Close()
 
-	handler := handleStripePage(db)
+	handler := newStripePage(db)
 	req := httptest.NewRequest("GET", "/stripePage", nil)
 	w := httptest.NewRecorder()
 
@@ -229,7 +237,7 @@ func TestHandleStripePageConcurrency(t *testing.T) {
 	}
 
 	db := setupHandlerTestDB(t, config)
-	handler := handleStripePage(db)
+	handler := newStripePage(db)
 
 	const numRequests = 10
 	results := make(chan int, numRequests)
@@ -288,7 +296,7 @@ func BenchmarkHandleStripePage(b *testing.B) {
 		b.Fatalf("Failed to create test user: %v", err)
 	}
 
-	handler := handleStripePage(db)
+	handler := newStripePage(db)
 	req := httptest.NewRequest("GET", "/stripePage", nil)
 
 	b.ResetTimer()
@@ -304,7 +312,7 @@ func BenchmarkHandleStripePage(b *testing.B) {
 // Integration test that combines database and handler testing
 func TestHandleStripePageIntegration(t *testing.T) {
 	config := &stripePageTestConfig{
-		testEmail:        "test@test.com", // Use the hardcoded email that handleStripePage actually uses
+		testEmail:        testConfig.DevUserEmail, // the user handleStripePage shows
 		expectedMinutes:  250,
 		shouldCreateUser: true,
 		userMinutesToSet: func() *int64 { v := int64(250); return &v }(),
@@ -320,7 +328,7 @@ func TestHandleStripePageIntegration(t *testing.T) {
 	assert.Equal(t, int64(250), minutes, "Database should have correct minutes")
 
 	// Test handler
-	handler := handleStripePage(db)
+	handler := newStripePage(db)
 	req := httptest.NewRequest("GET", "/stripePage", nil)
 	w := httptest.NewRecorder()
 
@@ -334,10 +342,10 @@ func TestHandleStripePageIntegration(t *testing.T) {
 // Test for future modifications - demonstrates how to test different email sources
 func TestHandleStripePageWithDifferentEmailSources(t *testing.T) {
 	// This test demonstrates how the handler could be modified to get email from different sources
-	// Currently it's hardcoded to "test@test.com", but this shows how to test different scenarios
+	// Currently it's the configured development user, but this shows how to test different scenarios
 
 	config := &stripePageTestConfig{
-		testEmail:        "test@test.com", // Current hardcoded email
+		testEmail:        testConfig.DevUserEmail, // the configured development user
 		expectedMinutes:  75,
 		shouldCreateUser: true,
 		userMinutesToSet: func() *int64 { v := int64(75); return &v }(),
@@ -345,7 +353,7 @@ func TestHandleStripePageWithDifferentEmailSources(t *testing.T) {
 	}
 
 	db := setupHandlerTestDB(t, config)
-	handler := handleStripePage(db)
+	handler := newStripePage(db)
 
 	// Test with different request contexts that could provide email in the future
 	testCases := []struct {
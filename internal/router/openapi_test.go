@@ -0,0 +1,325 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goDial/internal/api"
+	"goDial/internal/apikeys"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPI sends requests for every operation in the OpenAPI document
+// through the router, and checks each is answered with a status the document
+// lists for it and a body matching its schema. Creating a call is only sent
+// requests that fail validation here, as the rest needs moderation; the calls
+// package's tests cover it, and the api package's tests check the Call schema
+// against the type it returns.
+func TestOpenAPI(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	spec := loadOpenAPI(t)
+	// in development, so requests without a key act as the session's user
+	cfg := testConfig
+	cfg.Environment = config.Development
+	mux := NewRouter(cfg, db, newTestCallService(db)).(*http.ServeMux)
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET minutes = 42 WHERE id = ?", user.ID)
+	require.NoError(t, err)
+	_, err = db.CreateContact(ctx, database.CreateContactParams{UserID: user.ID, Name: "Sam Smith", PhoneNumber: "+13336664444", Notes: "Prefers mornings"})
+	require.NoError(t, err)
+	completed := completedCall(t, db, user)
+	pending, err := db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "Book a table", MaxAttempts: 1})
+	require.NoError(t, err)
+
+	requests := []struct {
+		method        string
+		path          string
+		body          string
+		authorization string
+		expectedCode  int
+	}{
+		{method: "POST", path: "/api/v1/calls", body: `{}`, expectedCode: http.StatusBadRequest},
+		{method: "POST", path: "/api/v1/calls", body: `{"phone":"+13336664444"}`, expectedCode: http.StatusBadRequest},
+		{method: "GET", path: "/api/v1/calls", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls?limit=1", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls?status=done", expectedCode: http.StatusBadRequest},
+		{method: "GET", path: "/api/v1/calls", authorization: "Bearer gdk_wrong", expectedCode: http.StatusUnauthorized},
+		{method: "GET", path: "/api/v1/calls/{completed}", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls/999", expectedCode: http.StatusNotFound},
+		{method: "GET", path: "/api/v1/calls/{completed}/transcript", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls/{completed}/transcript?format=json", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls/{completed}/transcript?format=vtt", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/calls/{completed}/transcript?format=pdf", expectedCode: http.StatusBadRequest},
+		{method: "POST", path: "/api/v1/calls/{pending}/cancel", expectedCode: http.StatusOK},
+		{method: "POST", path: "/api/v1/calls/{completed}/cancel", expectedCode: http.StatusConflict},
+		{method: "GET", path: "/api/v1/contacts", expectedCode: http.StatusOK},
+		{method: "GET", path: "/api/v1/balance", expectedCode: http.StatusOK},
+	}
+
+	ids := strings.NewReplacer("{completed}", fmt.Sprint(completed.ID), "{pending}", fmt.Sprint(pending.ID))
+	sent := map[string]bool{}
+	for _, tt := range requests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, ids.Replace(tt.path), strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			op, name := spec.operation(t, mux, req)
+			sent[name] = true
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedCode, w.Code, w.Body.String())
+			spec.checkResponse(t, op, w)
+		})
+	}
+
+	for name := range spec.operations() {
+		assert.True(t, sent[name], "no request was sent for %s", name)
+	}
+}
+
+// TestOpenAPI_Scopes checks each operation is only let through for keys with
+// the scope the document says it needs.
+func TestOpenAPI_Scopes(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	spec := loadOpenAPI(t)
+	mux := NewRouter(testConfig, db, newTestCallService(db))
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "owner@test.com", Name: "owner"})
+	require.NoError(t, err)
+	keys := map[string]string{}
+	for _, scope := range apikeys.Scopes {
+		key, _, err := apikeys.Create(ctx, db, user.ID, apikeys.Form{Name: scope, Scopes: []string{scope}})
+		require.NoError(t, err)
+		keys[scope] = key
+	}
+
+	for name, op := range spec.operations() {
+		scope, _ := op["x-required-scope"].(string)
+		require.Contains(t, apikeys.Scopes, scope, "%s needs a scope", name)
+
+		for _, keyScope := range apikeys.Scopes {
+			t.Run(fmt.Sprintf("%s with %s", name, keyScope), func(t *testing.T) {
+				method, path, _ := strings.Cut(name, " ")
+				req := httptest.NewRequest(method, api.Prefix+strings.ReplaceAll(path, "{id}", "999"), strings.NewReader(`{}`))
+				req.Header.Set("Authorization", "Bearer "+keys[keyScope])
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, req)
+
+				if keyScope == scope {
+					assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, w.Code, w.Body.String())
+				} else {
+					assert.Equal(t, http.StatusForbidden, w.Code)
+					assert.Contains(t, w.Body.String(), api.CodeInsufficientScope)
+				}
+			})
+		}
+	}
+}
+
+func TestOpenAPIRoute(t *testing.T) {
+	db := setupTestDB(t)
+	w := httptest.NewRecorder()
+	NewRouter(testConfig, db, newTestCallService(db)).ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(api.OpenAPI), w.Body.String())
+}
+
+// completedCall is a finished call of user's with an attempt, a fact and a
+// transcript.
+func completedCall(t *testing.T, db *database.DB, user database.User) database.Call {
+	t.Helper()
+	ctx := context.Background()
+	call, err := db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "Ask about opening hours", MaxAttempts: 1})
+	require.NoError(t, err)
+	_, err = db.CreateCallAttempt(ctx, database.CreateCallAttemptParams{CallID: call.ID, AttemptNumber: 1, ScheduledFor: time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	_, err = db.RecordCallFact(ctx, database.RecordCallFactParams{CallID: call.ID, Key: "opening_hours", Value: "9 to 5"})
+	require.NoError(t, err)
+	for _, log := range []database.CreateCallLogParams{
+		{CallID: call.ID, MessageType: "ai_response", Content: "Hi, when are you open?"},
+		{CallID: call.ID, MessageType: "user_speech", Content: "Nine to five."},
+	} {
+		_, err = db.CreateCallLog(ctx, log)
+		require.NoError(t, err)
+	}
+	_, err = db.ExecContext(ctx, "UPDATE call_attempts SET status = 'completed', duration_seconds = 42, billed_minutes = 1 WHERE call_id = ?", call.ID)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE calls SET status = 'completed', outcome = 'conversation', completed_at = CURRENT_TIMESTAMP WHERE id = ?", call.ID)
+	require.NoError(t, err)
+
+	call, err = db.GetCall(ctx, call.ID)
+	require.NoError(t, err)
+	return call
+}
+
+// openAPI is the OpenAPI document, read loosely enough to follow its $refs.
+type openAPI map[string]any
+
+func loadOpenAPI(t *testing.T) openAPI {
+	t.Helper()
+	var spec openAPI
+	require.NoError(t, json.Unmarshal(api.OpenAPI, &spec))
+	return spec
+}
+
+// operations are the document's operations by method and path, like
+// "GET /calls/{id}".
+func (s openAPI) operations() map[string]map[string]any {
+	operations := map[string]map[string]any{}
+	for path, item := range s["paths"].(map[string]any) {
+		for method, op := range item.(map[string]any) {
+			operations[strings.ToUpper(method)+" "+path] = op.(map[string]any)
+		}
+	}
+	return operations
+}
+
+// operation is the documented operation the router sends req to.
+func (s openAPI) operation(t *testing.T, mux *http.ServeMux, req *http.Request) (map[string]any, string) {
+	t.Helper()
+	_, pattern := mux.Handler(req)
+	method, path, _ := strings.Cut(pattern, " ")
+	name := method + " " + strings.TrimPrefix(path, api.Prefix)
+	op, ok := s.operations()[name]
+	require.True(t, ok, "the router sends %s %s to %q, which isn't documented", req.Method, req.URL.Path, pattern)
+	return op, name
+}
+
+// resolve follows node's $ref, if it has one.
+func (s openAPI) resolve(node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	var at any = map[string]any(s)
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		at = at.(map[string]any)[part]
+	}
+	return s.resolve(at.(map[string]any))
+}
+
+// checkResponse checks w is one of op's documented responses.
+func (s openAPI) checkResponse(t *testing.T, op map[string]any, w *httptest.ResponseRecorder) {
+	t.Helper()
+	response, ok := op["responses"].(map[string]any)[fmt.Sprint(w.Code)].(map[string]any)
+	require.True(t, ok, "status %d isn't documented", w.Code)
+	content := s.resolve(response)["content"].(map[string]any)
+	media, ok := content[w.Header().Get("Content-Type")].(map[string]any)
+	require.True(t, ok, "content type %q isn't documented", w.Header().Get("Content-Type"))
+
+	schema := media["schema"].(map[string]any)
+	var body any = w.Body.String()
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	}
+	for _, problem := range s.validate(schema, body, "body") {
+		t.Error(problem)
+	}
+}
+
+// validate checks value against schema, and returns what doesn't match.
+func (s openAPI) validate(schema map[string]any, value any, at string) []string {
+	schema = s.resolve(schema)
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v isn't one of %v", at, value, enum)}
+	}
+
+	var problems []string
+	switch schema["type"] {
+	case "object", nil:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't an object", at, value)}
+		}
+		properties, required := s.properties(schema)
+		for _, name := range required {
+			if _, ok := object[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %s", at, name))
+			}
+		}
+		for name, field := range object {
+			if property, ok := properties[name]; ok {
+				problems = append(problems, s.validate(property, field, at+"."+name)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				problems = append(problems, s.validate(additional, field, at+"."+name)...)
+			} else {
+				problems = append(problems, fmt.Sprintf("%s: %s isn't documented", at, name))
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't an array", at, value)}
+		}
+		for i, item := range array {
+			problems = append(problems, s.validate(schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v isn't a string", at, value)}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q isn't a date-time", at, str))
+			}
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			problems = append(problems, fmt.Sprintf("%s: %v isn't an integer", at, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %v isn't a number", at, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %v isn't a boolean", at, value))
+		}
+	}
+	return problems
+}
+
+// properties are an object schema's properties and required ones, merged
+// with those of its allOf parts.
+func (s openAPI) properties(schema map[string]any) (map[string]map[string]any, []string) {
+	properties := map[string]map[string]any{}
+	var required []string
+	if parts, ok := schema["allOf"].([]any); ok {
+		for _, part := range parts {
+			p, r := s.properties(s.resolve(part.(map[string]any)))
+			for name, property := range p {
+				properties[name] = property
+			}
+			required = append(required, r...)
+		}
+	}
+	if p, ok := schema["properties"].(map[string]any); ok {
+		for name, property := range p {
+			properties[name] = property.(map[string]any)
+		}
+	}
+	names, _ := schema["required"].([]any)
+	for _, name := range names {
+		required = append(required, name.(string))
+	}
+	return properties, required
+}
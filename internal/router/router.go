@@ -2,15 +2,20 @@ package router
 
 import (
 	"fmt"
+	"goDial/internal/api"
+	"goDial/internal/apikeys"
+	"goDial/internal/auth"
 	"goDial/internal/calls"
+	"goDial/internal/config"
 	"goDial/internal/database"
+	"goDial/internal/stripe"
 	"net/http"
-	"os"
 	"time"
 )
 
-func NewRouter(db *database.DB) http.Handler {
+func NewRouter(cfg config.Config, db *database.DB, callService *calls.Service) http.Handler {
 	mux := http.NewServeMux()
+	sessions := auth.NewSessions(db, cfg.DevUserEmail, cfg.Dev())
 
 	// Health check endpoint
 	mux.HandleFunc("/health", handleHealthCheck)
@@ -20,14 +25,87 @@ func NewRouter(db *database.DB) http.Handler {
 	mux.Handle("/static/", http.StripPrefix("/static/", fs))
 
 	// Live reload endpoint for development
-	mux.HandleFunc("/live-reload", handleLiveReload)
+	mux.HandleFunc("/live-reload", handleLiveReload(cfg.Dev()))
 
 	// Routes
 	mux.HandleFunc("/", handleHomePage)
-	mux.HandleFunc("/stripePage", handleStripePage(db))
+	mux.HandleFunc("/stripePage", handleStripePage(sessions, stripe.New(cfg)))
+	mux.HandleFunc("GET /settings", sessions.RequireUser(handleSettingsPage(db)))
+	mux.HandleFunc("POST /settings", sessions.RequireUser(handleUpdateSettings(db)))
+	mux.HandleFunc("POST /settings/phone", sessions.RequireUser(callService.HandleSendPhoneCode))
+	mux.HandleFunc("POST /settings/phone/verify", sessions.RequireUser(callService.HandleVerifyPhone))
+	mux.HandleFunc("GET /search", sessions.RequireUser(handleSearchPage(db)))
+
+	// webhooks notifying the user's own systems of their calls
+	mux.HandleFunc("GET /settings/webhooks", sessions.RequireUser(handleWebhooksPage(db)))
+	mux.HandleFunc("POST /settings/webhooks", sessions.RequireUser(handleCreateWebhook(db, cfg.Dev())))
+	mux.HandleFunc("POST /settings/webhooks/{id}/delete", sessions.RequireUser(handleDeleteWebhook(db)))
+	mux.HandleFunc("POST /settings/webhooks/deliveries/{id}/replay", sessions.RequireUser(handleReplayWebhookDelivery(callService.Webhooks())))
+
+	// keys for the user's own tools to use the API with
+	mux.HandleFunc("GET /settings/api-keys", sessions.RequireUser(handleAPIKeysPage(db)))
+	mux.HandleFunc("POST /settings/api-keys", sessions.RequireUser(handleCreateAPIKey(db)))
+	mux.HandleFunc("POST /settings/api-keys/{id}/revoke", sessions.RequireUser(handleRevokeAPIKey(db)))
+
+	// contacts, and the call form's search of them
+	mux.HandleFunc("GET /contacts", sessions.RequireUser(handleContactsPage(db)))
+	mux.HandleFunc("POST /contacts", sessions.RequireUser(handleCreateContact(db)))
+	mux.HandleFunc("GET /contacts/suggest", sessions.RequireUser(handleSuggestContacts(db)))
+	mux.HandleFunc("GET /contacts/{id}", sessions.RequireUser(handleContactPage(db)))
+	mux.HandleFunc("POST /contacts/{id}", sessions.RequireUser(handleUpdateContact(db)))
+	mux.HandleFunc("POST /contacts/{id}/delete", sessions.RequireUser(handleDeleteContact(db)))
+	mux.HandleFunc("GET /contacts/{id}/prefill", sessions.RequireUser(handlePrefillContact(db)))
+
+	// call templates, and the call form's picker of them
+	mux.HandleFunc("GET /templates", sessions.RequireUser(handleCallTemplatesPage(db)))
+	mux.HandleFunc("POST /templates", sessions.RequireUser(handleCreateCallTemplate(db)))
+	mux.HandleFunc("POST /templates/{id}/delete", sessions.RequireUser(handleDeleteCallTemplate(db)))
+	mux.HandleFunc("GET /templates/picker", sessions.RequireUser(handleCallTemplatePicker(db)))
+	mux.HandleFunc("GET /templates/fields", sessions.RequireUser(handleCallTemplateFields(db)))
+
+	// campaigns, calling everyone on an uploaded CSV
+	mux.HandleFunc("GET /campaigns", sessions.RequireUser(callService.HandleCampaigns))
+	mux.HandleFunc("POST /campaigns", sessions.RequireUser(callService.HandleCreateCampaign))
+	mux.HandleFunc("GET /campaigns/{id}", sessions.RequireUser(callService.HandleCampaign))
+	mux.HandleFunc("POST /campaigns/{id}/start", sessions.RequireUser(callService.HandleStartCampaign))
+	mux.HandleFunc("POST /campaigns/{id}/cancel", sessions.RequireUser(callService.HandleCancelCampaign))
+	mux.HandleFunc("GET /campaigns/{id}/export", sessions.RequireUser(callService.HandleExportCampaign))
 
 	// call related handlers
-	mux.HandleFunc("/handleCallProcedure", calls.HandleCallProcedure)
+	mux.HandleFunc("/handleCallProcedure", sessions.RequireUser(callService.HandleCallProcedure))
+	mux.HandleFunc("GET /calls/{id}", sessions.RequireUser(callService.HandleCallStatus))
+	mux.HandleFunc("GET /calls/{id}/confirm", sessions.RequireUser(callService.HandleConfirmCall))
+	mux.HandleFunc("POST /calls/{id}/approve", sessions.RequireUser(callService.HandleApproveCall))
+	mux.HandleFunc("POST /calls/{id}/takeover", sessions.RequireUser(callService.HandleTakeover))
+	mux.HandleFunc("GET /calls/{id}/recordings/{recordingID}", sessions.RequireUser(callService.HandleRecording))
+
+	// the JSON API, for other systems to place and follow calls
+	mux.HandleFunc("GET /api/openapi.json", api.HandleOpenAPI)
+	mux.HandleFunc("POST /api/v1/calls", sessions.RequireAPI(apikeys.ScopeCallsWrite, callService.HandleAPICreateCall))
+	mux.HandleFunc("GET /api/v1/calls", sessions.RequireAPI(apikeys.ScopeCallsRead, callService.HandleAPICalls))
+	mux.HandleFunc("GET /api/v1/calls/{id}", sessions.RequireAPI(apikeys.ScopeCallsRead, callService.HandleAPICall))
+	mux.HandleFunc("GET /api/v1/calls/{id}/transcript", sessions.RequireAPI(apikeys.ScopeCallsRead, callService.HandleAPICallTranscript))
+	mux.HandleFunc("POST /api/v1/calls/{id}/cancel", sessions.RequireAPI(apikeys.ScopeCallsWrite, callService.HandleAPICancelCall))
+	mux.HandleFunc("GET /api/v1/contacts", sessions.RequireAPI(apikeys.ScopeCallsRead, handleAPIContacts(db)))
+	mux.HandleFunc("GET /api/v1/balance", sessions.RequireAPI(apikeys.ScopeBillingRead, handleAPIBalance))
+	mux.HandleFunc("/api/v1/", api.NotFound)
+
+	// admin
+	mux.HandleFunc("GET /admin/dnc", requireAdmin(sessions, handleDNCAdminPage(db)))
+	mux.HandleFunc("POST /admin/dnc/import", requireAdmin(sessions, handleDNCImport(db)))
+
+	// telephony provider webhooks, one set per dial attempt
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/answer", callService.HandleAnswerWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/turn", callService.HandleTurnWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/status", callService.HandleStatusWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/takeover", callService.HandleTakeoverWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/whisper", callService.HandleWhisperWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/handoff", callService.HandleHandoffWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/attempts/{id}/recording", callService.HandleRecordingWebhook)
+
+	// calls that read users the code verifying their phone number
+	mux.HandleFunc("POST /webhooks/telephony/verifications/{id}/answer", callService.HandleVerificationAnswerWebhook)
+	mux.HandleFunc("POST /webhooks/telephony/verifications/{id}/status", callService.HandleVerificationStatusWebhook)
 
 	return mux
 }
@@ -39,42 +117,53 @@ func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, `{"status":"ok","timestamp":"%s"}`, time.Now().Format(time.RFC3339))
 }
 
-// handleLiveReload provides server-sent events for live reload functionality
-func handleLiveReload(w http.ResponseWriter, r *http.Request) {
-	// Only enable in development (when running through Air)
-	if os.Getenv("AIR_ENABLED") == "" && os.Getenv("GO_ENV") != "development" {
-		http.NotFound(w, r)
-		return
-	}
+// liveReloadHeartbeat is how often the live reload stream says it is still
+// there.
+var liveReloadHeartbeat = 30 * time.Second
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+// handleLiveReload provides server-sent events for live reload functionality,
+// only in development (dev).
+func handleLiveReload(dev bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !dev {
+			http.NotFound(w, r)
+			return
+		}
 
-	// Send initial connection message
-	fmt.Fprintf(w, "data: connected\n\n")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	// Flush the response
-	if flusher, ok := w.(http.Flusher); ok {
-		flusher.Flush()
-	}
+		// the stream outlives the server's write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			fmt.Printf("handleLiveReload(couldnt clear write deadline): %v\n", err)
+		}
 
-	// Keep the connection alive and send periodic heartbeats
-	ctx := r.Context()
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+		// Send initial connection message
+		fmt.Fprintf(w, "data: connected\n\n")
 
-	for {
-		select {
-		case <-ctx.Done():
-			// Client disconnected
-			return
-		case <-ticker.C:
-			// Send heartbeat to keep connection alive
-			fmt.Fprintf(w, "data: heartbeat\n\n")
-			if flusher, ok := w.(http.Flusher); ok {
-				flusher.Flush()
+		// Flush the response
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		// Keep the connection alive and send periodic heartbeats
+		ctx := r.Context()
+		ticker := time.NewTicker(liveReloadHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// Client disconnected
+				return
+			case <-ticker.C:
+				// Send heartbeat to keep connection alive
+				fmt.Fprintf(w, "data: heartbeat\n\n")
+				if flusher, ok := w.(http.Flusher); ok {
+					flusher.Flush()
+				}
 			}
 		}
 	}
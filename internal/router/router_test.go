@@ -1,12 +1,17 @@
 package router
 
 import (
+	"bufio"
+	"goDial/internal/calls"
+	"goDial/internal/config"
 	"goDial/internal/database"
+	"goDial/internal/jobs"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,15 +32,25 @@ func setupTestDB(t *testing.T) *database.DB {
 	return db
 }
 
+// testConfig is the configuration the routing tests run with: the defaults,
+// acting as the development user test@test.com.
+var testConfig = config.Default()
+
+// newTestCallService builds a call service without telephony and without
+// starting its job queue, so routing tests never place calls.
+func newTestCallService(db *database.DB) *calls.Service {
+	return calls.NewService(db, jobs.New(db, jobs.Options{}), nil, calls.NewAnthropicAgent(testConfig.AI), testConfig)
+}
+
 func TestNewRouter(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 	assert.NotNil(t, router, "Router should not be nil")
 }
 
 func TestHomeRoute(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	tests := []struct {
 		name           string
@@ -101,7 +116,7 @@ func TestHomeRoute(t *testing.T) {
 
 func TestStripePageRoute(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	tests := []struct {
 		name           string
@@ -143,9 +158,31 @@ func TestStripePageRoute(t *testing.T) {
 	}
 }
 
+func TestCallPagesNeedAUser(t *testing.T) {
+	db := setupTestDB(t)
+	router := NewRouter(testConfig, db, newTestCallService(db))
+
+	for _, route := range []string{
+		"POST /handleCallProcedure",
+		"GET /calls/1",
+		"GET /calls/1/confirm",
+		"POST /calls/1/approve",
+		"POST /calls/1/takeover",
+		"POST /settings/phone",
+		"POST /settings/phone/verify",
+	} {
+		t.Run(route, func(t *testing.T) {
+			method, path, _ := strings.Cut(route, " ")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
 func TestHealthCheckRoute(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	req := httptest.NewRequest("GET", "/health", nil)
 	w := httptest.NewRecorder()
@@ -162,7 +199,7 @@ func TestHealthCheckRoute(t *testing.T) {
 
 func TestStaticFileServing(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	tests := []struct {
 		name           string
@@ -201,7 +238,7 @@ func TestStaticFileServing(t *testing.T) {
 
 func TestRouterHTTPMethods(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	methods := []string{"GET", "POST", "PUT", "DELETE", "PATCH", "HEAD", "OPTIONS"}
 
@@ -220,7 +257,7 @@ func TestRouterHTTPMethods(t *testing.T) {
 
 func TestRouterConcurrency(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	// Test concurrent requests to ensure router is thread-safe
 	const numRequests = 100
@@ -297,7 +334,7 @@ func TestHandleSimpleHomeFunction(t *testing.T) {
 
 func TestRouterHeaders(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	req := httptest.NewRequest("GET", "/", nil)
 	req.Header.Set("User-Agent", "goDial-Test/1.0")
@@ -316,7 +353,7 @@ func TestRouterHeaders(t *testing.T) {
 
 func TestRouterErrorHandling(t *testing.T) {
 	db := setupTestDB(t)
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 
 	// Test various invalid paths
 	invalidPaths := []string{
@@ -356,7 +393,7 @@ func BenchmarkHomeRoute(b *testing.B) {
 	}
 	defer db.Close()
 
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 	req := httptest.NewRequest("GET", "/", nil)
 
 	b.ResetTimer()
@@ -377,7 +414,7 @@ func BenchmarkStripePageRoute(b *testing.B) {
 	}
 	defer db.Close()
 
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 	req := httptest.NewRequest("GET", "/stripePage", nil)
 
 	b.ResetTimer()
@@ -398,7 +435,7 @@ func BenchmarkStaticRoute(b *testing.B) {
 	}
 	defer db.Close()
 
-	router := NewRouter(db)
+	router := NewRouter(testConfig, db, newTestCallService(db))
 	req := httptest.NewRequest("GET", "/static/test.css", nil)
 
 	b.ResetTimer()
@@ -407,3 +444,36 @@ func BenchmarkStaticRoute(b *testing.B) {
 		router.ServeHTTP(w, req)
 	}
 }
+
+func TestLiveReloadOnlyInDevelopment(t *testing.T) {
+	db := setupTestDB(t)
+	w := httptest.NewRecorder()
+	NewRouter(testConfig, db, newTestCallService(db)).ServeHTTP(w, httptest.NewRequest("GET", "/live-reload", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLiveReloadOutlivesWriteTimeout(t *testing.T) {
+	heartbeat := liveReloadHeartbeat
+	liveReloadHeartbeat = 100 * time.Millisecond
+	t.Cleanup(func() { liveReloadHeartbeat = heartbeat })
+
+	srv := httptest.NewUnstartedServer(handleLiveReload(true))
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// heartbeats keep coming well after the write timeout
+	lines := bufio.NewScanner(resp.Body)
+	var events []string
+	for len(events) < 4 && lines.Scan() {
+		if lines.Text() != "" {
+			events = append(events, lines.Text())
+		}
+	}
+	require.NoError(t, lines.Err())
+	assert.Equal(t, []string{"data: connected", "data: heartbeat", "data: heartbeat", "data: heartbeat"}, events)
+}
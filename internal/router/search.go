@@ -0,0 +1,29 @@
+package router
+
+import (
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/search"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"strings"
+)
+
+// handleSearchPage finds the current user's calls by their objective,
+// recipient context or transcript.
+func handleSearchPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+		results, err := search.Calls(r.Context(), db, user.ID, query, search.DefaultLimit)
+		if err != nil {
+			fmt.Printf("handleSearchPage(couldnt search calls of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not search calls", http.StatusInternalServerError)
+			return
+		}
+
+		pages.Search(query, results).Render(r.Context(), w)
+	}
+}
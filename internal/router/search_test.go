@@ -0,0 +1,89 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name             string
+		noUser           bool
+		query            string
+		expectedCode     int
+		expectedBody     []string
+		unexpectedBody   []string
+		expectedCallLink bool
+	}{
+		{
+			name:             "finds the user's call by its transcript",
+			query:            "plumber+quote",
+			expectedCode:     http.StatusOK,
+			expectedBody:     []string{"Fix the kitchen sink", "<mark"},
+			unexpectedBody:   []string{"Someone else's plumber"},
+			expectedCallLink: true,
+		},
+		{
+			name:           "no matches",
+			query:          "birthday",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{"No calls matched your search."},
+			unexpectedBody: []string{"Fix the kitchen sink"},
+		},
+		{
+			name:           "no query shows the search box",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{`name="q"`},
+			unexpectedBody: []string{"No calls matched your search."},
+		},
+		{
+			name:         "needs a signed in user",
+			noUser:       true,
+			query:        "plumber",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			var call database.Call
+			if !tt.noUser {
+				user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+				require.NoError(t, err)
+				call, err = db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "Fix the kitchen sink"})
+				require.NoError(t, err)
+				_, err = db.CreateCallLog(ctx, database.CreateCallLogParams{CallID: call.ID, MessageType: "user_speech", Content: "The plumber gave me a quote of 200 dollars."})
+				require.NoError(t, err)
+			}
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			_, err = db.CreateCall(ctx, database.CreateCallParams{UserID: other.ID, PhoneNumber: "+13336665555", Objective: "Someone else's plumber quote"})
+			require.NoError(t, err)
+
+			router := NewRouter(testConfig, db, newTestCallService(db))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/search?q="+tt.query, nil))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+			if tt.expectedCallLink {
+				assert.Contains(t, w.Body.String(), fmt.Sprintf(`href="/calls/%d"`, call.ID))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/templates/pages"
+	"net/http"
+	"time"
+)
+
+// handleSettingsPage shows the current user's call preferences.
+func handleSettingsPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+
+		// a code requested a moment ago can still be entered after a reload
+		var pendingNumber string
+		verification, err := db.GetLatestPhoneVerification(r.Context(), user.ID)
+		if err == nil && time.Now().Before(verification.ExpiresAt) {
+			pendingNumber = verification.PhoneNumber
+		}
+
+		pages.Settings(user, pendingNumber, pages.SettingsNotice{}).Render(r.Context(), w)
+	}
+}
+
+// handleUpdateSettings saves the current user's call preferences.
+func handleUpdateSettings(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+
+		user, err := db.UpdateUserSettings(r.Context(), database.UpdateUserSettingsParams{
+			VoicemailCountsAsSuccess: r.FormValue("voicemailCountsAsSuccess") == "on",
+			ID:                       user.ID,
+		})
+		if err != nil {
+			fmt.Printf("handleUpdateSettings(couldnt save settings of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not save settings", http.StatusInternalServerError)
+			return
+		}
+
+		pages.Settings(user, "", pages.SettingsNotice{Text: "Settings saved."}).Render(r.Context(), w)
+	}
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	"context"
+	"goDial/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings(t *testing.T) {
+	tests := []struct {
+		name                     string
+		request                  func() *http.Request
+		expectedBody             string
+		voicemailCountsAsSuccess bool
+	}{
+		{
+			name:                     "shows the settings",
+			request:                  func() *http.Request { return httptest.NewRequest("GET", "/settings", nil) },
+			expectedBody:             "Settings",
+			voicemailCountsAsSuccess: true,
+		},
+		{
+			name: "unchecking voicemail keeps retrying for a person",
+			request: func() *http.Request {
+				req := httptest.NewRequest("POST", "/settings", strings.NewReader(url.Values{}.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			expectedBody:             "Settings saved.",
+			voicemailCountsAsSuccess: false,
+		},
+		{
+			name: "checking voicemail counts it as success",
+			request: func() *http.Request {
+				form := url.Values{"voicemailCountsAsSuccess": {"on"}}
+				req := httptest.NewRequest("POST", "/settings", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			expectedBody:             "Settings saved.",
+			voicemailCountsAsSuccess: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+			_, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			router := NewRouter(testConfig, db, newTestCallService(db))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.request())
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			user, err := db.GetUserByEmail(ctx, "test@test.com")
+			require.NoError(t, err)
+			assert.Equal(t, tt.voicemailCountsAsSuccess, user.VoicemailCountsAsSuccess)
+		})
+	}
+}
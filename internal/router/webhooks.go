@@ -0,0 +1,121 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"goDial/internal/auth"
+	"goDial/internal/database"
+	"goDial/internal/templates/pages"
+	"goDial/internal/webhooks"
+	"net/http"
+	"strconv"
+)
+
+// handleWebhooksPage lists the current user's webhook endpoints and their
+// recent deliveries.
+func handleWebhooksPage(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		renderWebhooks(w, r, db, user.ID, http.StatusOK, webhooks.Form{}, "")
+	}
+}
+
+// handleCreateWebhook adds a webhook endpoint for the current user, sending
+// the form back with the problem if it can't be saved. In development,
+// endpoints may be on this machine.
+func handleCreateWebhook(db *database.DB, dev bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "could not read form", http.StatusBadRequest)
+			return
+		}
+		form := webhooks.Form{URL: r.PostFormValue("url"), Events: r.PostForm["events"]}
+		if problem := form.Validate(r.Context(), dev); problem != "" {
+			renderWebhooks(w, r, db, user.ID, http.StatusBadRequest, form, problem)
+			return
+		}
+
+		if _, err := webhooks.Create(r.Context(), db, user.ID, form); err != nil {
+			fmt.Printf("handleCreateWebhook(couldnt save endpoint of user %d): %v\n", user.ID, err)
+			http.Error(w, "could not save webhook", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/settings/webhooks", http.StatusSeeOther)
+	}
+}
+
+// handleDeleteWebhook deletes one of the current user's webhook endpoints,
+// along with its deliveries.
+func handleDeleteWebhook(db *database.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		deleted, err := db.DeleteWebhookEndpoint(r.Context(), database.DeleteWebhookEndpointParams{ID: id, UserID: user.ID})
+		if err != nil {
+			fmt.Printf("handleDeleteWebhook(couldnt delete endpoint %d): %v\n", id, err)
+			http.Error(w, "could not delete webhook", http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.Redirect(w, r, "/settings/webhooks", http.StatusSeeOther)
+	}
+}
+
+// handleReplayWebhookDelivery sends one of the current user's deliveries
+// again.
+func handleReplayWebhookDelivery(notifier *webhooks.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _ := auth.UserFromContext(r.Context())
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		err = notifier.Replay(r.Context(), user.ID, id)
+		if errors.Is(err, webhooks.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if errors.Is(err, webhooks.ErrPending) {
+			http.Error(w, "this delivery is still being sent; replay it once it has been delivered or has failed", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			fmt.Printf("handleReplayWebhookDelivery(couldnt replay delivery %d): %v\n", id, err)
+			http.Error(w, "could not replay delivery", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/settings/webhooks", http.StatusSeeOther)
+	}
+}
+
+func renderWebhooks(w http.ResponseWriter, r *http.Request, db *database.DB, userID int64, status int, form webhooks.Form, problem string) {
+	endpoints, err := db.ListWebhookEndpoints(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderWebhooks(couldnt list endpoints of user %d): %v\n", userID, err)
+		http.Error(w, "could not load webhooks", http.StatusInternalServerError)
+		return
+	}
+	deliveries, err := db.ListWebhookDeliveries(r.Context(), userID)
+	if err != nil {
+		fmt.Printf("renderWebhooks(couldnt list deliveries of user %d): %v\n", userID, err)
+		http.Error(w, "could not load webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	pages.Webhooks(endpoints, deliveries, form, problem).Render(r.Context(), w)
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"goDial/internal/webhooks"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string // {endpoint} and {delivery} are the user's, {other} and {otherDelivery} someone else's
+		form             url.Values
+		expectedCode     int
+		expectedLocation string
+		expectedBody     []string
+		unexpectedBody   []string
+	}{
+		{
+			name:           "lists the user's endpoints, secrets and deliveries",
+			method:         "GET",
+			path:           "/settings/webhooks",
+			expectedCode:   http.StatusOK,
+			expectedBody:   []string{"https://crm.example.com/hooks", "whsec_", "call.completed", "Replay", "endpoint responded 500 Internal Server Error"},
+			unexpectedBody: []string{"https://other.example.com"},
+		},
+		{
+			name:             "adds an endpoint",
+			method:           "POST",
+			path:             "/settings/webhooks",
+			form:             url.Values{"url": {"https://crm.example.com/other"}, "events": {"call.created", "balance.low"}},
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/settings/webhooks",
+		},
+		{
+			name:         "invalid endpoint is sent back with the problem",
+			method:       "POST",
+			path:         "/settings/webhooks",
+			form:         url.Values{"url": {"http://crm.example.com/hooks"}, "events": {"call.created"}},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: []string{"Use an https:// URL.", `value="http://crm.example.com/hooks"`, `value="call.created" class="checkbox checkbox-primary" checked`},
+		},
+		{
+			name:             "deletes the user's endpoint",
+			method:           "POST",
+			path:             "/settings/webhooks/{endpoint}/delete",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/settings/webhooks",
+		},
+		{
+			name:         "can't delete someone else's endpoint",
+			method:       "POST",
+			path:         "/settings/webhooks/{other}/delete",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:             "replays the user's delivery",
+			method:           "POST",
+			path:             "/settings/webhooks/deliveries/{delivery}/replay",
+			expectedCode:     http.StatusSeeOther,
+			expectedLocation: "/settings/webhooks",
+		},
+		{
+			name:         "can't replay someone else's delivery",
+			method:       "POST",
+			path:         "/settings/webhooks/deliveries/{otherDelivery}/replay",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			ctx := context.Background()
+
+			user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "test@test.com", Name: "test"})
+			require.NoError(t, err)
+			endpoint, err := webhooks.Create(ctx, db, user.ID, webhooks.Form{URL: "https://crm.example.com/hooks", Events: []string{webhooks.EventCallCompleted}})
+			require.NoError(t, err)
+			delivery := failedDelivery(t, db, endpoint)
+
+			other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@test.com", Name: "other"})
+			require.NoError(t, err)
+			othersEndpoint, err := webhooks.Create(ctx, db, other.ID, webhooks.Form{URL: "https://other.example.com/hooks", Events: []string{webhooks.EventCallCompleted}})
+			require.NoError(t, err)
+			othersDelivery := failedDelivery(t, db, othersEndpoint)
+
+			ids := strings.NewReplacer(
+				"{endpoint}", fmt.Sprint(endpoint.ID),
+				"{other}", fmt.Sprint(othersEndpoint.ID),
+				"{delivery}", fmt.Sprint(delivery.ID),
+				"{otherDelivery}", fmt.Sprint(othersDelivery.ID),
+			)
+			req := httptest.NewRequest(tt.method, ids.Replace(tt.path), strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			w := httptest.NewRecorder()
+			// in development, so new endpoints' hosts aren't looked up
+			cfg := testConfig
+			cfg.Environment = config.Development
+			NewRouter(cfg, db, newTestCallService(db)).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedLocation != "" {
+				assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			}
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, w.Body.String(), expected)
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, w.Body.String(), unexpected)
+			}
+		})
+	}
+}
+
+func failedDelivery(t *testing.T, db *database.DB, endpoint database.WebhookEndpoint) database.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+	delivery, err := db.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{EndpointID: endpoint.ID, Event: webhooks.EventCallCompleted, Payload: `{}`})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE webhook_deliveries SET status = 'failed', attempts = 5, last_error = 'endpoint responded 500 Internal Server Error' WHERE id = ?", delivery.ID)
+	require.NoError(t, err)
+	return delivery
+}
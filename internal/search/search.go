@@ -0,0 +1,165 @@
+// Package search finds a user's calls by what they were about or by what was
+// said on them, with the FTS5 index the database migrations create for it.
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"goDial/internal/database"
+)
+
+// DefaultLimit is how many calls a search page shows.
+const DefaultLimit = 20
+
+// maxTerms caps how many words of a query are searched for.
+const maxTerms = 10
+
+// Result is a call matching a search.
+type Result struct {
+	CallID      int64
+	PhoneNumber string
+	Objective   string
+	CreatedAt   sql.NullTime
+	// Snippet is the text that matched, split so the matching words can be
+	// highlighted.
+	Snippet []Segment
+}
+
+// Segment is part of a snippet; Match is set on the words searched for.
+type Segment struct {
+	Text  string
+	Match bool
+}
+
+// Calls returns up to limit of userID's calls with every word of query in
+// their objective, recipient context or a line of their transcript, best
+// matches first. A query without any words matches nothing.
+func Calls(ctx context.Context, db database.DBTX, userID int64, query string, limit int) ([]Result, error) {
+	terms := queryTerms(query)
+	if len(terms) == 0 || limit < 1 {
+		return nil, nil
+	}
+	return searchCalls(ctx, db, userID, terms, limit)
+}
+
+// queryTerms splits a query into the lowercase words to search for.
+// Punctuation is dropped, so nothing in a query is read as search syntax.
+func queryTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+	return terms
+}
+
+// dedupe keeps the best match of each call, up to limit calls. Searches match
+// rows rather than calls, so one call can match more than once.
+func dedupe(matches []Result, limit int) []Result {
+	seen := make(map[int64]bool, len(matches))
+	var results []Result
+	for _, match := range matches {
+		if seen[match.CallID] {
+			continue
+		}
+		seen[match.CallID] = true
+		results = append(results, match)
+		if len(results) == limit {
+			break
+		}
+	}
+	return results
+}
+
+// snippets mark matches with control characters, which can't be in a query
+// term, and are split on them into segments
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+// ftsQuery ranks matching calls and transcript lines together by bm25. Rows
+// are over-fetched since a call can match more than once.
+const ftsQuery = `
+SELECT c.id, c.phone_number, c.objective, c.created_at, m.snippet
+FROM (
+	SELECT calls_search.rowid AS call_id,
+		snippet(calls_search, -1, char(2), char(3), '…', 16) AS snippet,
+		bm25(calls_search) AS score
+	FROM calls_search
+	WHERE calls_search MATCH ?1
+	UNION ALL
+	SELECT l.call_id,
+		snippet(call_logs_search, 0, char(2), char(3), '…', 16),
+		bm25(call_logs_search)
+	FROM call_logs_search
+	JOIN call_logs l ON l.id = call_logs_search.rowid
+	WHERE call_logs_search MATCH ?1 AND l.message_type IN ('user_speech', 'ai_response')
+) m
+JOIN calls c ON c.id = m.call_id
+WHERE c.user_id = ?2
+ORDER BY m.score, c.id DESC
+LIMIT ?3`
+
+func searchCalls(ctx context.Context, db database.DBTX, userID int64, terms []string, limit int) ([]Result, error) {
+	rows, err := db.QueryContext(ctx, ftsQuery, matchQuery(terms), userID, limit*4)
+	if err != nil {
+		return nil, fmt.Errorf("error searching calls: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []Result
+	for rows.Next() {
+		var match Result
+		var snippet string
+		if err := rows.Scan(&match.CallID, &match.PhoneNumber, &match.Objective, &match.CreatedAt, &snippet); err != nil {
+			return nil, fmt.Errorf("error reading search results: %w", err)
+		}
+		match.Snippet = markedSegments(snippet)
+		matches = append(matches, match)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading search results: %w", err)
+	}
+	return dedupe(matches, limit), nil
+}
+
+// matchQuery turns terms into an FTS5 query matching rows with every term,
+// each as a word prefix.
+func matchQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// markedSegments splits an FTS5 snippet on its match markers.
+func markedSegments(snippet string) []Segment {
+	var segments []Segment
+	for {
+		start := strings.Index(snippet, matchStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(snippet[start:], matchEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+		if start > 0 {
+			segments = append(segments, Segment{Text: snippet[:start]})
+		}
+		segments = append(segments, Segment{Text: snippet[start+len(matchStart) : end], Match: true})
+		snippet = snippet[end+len(matchEnd):]
+	}
+	if snippet != "" {
+		segments = append(segments, Segment{Text: snippet})
+	}
+	return segments
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// matched is the highlighted words of a snippet, lowercased.
+func matched(segments []Segment) []string {
+	var words []string
+	for _, segment := range segments {
+		if segment.Match {
+			words = append(words, strings.ToLower(segment.Text))
+		}
+	}
+	return words
+}
+
+func TestCalls(t *testing.T) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "search_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+
+	createCall := func(userID int64, objective, recipientContext string, lines ...string) database.Call {
+		call, err := db.CreateCall(ctx, database.CreateCallParams{
+			UserID:           userID,
+			PhoneNumber:      "+13336664444",
+			Objective:        objective,
+			RecipientContext: sql.NullString{String: recipientContext, Valid: recipientContext != ""},
+		})
+		require.NoError(t, err)
+		for i, line := range lines {
+			messageType := "ai_response"
+			if i%2 == 1 {
+				messageType = "user_speech"
+			}
+			_, err := db.CreateCallLog(ctx, database.CreateCallLogParams{CallID: call.ID, MessageType: messageType, Content: line})
+			require.NoError(t, err)
+		}
+		return call
+	}
+
+	plumber := createCall(user.ID, "Get a price for fixing the kitchen sink", "Joe's Plumbing",
+		"Hi, I'm calling about a leaking kitchen sink.",
+		"Sure. For that job the plumber gave me a quote of 200 dollars.",
+	)
+	bakery := createCall(user.ID, "Order a birthday cake", "",
+		"Hi, I'd like to order a cake for Saturday.",
+		"We can do a chocolate cake, would you like a quote?",
+	)
+	// the same words on someone else's call
+	createCall(other.ID, "Ask the plumber for a quote", "", "the plumber gave me a quote")
+	// system notes aren't searched
+	_, err = db.CreateCallLog(ctx, database.CreateCallLogParams{CallID: bakery.ID, MessageType: "system", Content: "voicemail from the plumber"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		query           string
+		expected        []int64
+		expectedMatches []string
+	}{
+		{
+			name:            "words from a transcript line",
+			query:           "plumber quote",
+			expected:        []int64{plumber.ID},
+			expectedMatches: []string{"plumber", "quote"},
+		},
+		{
+			name:            "objective",
+			query:           "Birthday",
+			expected:        []int64{bakery.ID},
+			expectedMatches: []string{"birthday"},
+		},
+		{
+			name:            "recipient context",
+			query:           "joe's plumbing",
+			expected:        []int64{plumber.ID},
+			expectedMatches: []string{"joe", "s", "plumbing"},
+		},
+		{
+			name:     "word prefixes",
+			query:    "choco",
+			expected: []int64{bakery.ID},
+		},
+		{
+			name:     "every word has to match",
+			query:    "plumber cake",
+			expected: nil,
+		},
+		{
+			name:     "search syntax is ignored",
+			query:    `"quote"* ^(`,
+			expected: []int64{plumber.ID, bakery.ID},
+		},
+		{
+			name:  "no words",
+			query: " -- ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := Calls(ctx, db, user.ID, tt.query, DefaultLimit)
+			require.NoError(t, err)
+
+			var ids []int64
+			for _, result := range results {
+				ids = append(ids, result.CallID)
+				assert.NotEmpty(t, result.Snippet)
+			}
+			assert.ElementsMatch(t, tt.expected, ids)
+			if tt.expectedMatches != nil {
+				assert.Subset(t, matched(results[0].Snippet), tt.expectedMatches)
+			}
+		})
+	}
+
+	results, err := Calls(ctx, db, user.ID, "quote", 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
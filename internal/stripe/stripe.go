@@ -1,12 +1,49 @@
+// Package stripe prices calling minutes, and will take payment for them
+// through Stripe.
 package stripe
 
 import (
 	"fmt"
-	"os"
+
+	"goDial/internal/config"
 )
 
-func GetStripePaymentForm() {
-	myStripeKey := os.Getenv("STRIPE_SECRET_API_KEY")
-	fmt.Println(myStripeKey)
+// Checkout sells calling minutes at the configured price.
+type Checkout struct {
+	secretKey string
+	billing   config.Billing
+}
+
+// New returns the checkout for cfg's Stripe account and prices.
+func New(cfg config.Config) *Checkout {
+	return &Checkout{secretKey: cfg.Stripe.SecretKey, billing: cfg.Billing}
+}
+
+// Configured reports whether there is a Stripe account to take payment with.
+func (c *Checkout) Configured() bool {
+	return c.secretKey != ""
+}
+
+// PricePerMinuteCents is what a calling minute costs, in cents.
+func (c *Checkout) PricePerMinuteCents() int64 {
+	return c.billing.PricePerMinuteCents
+}
+
+// Increment is how many minutes at a time can be bought.
+func (c *Checkout) Increment() int64 {
+	return c.billing.PurchaseIncrement
+}
+
+// PriceCents is what minutes cost, in cents.
+func (c *Checkout) PriceCents(minutes int64) int64 {
+	return minutes * c.billing.PricePerMinuteCents
+}
 
+// FormatCents formats cents as dollars, like $0.50.
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
 }
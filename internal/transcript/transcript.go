@@ -0,0 +1,220 @@
+// Package transcript renders a call's logs as a transcript: plain text for
+// people, JSON for tools, and WebVTT captions timed from the start of the
+// call so they can be played alongside its recording.
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// Format is how a transcript is rendered.
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatWebVTT Format = "vtt"
+)
+
+// ErrUnknownFormat is returned for formats other than text, json and vtt.
+var ErrUnknownFormat = errors.New("unknown transcript format")
+
+// ParseFormat reads a format as given in a query string or file extension.
+// An empty format is text.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "", "text", "txt":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "vtt", "webvtt":
+		return FormatWebVTT, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+}
+
+// ContentType is the MIME type a transcript in f is served as.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json"
+	case FormatWebVTT:
+		return "text/vtt; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// who said what, as named in transcripts
+const (
+	SpeakerAgent     = "agent"
+	SpeakerRecipient = "recipient"
+	SpeakerSystem    = "system"
+)
+
+// Entry is one line of a transcript.
+type Entry struct {
+	ID      int64     `json:"id"`
+	Speaker string    `json:"speaker"`
+	Text    string    `json:"text"`
+	At      time.Time `json:"at"`
+	// Offset is how far into the call the line was logged.
+	Offset time.Duration `json:"-"`
+	// OffsetSeconds is Offset for JSON readers.
+	OffsetSeconds float64 `json:"offset_seconds"`
+}
+
+// Entries turns call logs, in the order they were logged, into transcript
+// lines. Offsets are measured from the first log; a log without a timestamp
+// takes the offset of the one before it.
+func Entries(logs []database.CallLog) []Entry {
+	return EntriesFrom(logs, time.Time{})
+}
+
+// EntriesFrom is Entries for logs from partway through a call, with offsets
+// measured from start, when the call's first log was logged. A zero start is
+// the first of logs.
+func EntriesFrom(logs []database.CallLog, start time.Time) []Entry {
+	entries := make([]Entry, 0, len(logs))
+	start = start.UTC()
+	last := start
+	for _, log := range logs {
+		at := last
+		if log.Timestamp.Valid {
+			at = log.Timestamp.Time.UTC()
+		}
+		if start.IsZero() {
+			start = at
+		}
+		last = at
+
+		offset := at.Sub(start)
+		entries = append(entries, Entry{
+			ID:            log.ID,
+			Speaker:       speaker(log.MessageType),
+			Text:          log.Content,
+			At:            at,
+			Offset:        offset,
+			OffsetSeconds: offset.Seconds(),
+		})
+	}
+	return entries
+}
+
+func speaker(messageType string) string {
+	switch messageType {
+	case "ai_response":
+		return SpeakerAgent
+	case "user_speech":
+		return SpeakerRecipient
+	}
+	return SpeakerSystem
+}
+
+// Render writes logs to w as a transcript in format f.
+func Render(w io.Writer, f Format, logs []database.CallLog) error {
+	return RenderFrom(w, f, logs, time.Time{})
+}
+
+// RenderFrom is Render for logs from partway through a call, timed from
+// start like EntriesFrom.
+func RenderFrom(w io.Writer, f Format, logs []database.CallLog, start time.Time) error {
+	entries := EntriesFrom(logs, start)
+	switch f {
+	case FormatText:
+		return renderText(w, entries)
+	case FormatJSON:
+		return renderJSON(w, entries)
+	case FormatWebVTT:
+		return renderWebVTT(w, entries)
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, f)
+}
+
+// renderText writes one line per entry, e.g. "[01:05] Agent: Hello". System
+// entries are notes in brackets, so the conversation reads on its own.
+func renderText(w io.Writer, entries []Entry) error {
+	for _, entry := range entries {
+		var err error
+		if entry.Speaker == SpeakerSystem {
+			_, err = fmt.Fprintf(w, "[%s] (%s)\n", clock(entry.Offset), entry.Text)
+		} else {
+			_, err = fmt.Fprintf(w, "[%s] %s: %s\n", clock(entry.Offset), label(entry.Speaker), entry.Text)
+		}
+		if err != nil {
+			return fmt.Errorf("error writing transcript: %w", err)
+		}
+	}
+	return nil
+}
+
+func renderJSON(w io.Writer, entries []Entry) error {
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		return fmt.Errorf("error writing transcript: %w", err)
+	}
+	return nil
+}
+
+// minCue is how long a caption shows when the next line was logged at the
+// same second, or there is no next line.
+const minCue = 2 * time.Second
+
+// renderWebVTT writes a caption cue for each spoken line, lasting until the
+// next one starts. System entries aren't speech, so they are left out.
+func renderWebVTT(w io.Writer, entries []Entry) error {
+	var spoken []Entry
+	for _, entry := range entries {
+		if entry.Speaker != SpeakerSystem {
+			spoken = append(spoken, entry)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i, entry := range spoken {
+		end := entry.Offset + minCue
+		if i+1 < len(spoken) && spoken[i+1].Offset > entry.Offset {
+			end = spoken[i+1].Offset
+		}
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n<v %s>%s\n", i+1, vttTimestamp(entry.Offset), vttTimestamp(end), label(entry.Speaker), vttEscape(entry.Text))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("error writing transcript: %w", err)
+	}
+	return nil
+}
+
+func label(speaker string) string {
+	switch speaker {
+	case SpeakerAgent:
+		return "Agent"
+	case SpeakerRecipient:
+		return "Recipient"
+	}
+	return "System"
+}
+
+// clock formats an offset as mm:ss, or h:mm:ss past the first hour.
+func clock(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// vttTimestamp formats an offset as WebVTT's hh:mm:ss.ttt.
+func vttTimestamp(d time.Duration) string {
+	ms := int64(d / time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// vttEscape keeps cue text from being read as tags, or as a cue timing line.
+var vttEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
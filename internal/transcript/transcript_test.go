@@ -0,0 +1,116 @@
+package transcript
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func callLogs() []database.CallLog {
+	start := time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC)
+	at := func(seconds int) sql.NullTime {
+		return sql.NullTime{Time: start.Add(time.Duration(seconds) * time.Second), Valid: true}
+	}
+	return []database.CallLog{
+		{ID: 1, CallID: 7, MessageType: "system", Content: "attempt 1 answered", Timestamp: at(0)},
+		{ID: 2, CallID: 7, MessageType: "ai_response", Content: "Hi, is this the bakery?", Timestamp: at(1)},
+		{ID: 3, CallID: 7, MessageType: "user_speech", Content: "Yes, <how> can I help?", Timestamp: at(4)},
+		{ID: 4, CallID: 7, MessageType: "ai_response", Content: "I'd like to order a cake.", Timestamp: at(4)},
+		{ID: 5, CallID: 7, MessageType: "user_speech", Content: "Sure & what size?", Timestamp: at(65)},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format: FormatText,
+			expected: "[00:00] (attempt 1 answered)\n" +
+				"[00:01] Agent: Hi, is this the bakery?\n" +
+				"[00:04] Recipient: Yes, <how> can I help?\n" +
+				"[00:04] Agent: I'd like to order a cake.\n" +
+				"[01:05] Recipient: Sure & what size?\n",
+		},
+		{
+			format: FormatWebVTT,
+			expected: "WEBVTT\n" +
+				"\n1\n00:00:01.000 --> 00:00:04.000\n<v Agent>Hi, is this the bakery?\n" +
+				// the next line was logged the same second
+				"\n2\n00:00:04.000 --> 00:00:06.000\n<v Recipient>Yes, &lt;how&gt; can I help?\n" +
+				"\n3\n00:00:04.000 --> 00:01:05.000\n<v Agent>I'd like to order a cake.\n" +
+				"\n4\n00:01:05.000 --> 00:01:07.000\n<v Recipient>Sure &amp; what size?\n",
+		},
+		{
+			format: FormatJSON,
+			expected: `[{"id":1,"speaker":"system","text":"attempt 1 answered","at":"2026-10-18T18:00:00Z","offset_seconds":0},` +
+				`{"id":2,"speaker":"agent","text":"Hi, is this the bakery?","at":"2026-10-18T18:00:01Z","offset_seconds":1},` +
+				`{"id":3,"speaker":"recipient","text":"Yes, \u003chow\u003e can I help?","at":"2026-10-18T18:00:04Z","offset_seconds":4},` +
+				`{"id":4,"speaker":"agent","text":"I'd like to order a cake.","at":"2026-10-18T18:00:04Z","offset_seconds":4},` +
+				`{"id":5,"speaker":"recipient","text":"Sure \u0026 what size?","at":"2026-10-18T18:01:05Z","offset_seconds":65}]` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var b strings.Builder
+			require.NoError(t, Render(&b, tt.format, callLogs()))
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+}
+
+func TestRender_Empty(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, Render(&b, FormatWebVTT, nil))
+	assert.Equal(t, "WEBVTT\n", b.String())
+
+	b.Reset()
+	require.NoError(t, Render(&b, FormatJSON, nil))
+	assert.Equal(t, "[]\n", b.String())
+}
+
+func TestRenderFrom_KeepsOffsetsFromTheStart(t *testing.T) {
+	logs := callLogs()
+	var b strings.Builder
+	require.NoError(t, RenderFrom(&b, FormatText, logs[3:], logs[0].Timestamp.Time))
+	assert.Equal(t, "[00:04] Agent: I'd like to order a cake.\n[01:05] Recipient: Sure & what size?\n", b.String())
+
+	entries := EntriesFrom(logs[4:], logs[0].Timestamp.Time)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 65.0, entries[0].OffsetSeconds)
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Format
+		expectedErr bool
+	}{
+		{input: "", expected: FormatText},
+		{input: "txt", expected: FormatText},
+		{input: "JSON", expected: FormatJSON},
+		{input: ".vtt", expected: FormatWebVTT},
+		{input: "webvtt", expected: FormatWebVTT},
+		{input: "srt", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			format, err := ParseFormat(tt.input)
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrUnknownFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+}
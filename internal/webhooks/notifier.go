@@ -0,0 +1,216 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/jobs"
+)
+
+// JobDeliver is the kind of job posting one delivery to its endpoint.
+const JobDeliver = "webhooks.deliver"
+
+// deliveryTimeout bounds how long an endpoint has to respond.
+const deliveryTimeout = 10 * time.Second
+
+type deliveryJob struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// Envelope is the body of every delivery.
+type Envelope struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Notifier sends events to the endpoints subscribed to them.
+type Notifier struct {
+	db     *database.DB
+	queue  *jobs.Queue
+	client *http.Client
+	now    func() time.Time
+}
+
+// New creates a notifier and registers its deliveries with queue. Outside
+// development, it refuses to connect to any address that isn't public,
+// whatever the endpoint's host resolves to by the time it is sent to.
+func New(db *database.DB, queue *jobs.Queue, dev bool) *Notifier {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !dev {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// a proxy would connect on the notifier's behalf, unchecked
+	transport.Proxy = nil
+	n := &Notifier{
+		db:     db,
+		queue:  queue,
+		client: &http.Client{Timeout: deliveryTimeout, Transport: transport},
+		now:    time.Now,
+	}
+	queue.Register(JobDeliver, n.Deliver)
+	return n
+}
+
+// errPrivateAddress is returned for deliveries to addresses that aren't
+// public.
+var errPrivateAddress = errors.New("endpoint is not on the public internet")
+
+// refusePrivate is a dialer's Control, run on the address about to be
+// connected to, after the host is resolved.
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("%w: %s", errPrivateAddress, host)
+	}
+	return nil
+}
+
+// Emit queues a delivery of event, with data as its payload, to each of
+// userID's endpoints subscribed to it.
+func (n *Notifier) Emit(ctx context.Context, userID int64, event string, data any) error {
+	endpoints, err := n.db.ListWebhookEndpointsForEvent(ctx, database.ListWebhookEndpointsForEventParams{UserID: userID, Event: event})
+	if err != nil {
+		return fmt.Errorf("error listing endpoints for %s: %w", event, err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(Envelope{Event: event, CreatedAt: n.now().UTC(), Data: data})
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", event, err)
+	}
+	for _, endpoint := range endpoints {
+		delivery, err := n.db.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			EndpointID: endpoint.ID,
+			Event:      event,
+			Payload:    string(payload),
+		})
+		if err != nil {
+			return fmt.Errorf("error logging %s delivery to endpoint %d: %w", event, endpoint.ID, err)
+		}
+		if _, err := n.queue.Enqueue(ctx, JobDeliver, deliveryJob{DeliveryID: delivery.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deliver posts a delivery to its endpoint and logs how it went. Failures
+// are returned so the queue retries them; once it runs out of attempts the
+// delivery is logged as failed, to be replayed by hand.
+func (n *Notifier) Deliver(ctx context.Context, job database.Job) error {
+	var payload deliveryJob
+	if err := jobs.Decode(job, &payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	delivery, err := n.db.GetWebhookDelivery(ctx, payload.DeliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the endpoint was deleted, and its deliveries with it
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error loading webhook delivery %d: %w", payload.DeliveryID, err)
+	}
+	if delivery.Status == StatusDelivered {
+		return nil
+	}
+
+	code, postErr := n.post(ctx, delivery)
+	attempt := database.RecordWebhookAttemptParams{
+		Status:         StatusDelivered,
+		ResponseStatus: sql.NullInt64{Int64: int64(code), Valid: code != 0},
+		LastAttemptAt:  sql.NullTime{Time: n.now().UTC(), Valid: true},
+		ID:             delivery.ID,
+	}
+	if postErr == nil {
+		attempt.DeliveredAt = attempt.LastAttemptAt
+	} else {
+		attempt.Status = StatusPending
+		if job.Attempts >= job.MaxAttempts {
+			attempt.Status = StatusFailed
+		}
+		attempt.LastError = sql.NullString{String: postErr.Error(), Valid: true}
+	}
+	if err := n.db.RecordWebhookAttempt(ctx, attempt); err != nil {
+		return fmt.Errorf("error logging attempt of webhook delivery %d: %w", delivery.ID, err)
+	}
+	return postErr
+}
+
+// post sends a delivery, returning the endpoint's response status if it got
+// one. Anything but a 2xx is an error.
+func (n *Notifier) post(ctx context.Context, delivery database.GetWebhookDeliveryRow) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error building request to %s: %w", delivery.Url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goDial-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, n.now(), body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error posting to %s: %w", delivery.Url, err)
+	}
+	defer resp.Body.Close()
+	// read a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Replay sends one of userID's deliveries again, whether it failed or not.
+// The receiver gets the same body and delivery ID, with a fresh signature. A
+// delivery still being sent, or retried, can't be replayed until it is done,
+// so the receiver doesn't get it twice.
+func (n *Notifier) Replay(ctx context.Context, userID, deliveryID int64) error {
+	status, err := n.db.GetWebhookDeliveryStatus(ctx, database.GetWebhookDeliveryStatusParams{ID: deliveryID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error loading webhook delivery %d: %w", deliveryID, err)
+	}
+	if status == StatusPending {
+		return ErrPending
+	}
+
+	replayed, err := n.db.ReplayWebhookDelivery(ctx, database.ReplayWebhookDeliveryParams{ID: deliveryID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error replaying webhook delivery %d: %w", deliveryID, err)
+	}
+	if replayed == 0 {
+		// it was replayed, or deleted, since it was looked up
+		return ErrPending
+	}
+	_, err = n.queue.Enqueue(ctx, JobDeliver, deliveryJob{DeliveryID: deliveryID})
+	return err
+}
@@ -0,0 +1,236 @@
+// Package webhooks notifies users' own systems, such as a CRM, of events in
+// goDial. Each event is posted as JSON to every endpoint the user subscribed
+// to it, signed with the endpoint's secret. Deliveries are logged, retried by
+// the job queue with its backoff, and can be replayed from the log.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"goDial/internal/database"
+)
+
+// events endpoints can subscribe to
+const (
+	EventCallCreated   = "call.created"
+	EventCallCompleted = "call.completed"
+	EventCallFailed    = "call.failed"
+	EventBalanceLow    = "balance.low"
+)
+
+// Events are every event, in the order the settings page offers them.
+var Events = []string{EventCallCreated, EventCallCompleted, EventCallFailed, EventBalanceLow}
+
+// delivery statuses, matching the CHECK constraint on webhook_deliveries.status
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// headers sent with every delivery
+const (
+	SignatureHeader = "GoDial-Signature"
+	EventHeader     = "GoDial-Event"
+	DeliveryHeader  = "GoDial-Delivery"
+)
+
+// SignatureTolerance is how far a signature's time can be from the receiver's
+// clock before Verify rejects it, so a captured delivery can't be replayed
+// by someone else much later.
+const SignatureTolerance = 5 * time.Minute
+
+// ErrBadSignature is returned by Verify for a delivery not signed with the
+// endpoint's secret, or signed too long ago.
+var ErrBadSignature = errors.New("webhook signature does not match")
+
+// ErrNotFound is returned for a delivery that doesn't exist or belongs to
+// another user.
+var ErrNotFound = errors.New("webhook delivery not found")
+
+// ErrPending is returned for replaying a delivery that is still queued to be
+// sent or retried.
+var ErrPending = errors.New("webhook delivery is still being sent")
+
+// Sign returns the signature header for body sent at t: the time in unix
+// seconds and the hex HMAC-SHA256, keyed with secret, of the time, a dot and
+// the body.
+func Sign(secret string, t time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", t.Unix(), signature(secret, t.Unix(), body))
+}
+
+// Verify checks a delivery's signature header the way a receiver should.
+func Verify(secret, header string, body []byte, now time.Time) error {
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed header %q", ErrBadSignature, header)
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return fmt.Errorf("%w: signed %s from now", ErrBadSignature, age.Round(time.Second))
+	}
+
+	expected := signature(secret, timestamp, body)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+func signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSecret makes the secret a new endpoint's deliveries are signed with.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Form is an endpoint as entered on the settings page.
+type Form struct {
+	URL    string
+	Events []string
+}
+
+// maxURLLength limits endpoint URLs.
+const maxURLLength = 500
+
+// Validate trims the form and checks it. It returns a problem to show the
+// user, or "" if the endpoint can be saved. Outside development, endpoints
+// must be on the public internet, so they can't be used to reach this
+// server's own network; the host is looked up to check.
+func (f *Form) Validate(ctx context.Context, dev bool) string {
+	f.URL = strings.TrimSpace(f.URL)
+
+	u, err := url.Parse(f.URL)
+	if f.URL == "" || err != nil || u.Host == "" || len(f.URL) > maxURLLength {
+		return "Enter the full URL to send events to, like https://example.com/hooks/godial."
+	}
+	// plain http is only for trying out a receiver on the same machine
+	if u.Scheme != "https" && !(dev && u.Scheme == "http" && isLocal(u.Hostname())) {
+		return "Use an https:// URL."
+	}
+	if !dev {
+		if problem := checkHost(ctx, u.Hostname()); problem != "" {
+			return problem
+		}
+	}
+
+	if len(f.Events) == 0 {
+		return "Pick at least one event."
+	}
+	for _, event := range f.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Sprintf("There's no %q event.", event)
+		}
+	}
+	return ""
+}
+
+func isLocal(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// lookupIP resolves endpoint hosts. Tests replace it.
+var lookupIP = net.DefaultResolver.LookupIP
+
+// checkHost returns a problem to show the user if host doesn't resolve, or
+// resolves to an address that isn't public.
+func checkHost(ctx context.Context, host string) string {
+	ips, err := lookupIP(ctx, "ip", host)
+	if err != nil || len(ips) == 0 {
+		return fmt.Sprintf("Couldn't find %s. Check the URL.", host)
+	}
+	for _, ip := range ips {
+		if !isPublic(ip) {
+			return "Send events to a server on the public internet, not a private or local address."
+		}
+	}
+	return ""
+}
+
+// sharedAddresses are carrier-grade NAT addresses, which some clouds serve
+// their metadata from.
+var sharedAddresses = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublic reports whether ip is on the public internet: not loopback,
+// private, link-local (where cloud metadata services live), shared,
+// unspecified or multicast.
+func isPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	// 0.0.0.0/8 is "this network"
+	if len(ip) == 0 || ip[0] == 0 {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast() && !sharedAddresses.Contains(ip)
+}
+
+// Create saves a validated form as one of userID's endpoints, with a new
+// secret.
+func Create(ctx context.Context, q database.Querier, userID int64, f Form) (database.WebhookEndpoint, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return database.WebhookEndpoint{}, err
+	}
+
+	// kept in the order of Events, however they were picked
+	var events []string
+	for _, event := range Events {
+		if slices.Contains(f.Events, event) {
+			events = append(events, event)
+		}
+	}
+
+	endpoint, err := q.CreateWebhookEndpoint(ctx, database.CreateWebhookEndpointParams{
+		UserID: userID,
+		Url:    f.URL,
+		Secret: secret,
+		Events: strings.Join(events, ","),
+	})
+	if err != nil {
+		return database.WebhookEndpoint{}, fmt.Errorf("error saving webhook endpoint: %w", err)
+	}
+	return endpoint, nil
+}
+
+// Subscriptions are the events an endpoint is sent.
+func Subscriptions(endpoint database.WebhookEndpoint) []string {
+	return strings.Split(endpoint.Events, ",")
+}
@@ -0,0 +1,310 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"goDial/internal/database"
+	"goDial/internal/jobs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	sent := time.Date(2026, time.October, 18, 18, 0, 0, 0, time.UTC)
+	body := []byte(`{"event":"call.completed"}`)
+	header := Sign("whsec_test", sent, body)
+	assert.Regexp(t, `^t=1792346400,v1=[0-9a-f]{64}$`, header)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		valid  bool
+	}{
+		{name: "as sent", secret: "whsec_test", header: header, body: body, now: sent.Add(time.Minute), valid: true},
+		{name: "one of several signatures", secret: "whsec_test", header: "t=1792346400,v1=00," + header[len("t=1792346400,"):], body: body, now: sent, valid: true},
+		{name: "other secret", secret: "whsec_other", header: header, body: body, now: sent},
+		{name: "changed body", secret: "whsec_test", header: header, body: []byte(`{"event":"call.failed"}`), now: sent},
+		{name: "too old", secret: "whsec_test", header: header, body: body, now: sent.Add(SignatureTolerance + time.Second)},
+		{name: "malformed", secret: "whsec_test", header: "sha256=abc", body: body, now: sent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrBadSignature)
+			}
+		})
+	}
+}
+
+// fakeDNS stands in for the resolver while a test runs.
+func fakeDNS(t *testing.T, hosts map[string]string) {
+	t.Helper()
+	lookup := lookupIP
+	lookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		if ip, ok := hosts[host]; ok {
+			return []net.IP{net.ParseIP(ip)}, nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	t.Cleanup(func() { lookupIP = lookup })
+}
+
+func TestForm_Validate(t *testing.T) {
+	fakeDNS(t, map[string]string{
+		"crm.example.com":      "93.184.215.14",
+		"intranet.example.com": "10.0.0.12",
+		"localhost":            "127.0.0.1",
+	})
+
+	tests := []struct {
+		name            string
+		form            Form
+		dev             bool
+		expectedProblem string
+	}{
+		{name: "valid", form: Form{URL: " https://crm.example.com/hooks ", Events: []string{EventCallCompleted}}},
+		{name: "http on this machine in development", form: Form{URL: "http://127.0.0.1:9000/hooks", Events: []string{EventCallCompleted}}, dev: true},
+		{name: "private address in development", form: Form{URL: "https://intranet.example.com/hooks", Events: []string{EventCallCompleted}}, dev: true},
+		{name: "http on this machine", form: Form{URL: "http://127.0.0.1:9000/hooks", Events: []string{EventCallCompleted}}, expectedProblem: "Use an https:// URL."},
+		{name: "this machine", form: Form{URL: "https://localhost/hooks", Events: []string{EventCallCompleted}}, expectedProblem: privateProblem},
+		{name: "host on a private network", form: Form{URL: "https://intranet.example.com/hooks", Events: []string{EventCallCompleted}}, expectedProblem: privateProblem},
+		{name: "cloud metadata", form: Form{URL: "https://169.254.169.254/latest/meta-data", Events: []string{EventCallCompleted}}, expectedProblem: privateProblem},
+		{name: "unknown host", form: Form{URL: "https://crm.example.invalid/hooks", Events: []string{EventCallCompleted}}, expectedProblem: "Couldn't find crm.example.invalid. Check the URL."},
+		{name: "plain http", form: Form{URL: "http://crm.example.com/hooks", Events: []string{EventCallCompleted}}, expectedProblem: "Use an https:// URL."},
+		{name: "not a URL", form: Form{URL: "crm.example.com", Events: []string{EventCallCompleted}}, expectedProblem: "Enter the full URL to send events to, like https://example.com/hooks/godial."},
+		{name: "no events", form: Form{URL: "https://crm.example.com/hooks"}, expectedProblem: "Pick at least one event."},
+		{name: "unknown event", form: Form{URL: "https://crm.example.com/hooks", Events: []string{"call.answered"}}, expectedProblem: `There's no "call.answered" event.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := tt.form
+			assert.Equal(t, tt.expectedProblem, form.Validate(context.Background(), tt.dev))
+		})
+	}
+}
+
+const privateProblem = "Send events to a server on the public internet, not a private or local address."
+
+func TestIsPublic(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.215.14":        true,
+		"2606:2800:21f::1":     true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.100.100.200":      false,
+		"0.0.0.0":              false,
+		"0.1.2.3":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:10.0.0.1":      false,
+		"fd00:ec2::254":        false,
+		"fe80::1":              false,
+		"224.0.0.1":            false,
+		"::":                   false,
+		"::ffff:93.184.215.14": true,
+	}
+	for address, public := range tests {
+		t.Run(address, func(t *testing.T) {
+			assert.Equal(t, public, isPublic(net.ParseIP(address)))
+		})
+	}
+}
+
+// receiver is an endpoint in a user's own system. It answers with the next of
+// its codes, then 200 once they run out.
+type receiver struct {
+	*httptest.Server
+	secret string
+
+	mu         sync.Mutex
+	codes      []int
+	deliveries []received
+}
+
+type received struct {
+	ID       string
+	Event    string
+	Envelope Envelope
+}
+
+func newReceiver(t *testing.T, codes ...int) *receiver {
+	rc := &receiver{codes: codes}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		assert.NoError(t, Verify(rc.secret, r.Header.Get(SignatureHeader), body, time.Now()), "deliveries are signed")
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal(body, &envelope))
+		rc.deliveries = append(rc.deliveries, received{ID: r.Header.Get(DeliveryHeader), Event: r.Header.Get(EventHeader), Envelope: envelope})
+
+		code := http.StatusOK
+		if len(rc.codes) > 0 {
+			code, rc.codes = rc.codes[0], rc.codes[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) received() []received {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]received(nil), rc.deliveries...)
+}
+
+func setupNotifier(t *testing.T, opts jobs.Options) (*Notifier, *database.DB, *jobs.Queue, database.User) {
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "webhooks_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	user, err := db.CreateUser(context.Background(), database.CreateUserParams{Email: "user@example.com", Name: "Test User"})
+	require.NoError(t, err)
+	queue := jobs.New(db, opts)
+	// the test receivers are on this machine
+	return New(db, queue, true), db, queue, user
+}
+
+func (rc *receiver) subscribe(t *testing.T, db *database.DB, userID int64, events ...string) database.WebhookEndpoint {
+	endpoint, err := Create(context.Background(), db, userID, Form{URL: rc.URL, Events: events})
+	require.NoError(t, err)
+	rc.secret = endpoint.Secret
+	return endpoint
+}
+
+func TestNotifier_RetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	notifier, db, queue, user := setupNotifier(t, jobs.Options{
+		PollInterval: 10 * time.Millisecond,
+		BaseBackoff:  10 * time.Millisecond,
+		MaxBackoff:   50 * time.Millisecond,
+	})
+	rc := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	rc.subscribe(t, db, user.ID, EventCallCompleted)
+
+	queue.Start()
+	t.Cleanup(func() { queue.Shutdown(ctx) })
+	require.NoError(t, notifier.Emit(ctx, user.ID, EventCallCompleted, map[string]any{"id": 7}))
+	require.NoError(t, notifier.Emit(ctx, user.ID, EventCallFailed, map[string]any{"id": 8}), "no endpoint is subscribed to it")
+
+	var deliveries []database.ListWebhookDeliveriesRow
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = db.ListWebhookDeliveries(ctx, user.ID)
+		require.NoError(t, err)
+		return len(deliveries) == 1 && deliveries[0].Status == StatusDelivered
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, int64(3), deliveries[0].Attempts)
+	assert.Equal(t, int64(http.StatusOK), deliveries[0].ResponseStatus.Int64)
+	got := rc.received()
+	require.Len(t, got, 3)
+	for _, r := range got {
+		assert.Equal(t, strconv.FormatInt(deliveries[0].ID, 10), r.ID, "retries are the same delivery")
+		assert.Equal(t, EventCallCompleted, r.Event)
+		assert.Equal(t, EventCallCompleted, r.Envelope.Event)
+		assert.Equal(t, map[string]any{"id": float64(7)}, r.Envelope.Data)
+	}
+}
+
+// deliverQueued runs the queued deliveries by hand, as the queue's first try
+// at them.
+func deliverQueued(t *testing.T, notifier *Notifier, db *database.DB) []error {
+	t.Helper()
+	queued, err := db.ListJobsByStatus(context.Background(), "queued")
+	require.NoError(t, err)
+	var errs []error
+	for _, job := range queued {
+		_, err := db.ExecContext(context.Background(), "UPDATE jobs SET status = 'succeeded' WHERE id = ?", job.ID)
+		require.NoError(t, err)
+		job.Attempts = 1
+		errs = append(errs, notifier.Deliver(context.Background(), job))
+	}
+	return errs
+}
+
+func TestNotifier_FailsThenReplays(t *testing.T) {
+	ctx := context.Background()
+	notifier, db, _, user := setupNotifier(t, jobs.Options{})
+	other, err := db.CreateUser(ctx, database.CreateUserParams{Email: "other@example.com", Name: "Other User"})
+	require.NoError(t, err)
+	rc := newReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	rc.subscribe(t, db, user.ID, EventBalanceLow)
+
+	require.NoError(t, notifier.Emit(ctx, user.ID, EventBalanceLow, map[string]any{"minutes": 4}))
+	errs := deliverQueued(t, notifier, db)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "endpoint responded 500 Internal Server Error")
+	deliveries, err := db.ListWebhookDeliveries(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	assert.Equal(t, StatusPending, delivery.Status, "to be retried")
+	assert.ErrorIs(t, notifier.Replay(ctx, user.ID, delivery.ID), ErrPending, "not while its retry is pending")
+
+	// the queue's last try
+	last := database.Job{Kind: JobDeliver, Payload: `{"delivery_id":` + strconv.FormatInt(delivery.ID, 10) + `}`, Attempts: 5, MaxAttempts: 5}
+	assert.Error(t, notifier.Deliver(ctx, last))
+	deliveries, err = db.ListWebhookDeliveries(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, deliveries[0].Status)
+	assert.Equal(t, int64(2), deliveries[0].Attempts)
+	assert.Equal(t, int64(http.StatusServiceUnavailable), deliveries[0].ResponseStatus.Int64)
+	assert.Equal(t, "endpoint responded 503 Service Unavailable", deliveries[0].LastError.String)
+
+	assert.ErrorIs(t, notifier.Replay(ctx, other.ID, delivery.ID), ErrNotFound)
+	require.NoError(t, notifier.Replay(ctx, user.ID, delivery.ID))
+	assert.ErrorIs(t, notifier.Replay(ctx, user.ID, delivery.ID), ErrPending, "nor twice")
+	assert.Equal(t, []error{nil}, deliverQueued(t, notifier, db), "sent once")
+	deliveries, err = db.ListWebhookDeliveries(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, deliveries[0].Status)
+
+	got := rc.received()
+	require.Len(t, got, 3)
+	assert.Equal(t, got[0].ID, got[2].ID, "a replay is the same delivery")
+	assert.Equal(t, got[0].Envelope, got[2].Envelope)
+}
+
+func TestNotifier_RefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	_, db, queue, user := setupNotifier(t, jobs.Options{})
+	notifier := New(db, queue, false)
+	// saved before the host was pointed at this machine
+	rc := newReceiver(t)
+	rc.subscribe(t, db, user.ID, EventCallCompleted)
+
+	require.NoError(t, notifier.Emit(ctx, user.ID, EventCallCompleted, map[string]any{"id": 7}))
+	errs := deliverQueued(t, notifier, db)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errPrivateAddress)
+	assert.Empty(t, rc.received())
+}
@@ -0,0 +1,243 @@
+// Package client is a Go client for the goDial API, for services that place
+// calls and follow them until they finish.
+//
+//	c := client.New("https://godial.example.com", os.Getenv("GODIAL_API_KEY"))
+//	call, err := c.CreateCall(ctx, client.CreateCallRequest{
+//		PhoneNumber: "+13336664444",
+//		Recipient:   "Sam Smith, the restaurant's manager",
+//		Objective:   "Book a table for four on Friday at 7pm",
+//	})
+//	...
+//	finished, err := c.WaitForCall(ctx, call.ID, 10*time.Second)
+//
+// The API is described by the OpenAPI document served at /api/openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prefix is where the version of the API this package speaks is served.
+const prefix = "/api/v1"
+
+// Client calls the goDial API with an API key. Its methods return an *Error
+// when the API answers with one.
+type Client struct {
+	// BaseURL is where goDial is served, like https://godial.example.com.
+	BaseURL string
+	// APIKey is a personal API key, made in goDial's settings. Each method
+	// needs the scope its documentation names.
+	APIKey string
+	// HTTPClient sends the requests. http.DefaultClient is used if it is nil.
+	HTTPClient *http.Client
+}
+
+// New returns a client for the goDial at baseURL.
+func New(baseURL, apiKey string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), APIKey: apiKey}
+}
+
+// Error codes the API answers with, in Error.Code.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeUnauthorized      = "unauthorized"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeInternal          = "internal_error"
+	CodeForbidden         = "forbidden"
+	CodeDoNotCall         = "do_not_call"
+	CodeUnavailable       = "unavailable"
+)
+
+// Error is an error response from the API.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"error"`
+	Message    string `json:"message"`
+	// Field is the request field that needs fixing, for invalid_request.
+	Field string `json:"field,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("goDial: %s: %s (%s)", e.Code, e.Message, e.Field)
+	}
+	return fmt.Sprintf("goDial: %s: %s", e.Code, e.Message)
+}
+
+// CreateCall places a call. It is queued to be dialed straight away, with the
+// opening line and plan the agent drafts for it. Needs calls:write.
+func (c *Client) CreateCall(ctx context.Context, req CreateCallRequest) (Call, error) {
+	var call Call
+	err := c.do(ctx, http.MethodPost, "/calls", req, &call)
+	return call, err
+}
+
+// ListCalls lists a page of calls, newest first. Needs calls:read.
+func (c *Client) ListCalls(ctx context.Context, opts ListCallsOptions) (CallList, error) {
+	query := url.Values{}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Limit != 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.BeforeID != 0 {
+		query.Set("before_id", strconv.FormatInt(opts.BeforeID, 10))
+	}
+	path := "/calls"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var list CallList
+	err := c.do(ctx, http.MethodGet, path, nil, &list)
+	return list, err
+}
+
+// GetCall returns a call with its attempts, what the agent learned and the
+// transcript so far. Needs calls:read.
+func (c *Client) GetCall(ctx context.Context, id int64) (CallDetail, error) {
+	var call CallDetail
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/calls/%d", id), nil, &call)
+	return call, err
+}
+
+// Transcript returns a call's transcript in format: TranscriptText,
+// TranscriptJSON or TranscriptVTT. Needs calls:read.
+func (c *Client) Transcript(ctx context.Context, id int64, format string) (string, error) {
+	var transcript bytes.Buffer
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/calls/%d/transcript?format=%s", id, url.QueryEscape(format)), nil, &transcript)
+	return transcript.String(), err
+}
+
+// TranscriptAfter returns the lines of a call's transcript after the one with
+// id afterID, or all of them for 0, and the call's status when they were read.
+// Polling it with the id of the last line seen follows a call as it happens.
+// Needs calls:read.
+func (c *Client) TranscriptAfter(ctx context.Context, id, afterID int64) (TranscriptUpdate, error) {
+	var update TranscriptUpdate
+	header, err := c.send(ctx, http.MethodGet, fmt.Sprintf("/calls/%d/transcript?format=%s&after_id=%d", id, TranscriptJSON, afterID), nil, &update.Entries)
+	update.Status = header.Get("X-Call-Status")
+	return update, err
+}
+
+// CancelCall cancels a call that hasn't finished, and returns it. Canceling a
+// finished call is an *Error with CodeConflict. Needs calls:write.
+func (c *Client) CancelCall(ctx context.Context, id int64) (Call, error) {
+	var call Call
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/calls/%d/cancel", id), nil, &call)
+	return call, err
+}
+
+// ListContacts lists all the key owner's contacts, by name. Needs calls:read.
+func (c *Client) ListContacts(ctx context.Context) ([]Contact, error) {
+	var list struct {
+		Contacts []Contact `json:"contacts"`
+	}
+	err := c.do(ctx, http.MethodGet, "/contacts", nil, &list)
+	return list.Contacts, err
+}
+
+// Balance returns the calling minutes the key's owner has left. Needs
+// billing:read.
+func (c *Client) Balance(ctx context.Context) (int64, error) {
+	var balance struct {
+		Minutes int64 `json:"minutes"`
+	}
+	err := c.do(ctx, http.MethodGet, "/balance", nil, &balance)
+	return balance.Minutes, err
+}
+
+// WaitForCall polls a call every interval until it has finished, and returns
+// it. It gives up when ctx is done or the call can't be fetched, returning the
+// call as it last saw it with the error. Needs calls:read.
+func (c *Client) WaitForCall(ctx context.Context, id int64, interval time.Duration) (CallDetail, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last CallDetail
+	for {
+		call, err := c.GetCall(ctx, id)
+		if err != nil {
+			return last, err
+		}
+		if call.Finished() {
+			return call, nil
+		}
+		last = call
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// do sends a request to the API with body as JSON, if it isn't nil, and reads
+// the response into out: decoded from JSON, or copied as it is when out is a
+// *bytes.Buffer.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	_, err := c.send(ctx, method, path, body, out)
+	return err
+}
+
+// send is do for callers that read the response's headers too.
+func (c *Client) send(ctx context.Context, method, path string, body, out any) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding request: %w", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+prefix+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil || apiErr.Code == "" {
+			return resp.Header, fmt.Errorf("%s %s answered %s", method, path, resp.Status)
+		}
+		return resp.Header, apiErr
+	}
+
+	if buf, ok := out.(*bytes.Buffer); ok {
+		if _, err := io.Copy(buf, resp.Body); err != nil {
+			return resp.Header, fmt.Errorf("error reading response of %s %s: %w", method, path, err)
+		}
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("error reading response of %s %s: %w", method, path, err)
+	}
+	return resp.Header, nil
+}
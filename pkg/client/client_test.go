@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"goDial/internal/api"
+	"goDial/internal/apikeys"
+	"goDial/internal/calls"
+	"goDial/internal/config"
+	"goDial/internal/database"
+	"goDial/internal/jobs"
+	"goDial/internal/router"
+	"goDial/internal/transcript"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClient runs the client against the real API.
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.InitDB(filepath.Join(t.TempDir(), "client_test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	cfg := config.Default()
+	server := httptest.NewServer(router.NewRouter(cfg, db, calls.NewService(db, jobs.New(db, jobs.Options{}), nil, calls.NewAnthropicAgent(cfg.AI), cfg)))
+	t.Cleanup(server.Close)
+
+	user, err := db.CreateUser(ctx, database.CreateUserParams{Email: "owner@example.com", Name: "Owner"})
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET minutes = 42 WHERE id = ?", user.ID)
+	require.NoError(t, err)
+	key, _, err := apikeys.Create(ctx, db, user.ID, apikeys.Form{Name: "client test", Scopes: apikeys.Scopes})
+	require.NoError(t, err)
+	_, err = db.CreateContact(ctx, database.CreateContactParams{UserID: user.ID, Name: "Sam Smith", PhoneNumber: "+13336664444"})
+	require.NoError(t, err)
+	first, err := db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336664444", Objective: "Ask about opening hours", MaxAttempts: 1})
+	require.NoError(t, err)
+	_, err = db.CreateCallLog(ctx, database.CreateCallLogParams{CallID: first.ID, MessageType: "ai_response", Content: "Hi, when are you open?"})
+	require.NoError(t, err)
+	second, err := db.CreateCall(ctx, database.CreateCallParams{UserID: user.ID, PhoneNumber: "+13336665555", Objective: "Book a table", MaxAttempts: 1})
+	require.NoError(t, err)
+
+	c := New(server.URL+"/", key)
+
+	minutes, err := c.Balance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), minutes)
+
+	contacts, err := c.ListContacts(ctx)
+	require.NoError(t, err)
+	require.Len(t, contacts, 1)
+	assert.Equal(t, "Sam Smith", contacts[0].Name)
+
+	page, err := c.ListCalls(ctx, ListCallsOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page.Calls, 1)
+	assert.Equal(t, second.ID, page.Calls[0].ID)
+	require.NotNil(t, page.NextBeforeID)
+	page, err = c.ListCalls(ctx, ListCallsOptions{Limit: 1, BeforeID: *page.NextBeforeID})
+	require.NoError(t, err)
+	require.Len(t, page.Calls, 1)
+	assert.Equal(t, first.ID, page.Calls[0].ID)
+	assert.Nil(t, page.NextBeforeID)
+
+	detail, err := c.GetCall(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ask about opening hours", detail.Objective)
+	require.Len(t, detail.Transcript, 1)
+	assert.Equal(t, "agent", detail.Transcript[0].Speaker)
+
+	text, err := c.Transcript(ctx, first.ID, TranscriptText)
+	require.NoError(t, err)
+	assert.Contains(t, text, "Agent: Hi, when are you open?")
+
+	update, err := c.TranscriptAfter(ctx, first.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, update.Entries, 1)
+	assert.Equal(t, detail.Transcript[0], update.Entries[0])
+	assert.Equal(t, StatusPending, update.Status)
+	assert.False(t, update.Finished())
+	update, err = c.TranscriptAfter(ctx, first.ID, update.Entries[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, update.Entries)
+
+	canceled, err := c.CancelCall(ctx, second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, canceled.Status)
+	assert.True(t, canceled.Finished())
+
+	var apiErr *Error
+	_, err = c.CancelCall(ctx, second.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, CodeConflict, apiErr.Code)
+
+	_, err = c.GetCall(ctx, 999)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, CodeNotFound, apiErr.Code)
+
+	_, err = c.CreateCall(ctx, CreateCallRequest{Recipient: "Sam"})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, CodeInvalidRequest, apiErr.Code)
+	assert.Equal(t, "phone_number", apiErr.Field)
+	assert.Equal(t, "goDial: invalid_request: Enter the number to call. (phone_number)", err.Error())
+
+	_, err = New(server.URL, "gdk_wrong").Balance(ctx)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClient_CreateCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST /api/v1/calls", r.Method+" "+r.URL.Path)
+		assert.Equal(t, "Bearer gdk_key", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, map[string]any{
+			"phone_number": "+13336664444",
+			"recipient":    "Sam Smith",
+			"template_id":  float64(3),
+			"variables":    map[string]any{"day": "Friday"},
+		}, req, "unset options are left out")
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":7,"phone_number":"+13336664444","recipient":"Sam Smith","objective":"Book a table on Friday","status":"pending","max_attempts":1,"record":false}`)
+	}))
+	t.Cleanup(server.Close)
+
+	call, err := New(server.URL, "gdk_key").CreateCall(context.Background(), CreateCallRequest{
+		PhoneNumber: "+13336664444",
+		Recipient:   "Sam Smith",
+		TemplateID:  3,
+		Variables:   map[string]string{"day": "Friday"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), call.ID)
+	assert.Equal(t, "Book a table on Friday", call.Objective)
+	assert.False(t, call.Finished())
+}
+
+func TestClient_WaitForCall(t *testing.T) {
+	tests := []struct {
+		name           string
+		finishAfter    int32
+		timeout        time.Duration
+		expectedStatus string
+		expectedErr    error
+	}{
+		{name: "returns the finished call", finishAfter: 3, timeout: time.Second, expectedStatus: StatusCompleted},
+		{name: "gives up when the context is done", finishAfter: 1000, timeout: 50 * time.Millisecond, expectedStatus: StatusInProgress, expectedErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var polls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "GET /api/v1/calls/7", r.Method+" "+r.URL.Path)
+				status := StatusInProgress
+				if polls.Add(1) >= tt.finishAfter {
+					status = StatusCompleted
+				}
+				fmt.Fprintf(w, `{"id":7,"status":%q,"attempts":[],"facts":[],"transcript":[]}`, status)
+			}))
+			t.Cleanup(server.Close)
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			call, err := New(server.URL, "gdk_key").WaitForCall(ctx, 7, 5*time.Millisecond)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedStatus, call.Status)
+		})
+	}
+}
+
+func TestClient_UnexpectedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := New(server.URL, "gdk_key").Balance(context.Background())
+	var apiErr *Error
+	assert.False(t, errors.As(err, &apiErr))
+	assert.EqualError(t, err, "GET /balance answered 502 Bad Gateway")
+}
+
+// TestTypesMatchAPI checks the client's types read and write the same JSON
+// fields as the API's.
+func TestTypesMatchAPI(t *testing.T) {
+	pairs := []struct {
+		client, api any
+	}{
+		{CreateCallRequest{}, api.CreateCallRequest{}},
+		{Call{}, api.Call{}},
+		{CallDetail{}, api.CallDetail{}},
+		{Attempt{}, api.Attempt{}},
+		{Fact{}, api.Fact{}},
+		{TranscriptEntry{}, transcript.Entry{}},
+		{CallList{}, api.CallList{}},
+		{Contact{}, api.Contact{}},
+		{Error{}, api.Error{}},
+	}
+
+	for _, pair := range pairs {
+		t.Run(reflect.TypeOf(pair.client).Name(), func(t *testing.T) {
+			assert.ElementsMatch(t, jsonTags(reflect.TypeOf(pair.api)), jsonTags(reflect.TypeOf(pair.client)))
+		})
+	}
+}
+
+// jsonTags are the json tags of a struct's fields, and of the structs it
+// embeds.
+func jsonTags(typ reflect.Type) []string {
+	var tags []string
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if field.Anonymous {
+			tags = append(tags, jsonTags(field.Type)...)
+			continue
+		}
+		if tag := field.Tag.Get("json"); tag != "-" && !strings.HasPrefix(tag, "-,") {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,154 @@
+package client
+
+import "time"
+
+// CreateCallRequest is a call to place.
+type CreateCallRequest struct {
+	// PhoneNumber is the number to call, like +13336664444.
+	PhoneNumber string `json:"phone_number"`
+	// Recipient is who is being called, and anything the agent should
+	// know about them.
+	Recipient string `json:"recipient"`
+	// Objective is what the call should get done. It may be left out when
+	// TemplateID brings one.
+	Objective string `json:"objective,omitempty"`
+	// Context is anything else the agent should know.
+	Context string `json:"context,omitempty"`
+
+	// retries, left out to dial once
+	MaxAttempts         int64 `json:"max_attempts,omitempty"`
+	RetrySpacingMinutes int64 `json:"retry_spacing_minutes,omitempty"`
+	// RetryWindowStart and RetryWindowEnd are the times of day, like 09:00,
+	// retries are made between.
+	RetryWindowStart string `json:"retry_window_start,omitempty"`
+	RetryWindowEnd   string `json:"retry_window_end,omitempty"`
+
+	Record bool `json:"record,omitempty"`
+
+	// TemplateID fills in the objective and context from one of the key
+	// owner's call templates, with Variables as the values of its variables.
+	TemplateID int64             `json:"template_id,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"`
+}
+
+// Call statuses
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Call is a call and where it has got to.
+type Call struct {
+	ID          int64  `json:"id"`
+	PhoneNumber string `json:"phone_number"`
+	Recipient   string `json:"recipient"`
+	Objective   string `json:"objective"`
+	Status      string `json:"status"`
+	// StatusReason is why the call is in its status, like why it failed.
+	StatusReason string `json:"status_reason,omitempty"`
+	// Outcome is how the call ended, once it has.
+	Outcome     string     `json:"outcome,omitempty"`
+	OpeningLine string     `json:"opening_line,omitempty"`
+	MaxAttempts int64      `json:"max_attempts"`
+	Record      bool       `json:"record"`
+	ContactID   *int64     `json:"contact_id,omitempty"`
+	CampaignID  *int64     `json:"campaign_id,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Finished reports whether the call is over, one way or the other.
+func (c Call) Finished() bool {
+	return c.Status == StatusCompleted || c.Status == StatusFailed
+}
+
+// CallDetail is a call with everything that has happened on it so far.
+type CallDetail struct {
+	Call
+	Attempts []Attempt `json:"attempts"`
+	// Facts are what the agent learned on the call, in the order it noted
+	// them.
+	Facts      []Fact            `json:"facts"`
+	Transcript []TranscriptEntry `json:"transcript"`
+}
+
+// Attempt is one dial of a call.
+type Attempt struct {
+	Number          int64      `json:"number"`
+	Status          string     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	BilledMinutes   int64      `json:"billed_minutes"`
+	ScheduledFor    time.Time  `json:"scheduled_for"`
+	AnsweredAt      *time.Time `json:"answered_at,omitempty"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+}
+
+// Fact is something the agent learned on a call.
+type Fact struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// TranscriptEntry is a line of a call's transcript.
+type TranscriptEntry struct {
+	ID int64 `json:"id"`
+	// Speaker is agent, recipient or system.
+	Speaker string    `json:"speaker"`
+	Text    string    `json:"text"`
+	At      time.Time `json:"at"`
+	// OffsetSeconds is how far into the call the line was said.
+	OffsetSeconds float64 `json:"offset_seconds"`
+}
+
+// TranscriptUpdate is the lines of a call's transcript after the last one
+// seen, and the call's status when they were read.
+type TranscriptUpdate struct {
+	Entries []TranscriptEntry
+	Status  string
+}
+
+// Finished reports whether the call is over, so no more lines will come.
+func (u TranscriptUpdate) Finished() bool {
+	return u.Status == StatusCompleted || u.Status == StatusFailed
+}
+
+// Transcript formats
+const (
+	TranscriptText = "text"
+	TranscriptJSON = "json"
+	TranscriptVTT  = "vtt"
+)
+
+// ListCallsOptions picks the page of calls ListCalls returns. The zero value
+// is the newest 50 calls.
+type ListCallsOptions struct {
+	// Status only lists calls in it.
+	Status string
+	// Limit is how many calls to list, up to 200.
+	Limit int
+	// BeforeID only lists calls older than it: the NextBeforeID of the page
+	// before.
+	BeforeID int64
+}
+
+// CallList is a page of calls, newest first.
+type CallList struct {
+	Calls []Call `json:"calls"`
+	// NextBeforeID is the ListCallsOptions.BeforeID of the next page, or nil
+	// on the last page.
+	NextBeforeID *int64 `json:"next_before_id,omitempty"`
+}
+
+// Contact is someone in the key owner's contacts.
+type Contact struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	PhoneNumber       string `json:"phone_number"`
+	Notes             string `json:"notes,omitempty"`
+	Timezone          string `json:"timezone,omitempty"`
+	PreferredLanguage string `json:"preferred_language,omitempty"`
+}
@@ -52,7 +52,7 @@ if check_css_needed; then
 fi
 
 # Build the Go application (always needed for Air)
-GO_ENV="development" AIR_ENABLED="1" go build -o ./tmp/main ./cmd/main.go
+GO_ENV="development" AIR_ENABLED="1" go build -tags sqlite_fts5 -o ./tmp/main ./cmd/main.go
 
 # Give the binary a moment to be ready for execution
 sleep 0.5 
\ No newline at end of file
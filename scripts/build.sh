@@ -77,7 +77,7 @@ fi
 
 # Run tests before building
 echo -e "${YELLOW}🧪 Running tests...${NC}"
-if go test -short ./...; then
+if go test -tags sqlite_fts5 -short ./...; then
     echo -e "${GREEN}✅ All tests passed${NC}"
 else
     echo -e "${RED}❌ Tests failed${NC}"
@@ -91,6 +91,7 @@ echo -e "${YELLOW}🏗️  Building application...${NC}"
 
 # Build flags for production
 BUILD_FLAGS=(
+    -tags sqlite_fts5
     -ldflags "-s -w -X main.version=$VERSION -X main.commit=$COMMIT -X main.buildTime=$BUILD_TIME"
     -trimpath
     -o "$BINARY_PATH"
@@ -28,7 +28,7 @@ fi
 
 # Build the Go application
 echo "Building Go application..."
-if go build -o bin/goDial cmd/main.go; then
+if go build -tags sqlite_fts5 -o bin/goDial cmd/main.go; then
     echo "✓ Go application built successfully"
 else
     echo "✗ Go build failed"
@@ -59,6 +59,7 @@ INSERT OR IGNORE INTO users (email, name, minutes) VALUES
     ('bob@example.com', 'Bob Smith', 10),
     ('charlie@example.com', 'Charlie Brown', 1000),
     ('diana@example.com', 'Diana Prince', 1);
+UPDATE users SET is_admin = 1 WHERE email = 'test@test.com';
 EOF
 
 # Get user IDs for foreign key references
@@ -26,7 +26,7 @@ run_tests() {
     go clean -testcache
     
     # Run tests with minimal output for watch mode
-    if go test -short ./...; then
+    if go test -tags sqlite_fts5 -short ./...; then
         echo -e "${GREEN}✅ Tests passed at $(date)${NC}"
     else
         echo -e "${RED}❌ Tests failed at $(date)${NC}"
@@ -40,7 +40,7 @@ echo -e "${YELLOW} Running tests with coverage...${NC}"
 echo ""
 
 # Run tests with coverage
-if go test -v -race -coverprofile="$COVERAGE_FILE" -covermode=atomic ./...; then
+if go test -tags sqlite_fts5 -v -race -coverprofile="$COVERAGE_FILE" -covermode=atomic ./...; then
     echo ""
     echo -e "${GREEN}✅ All tests passed!${NC}"
     
@@ -82,7 +82,7 @@ else
     echo "  • Ensure database is properly set up (run 'db-migrate')"
     echo "  • Verify all dependencies are installed"
     echo "  • Run 'go mod tidy' to clean up dependencies"
-    echo "  • Run individual test files: go test -v ./internal/package_name"
+    echo "  • Run individual test files: go test -tags sqlite_fts5 -v ./internal/package_name"
     exit 1
 fi
 
@@ -94,10 +94,10 @@ echo "  • Open HTML report: open $COVERAGE_HTML (macOS) or xdg-open $COVERAGE_
 echo ""
 
 echo -e "${YELLOW}🔧 Test Commands:${NC}"
-echo "  • Run specific package: go test -v ./internal/package_name"
-echo "  • Run specific test: go test -v -run TestName ./internal/package_name"
-echo "  • Run tests with race detection: go test -race ./..."
-echo "  • Run benchmarks: go test -bench=. ./..."
+echo "  • Run specific package: go test -tags sqlite_fts5 -v ./internal/package_name"
+echo "  • Run specific test: go test -tags sqlite_fts5 -v -run TestName ./internal/package_name"
+echo "  • Run tests with race detection: go test -tags sqlite_fts5 -race ./..."
+echo "  • Run benchmarks: go test -tags sqlite_fts5 -bench=. ./..."
 echo "  • Watch tests: test-watch"
 echo ""
 
@@ -92,7 +92,7 @@ fi
 # Run tests to verify everything still works
 echo ""
 echo -e "${YELLOW}🧪 Running tests to verify updates...${NC}"
-if go test -short ./...; then
+if go test -tags sqlite_fts5 -short ./...; then
     echo -e "${GREEN}✅ All tests passed after updates${NC}"
 else
     echo -e "${RED}❌ Tests failed after updates${NC}"